@@ -0,0 +1,175 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Browse IDs of the feeds of the signed in account
+const (
+	myUploadsBrowseID   = "FEmy_videos"
+	myPlaylistsBrowseID = "FEplaylist_aggregation"
+)
+
+// Privacy states of uploaded videos
+const (
+	PrivacyPublic   = "public"
+	PrivacyUnlisted = "unlisted"
+	PrivacyPrivate  = "private"
+)
+
+// UploadedVideo is a video uploaded by the signed in account, its JSON field names are stable
+type UploadedVideo struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Privacy is PrivacyPublic, PrivacyUnlisted or PrivacyPrivate
+	Privacy string `json:"privacy"`
+	// ViewCount is approximate for some videos, YouTube rounds it to three significant digits
+	ViewCount int64 `json:"viewCount"`
+	// PublishedTime is relative as displayed by YouTube, e.g. "2 days ago"
+	PublishedTime string        `json:"publishedTime"`
+	Duration      time.Duration `json:"-"`
+	Thumbnails    Thumbnails    `json:"thumbnails"`
+}
+
+// AccountPlaylist is a playlist of the signed in account, including the private ones
+type AccountPlaylist struct {
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	VideoCount int        `json:"videoCount"`
+	Thumbnails Thumbnails `json:"thumbnails"`
+}
+
+// GetMyUploads fetches all videos uploaded by the signed in account, including the unlisted and private ones.
+// ErrNotAuthenticated is returned without the cookies of an account, see IsAuthenticated.
+func (c *Client) GetMyUploads(ctx context.Context) ([]*UploadedVideo, error) {
+	responses, err := c.browseAccount(ctx, myUploadsBrowseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var videos []*UploadedVideo
+	seen := make(map[string]bool)
+	for _, response := range responses {
+		for _, key := range []string{"videoRenderer", "gridVideoRenderer", "playlistVideoRenderer"} {
+			var renderers []struct {
+				VideoID           string        `json:"videoId"`
+				Title             innertubeText `json:"title"`
+				ViewCountText     innertubeText `json:"viewCountText"`
+				PublishedTimeText innertubeText `json:"publishedTimeText"`
+				LengthText        innertubeText `json:"lengthText"`
+				Badges            []struct {
+					MetadataBadgeRenderer struct {
+						Label string `json:"label"`
+					} `json:"metadataBadgeRenderer"`
+				} `json:"badges"`
+				Thumbnail struct {
+					Thumbnails Thumbnails `json:"thumbnails"`
+				} `json:"thumbnail"`
+			}
+			if err := findRenderers(response, key, &renderers); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrParse, err)
+			}
+
+			for _, r := range renderers {
+				if r.VideoID == "" || seen[r.VideoID] {
+					continue
+				}
+				seen[r.VideoID] = true
+
+				video := &UploadedVideo{
+					ID:            r.VideoID,
+					Title:         r.Title.String(),
+					Privacy:       PrivacyPublic,
+					ViewCount:     parseApproxCount(r.ViewCountText.String()),
+					PublishedTime: r.PublishedTimeText.String(),
+					Duration:      parseClockDuration(r.LengthText.String()),
+					Thumbnails:    r.Thumbnail.Thumbnails,
+				}
+				for _, badge := range r.Badges {
+					switch label := strings.ToLower(badge.MetadataBadgeRenderer.Label); label {
+					case PrivacyUnlisted, PrivacyPrivate:
+						video.Privacy = label
+					}
+				}
+				videos = append(videos, video)
+			}
+		}
+	}
+	return videos, nil
+}
+
+// GetMyPlaylists fetches all playlists of the signed in account, including the private ones.
+// ErrNotAuthenticated is returned without the cookies of an account, see IsAuthenticated.
+func (c *Client) GetMyPlaylists(ctx context.Context) ([]*AccountPlaylist, error) {
+	responses, err := c.browseAccount(ctx, myPlaylistsBrowseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var playlists []*AccountPlaylist
+	seen := make(map[string]bool)
+	for _, response := range responses {
+		for _, key := range []string{"gridPlaylistRenderer", "playlistRenderer"} {
+			var renderers []struct {
+				PlaylistID          string        `json:"playlistId"`
+				Title               innertubeText `json:"title"`
+				VideoCount          string        `json:"videoCount"`
+				VideoCountText      innertubeText `json:"videoCountText"`
+				VideoCountShortText innertubeText `json:"videoCountShortText"`
+				Thumbnail           struct {
+					Thumbnails Thumbnails `json:"thumbnails"`
+				} `json:"thumbnail"`
+			}
+			if err := findRenderers(response, key, &renderers); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrParse, err)
+			}
+
+			for _, r := range renderers {
+				if r.PlaylistID == "" || seen[r.PlaylistID] {
+					continue
+				}
+				seen[r.PlaylistID] = true
+
+				count, err := strconv.Atoi(r.VideoCount)
+				if err != nil {
+					count = int(parseApproxCount(r.VideoCountText.String()))
+				}
+				if count == 0 {
+					count = int(parseApproxCount(r.VideoCountShortText.String()))
+				}
+				playlists = append(playlists, &AccountPlaylist{
+					ID:         r.PlaylistID,
+					Title:      r.Title.String(),
+					VideoCount: count,
+					Thumbnails: r.Thumbnail.Thumbnails,
+				})
+			}
+		}
+	}
+	return playlists, nil
+}
+
+// browseAccount requests all pages of a feed of the signed in account
+func (c *Client) browseAccount(ctx context.Context, browseID string) ([]interface{}, error) {
+	if !c.IsAuthenticated() {
+		return nil, ErrNotAuthenticated
+	}
+
+	response, err := c.browse(ctx, browseID, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []interface{}{response}
+	for token := findContinuation(response); token != ""; token = findContinuation(response) {
+		if response, err = c.browseContinuation(ctx, token); err != nil {
+			return nil, err
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
@@ -0,0 +1,81 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSapisidHash(t *testing.T) {
+	assert.Equal(t, "SAPISIDHASH 1700000000_747622f274182ecf105054645d6a0093199ab03d",
+		sapisidHash("abc/def", youtubeOrigin, time.Unix(1700000000, 0)))
+}
+
+// signIn adds the cookies of a signed in account to the client
+func signIn(t *testing.T, client *Client) {
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	u, _ := url.Parse(youtubeOrigin)
+	jar.SetCookies(u, []*http.Cookie{{Name: "SAPISID", Value: "abc/def", Domain: ".youtube.com", Path: "/"}})
+	client.HTTPClient.Jar = jar
+}
+
+func TestClient_GetMyUploads(t *testing.T) {
+	innertubeCache.configs = nil
+	defer func() { innertubeCache.configs = nil }()
+
+	client := newInnertubeTestClient(func(endpoint string, payload map[string]interface{}) string {
+		if payload["continuation"] == "page2" {
+			return `{"onResponseReceivedActions":[{"appendContinuationItemsAction":{"continuationItems":[
+				{"gridVideoRenderer":{"videoId":"private0001","title":{"simpleText":"Private"},
+					"badges":[{"metadataBadgeRenderer":{"label":"Private"}}]}}]}}]}`
+		}
+		assert.Equal(t, myUploadsBrowseID, payload["browseId"])
+		return `{"contents":{"items":[
+			{"gridVideoRenderer":{"videoId":"public00001","title":{"runs":[{"text":"Public"}]},
+				"viewCountText":{"simpleText":"1,234 views"},"lengthText":{"simpleText":"4:05"}}},
+			{"gridVideoRenderer":{"videoId":"unlisted001","title":{"simpleText":"Unlisted"},
+				"badges":[{"metadataBadgeRenderer":{"label":"Unlisted"}}]}},
+			{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"page2"}}}}]}}`
+	})
+
+	_, err := client.GetMyUploads(context.Background())
+	assert.True(t, errors.Is(err, ErrNotAuthenticated))
+
+	signIn(t, client)
+	assert.True(t, client.IsAuthenticated())
+	videos, err := client.GetMyUploads(context.Background())
+	require.NoError(t, err)
+	require.Len(t, videos, 3)
+	assert.Equal(t, UploadedVideo{ID: "public00001", Title: "Public", Privacy: PrivacyPublic, ViewCount: 1234, Duration: 245 * time.Second}, *videos[0])
+	assert.Equal(t, PrivacyUnlisted, videos[1].Privacy)
+	assert.Equal(t, "private0001", videos[2].ID)
+	assert.Equal(t, PrivacyPrivate, videos[2].Privacy)
+}
+
+func TestClient_GetMyPlaylists(t *testing.T) {
+	innertubeCache.configs = nil
+	defer func() { innertubeCache.configs = nil }()
+
+	client := newInnertubeTestClient(func(endpoint string, payload map[string]interface{}) string {
+		assert.Equal(t, myPlaylistsBrowseID, payload["browseId"])
+		return `{"contents":{"items":[
+			{"gridPlaylistRenderer":{"playlistId":"PLfirst","title":{"simpleText":"First"},"videoCountShortText":{"simpleText":"12"}}},
+			{"playlistRenderer":{"playlistId":"PLsecond","title":{"simpleText":"Second"},"videoCount":"3"}}]}}`
+	})
+	signIn(t, client)
+
+	playlists, err := client.GetMyPlaylists(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*AccountPlaylist{
+		{ID: "PLfirst", Title: "First", VideoCount: 12},
+		{ID: "PLsecond", Title: "Second", VideoCount: 3},
+	}, playlists)
+}
@@ -0,0 +1,95 @@
+package youtube
+
+import (
+	"context"
+	"log"
+	"strconv"
+)
+
+var _ Extractor = AndroidExtractor{}
+
+// AndroidExtractor prefers the formats of the player of the Android app, their URLs are neither ciphered
+// nor throttled. The video is fetched by the Fallback extractor, DefaultExtractor if nil, and its formats
+// get the URLs of the Android formats with the same itag. Formats missing in the answer of the app,
+// or all of them if the app is refused, keep the URLs of the fallback.
+type AndroidExtractor struct {
+	Fallback Extractor
+}
+
+func (e AndroidExtractor) fallback() Extractor {
+	if e.Fallback != nil {
+		return e.Fallback
+	}
+	return DefaultExtractor{}
+}
+
+// ExtractVideo fetches the video by the fallback extractor and replaces the URLs of its formats by the ones of the app
+func (e AndroidExtractor) ExtractVideo(ctx context.Context, client *Client, videoID string) (*Video, error) {
+	video, err := e.fallback().ExtractVideo(ctx, client, videoID)
+	if err != nil {
+		return video, err
+	}
+
+	formats, err := client.androidFormats(ctx, videoID)
+	if err != nil {
+		if client.Debug {
+			log.Println("android player:", err)
+		}
+		return video, nil
+	}
+
+	video.Formats.preferURLs(formats)
+	return video, nil
+}
+
+// StreamURL resolves the URL by the fallback extractor, the formats of the app already have their URLs
+func (e AndroidExtractor) StreamURL(ctx context.Context, client *Client, video *Video, format *Format) (string, error) {
+	return e.fallback().StreamURL(ctx, client, video, format)
+}
+
+// androidFormats requests the formats of the video from the player of the Android app
+func (c *Client) androidFormats(ctx context.Context, videoID string) (FormatList, error) {
+	request := map[string]interface{}{
+		"videoId":        videoID,
+		"contentCheckOk": true,
+		"racyCheckOk":    true,
+	}
+
+	var prData playerResponseData
+	if err := c.innertubeClientRequest(ctx, "player", "", androidApp, request, &prData); err != nil {
+		return nil, err
+	}
+	if prData.PlayabilityStatus.Status != "OK" {
+		return nil, &ErrPlayabiltyStatus{
+			Status: prData.PlayabilityStatus.Status,
+			Reason: prData.PlayabilityStatus.Reason,
+		}
+	}
+	return append(prData.StreamingData.Formats, prData.StreamingData.AdaptiveFormats...), nil
+}
+
+// preferURLs replaces the URLs of the formats by the ones of the preferred formats with the same itag and audio track
+func (list FormatList) preferURLs(preferred FormatList) {
+	urls := make(map[string]string, len(preferred))
+	for i := range preferred {
+		if preferred[i].URL != "" {
+			urls[preferred[i].streamKey()] = preferred[i].URL
+		}
+	}
+
+	for i := range list {
+		if streamURL, ok := urls[list[i].streamKey()]; ok {
+			list[i].URL = streamURL
+			list[i].Cipher = ""
+		}
+	}
+}
+
+// streamKey identifies the stream of a format across clients, by its itag and audio track
+func (f *Format) streamKey() string {
+	key := strconv.Itoa(f.ItagNo)
+	if f.AudioTrack != nil {
+		key += "/" + f.AudioTrack.ID
+	}
+	return key
+}
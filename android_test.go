@@ -0,0 +1,61 @@
+package youtube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedExtractor returns copies of the same video
+type fixedExtractor struct {
+	DefaultExtractor
+	video Video
+}
+
+func (e fixedExtractor) ExtractVideo(ctx context.Context, client *Client, videoID string) (*Video, error) {
+	video := e.video
+	video.Formats = append(FormatList(nil), e.video.Formats...)
+	return &video, nil
+}
+
+func TestAndroidExtractor(t *testing.T) {
+	innertubeCache.configs = nil
+	defer func() { innertubeCache.configs = nil }()
+
+	status := "OK"
+	client := newInnertubeTestClient(func(endpoint string, payload map[string]interface{}) string {
+		assert.Equal(t, "player", endpoint)
+		clientContext := payload["context"].(map[string]interface{})["client"].(map[string]interface{})
+		assert.Equal(t, "ANDROID", clientContext["clientName"])
+		assert.Equal(t, "BaW_jenozKc", payload["videoId"])
+		return `{"playabilityStatus":{"status":"` + status + `"},"streamingData":{` +
+			`"formats":[{"itag":18,"url":"https://android.example.com/videoplayback?itag=18"}],` +
+			`"adaptiveFormats":[{"itag":251,"url":"https://android.example.com/videoplayback?itag=251&xtags=lang%3Dde",` +
+			`"audioTrack":{"id":"de.3"}}]}}`
+	})
+	client.Extractor = AndroidExtractor{Fallback: fixedExtractor{video: Video{
+		ID: "BaW_jenozKc",
+		Formats: FormatList{
+			{ItagNo: 18, Cipher: "s=abc&sp=sig&url=https%3A%2F%2Fweb.example.com%2Fvideoplayback%3Fitag%3D18"},
+			{ItagNo: 22, URL: "https://web.example.com/videoplayback?itag=22"},
+			{ItagNo: 251, URL: "https://web.example.com/videoplayback?itag=251&xtags=lang%3Den", AudioTrack: &AudioTrack{ID: "en.4"}},
+			{ItagNo: 251, URL: "https://web.example.com/videoplayback?itag=251&xtags=lang%3Dde", AudioTrack: &AudioTrack{ID: "de.3"}},
+		},
+	}}}
+
+	video, err := client.GetVideo("BaW_jenozKc")
+	require.NoError(t, err)
+	assert.Equal(t, "https://android.example.com/videoplayback?itag=18", video.Formats[0].URL)
+	assert.Empty(t, video.Formats[0].Cipher)
+	assert.Equal(t, "https://web.example.com/videoplayback?itag=22", video.Formats[1].URL, "formats missing in the app keep the web URL")
+	assert.Equal(t, "https://web.example.com/videoplayback?itag=251&xtags=lang%3Den", video.Formats[2].URL)
+	assert.Equal(t, "https://android.example.com/videoplayback?itag=251&xtags=lang%3Dde", video.Formats[3].URL)
+
+	status = "LOGIN_REQUIRED"
+	video, err = client.GetVideo("BaW_jenozKc")
+	require.NoError(t, err)
+	assert.Empty(t, video.Formats[0].URL)
+	assert.NotEmpty(t, video.Formats[0].Cipher)
+}
@@ -0,0 +1,98 @@
+package youtubedr
+
+import (
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	protoMessagePattern = regexp.MustCompile(`^message (\w+) \{`)
+	protoFieldPattern   = regexp.MustCompile(`^(repeated )?([\w.]+) \w+ = (\d+);`)
+)
+
+// protoFields reads the field numbers and wire types of the messages of the .proto file
+func protoFields(t *testing.T) map[string]map[int]int {
+	data, err := ioutil.ReadFile("../proto/youtubedr.proto")
+	require.NoError(t, err)
+
+	messages := make(map[string]map[int]int)
+	var current string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if m := protoMessagePattern.FindStringSubmatch(line); m != nil {
+			current = m[1]
+			messages[current] = make(map[int]int)
+			continue
+		}
+		m := protoFieldPattern.FindStringSubmatch(line)
+		if m == nil || current == "" {
+			continue
+		}
+		number, _ := strconv.Atoi(m[3])
+		switch m[2] {
+		case "int32", "int64", "State", "Download.State":
+			messages[current][number] = wireVarint
+		default:
+			messages[current][number] = wireBytes
+		}
+	}
+	return messages
+}
+
+// fill sets every field of the message to a value which is not the default
+func fill(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString("x")
+		case reflect.Int32, reflect.Int64:
+			f.SetInt(1)
+		case reflect.Slice:
+			f.Set(reflect.MakeSlice(f.Type(), 1, 1))
+			if elem := f.Index(0); elem.Kind() == reflect.Ptr {
+				elem.Set(reflect.New(elem.Type().Elem()))
+			}
+		}
+	}
+}
+
+// TestMessages_proto checks the hand-written messages against the definition in the .proto file
+func TestMessages_proto(t *testing.T) {
+	messages := map[string]message{
+		"GetVideoRequest":       &GetVideoRequest{},
+		"Video":                 &Video{},
+		"ListFormatsRequest":    &ListFormatsRequest{},
+		"ListFormatsResponse":   &ListFormatsResponse{},
+		"Format":                &Format{},
+		"StartDownloadRequest":  &StartDownloadRequest{},
+		"Download":              &Download{},
+		"StreamProgressRequest": &StreamProgressRequest{},
+		"Progress":              &Progress{},
+		"CancelDownloadRequest": &CancelDownloadRequest{},
+	}
+
+	defined := protoFields(t)
+	require.Len(t, defined, len(messages), "every message of the .proto file is implemented")
+	for name, fields := range defined {
+		t.Run(name, func(t *testing.T) {
+			m, ok := messages[name]
+			require.True(t, ok)
+			fill(reflect.ValueOf(m).Elem())
+
+			encoded := make(map[int]int)
+			require.NoError(t, decode(m.Marshal(), func(f field) error {
+				encoded[f.number] = f.wireType
+				return nil
+			}))
+			assert.Equal(t, fields, encoded)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package youtubedr
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceName is the full name of the gRPC service, the prefix of the paths of its methods
+const ServiceName = "youtubedr.v1.Youtubedr"
+
+// maxMessageSize limits the size of request messages like the default of gRPC servers
+const maxMessageSize = 4 << 20
+
+// Code is a gRPC status code
+type Code uint32
+
+// The gRPC status codes used by the API, see https://grpc.github.io/grpc/core/md_doc_statuscodes.html
+const (
+	OK                Code = 0
+	Canceled          Code = 1
+	Unknown           Code = 2
+	InvalidArgument   Code = 3
+	DeadlineExceeded  Code = 4
+	NotFound          Code = 5
+	PermissionDenied  Code = 7
+	ResourceExhausted Code = 8
+	Unimplemented     Code = 12
+	Internal          Code = 13
+	Unavailable       Code = 14
+)
+
+// Status is an error with a gRPC status code, other errors of a server are sent as Unknown
+type Status struct {
+	Code    Code
+	Message string
+}
+
+func (s *Status) Error() string {
+	return fmt.Sprintf("rpc error: code = %d desc = %s", s.Code, s.Message)
+}
+
+// Errorf returns a Status error
+func Errorf(code Code, format string, a ...interface{}) error {
+	return &Status{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+// statusOf returns the status sent for the error of a method
+func statusOf(err error) *Status {
+	var status *Status
+	switch {
+	case err == nil:
+		return &Status{Code: OK}
+	case errors.As(err, &status):
+		return status
+	case errors.Is(err, context.Canceled):
+		return &Status{Code: Canceled, Message: err.Error()}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Status{Code: DeadlineExceeded, Message: err.Error()}
+	}
+	return &Status{Code: Unknown, Message: err.Error()}
+}
+
+// YoutubedrServer is the service of youtubedr.proto
+type YoutubedrServer interface {
+	// GetVideo fetches the metadata of a video
+	GetVideo(context.Context, *GetVideoRequest) (*Video, error)
+	// ListFormats returns the formats of a video
+	ListFormats(context.Context, *ListFormatsRequest) (*ListFormatsResponse, error)
+	// StartDownload queues a download and returns immediately
+	StartDownload(context.Context, *StartDownloadRequest) (*Download, error)
+	// StreamProgress sends the progress of a download until it has finished
+	StreamProgress(*StreamProgressRequest, ProgressStream) error
+	// CancelDownload stops a queued or running download
+	CancelDownload(context.Context, *CancelDownloadRequest) (*Download, error)
+}
+
+// ProgressStream sends the messages of StreamProgress
+type ProgressStream interface {
+	// Context is canceled once the client is gone
+	Context() context.Context
+	Send(*Progress) error
+}
+
+// NewHandler serves the gRPC protocol for the server. gRPC clients require HTTP/2,
+// so the handler has to be served over TLS or with unencrypted HTTP/2.
+// Compressed messages are not supported, which clients only send if the server accepts them.
+func NewHandler(srv YoutubedrServer) http.Handler {
+	return &handler{srv: srv}
+}
+
+type handler struct {
+	srv YoutubedrServer
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/grpc" &&
+		!strings.HasPrefix(contentType, "application/grpc+proto") && !strings.HasPrefix(contentType, "application/grpc;") {
+		http.Error(w, "Content-Type must be application/grpc", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	ctx := r.Context()
+	if timeout, ok := parseTimeout(r.Header.Get("Grpc-Timeout")); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Add("Trailer", "Grpc-Status")
+	w.Header().Add("Trailer", "Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+
+	status := statusOf(h.call(ctx, w, r.Body, strings.TrimPrefix(r.URL.Path, "/"+ServiceName+"/")))
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(status.Code)))
+	if status.Message != "" {
+		w.Header().Set("Grpc-Message", encodeStatusMessage(status.Message))
+	}
+}
+
+// call reads the request of the method, calls the server and writes its response
+func (h *handler) call(ctx context.Context, w http.ResponseWriter, body io.Reader, method string) error {
+	unary := func(req message, call func() (message, error)) error {
+		if err := readMessage(body, req); err != nil {
+			return err
+		}
+		resp, err := call()
+		if err != nil {
+			return err
+		}
+		return writeMessage(w, resp)
+	}
+
+	switch method {
+	case "GetVideo":
+		req := &GetVideoRequest{}
+		return unary(req, func() (message, error) { return h.srv.GetVideo(ctx, req) })
+	case "ListFormats":
+		req := &ListFormatsRequest{}
+		return unary(req, func() (message, error) { return h.srv.ListFormats(ctx, req) })
+	case "StartDownload":
+		req := &StartDownloadRequest{}
+		return unary(req, func() (message, error) { return h.srv.StartDownload(ctx, req) })
+	case "CancelDownload":
+		req := &CancelDownloadRequest{}
+		return unary(req, func() (message, error) { return h.srv.CancelDownload(ctx, req) })
+	case "StreamProgress":
+		req := &StreamProgressRequest{}
+		if err := readMessage(body, req); err != nil {
+			return err
+		}
+		return h.srv.StreamProgress(req, &progressStream{ctx: ctx, w: w})
+	}
+	return Errorf(Unimplemented, "unknown method %s of %s", method, ServiceName)
+}
+
+type progressStream struct {
+	ctx context.Context
+	w   http.ResponseWriter
+}
+
+func (s *progressStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *progressStream) Send(p *Progress) error {
+	return writeMessage(s.w, p)
+}
+
+// readMessage reads a length-prefixed message, the 5 byte prefix is the compression flag and the size
+func readMessage(r io.Reader, m message) error {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return Errorf(InvalidArgument, "missing request message: %v", err)
+	}
+	if prefix[0] != 0 {
+		return Errorf(Unimplemented, "compressed messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxMessageSize {
+		return Errorf(ResourceExhausted, "request message of %d bytes exceeds the limit of %d bytes", size, maxMessageSize)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return Errorf(InvalidArgument, "truncated request message: %v", err)
+	}
+	if err := m.Unmarshal(data); err != nil {
+		return Errorf(InvalidArgument, "%v", err)
+	}
+	return nil
+}
+
+// writeMessage writes a length-prefixed message and flushes it to the client
+func writeMessage(w http.ResponseWriter, m message) error {
+	data := m.Marshal()
+	frame := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	if _, err := w.Write(append(frame, data...)); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// parseTimeout parses the Grpc-Timeout header, like 100m for 100 milliseconds
+func parseTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+	unit, ok := units[value[len(value)-1]]
+	return time.Duration(n) * unit, ok
+}
+
+// encodeStatusMessage percent-encodes the Grpc-Message header as required by the gRPC protocol
+func encodeStatusMessage(msg string) string {
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		if c := msg[i]; c < 0x20 || c > 0x7E || c == '%' {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
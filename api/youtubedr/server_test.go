@@ -0,0 +1,168 @@
+package youtubedr
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testServer struct {
+	YoutubedrServer
+}
+
+func (testServer) GetVideo(ctx context.Context, req *GetVideoRequest) (*Video, error) {
+	if req.Video == "missing" {
+		return nil, Errorf(NotFound, "video %s: not found\n100%%", req.Video)
+	}
+	if req.Video == "slow" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &Video{ID: req.Video, Title: "test"}, nil
+}
+
+func (testServer) StreamProgress(req *StreamProgressRequest, stream ProgressStream) error {
+	for i := int64(1); i <= 3; i++ {
+		if err := stream.Send(&Progress{DownloadID: req.DownloadID, State: DownloadStateRunning, BytesCompleted: i}); err != nil {
+			return err
+		}
+	}
+	return errors.New("connection reset")
+}
+
+func frame(m message) []byte {
+	data := m.Marshal()
+	prefix := make([]byte, 5)
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+	return append(prefix, data...)
+}
+
+func call(t *testing.T, method string, body []byte, header http.Header) *http.Response {
+	r := httptest.NewRequest(http.MethodPost, "/"+ServiceName+"/"+method, bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/grpc")
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	NewHandler(testServer{}).ServeHTTP(w, r)
+	return w.Result()
+}
+
+// messages reads the length-prefixed messages of the response body
+func messages(t *testing.T, resp *http.Response, newMessage func() message) []message {
+	var body bytes.Buffer
+	_, err := body.ReadFrom(resp.Body)
+	require.NoError(t, err)
+
+	var list []message
+	for body.Len() > 0 {
+		m := newMessage()
+		require.NoError(t, readMessage(&body, m))
+		list = append(list, m)
+	}
+	return list
+}
+
+func TestHandler_unary(t *testing.T) {
+	resp := call(t, "GetVideo", frame(&GetVideoRequest{Video: "BaW_jenozKc"}), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/grpc", resp.Header.Get("Content-Type"))
+	assert.Equal(t, []message{&Video{ID: "BaW_jenozKc", Title: "test"}}, messages(t, resp, func() message { return &Video{} }))
+	assert.Equal(t, "0", resp.Trailer.Get("Grpc-Status"))
+	assert.Empty(t, resp.Trailer.Get("Grpc-Message"))
+}
+
+func TestHandler_errors(t *testing.T) {
+	compressed := frame(&GetVideoRequest{Video: "BaW_jenozKc"})
+	compressed[0] = 1
+	tooLarge := []byte{0, 0xff, 0xff, 0xff, 0xff}
+
+	tests := []struct {
+		name    string
+		method  string
+		body    []byte
+		header  http.Header
+		code    string
+		message string
+	}{
+		{"status", "GetVideo", frame(&GetVideoRequest{Video: "missing"}), nil, "5", "video missing: not found%0A100%25"},
+		{"timeout", "GetVideo", frame(&GetVideoRequest{Video: "slow"}), http.Header{"Grpc-Timeout": {"10m"}}, "4", "context deadline exceeded"},
+		{"unknown method", "DeleteVideo", frame(&GetVideoRequest{}), nil, "12", "unknown method DeleteVideo of youtubedr.v1.Youtubedr"},
+		{"missing request", "GetVideo", nil, nil, "3", "missing request message: EOF"},
+		{"truncated request", "GetVideo", frame(&GetVideoRequest{Video: "BaW_jenozKc"})[:8], nil, "3", "truncated request message: unexpected EOF"},
+		{"invalid request", "GetVideo", []byte{0, 0, 0, 0, 1, 0x80}, nil, "3", "invalid protobuf message: truncated field key"},
+		{"compressed request", "GetVideo", compressed, nil, "12", "compressed messages are not supported"},
+		{"request too large", "GetVideo", tooLarge, nil, "8", "request message of 4294967295 bytes exceeds the limit of 4194304 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := call(t, tt.method, tt.body, tt.header)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Empty(t, messages(t, resp, func() message { return &Video{} }))
+			assert.Equal(t, tt.code, resp.Trailer.Get("Grpc-Status"))
+			assert.Equal(t, tt.message, resp.Trailer.Get("Grpc-Message"))
+		})
+	}
+}
+
+func TestHandler_stream(t *testing.T) {
+	resp := call(t, "StreamProgress", frame(&StreamProgressRequest{DownloadID: "7"}), nil)
+	assert.Equal(t, []message{
+		&Progress{DownloadID: "7", State: DownloadStateRunning, BytesCompleted: 1},
+		&Progress{DownloadID: "7", State: DownloadStateRunning, BytesCompleted: 2},
+		&Progress{DownloadID: "7", State: DownloadStateRunning, BytesCompleted: 3},
+	}, messages(t, resp, func() message { return &Progress{} }))
+	assert.Equal(t, "2", resp.Trailer.Get("Grpc-Status"))
+	assert.Equal(t, "connection reset", resp.Trailer.Get("Grpc-Message"))
+}
+
+func TestHandler_notGRPC(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/"+ServiceName+"/GetVideo", bytes.NewReader(frame(&GetVideoRequest{})))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewHandler(testServer{}).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/"+ServiceName+"/GetVideo", nil)
+	w = httptest.NewRecorder()
+	NewHandler(testServer{}).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		timeout time.Duration
+		ok      bool
+	}{
+		{"1H", time.Hour, true},
+		{"2M", 2 * time.Minute, true},
+		{"30S", 30 * time.Second, true},
+		{"100m", 100 * time.Millisecond, true},
+		{"5u", 5 * time.Microsecond, true},
+		{"99999999n", 99999999, true},
+		{"", 0, false},
+		{"m", 0, false},
+		{"100", 0, false},
+		{"100x", 0, false},
+		{"-1S", 0, false},
+		{"123456789S", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			timeout, ok := parseTimeout(tt.value)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, tt.timeout, timeout)
+			}
+		})
+	}
+}
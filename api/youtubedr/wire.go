@@ -0,0 +1,138 @@
+package youtubedr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidMessage is returned when a message does not follow the protobuf wire format
+var ErrInvalidMessage = errors.New("invalid protobuf message")
+
+// Wire types of the protobuf encoding
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// encoder appends fields in the protobuf wire format, fields with the default value are left out like in proto3
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) tag(field, wireType int) {
+	e.buf = appendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+func (e *encoder) uint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.buf = appendUvarint(e.buf, v)
+}
+
+// int encodes int32, int64 and enum fields, negative values are sign extended to ten bytes
+func (e *encoder) int(field int, v int64) {
+	e.uint(field, uint64(v))
+}
+
+func (e *encoder) string(field int, s string) {
+	if s == "" {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// element encodes an element of a repeated message or string field, it is written even if empty
+func (e *encoder) element(field int, m []byte) {
+	e.tag(field, wireBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(m)))
+	e.buf = append(e.buf, m...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+// field is a decoded field, value is set for varints and data for length-delimited fields
+type field struct {
+	number   int
+	wireType int
+	value    uint64
+	data     []byte
+}
+
+func (f field) int32() int32 {
+	return int32(f.value)
+}
+
+func (f field) int64() int64 {
+	return int64(f.value)
+}
+
+func (f field) string() string {
+	return string(f.data)
+}
+
+// decode calls fn for every field of the message, fields of unknown numbers are passed on to be skipped
+func decode(data []byte, fn func(f field) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("%w: truncated field key", ErrInvalidMessage)
+		}
+		data = data[n:]
+
+		f := field{number: int(key >> 3), wireType: int(key & 7)}
+		if f.number == 0 {
+			return fmt.Errorf("%w: field number 0", ErrInvalidMessage)
+		}
+
+		switch f.wireType {
+		case wireVarint:
+			if f.value, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("%w: truncated varint of field %d", ErrInvalidMessage, f.number)
+			}
+		case wireFixed64, wireFixed32:
+			n = 8
+			if f.wireType == wireFixed32 {
+				n = 4
+			}
+			if len(data) < n {
+				return fmt.Errorf("%w: truncated field %d", ErrInvalidMessage, f.number)
+			}
+		case wireBytes:
+			length, m := binary.Uvarint(data)
+			if m <= 0 || uint64(len(data)-m) < length {
+				return fmt.Errorf("%w: truncated field %d", ErrInvalidMessage, f.number)
+			}
+			f.data = data[m : m+int(length)]
+			n = m + int(length)
+		default:
+			return fmt.Errorf("%w: unsupported wire type %d of field %d", ErrInvalidMessage, f.wireType, f.number)
+		}
+		data = data[n:]
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expect checks the wire type of a known field
+func (f field) expect(wireType int) error {
+	if f.wireType != wireType {
+		return fmt.Errorf("%w: wire type %d of field %d, expected %d", ErrInvalidMessage, f.wireType, f.number, wireType)
+	}
+	return nil
+}
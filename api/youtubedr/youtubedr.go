@@ -0,0 +1,403 @@
+// Package youtubedr implements the gRPC API of youtubedr serve, defined in api/proto/youtubedr.proto.
+//
+// The messages and the service handler are written by hand against the protobuf wire format and the gRPC
+// protocol over HTTP/2, so the module does not depend on the protobuf and gRPC runtimes. They only cover
+// the messages of the .proto file, TestMessages_proto checks them against it, changes of the .proto file
+// have to be made here as well. Clients generate their stubs from the .proto file with protoc as usual.
+//
+// Plaintext gRPC clients require unencrypted HTTP/2, which net/http serves since Go 1.24.
+package youtubedr
+
+// message is implemented by all messages of the API
+type message interface {
+	Marshal() []byte
+	Unmarshal(data []byte) error
+}
+
+// DownloadState is the state of a download, Download.State in the .proto file
+type DownloadState int32
+
+const (
+	DownloadStateUnspecified DownloadState = iota
+	DownloadStateQueued
+	DownloadStateRunning
+	DownloadStateDone
+	DownloadStateFailed
+	DownloadStateCanceled
+	DownloadStatePaused
+)
+
+func (s DownloadState) String() string {
+	switch s {
+	case DownloadStateQueued:
+		return "STATE_QUEUED"
+	case DownloadStateRunning:
+		return "STATE_RUNNING"
+	case DownloadStateDone:
+		return "STATE_DONE"
+	case DownloadStateFailed:
+		return "STATE_FAILED"
+	case DownloadStateCanceled:
+		return "STATE_CANCELED"
+	case DownloadStatePaused:
+		return "STATE_PAUSED"
+	default:
+		return "STATE_UNSPECIFIED"
+	}
+}
+
+// GetVideoRequest asks for the metadata of a video
+type GetVideoRequest struct {
+	// Video is the URL or ID of the video
+	Video string
+}
+
+func (m *GetVideoRequest) Marshal() []byte {
+	var e encoder
+	e.string(1, m.Video)
+	return e.buf
+}
+
+func (m *GetVideoRequest) Unmarshal(data []byte) error {
+	*m = GetVideoRequest{}
+	return decode(data, func(f field) error {
+		if f.number == 1 {
+			m.Video = f.string()
+			return f.expect(wireBytes)
+		}
+		return nil
+	})
+}
+
+// Video is the metadata of a video
+type Video struct {
+	ID              string
+	Title           string
+	Author          string
+	Description     string
+	DurationSeconds int64
+	// PublishDate is formatted as YYYY-MM-DD
+	PublishDate string
+}
+
+func (m *Video) Marshal() []byte {
+	var e encoder
+	e.string(1, m.ID)
+	e.string(2, m.Title)
+	e.string(3, m.Author)
+	e.string(4, m.Description)
+	e.int(5, m.DurationSeconds)
+	e.string(6, m.PublishDate)
+	return e.buf
+}
+
+func (m *Video) Unmarshal(data []byte) error {
+	*m = Video{}
+	return decode(data, func(f field) error {
+		switch f.number {
+		case 1:
+			m.ID = f.string()
+		case 2:
+			m.Title = f.string()
+		case 3:
+			m.Author = f.string()
+		case 4:
+			m.Description = f.string()
+		case 5:
+			m.DurationSeconds = f.int64()
+			return f.expect(wireVarint)
+		case 6:
+			m.PublishDate = f.string()
+		default:
+			return nil
+		}
+		return f.expect(wireBytes)
+	})
+}
+
+// ListFormatsRequest asks for the formats of a video
+type ListFormatsRequest struct {
+	// Video is the URL or ID of the video
+	Video string
+}
+
+func (m *ListFormatsRequest) Marshal() []byte {
+	var e encoder
+	e.string(1, m.Video)
+	return e.buf
+}
+
+func (m *ListFormatsRequest) Unmarshal(data []byte) error {
+	*m = ListFormatsRequest{}
+	return decode(data, func(f field) error {
+		if f.number == 1 {
+			m.Video = f.string()
+			return f.expect(wireBytes)
+		}
+		return nil
+	})
+}
+
+// ListFormatsResponse are the formats of a video
+type ListFormatsResponse struct {
+	Formats []*Format
+}
+
+func (m *ListFormatsResponse) Marshal() []byte {
+	var e encoder
+	for _, format := range m.Formats {
+		e.element(1, format.Marshal())
+	}
+	return e.buf
+}
+
+func (m *ListFormatsResponse) Unmarshal(data []byte) error {
+	*m = ListFormatsResponse{}
+	return decode(data, func(f field) error {
+		if f.number != 1 {
+			return nil
+		}
+		if err := f.expect(wireBytes); err != nil {
+			return err
+		}
+		format := &Format{}
+		if err := format.Unmarshal(f.data); err != nil {
+			return err
+		}
+		m.Formats = append(m.Formats, format)
+		return nil
+	})
+}
+
+// Format mirrors youtube.FormatInfo
+type Format struct {
+	Itag         int32
+	VideoQuality string
+	AudioQuality string
+	Size         int64
+	Bitrate      int32
+	MimeType     string
+	Container    string
+	VideoCodec   string
+	AudioCodec   string
+	Width        int32
+	Height       int32
+	FPS          int32
+}
+
+func (m *Format) Marshal() []byte {
+	var e encoder
+	e.int(1, int64(m.Itag))
+	e.string(2, m.VideoQuality)
+	e.string(3, m.AudioQuality)
+	e.int(4, m.Size)
+	e.int(5, int64(m.Bitrate))
+	e.string(6, m.MimeType)
+	e.string(7, m.Container)
+	e.string(8, m.VideoCodec)
+	e.string(9, m.AudioCodec)
+	e.int(10, int64(m.Width))
+	e.int(11, int64(m.Height))
+	e.int(12, int64(m.FPS))
+	return e.buf
+}
+
+func (m *Format) Unmarshal(data []byte) error {
+	*m = Format{}
+	return decode(data, func(f field) error {
+		switch f.number {
+		case 1:
+			m.Itag = f.int32()
+		case 2:
+			m.VideoQuality = f.string()
+			return f.expect(wireBytes)
+		case 3:
+			m.AudioQuality = f.string()
+			return f.expect(wireBytes)
+		case 4:
+			m.Size = f.int64()
+		case 5:
+			m.Bitrate = f.int32()
+		case 6:
+			m.MimeType = f.string()
+			return f.expect(wireBytes)
+		case 7:
+			m.Container = f.string()
+			return f.expect(wireBytes)
+		case 8:
+			m.VideoCodec = f.string()
+			return f.expect(wireBytes)
+		case 9:
+			m.AudioCodec = f.string()
+			return f.expect(wireBytes)
+		case 10:
+			m.Width = f.int32()
+		case 11:
+			m.Height = f.int32()
+		case 12:
+			m.FPS = f.int32()
+		default:
+			return nil
+		}
+		return f.expect(wireVarint)
+	})
+}
+
+// StartDownloadRequest queues a download
+type StartDownloadRequest struct {
+	// Video is the URL or ID of the video
+	Video string
+	// Quality is an itag, quality or quality label, see youtube.FormatOptions
+	Quality string
+	Codecs  []string
+	// OutputFile is relative to the output directory, it defaults to the output template
+	OutputFile string
+}
+
+func (m *StartDownloadRequest) Marshal() []byte {
+	var e encoder
+	e.string(1, m.Video)
+	e.string(2, m.Quality)
+	for _, codec := range m.Codecs {
+		e.element(3, []byte(codec))
+	}
+	e.string(4, m.OutputFile)
+	return e.buf
+}
+
+func (m *StartDownloadRequest) Unmarshal(data []byte) error {
+	*m = StartDownloadRequest{}
+	return decode(data, func(f field) error {
+		switch f.number {
+		case 1:
+			m.Video = f.string()
+		case 2:
+			m.Quality = f.string()
+		case 3:
+			m.Codecs = append(m.Codecs, f.string())
+		case 4:
+			m.OutputFile = f.string()
+		default:
+			return nil
+		}
+		return f.expect(wireBytes)
+	})
+}
+
+// Download is a queued download
+type Download struct {
+	ID      string
+	VideoID string
+	State   DownloadState
+	Error   string
+}
+
+func (m *Download) Marshal() []byte {
+	var e encoder
+	e.string(1, m.ID)
+	e.string(2, m.VideoID)
+	e.int(3, int64(m.State))
+	e.string(4, m.Error)
+	return e.buf
+}
+
+func (m *Download) Unmarshal(data []byte) error {
+	*m = Download{}
+	return decode(data, func(f field) error {
+		switch f.number {
+		case 1:
+			m.ID = f.string()
+		case 2:
+			m.VideoID = f.string()
+		case 3:
+			m.State = DownloadState(f.int32())
+			return f.expect(wireVarint)
+		case 4:
+			m.Error = f.string()
+		default:
+			return nil
+		}
+		return f.expect(wireBytes)
+	})
+}
+
+// StreamProgressRequest subscribes to the progress of a download
+type StreamProgressRequest struct {
+	DownloadID string
+}
+
+func (m *StreamProgressRequest) Marshal() []byte {
+	var e encoder
+	e.string(1, m.DownloadID)
+	return e.buf
+}
+
+func (m *StreamProgressRequest) Unmarshal(data []byte) error {
+	*m = StreamProgressRequest{}
+	return decode(data, func(f field) error {
+		if f.number == 1 {
+			m.DownloadID = f.string()
+			return f.expect(wireBytes)
+		}
+		return nil
+	})
+}
+
+// Progress is an update of a download
+type Progress struct {
+	DownloadID     string
+	State          DownloadState
+	BytesCompleted int64
+	BytesTotal     int64
+}
+
+func (m *Progress) Marshal() []byte {
+	var e encoder
+	e.string(1, m.DownloadID)
+	e.int(2, int64(m.State))
+	e.int(3, m.BytesCompleted)
+	e.int(4, m.BytesTotal)
+	return e.buf
+}
+
+func (m *Progress) Unmarshal(data []byte) error {
+	*m = Progress{}
+	return decode(data, func(f field) error {
+		switch f.number {
+		case 1:
+			m.DownloadID = f.string()
+			return f.expect(wireBytes)
+		case 2:
+			m.State = DownloadState(f.int32())
+		case 3:
+			m.BytesCompleted = f.int64()
+		case 4:
+			m.BytesTotal = f.int64()
+		default:
+			return nil
+		}
+		return f.expect(wireVarint)
+	})
+}
+
+// CancelDownloadRequest stops a download
+type CancelDownloadRequest struct {
+	DownloadID string
+}
+
+func (m *CancelDownloadRequest) Marshal() []byte {
+	var e encoder
+	e.string(1, m.DownloadID)
+	return e.buf
+}
+
+func (m *CancelDownloadRequest) Unmarshal(data []byte) error {
+	*m = CancelDownloadRequest{}
+	return decode(data, func(f field) error {
+		if f.number == 1 {
+			m.DownloadID = f.string()
+			return f.expect(wireBytes)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+package youtubedr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessages_roundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   message
+		out  message
+	}{
+		{"GetVideoRequest", &GetVideoRequest{Video: "BaW_jenozKc"}, &GetVideoRequest{}},
+		{"Video", &Video{ID: "BaW_jenozKc", Title: "test", Author: "someone", Description: "a\nb",
+			DurationSeconds: 3725, PublishDate: "2012-10-02"}, &Video{}},
+		{"ListFormatsRequest", &ListFormatsRequest{Video: "BaW_jenozKc"}, &ListFormatsRequest{}},
+		{"ListFormatsResponse", &ListFormatsResponse{Formats: []*Format{
+			{Itag: 22, VideoQuality: "720p", AudioQuality: "medium", Size: 1 << 40, Bitrate: 1000000, MimeType: "video/mp4",
+				Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Width: 1280, Height: 720, FPS: 30},
+			{},
+			{Itag: 140, Bitrate: -1},
+		}}, &ListFormatsResponse{}},
+		{"StartDownloadRequest", &StartDownloadRequest{Video: "BaW_jenozKc", Quality: "hd720", Codecs: []string{"avc1", ""},
+			OutputFile: "a/b.mp4"}, &StartDownloadRequest{}},
+		{"Download", &Download{ID: "1", VideoID: "BaW_jenozKc", State: DownloadStateFailed, Error: "boom"}, &Download{}},
+		{"StreamProgressRequest", &StreamProgressRequest{DownloadID: "1"}, &StreamProgressRequest{}},
+		{"Progress", &Progress{DownloadID: "1", State: DownloadStateRunning, BytesCompleted: 10, BytesTotal: 20}, &Progress{}},
+		{"CancelDownloadRequest", &CancelDownloadRequest{DownloadID: "1"}, &CancelDownloadRequest{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.out.Unmarshal(tt.in.Marshal()))
+			assert.Equal(t, tt.in, tt.out)
+		})
+	}
+}
+
+func TestVideo_wireFormat(t *testing.T) {
+	// as encoded by protoc for {id: "abc", duration_seconds: 150}
+	data := []byte{0x0a, 0x03, 'a', 'b', 'c', 0x28, 0x96, 0x01}
+	assert.Equal(t, data, (&Video{ID: "abc", DurationSeconds: 150}).Marshal())
+
+	// unknown fields of all wire types are skipped
+	unknown := append([]byte{0x78, 0x01, 0x81, 0x01, 1, 2, 3, 4, 5, 6, 7, 8, 0x8a, 0x01, 0x01, 'x', 0x95, 0x01, 1, 2, 3, 4}, data...)
+	var v Video
+	require.NoError(t, v.Unmarshal(unknown))
+	assert.Equal(t, Video{ID: "abc", DurationSeconds: 150}, v)
+
+	// negative int32 are sign extended to ten bytes
+	assert.Len(t, (&Format{Itag: -1}).Marshal(), 11)
+}
+
+func TestMessage_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated key", []byte{0x80}},
+		{"field number 0", []byte{0x00, 0x01}},
+		{"truncated varint", []byte{0x28, 0x96}},
+		{"truncated string", []byte{0x0a, 0x05, 'a'}},
+		{"truncated fixed64", []byte{0x79, 1, 2}},
+		{"group", []byte{0x0b}},
+		{"wrong wire type", []byte{0x08, 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Video{}).Unmarshal(tt.data)
+			assert.True(t, errors.Is(err, ErrInvalidMessage), "%v", err)
+		})
+	}
+}
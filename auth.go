@@ -0,0 +1,59 @@
+package youtube
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// youtubeOrigin is the origin the authorization of innertube requests is bound to
+const youtubeOrigin = "https://www.youtube.com"
+
+// sapisidCookies are the cookies holding the SAPISID of a signed in browser, in order of preference
+var sapisidCookies = []string{"SAPISID", "__Secure-3PAPISID"}
+
+// IsAuthenticated tells whether the cookie jar of the HTTP client holds the cookies of a signed in account,
+// e.g. exported from a browser. Innertube requests are authorized by them.
+func (c *Client) IsAuthenticated() bool {
+	return c.sapisid() != ""
+}
+
+// sapisid returns the SAPISID cookie of youtube.com, empty if not signed in
+func (c *Client) sapisid() string {
+	jar := c.httpClient().Jar
+	if jar == nil {
+		return ""
+	}
+	u, _ := url.Parse(youtubeOrigin)
+	cookies := jar.Cookies(u)
+	for _, name := range sapisidCookies {
+		for _, cookie := range cookies {
+			if cookie.Name == name && cookie.Value != "" {
+				return cookie.Value
+			}
+		}
+	}
+	return ""
+}
+
+// authorize adds the SAPISIDHASH authorization of a signed in account to an innertube request,
+// the cookies themselves are added by the cookie jar
+func (c *Client) authorize(req *http.Request) {
+	sapisid := c.sapisid()
+	if sapisid == "" {
+		return
+	}
+	req.Header.Set("Authorization", sapisidHash(sapisid, youtubeOrigin, time.Now()))
+	req.Header.Set("X-Origin", youtubeOrigin)
+	req.Header.Set("X-Goog-AuthUser", "0")
+}
+
+// sapisidHash builds the authorization header: SAPISIDHASH <time>_<SHA-1 of "<time> <SAPISID> <origin>">
+func sapisidHash(sapisid, origin string, now time.Time) string {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	sum := sha1.Sum([]byte(timestamp + " " + sapisid + " " + origin))
+	return "SAPISIDHASH " + timestamp + "_" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,88 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Availability classifies whether a video can be watched
+type Availability string
+
+// Availabilities of videos
+const (
+	AvailabilityPublic        Availability = "public"
+	AvailabilityUnlisted      Availability = "unlisted"
+	AvailabilityPrivate       Availability = "private"
+	AvailabilityDeleted       Availability = "deleted"
+	AvailabilityAgeRestricted Availability = "age_restricted"
+	AvailabilityGeoBlocked    Availability = "geo_blocked"
+	// AvailabilityUnknown is returned for other states, e.g. offline live streams or videos requiring a login
+	AvailabilityUnknown Availability = "unknown"
+)
+
+// CheckAvailability classifies a video, given by its ID or URL, with a single request.
+// Unlike GetVideo, it neither parses the formats nor fetches the watch page or the player.
+func (c *Client) CheckAvailability(ctx context.Context, videoURL string) (Availability, error) {
+	id, err := extractVideoID(videoURL)
+	if err != nil {
+		return AvailabilityUnknown, fmt.Errorf("extractVideoID failed: %w", err)
+	}
+
+	body, err := c.httpGetBodyBytes(ctx, videoInfoURL(id))
+	if err != nil {
+		return AvailabilityUnknown, err
+	}
+
+	answer, err := url.ParseQuery(string(body))
+	if err != nil {
+		return AvailabilityUnknown, err
+	}
+	if status := answer.Get("status"); status != "ok" {
+		return AvailabilityUnknown, &ErrResponseStatus{
+			Status: status,
+			Reason: answer.Get("reason"),
+		}
+	}
+
+	var prData struct {
+		PlayabilityStatus struct {
+			Status          string `json:"status"`
+			Reason          string `json:"reason"`
+			PlayableInEmbed bool   `json:"playableInEmbed"`
+		} `json:"playabilityStatus"`
+		Microformat struct {
+			PlayerMicroformatRenderer struct {
+				IsUnlisted bool `json:"isUnlisted"`
+			} `json:"playerMicroformatRenderer"`
+		} `json:"microformat"`
+	}
+	if err := json.Unmarshal([]byte(answer.Get("player_response")), &prData); err != nil {
+		return AvailabilityUnknown, fmt.Errorf("%w: unable to parse player response JSON: %v", ErrParse, err)
+	}
+
+	status := prData.PlayabilityStatus
+	switch (ErrPlayabiltyStatus{Status: status.Status, Reason: status.Reason}).Category() {
+	case ErrPrivate:
+		return AvailabilityPrivate, nil
+	case ErrNotFound:
+		return AvailabilityDeleted, nil
+	case ErrAgeRestricted:
+		return AvailabilityAgeRestricted, nil
+	case ErrGeoBlocked:
+		return AvailabilityGeoBlocked, nil
+	case ErrRateLimited:
+		return AvailabilityUnknown, ErrRateLimited
+	}
+
+	// videos which cannot be embedded still exist and are playable on the watch page
+	if status.Status == "OK" || (status.Status == "UNPLAYABLE" && !status.PlayableInEmbed) {
+		if prData.Microformat.PlayerMicroformatRenderer.IsUnlisted {
+			return AvailabilityUnlisted, nil
+		}
+		return AvailabilityPublic, nil
+	}
+
+	return AvailabilityUnknown, nil
+}
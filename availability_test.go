@@ -0,0 +1,43 @@
+package youtube
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_CheckAvailability(t *testing.T) {
+	tests := []struct {
+		playerResponse string
+		want           Availability
+	}{
+		{`{"playabilityStatus":{"status":"OK"}}`, AvailabilityPublic},
+		{`{"playabilityStatus":{"status":"OK"},"microformat":{"playerMicroformatRenderer":{"isUnlisted":true}}}`, AvailabilityUnlisted},
+		{`{"playabilityStatus":{"status":"UNPLAYABLE","reason":"Playback on other websites has been disabled"}}`, AvailabilityPublic},
+		{`{"playabilityStatus":{"status":"LOGIN_REQUIRED","reason":"This video is private."}}`, AvailabilityPrivate},
+		{`{"playabilityStatus":{"status":"ERROR","reason":"Video unavailable"}}`, AvailabilityDeleted},
+		{`{"playabilityStatus":{"status":"LOGIN_REQUIRED","reason":"Sign in to confirm your age"}}`, AvailabilityAgeRestricted},
+		{`{"playabilityStatus":{"status":"UNPLAYABLE","reason":"The uploader has not made this video available in your country.","playableInEmbed":true}}`, AvailabilityGeoBlocked},
+		{`{"playabilityStatus":{"status":"LIVE_STREAM_OFFLINE","playableInEmbed":true}}`, AvailabilityUnknown},
+	}
+
+	for _, tt := range tests {
+		client := &Client{HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "/get_video_info", req.URL.Path, "only the video info must be requested")
+			body := url.Values{"status": {"ok"}, "player_response": {tt.playerResponse}}.Encode()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		})}}
+
+		availability, err := client.CheckAvailability(context.Background(), "BaW_jenozKc")
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, availability, tt.playerResponse)
+	}
+}
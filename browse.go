@@ -0,0 +1,135 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// browse requests a page of the innertube browse endpoint, e.g. a tab of a channel.
+// The region is an optional country code for regional pages like the trending videos.
+func (c *Client) browse(ctx context.Context, browseID, params, region string) (interface{}, error) {
+	request := map[string]interface{}{"browseId": browseID}
+	if params != "" {
+		request["params"] = params
+	}
+
+	var response interface{}
+	if err := c.innertubeRequest(ctx, "browse", region, request, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// browseContinuation requests the next page of a browse response
+func (c *Client) browseContinuation(ctx context.Context, token string) (interface{}, error) {
+	var response interface{}
+	if err := c.innertubeRequest(ctx, "browse", "", map[string]interface{}{"continuation": token}, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// findRenderers decodes all values of the key in the response into the slice pointed to by renderers.
+// Renderers are nested deeply in innertube responses and their position changes often, so they are searched for.
+func findRenderers(response interface{}, key string, renderers interface{}) error {
+	var found []interface{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				if k == key {
+					found = append(found, child)
+					continue
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(response)
+
+	data, err := json.Marshal(found)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, renderers)
+}
+
+// findContinuation returns the first continuation token of the response, empty if there is no further page
+func findContinuation(response interface{}) string {
+	var commands []struct {
+		Token string `json:"token"`
+	}
+	if err := findRenderers(response, "continuationCommand", &commands); err != nil {
+		return ""
+	}
+	for _, command := range commands {
+		if command.Token != "" {
+			return command.Token
+		}
+	}
+	return ""
+}
+
+// innertubeText is a text of an innertube response, it is either simple or made of runs
+type innertubeText struct {
+	SimpleText string `json:"simpleText"`
+	Runs       []struct {
+		Text               string `json:"text"`
+		NavigationEndpoint struct {
+			URLEndpoint struct {
+				URL string `json:"url"`
+			} `json:"urlEndpoint"`
+			WatchEndpoint struct {
+				VideoID string `json:"videoId"`
+			} `json:"watchEndpoint"`
+			BrowseEndpoint struct {
+				BrowseID string `json:"browseId"`
+			} `json:"browseEndpoint"`
+		} `json:"navigationEndpoint"`
+	} `json:"runs"`
+}
+
+func (t innertubeText) String() string {
+	if t.SimpleText != "" {
+		return t.SimpleText
+	}
+	var sb strings.Builder
+	for _, run := range t.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// parseApproxCount parses counts as displayed by YouTube, e.g. "1.23M subscribers" or "1,234 views".
+// It returns 0 if the text has no count.
+func parseApproxCount(text string) int64 {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0
+	}
+	number := strings.ReplaceAll(fields[0], ",", "")
+
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(number, "K"):
+		multiplier = 1e3
+	case strings.HasSuffix(number, "M"):
+		multiplier = 1e6
+	case strings.HasSuffix(number, "B"):
+		multiplier = 1e9
+	}
+	number = strings.TrimRight(number, "KMB")
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(n*multiplier + 0.5)
+}
@@ -0,0 +1,42 @@
+package youtube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseApproxCount(t *testing.T) {
+	tests := map[string]int64{
+		"1.23M subscribers": 1230000,
+		"12K subscribers":   12000,
+		"1,234,567 views":   1234567,
+		"2.5B views":        2500000000,
+		"No views":          0,
+		"":                  0,
+	}
+	for text, want := range tests {
+		assert.Equal(t, want, parseApproxCount(text), text)
+	}
+}
+
+func TestFindRenderers(t *testing.T) {
+	response := map[string]interface{}{
+		"contents": []interface{}{
+			map[string]interface{}{"videoRenderer": map[string]interface{}{"videoId": "a"}},
+			map[string]interface{}{"shelf": map[string]interface{}{
+				"items": []interface{}{map[string]interface{}{"videoRenderer": map[string]interface{}{"videoId": "b"}}},
+			}},
+		},
+		"continuationItemRenderer": map[string]interface{}{
+			"continuationEndpoint": map[string]interface{}{"continuationCommand": map[string]interface{}{"token": "next"}},
+		},
+	}
+
+	var videos []struct {
+		VideoID string `json:"videoId"`
+	}
+	assert.NoError(t, findRenderers(response, "videoRenderer", &videos))
+	assert.Len(t, videos, 2)
+	assert.Equal(t, "next", findContinuation(response))
+}
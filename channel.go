@@ -0,0 +1,157 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// channelAboutParams selects the about tab of a channel in browse requests
+const channelAboutParams = "EgVhYm91dA=="
+
+var (
+	channelIDRegex    = regexp.MustCompile("^UC[A-Za-z0-9_-]{22}$")
+	channelInURLRegex = regexp.MustCompile(`/channel/(UC[A-Za-z0-9_-]{22})(?:[/?#]|$)`)
+)
+
+// ChannelInfo is the metadata of a channel as shown on its about tab.
+// Its JSON field names are stable, so it can be stored in manifest files.
+type ChannelInfo struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// SubscriberCount is approximate, YouTube rounds it to three significant digits
+	SubscriberCount int64         `json:"subscriberCount"`
+	ViewCount       int64         `json:"viewCount"`
+	Country         string        `json:"country,omitempty"`
+	CreationDate    time.Time     `json:"creationDate"`
+	Avatars         Thumbnails    `json:"avatars"`
+	Banners         Thumbnails    `json:"banners"`
+	Links           []ChannelLink `json:"links"`
+}
+
+// ChannelLink is a link of a channel to an external site
+type ChannelLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// GetChannelInfo fetches the about tab of a channel, given by its ID or URL
+func (c *Client) GetChannelInfo(ctx context.Context, channel string) (*ChannelInfo, error) {
+	id, err := extractChannelID(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.browse(ctx, id, channelAboutParams, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []struct {
+		Title               string        `json:"title"`
+		SubscriberCountText innertubeText `json:"subscriberCountText"`
+		Avatar              struct {
+			Thumbnails Thumbnails `json:"thumbnails"`
+		} `json:"avatar"`
+		Banner struct {
+			Thumbnails Thumbnails `json:"thumbnails"`
+		} `json:"banner"`
+	}
+	if err := findRenderers(response, "c4TabbedHeaderRenderer", &headers); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParse, err)
+	}
+
+	var abouts []struct {
+		Title          innertubeText `json:"title"`
+		Description    innertubeText `json:"description"`
+		Country        innertubeText `json:"country"`
+		JoinedDateText innertubeText `json:"joinedDateText"`
+		ViewCountText  innertubeText `json:"viewCountText"`
+		PrimaryLinks   []struct {
+			Title              innertubeText `json:"title"`
+			NavigationEndpoint struct {
+				URLEndpoint struct {
+					URL string `json:"url"`
+				} `json:"urlEndpoint"`
+			} `json:"navigationEndpoint"`
+		} `json:"primaryLinks"`
+	}
+	if err := findRenderers(response, "channelAboutFullMetadataRenderer", &abouts); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParse, err)
+	}
+	if len(abouts) == 0 {
+		return nil, fmt.Errorf("%w: no channel metadata found", ErrParse)
+	}
+	about := abouts[0]
+
+	info := &ChannelInfo{
+		ID:          id,
+		Title:       about.Title.String(),
+		Description: about.Description.String(),
+		ViewCount:   parseApproxCount(about.ViewCountText.String()),
+		Country:     about.Country.String(),
+	}
+
+	// e.g. "Joined Mar 11, 2008"
+	joined := strings.TrimSpace(strings.TrimPrefix(about.JoinedDateText.String(), "Joined"))
+	if date, err := time.Parse("Jan 2, 2006", joined); err == nil {
+		info.CreationDate = date
+	}
+
+	for _, link := range about.PrimaryLinks {
+		info.Links = append(info.Links, ChannelLink{
+			Title: link.Title.String(),
+			URL:   unwrapRedirectURL(link.NavigationEndpoint.URLEndpoint.URL),
+		})
+	}
+
+	if len(headers) > 0 {
+		header := headers[0]
+		if info.Title == "" {
+			info.Title = header.Title
+		}
+		info.SubscriberCount = parseApproxCount(header.SubscriberCountText.String())
+		info.Avatars = absoluteThumbnails(header.Avatar.Thumbnails)
+		info.Banners = absoluteThumbnails(header.Banner.Thumbnails)
+	}
+
+	return info, nil
+}
+
+func extractChannelID(channel string) (string, error) {
+	if channelIDRegex.MatchString(channel) {
+		return channel, nil
+	}
+
+	if matches := channelInURLRegex.FindStringSubmatch(channel); matches != nil {
+		return matches[1], nil
+	}
+
+	return "", ErrInvalidChannel
+}
+
+// unwrapRedirectURL returns the target of links going through https://www.youtube.com/redirect
+func unwrapRedirectURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path != "/redirect" {
+		return rawURL
+	}
+	if target := u.Query().Get("q"); target != "" {
+		return target
+	}
+	return rawURL
+}
+
+// absoluteThumbnails adds the scheme to protocol relative thumbnail URLs
+func absoluteThumbnails(list Thumbnails) Thumbnails {
+	for i := range list {
+		if strings.HasPrefix(list[i].URL, "//") {
+			list[i].URL = "https:" + list[i].URL
+		}
+	}
+	return list
+}
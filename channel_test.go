@@ -0,0 +1,56 @@
+package youtube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testChannelAbout = `{"header":{"c4TabbedHeaderRenderer":{"channelId":"UCdN4aXTrHAtfgbVG9HjBmxQ","title":"Test Channel",
+"avatar":{"thumbnails":[{"url":"//yt3.ggpht.com/avatar=s48","width":48,"height":48}]},
+"banner":{"thumbnails":[{"url":"https://yt3.ggpht.com/banner=w1060","width":1060,"height":175}]},
+"subscriberCountText":{"simpleText":"1.23M subscribers"}}},
+"contents":{"twoColumnBrowseResultsRenderer":{"tabs":[{"tabRenderer":{"title":"About","content":{"sectionListRenderer":{"contents":[
+{"itemSectionRenderer":{"contents":[{"channelAboutFullMetadataRenderer":{
+"description":{"simpleText":"All about testing"},
+"viewCountText":{"simpleText":"45,678,901 views"},
+"joinedDateText":{"runs":[{"text":"Joined "},{"text":"Mar 11, 2008"}]},
+"country":{"simpleText":"Germany"},
+"title":{"simpleText":"Test Channel"},
+"primaryLinks":[{"title":{"simpleText":"Website"},"navigationEndpoint":{"urlEndpoint":{"url":"https://www.youtube.com/redirect?event=channel_banner&q=https%3A%2F%2Fexample.com%2F"}}}]
+}}]}}]}}}}]}}}`
+
+func TestClient_GetChannelInfo(t *testing.T) {
+	var browseID, params interface{}
+	client := newInnertubeTestClient(func(endpoint string, payload map[string]interface{}) string {
+		browseID, params = payload["browseId"], payload["params"]
+		return testChannelAbout
+	})
+
+	info, err := client.GetChannelInfo(context.Background(), "https://www.youtube.com/channel/UCdN4aXTrHAtfgbVG9HjBmxQ/videos")
+	require.NoError(t, err)
+	assert.Equal(t, "UCdN4aXTrHAtfgbVG9HjBmxQ", browseID)
+	assert.Equal(t, channelAboutParams, params)
+
+	assert.Equal(t, "Test Channel", info.Title)
+	assert.Equal(t, "All about testing", info.Description)
+	assert.Equal(t, int64(1230000), info.SubscriberCount)
+	assert.Equal(t, int64(45678901), info.ViewCount)
+	assert.Equal(t, "Germany", info.Country)
+	assert.Equal(t, time.Date(2008, 3, 11, 0, 0, 0, 0, time.UTC), info.CreationDate)
+	assert.Equal(t, "https://yt3.ggpht.com/avatar=s48", info.Avatars[0].URL)
+	assert.Equal(t, uint(1060), info.Banners[0].Width)
+	assert.Equal(t, []ChannelLink{{Title: "Website", URL: "https://example.com/"}}, info.Links)
+}
+
+func TestExtractChannelID(t *testing.T) {
+	_, err := extractChannelID("https://www.youtube.com/user/test")
+	assert.Equal(t, ErrInvalidChannel, err)
+
+	id, err := extractChannelID("UCdN4aXTrHAtfgbVG9HjBmxQ")
+	assert.NoError(t, err)
+	assert.Equal(t, "UCdN4aXTrHAtfgbVG9HjBmxQ", id)
+}
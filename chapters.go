@@ -0,0 +1,50 @@
+package youtube
+
+import "time"
+
+// Rules of YouTube for chapters in descriptions
+const (
+	minDescriptionChapters      = 3
+	minDescriptionChapterLength = 10 * time.Second
+)
+
+// Chapter is a titled part of a video or media file
+type Chapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// DescriptionChapters returns the chapters of the timestamps in a description, like
+//
+//	0:00 Intro
+//	1:05 The problem
+//
+// The timestamps must follow the rules of YouTube: start at 0:00, ascend, name at least three chapters
+// and leave ten seconds for each one. Otherwise nil is returned. The last chapter ends at the duration if known.
+func DescriptionChapters(entities []DescriptionEntity, duration time.Duration) []Chapter {
+	var chapters []Chapter
+	for _, entity := range entities {
+		if entity.Type != EntityTimestamp || entity.Title == "" {
+			continue
+		}
+		if len(chapters) == 0 {
+			if entity.Time != 0 {
+				continue
+			}
+		} else if last := &chapters[len(chapters)-1]; entity.Time-last.Start < minDescriptionChapterLength {
+			return nil
+		} else {
+			last.End = entity.Time
+		}
+		chapters = append(chapters, Chapter{Title: entity.Title, Start: entity.Time})
+	}
+
+	if len(chapters) < minDescriptionChapters {
+		return nil
+	}
+	if last := &chapters[len(chapters)-1]; duration > last.Start {
+		last.End = duration
+	}
+	return chapters
+}
@@ -0,0 +1,47 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescriptionChapters(t *testing.T) {
+	entities := ParseDescription("Talk at dotGo, slides at 3:00 of the video.\n" +
+		"0:00 Intro\n" +
+		"1:05 - The problem\n" +
+		"2:30 Questions")
+
+	assert.Equal(t, []Chapter{
+		{Title: "Intro", Start: 0, End: time.Minute + 5*time.Second},
+		{Title: "The problem", Start: time.Minute + 5*time.Second, End: 150 * time.Second},
+		{Title: "Questions", Start: 150 * time.Second, End: 200 * time.Second},
+	}, DescriptionChapters(entities, 200*time.Second))
+
+	chapters := DescriptionChapters(entities, 0)
+	require.Len(t, chapters, 3)
+	assert.Equal(t, time.Duration(0), chapters[2].End)
+
+	// too few chapters, no start at 0:00, too short chapters
+	assert.Nil(t, DescriptionChapters(ParseDescription("0:00 Intro\n1:00 End"), time.Hour))
+	assert.Nil(t, DescriptionChapters(ParseDescription("0:10 Intro\n1:00 Middle\n2:00 End"), time.Hour))
+	assert.Nil(t, DescriptionChapters(ParseDescription("0:00 Intro\n0:05 Middle\n2:00 End"), time.Hour))
+}
+
+func TestVideo_parsePlayerResponse_DescriptionChapters(t *testing.T) {
+	const data = `{"playabilityStatus":{"status":"OK"},` +
+		`"videoDetails":{"title":"test","lengthSeconds":"200","shortDescription":"0:00 Intro\n1:00 Middle\n2:00 End"},` +
+		`"streamingData":{"formats":[{"itag":18,"url":"https://example.com/videoplayback?itag=18"}]}}`
+
+	v := &Video{}
+	require.NoError(t, v.parsePlayerResponse([]byte(data), true, parseOptions{}))
+	assert.Len(t, v.DescriptionEntities, 3)
+	assert.Nil(t, v.Chapters)
+
+	v = &Video{}
+	require.NoError(t, v.parsePlayerResponse([]byte(data), true, parseOptions{descriptionChapters: true}))
+	require.Len(t, v.Chapters, 3)
+	assert.Equal(t, "End", v.Chapters[2].Title)
+}
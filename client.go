@@ -3,10 +3,13 @@ package youtube
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 // Client offers methods to download video metadata and video streams.
@@ -18,10 +21,45 @@ type Client struct {
 	DebugHTTPClient bool
 
 	// HTTPClient can be used to set a custom HTTP client.
-	// If not set, http.DefaultClient will be used
+	// If not set, a client with the Transport configuration or http.DefaultClient will be used
 	HTTPClient *http.Client
 
-	// decipherOpsCache cache decipher operations
+	// Transport tunes the connections if HTTPClient is not set, e.g. to disable HTTP/2
+	Transport *TransportConfig
+
+	// StreamHostRewrite changes the URLs of streams, e.g. to route the googlevideo hosts through a caching proxy
+	// or a mirror, see StreamHostMapping. Metadata requests are not affected.
+	StreamHostRewrite func(*url.URL) *url.URL
+
+	// DebugRecorder receives all HTTP exchanges, to reproduce extraction failures
+	DebugRecorder Recorder
+
+	// DecipherStrategy runs the challenges of the player, e.g. a JSDecipherStrategy with a JavaScript interpreter.
+	// If not set, the signature operations are parsed from the player.
+	DecipherStrategy DecipherStrategy
+
+	// CacheDir keeps the state of the session, like the visitor data, across runs
+	CacheDir string
+
+	// POTokenProvider supplies the proof-of-origin tokens added to the URLs of streams
+	POTokenProvider POTokenProvider
+
+	// Extractor fetches the videos and resolves the stream URLs, DefaultExtractor if not set
+	Extractor Extractor
+
+	// ParseMode controls how deviations of player responses from the expected schema are handled,
+	// ParseStrict if not set
+	ParseMode ParseMode
+	// KeepRawPlayerResponse retains the player responses as Video.RawPlayerResponse
+	KeepRawPlayerResponse bool
+	// ChaptersFromDescription sets Video.Chapters from the timestamps of the description, see DescriptionChapters
+	ChaptersFromDescription bool
+
+	// MaxResponseSize caps the bytes read from metadata responses like pages and players, defaults to 32 MiB.
+	// Negative values disable the limit. Streams are not affected.
+	MaxResponseSize int64
+
+	// decipherOpsCache cache decipher operations per player version
 	decipherOpsCache DecipherOperationsCache
 }
 
@@ -30,19 +68,30 @@ func (c *Client) GetVideo(url string) (*Video, error) {
 	return c.GetVideoContext(context.Background(), url)
 }
 
-// GetVideoContext fetches video metadata with a context
+// GetVideoContext fetches video metadata with a context.
+// Clip URLs are resolved to the clipped video, Video.Clip holds the offsets of the clip.
 func (c *Client) GetVideoContext(ctx context.Context, url string) (*Video, error) {
+	if IsClipURL(url) {
+		clip, err := c.GetClip(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		v, err := c.extractor().ExtractVideo(ctx, c, clip.VideoID)
+		if v != nil {
+			v.Clip = clip
+		}
+		return v, err
+	}
+
 	id, err := extractVideoID(url)
 	if err != nil {
 		return nil, fmt.Errorf("extractVideoID failed: %w", err)
 	}
-	return c.videoFromID(ctx, id)
+	return c.extractor().ExtractVideo(ctx, c, id)
 }
 
 func (c *Client) videoFromID(ctx context.Context, id string) (*Video, error) {
-	// Circumvent age restriction to pretend access through googleapis.com
-	eurl := "https://youtube.googleapis.com/v/" + id
-	body, err := c.httpGetBodyBytes(ctx, "https://youtube.com/get_video_info?video_id="+id+"&eurl="+eurl)
+	body, err := c.httpGetBodyBytes(ctx, videoInfoURL(id))
 	if err != nil {
 		return nil, err
 	}
@@ -51,21 +100,35 @@ func (c *Client) videoFromID(ctx context.Context, id string) (*Video, error) {
 		ID: id,
 	}
 
-	err = v.parseVideoInfo(body)
+	err = v.parseVideoInfo(body, c.parseOptions())
+
+	// The video info is refused for some videos, the player of the innertube API serves them
+	var statusErr *ErrResponseStatus
+	if errors.As(err, &statusErr) {
+		return v, c.videoFromInnertube(ctx, v)
+	}
 
 	// If the uploader has disabled embedding the video on other sites, parse video page
 	if err == ErrNotPlayableInEmbed {
-		html, err := c.httpGetBodyBytes(ctx, "https://www.youtube.com/watch?v="+id)
+		page, err := c.getWatchPage(ctx, "https://www.youtube.com/watch?v="+id)
 		if err != nil {
 			return nil, err
 		}
+		c.updateInnertubeConfig(page.config)
 
-		return v, v.parseVideoPage(html)
+		return v, v.parseVideoPage(page, c.parseOptions())
 	}
 
 	return v, err
 }
 
+// videoInfoURL returns the URL of the video info of the video
+func videoInfoURL(id string) string {
+	// Circumvent age restriction to pretend access through googleapis.com
+	eurl := "https://youtube.googleapis.com/v/" + id
+	return "https://youtube.com/get_video_info?video_id=" + id + "&eurl=" + eurl
+}
+
 // Fetch playlist metadata
 func (c *Client) GetPlaylist(url string) (*Playlist, error) {
 	return c.GetPlaylistContext(context.Background(), url)
@@ -74,7 +137,12 @@ func (c *Client) GetPlaylist(url string) (*Playlist, error) {
 // Fetch playlist metadata, with a context, along with a list of Videos, and some basic information
 // for these videos. Playlist entries cannot be downloaded, as they lack all the required metadata, but
 // can be used to enumerate all IDs, Authors, Titles, etc.
+// The special playlists WatchLaterPlaylist, LikedVideosPlaylist and HistoryPlaylist require authentication.
 func (c *Client) GetPlaylistContext(ctx context.Context, url string) (*Playlist, error) {
+	if id, ok := extractSpecialPlaylistID(url); ok {
+		return c.getSpecialPlaylist(ctx, id)
+	}
+
 	id, err := extractPlaylistID(url)
 	if err != nil {
 		return nil, fmt.Errorf("extractPlaylistID failed: %w", err)
@@ -87,16 +155,20 @@ func (c *Client) GetPlaylistContext(ctx context.Context, url string) (*Playlist,
 	}
 	defer resp.Body.Close()
 
-	p := &Playlist{ID: id}
-	return p, json.NewDecoder(resp.Body).Decode(p)
+	p := &Playlist{ID: id, client: c}
+	if err := json.NewDecoder(resp.Body).Decode(p); err != nil {
+		return p, err
+	}
+	p.VideoCount = len(p.Videos)
+	return p, nil
 }
 
 func (c *Client) VideoFromPlaylistEntry(entry *PlaylistEntry) (*Video, error) {
-	return c.videoFromID(context.Background(), entry.ID)
+	return c.VideoFromPlaylistEntryContext(context.Background(), entry)
 }
 
 func (c *Client) VideoFromPlaylistEntryContext(ctx context.Context, entry *PlaylistEntry) (*Video, error) {
-	return c.videoFromID(ctx, entry.ID)
+	return c.extractor().ExtractVideo(ctx, c, entry.ID)
 }
 
 // GetStream returns the HTTP response for a specific format
@@ -111,7 +183,64 @@ func (c *Client) GetStreamContext(ctx context.Context, video *Video, format *For
 		return nil, err
 	}
 
-	return c.httpGet(ctx, url)
+	req, err := c.newStreamRequest(ctx, format, url)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpDo(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// GetStreamRange returns the HTTP response for the byte window [start, end] of a specific format.
+// The server has to answer with 206 Partial Content for exactly the requested window,
+// otherwise ErrRangeNotSupported is returned.
+func (c *Client) GetStreamRange(ctx context.Context, video *Video, format *Format, start, end int64) (*http.Response, error) {
+	if start < 0 || end < start {
+		return nil, ErrInvalidRange
+	}
+
+	url, err := c.GetStreamURLContext(ctx, video, format)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newStreamRequest(ctx, format, url)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := c.httpDo(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		resp.Body.Close()
+		return nil, ErrRangeNotSupported
+	default:
+		resp.Body.Close()
+		return nil, ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+
+	// The server may shorten the window at the end of the stream, but it must start where we asked
+	var gotStart, gotEnd int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/", &gotStart, &gotEnd); err != nil || gotStart != start || gotEnd > end {
+		resp.Body.Close()
+		return nil, ErrRangeNotSupported
+	}
+
+	return resp, nil
 }
 
 // GetStreamURL returns the url for a specific format
@@ -121,35 +250,57 @@ func (c *Client) GetStreamURL(video *Video, format *Format) (string, error) {
 
 // GetStreamURL returns the url for a specific format with a context
 func (c *Client) GetStreamURLContext(ctx context.Context, video *Video, format *Format) (string, error) {
-	if format.URL != "" {
-		return format.URL, nil
+	streamURL, err := c.extractor().StreamURL(ctx, c, video, format)
+	if err != nil {
+		return "", err
 	}
-
-	cipher := format.Cipher
-	if cipher == "" {
-		return "", ErrCipherNotFound
+	if streamURL, err = c.addPOToken(ctx, video.ID, streamURL); err != nil {
+		return "", fmt.Errorf("po token: %w", err)
 	}
-
-	return c.decipherURL(ctx, video.ID, cipher)
+	if streamURL, err = format.applyQueryParams(streamURL); err != nil {
+		return "", err
+	}
+	return c.RewriteStreamURL(streamURL), nil
 }
 
-// httpGet does a HTTP GET request, checks the response to be a 200 OK and returns it
-func (c *Client) httpGet(ctx context.Context, url string) (resp *http.Response, err error) {
-	client := c.HTTPClient
-	if client == nil {
-		client = http.DefaultClient
+// httpClient returns the HTTP client to use for requests
+func (c *Client) httpClient() *http.Client {
+	switch {
+	case c.HTTPClient != nil:
+		return c.HTTPClient
+	case c.Transport != nil:
+		return c.Transport.HTTPClient()
+	default:
+		return http.DefaultClient
 	}
+}
+
+// httpDo sends the request with the configured HTTP client
+func (c *Client) httpDo(req *http.Request) (*http.Response, error) {
+	client := c.httpClient()
 
 	if c.DebugHTTPClient {
-		log.Println("GET", url)
+		log.Println(req.Method, req.URL)
+	}
+
+	if c.DebugRecorder == nil {
+		return client.Do(req)
 	}
 
+	start := time.Now()
+	resp, err := client.Do(req)
+	recordExchange(c.DebugRecorder, req, resp, err, start, c.maxResponseSize())
+	return resp, err
+}
+
+// httpGet does a HTTP GET request, checks the response to be a 200 OK and returns it
+func (c *Client) httpGet(ctx context.Context, url string) (resp *http.Response, err error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err = client.Do(req)
+	resp, err = c.httpDo(req)
 	if err != nil {
 		return nil, err
 	}
@@ -170,5 +321,5 @@ func (c *Client) httpGetBodyBytes(ctx context.Context, url string) ([]byte, erro
 	}
 	defer resp.Body.Close()
 
-	return ioutil.ReadAll(resp.Body)
+	return ioutil.ReadAll(c.limitResponse(resp.Body))
 }
@@ -1,7 +1,13 @@
 package youtube
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,3 +93,40 @@ func TestGetVideo(t *testing.T) {
 	require.NotEmpty(video.HLSManifestURL)
 	require.NotEmpty(video.DASHManifestURL)
 }
+
+func TestGetStreamRange(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	rangeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer rangeServer.Close()
+
+	plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer plainServer.Close()
+
+	ctx := context.Background()
+	client := Client{}
+
+	t.Run("window", func(t *testing.T) {
+		require := require.New(t)
+		resp, err := client.GetStreamRange(ctx, &Video{}, &Format{URL: rangeServer.URL}, 5, 9)
+		require.NoError(err)
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(err)
+		require.Equal("56789", string(body))
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := client.GetStreamRange(ctx, &Video{}, &Format{URL: rangeServer.URL}, 9, 5)
+		assert.Equal(t, ErrInvalidRange, err)
+	})
+
+	t.Run("range not supported", func(t *testing.T) {
+		_, err := client.GetStreamRange(ctx, &Video{}, &Format{URL: plainServer.URL}, 5, 9)
+		assert.Equal(t, ErrRangeNotSupported, err)
+	})
+}
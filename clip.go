@@ -0,0 +1,92 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	clipURLPattern     = regexp.MustCompile(`youtube\.com/clip/([\w-]+)`)
+	clipConfigPattern  = regexp.MustCompile(`"clipConfig":(\{[^{}]*\})`)
+	clipVideoIDPattern = regexp.MustCompile(`"videoId":"([\w-]{11})"`)
+)
+
+// Clip is a section of a video shared by a youtube.com/clip URL
+type Clip struct {
+	ID      string
+	VideoID string
+	Start   time.Duration
+	End     time.Duration
+}
+
+// IsClipURL reports whether the URL is a youtube.com/clip URL
+func IsClipURL(url string) bool {
+	return clipURLPattern.MatchString(url)
+}
+
+// GetClip resolves a clip URL to the underlying video and the offsets of the clip
+func (c *Client) GetClip(ctx context.Context, clipURL string) (*Clip, error) {
+	match := clipURLPattern.FindStringSubmatch(clipURL)
+	if match == nil {
+		return nil, ErrInvalidClip
+	}
+
+	page, err := c.httpGetBodyBytes(ctx, "https://www.youtube.com/clip/"+match[1])
+	if err != nil {
+		return nil, err
+	}
+	return parseClipPage(match[1], page)
+}
+
+// parseClipPage reads the video ID and the offsets from the page of a clip
+func parseClipPage(id string, page []byte) (*Clip, error) {
+	match := clipConfigPattern.FindSubmatch(page)
+	if match == nil {
+		return nil, fmt.Errorf("%w: no clipConfig found", ErrParse)
+	}
+
+	var config struct {
+		PostID      string `json:"postId"`
+		StartTimeMs string `json:"startTimeMs"`
+		EndTimeMs   string `json:"endTimeMs"`
+	}
+	if err := json.Unmarshal(match[1], &config); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse clipConfig: %v", ErrParse, err)
+	}
+	start, err := strconv.ParseInt(config.StartTimeMs, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid clip start %q", ErrParse, config.StartTimeMs)
+	}
+	end, err := strconv.ParseInt(config.EndTimeMs, 10, 64)
+	if err != nil || end <= start {
+		return nil, fmt.Errorf("%w: invalid clip end %q", ErrParse, config.EndTimeMs)
+	}
+
+	clip := &Clip{
+		ID:    id,
+		Start: time.Duration(start) * time.Millisecond,
+		End:   time.Duration(end) * time.Millisecond,
+	}
+
+	// the player response describes the clipped video
+	if match := playerResponsePattern.FindSubmatch(page); match != nil {
+		var prData playerResponseData
+		if json.Unmarshal(match[1], &prData) == nil {
+			clip.VideoID = prData.VideoDetails.VideoID
+		}
+	}
+	if clip.VideoID == "" {
+		if match := clipVideoIDPattern.FindSubmatch(page); match != nil {
+			clip.VideoID = string(match[1])
+		}
+	}
+	if clip.VideoID == "" {
+		return nil, fmt.Errorf("%w: no video ID found on clip page", ErrParse)
+	}
+
+	return clip, nil
+}
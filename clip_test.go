@@ -0,0 +1,39 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsClipURL(t *testing.T) {
+	assert.True(t, IsClipURL("https://www.youtube.com/clip/UgkxU2HSeGL_NvmDJ-nQJrlLwllwMDBdGZFs"))
+	assert.True(t, IsClipURL("youtube.com/clip/UgkxU2HSeGL_NvmDJ-nQJrlLwllwMDBdGZFs?si=abc"))
+	assert.False(t, IsClipURL("https://www.youtube.com/watch?v=BaW_jenozKc"))
+
+	_, err := extractVideoID("https://www.youtube.com/clip/UgkxU2HSeGL_NvmDJ-nQJrlLwllwMDBdGZFs")
+	assert.True(t, errors.Is(err, ErrInvalidClip))
+}
+
+func TestParseClipPage(t *testing.T) {
+	page := []byte(`<script>var ytInitialPlayerResponse = {"videoDetails":{"videoId":"BaW_jenozKc"}};</script>` +
+		`<script>var ytInitialData = {"clipConfig":{"postId":"UgkxU2HSeGL","startTimeMs":"1500","endTimeMs":"16500"}};</script>`)
+
+	clip, err := parseClipPage("UgkxU2HSeGL", page)
+	require.NoError(t, err)
+	assert.Equal(t, &Clip{ID: "UgkxU2HSeGL", VideoID: "BaW_jenozKc", Start: 1500 * time.Millisecond, End: 16500 * time.Millisecond}, clip)
+
+	// without the player response the first video ID of the page is taken
+	clip, err = parseClipPage("UgkxU2HSeGL", []byte(`{"videoId":"BaW_jenozKc","clipConfig":{"startTimeMs":"0","endTimeMs":"5000"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "BaW_jenozKc", clip.VideoID)
+
+	_, err = parseClipPage("UgkxU2HSeGL", []byte(`<html></html>`))
+	assert.True(t, errors.Is(err, ErrParse))
+
+	_, err = parseClipPage("UgkxU2HSeGL", []byte(`{"clipConfig":{"startTimeMs":"5000","endTimeMs":"1000"}}`))
+	assert.True(t, errors.Is(err, ErrParse))
+}
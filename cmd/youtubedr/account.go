@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/spf13/cobra"
+)
+
+var accountCmdOpts struct {
+	outputFormat string
+}
+
+// accountCmd groups the commands listing the content of the signed in account
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "Lists the uploads and playlists of the signed in account",
+	Long: `Lists the content of the account signed in by the cookies given with --cookies, a cookies.txt
+exported from a browser. Unlisted and private videos and playlists are included.`,
+	Example: `account uploads --cookies cookies.txt -o json > uploads.json`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
+		if accountCmdOpts.outputFormat != "table" && accountCmdOpts.outputFormat != "json" {
+			return fmt.Errorf("output format %s is not valid", accountCmdOpts.outputFormat)
+		}
+		if cookiesFile == "" {
+			return fmt.Errorf("%w, export them from a browser and pass them with --cookies", youtube.ErrNotAuthenticated)
+		}
+		return nil
+	},
+}
+
+var accountUploadsCmd = &cobra.Command{
+	Use:          "uploads",
+	Short:        "Lists the videos uploaded by the account",
+	Example:      `account uploads --cookies cookies.txt`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		videos, err := getDownloader().GetMyUploads(context.Background())
+		if err != nil {
+			return err
+		}
+		if accountCmdOpts.outputFormat == "json" {
+			return writeAccountJSON(videos)
+		}
+
+		table := newTable(os.Stdout, []string{"id", "title", "privacy", "views", "duration", "published"})
+		for _, video := range videos {
+			table.Append([]string{
+				video.ID,
+				video.Title,
+				video.Privacy,
+				strconv.FormatInt(video.ViewCount, 10),
+				video.Duration.String(),
+				video.PublishedTime,
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var accountPlaylistsCmd = &cobra.Command{
+	Use:          "playlists",
+	Short:        "Lists the playlists of the account",
+	Example:      `account playlists --cookies cookies.txt`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		playlists, err := getDownloader().GetMyPlaylists(context.Background())
+		if err != nil {
+			return err
+		}
+		if accountCmdOpts.outputFormat == "json" {
+			return writeAccountJSON(playlists)
+		}
+
+		table := newTable(os.Stdout, []string{"id", "title", "videos"})
+		for _, playlist := range playlists {
+			table.Append([]string{playlist.ID, playlist.Title, strconv.Itoa(playlist.VideoCount)})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+func writeAccountJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func init() {
+	rootCmd.AddCommand(accountCmd)
+	accountCmd.AddCommand(accountUploadsCmd, accountPlaylistsCmd)
+
+	accountCmd.PersistentFlags().StringVarP(&accountCmdOpts.outputFormat, "output", "o", "table", "table, json")
+}
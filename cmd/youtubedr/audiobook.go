@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/spf13/cobra"
+)
+
+// audiobookCmd concatenates the audio of a playlist into a single file with chapters
+var audiobookCmd = &cobra.Command{
+	Use:   "audiobook",
+	Short: "Downloads the audio of a playlist into a single file with a chapter per video",
+	Long: `Downloads the audio of all videos of a playlist and concatenates it into a single M4B or MKA file
+with a chapter marker per video, e.g. to listen to a lecture series like an audiobook. Requires ffmpeg.`,
+	Example:      `audiobook --output-file lectures.m4b https://www.youtube.com/playlist\?list\=PLqAfPOrmacr963ATEroh67fbvjmTzTEx5`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFFMPEG(); err != nil {
+			return err
+		}
+		policy, err := collisionPolicy()
+		if err != nil {
+			return err
+		}
+
+		playlist, err := getPlaylist(args[0])
+		if err != nil {
+			return err
+		}
+		if playlist == nil {
+			return youtube.ErrInvalidPlaylist
+		}
+
+		dl := getDownloader()
+		dl.OnCollision = policy
+		dl.FFmpegPath = ffmpegPath()
+		dl.AudioLanguage = audioLanguage
+		return dl.DownloadAudiobook(context.Background(), playlist, audiobookCmdOpts.outputFile)
+	},
+}
+
+var audiobookCmdOpts struct {
+	outputFile string
+}
+
+func init() {
+	rootCmd.AddCommand(audiobookCmd)
+
+	audiobookCmd.Flags().StringVarP(&outputDir, "directory", "d", ".", "The output directory.")
+	audiobookCmd.Flags().StringVar(&audiobookCmdOpts.outputFile, "output-file", "", "Name of the audiobook, the extension .m4b or .mka selects the container (default is the playlist title with .m4b)")
+	audiobookCmd.Flags().StringVar(&ffmpegLocation, "ffmpeg-location", "", "Location of the ffmpeg binary or its containing directory")
+	audiobookCmd.Flags().StringVar(&audioLanguage, "audio-lang", "", "Preferred audio track language of dubbed videos, e.g. en (default is the original track)")
+	addCollisionFlags(audiobookCmd.Flags())
+	addTempDirFlag(audiobookCmd.Flags())
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serveAuth authenticates the requests of serve with API keys or basic auth and limits the request rate per client.
+// API keys are accepted as bearer token, in the X-API-Key header or as api_key query parameter,
+// which players and WebSocket clients unable to set headers can use.
+// The name of the client is added to the request context, see serveClientName.
+type serveAuth struct {
+	// keys maps API keys to their clients
+	keys map[string]serveClient
+	// users maps basic auth users to their passwords
+	users map[string]string
+	// rateLimit is the requests per minute of clients without their own limit, 0 is unlimited
+	rateLimit int
+
+	mu       sync.Mutex
+	limiters map[string]*requestLimiter
+}
+
+// serveClient is an authenticated API key or user
+type serveClient struct {
+	// name is the user or the name of the key, keys and users with the same name are the same client
+	name string
+	// limit is the rate limit in requests per minute, 0 is unlimited
+	limit int
+}
+
+// clientNamePattern restricts client names to valid directory names
+var clientNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+type serveClientKey struct{}
+
+// serveClientName returns the name of the authenticated client, it is empty without authentication
+func serveClientName(ctx context.Context) string {
+	name, _ := ctx.Value(serveClientKey{}).(string)
+	return name
+}
+
+// newServeAuth parses the API keys, given as [NAME:]KEY[=REQUESTS_PER_MINUTE], and the USER:PASSWORD pairs.
+// Keys without name are named after a hash of the key.
+func newServeAuth(apiKeys, basicAuth []string, rateLimit int) (*serveAuth, error) {
+	a := &serveAuth{
+		keys:      make(map[string]serveClient),
+		users:     make(map[string]string),
+		rateLimit: rateLimit,
+		limiters:  make(map[string]*requestLimiter),
+	}
+
+	for _, apiKey := range apiKeys {
+		parts := strings.SplitN(apiKey, "=", 2)
+		client := serveClient{limit: rateLimit}
+		if len(parts) == 2 {
+			var err error
+			if client.limit, err = strconv.Atoi(parts[1]); err != nil || client.limit < 0 {
+				return nil, fmt.Errorf("invalid API key %q: expected [NAME:]KEY=REQUESTS_PER_MINUTE", apiKey)
+			}
+		}
+
+		key := parts[0]
+		if named := strings.SplitN(key, ":", 2); len(named) == 2 {
+			client.name, key = named[0], named[1]
+			if !clientNamePattern.MatchString(client.name) {
+				return nil, fmt.Errorf("invalid API key name %q: only letters, digits, '.', '_' and '-' are allowed", client.name)
+			}
+		} else {
+			hash := sha256.Sum256([]byte(key))
+			client.name = "key-" + hex.EncodeToString(hash[:4])
+		}
+		if key == "" {
+			return nil, fmt.Errorf("invalid API key %q: key is empty", apiKey)
+		}
+		a.keys[key] = client
+	}
+
+	for _, credentials := range basicAuth {
+		parts := strings.SplitN(credentials, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid basic auth credentials for user %q: expected USER:PASSWORD", parts[0])
+		}
+		if !clientNamePattern.MatchString(parts[0]) {
+			return nil, fmt.Errorf("invalid user %q: only letters, digits, '.', '_' and '-' are allowed", parts[0])
+		}
+		a.users[parts[0]] = parts[1]
+	}
+
+	return a, nil
+}
+
+// enabled checks whether any credentials are configured, otherwise all requests are allowed
+func (a *serveAuth) enabled() bool {
+	return len(a.keys) > 0 || len(a.users) > 0
+}
+
+// handler rejects requests without valid credentials with 401 and requests above the rate limit with 429
+func (a *serveAuth) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, ok := a.authenticate(r)
+		if !ok {
+			if len(a.users) > 0 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="youtubedr"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if wait := a.reserve(client); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		if client.name != "" {
+			r = r.WithContext(context.WithValue(r.Context(), serveClientKey{}, client.name))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate returns the client of the request, all requests are anonymous clients without credentials
+func (a *serveAuth) authenticate(r *http.Request) (client serveClient, ok bool) {
+	if !a.enabled() {
+		return serveClient{}, true
+	}
+
+	if user, password, hasBasic := r.BasicAuth(); hasBasic {
+		expected, known := a.users[user]
+		if known && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1 {
+			return serveClient{name: user, limit: a.rateLimit}, true
+		}
+		return serveClient{}, false
+	}
+
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		key = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if key == "" {
+		key = r.URL.Query().Get("api_key")
+	}
+	if key == "" {
+		return serveClient{}, false
+	}
+
+	// compare with every key, so the time does not tell how much of a key matched
+	for candidate, candidateClient := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(candidate)) == 1 {
+			client, ok = candidateClient, true
+		}
+	}
+	return client, ok
+}
+
+// reserve takes a request from the budget of the client, it returns how long to wait if the budget is used up.
+// Keys and users of the same name share a budget.
+func (a *serveAuth) reserve(client serveClient) time.Duration {
+	if client.limit <= 0 {
+		return 0
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limiter, ok := a.limiters[client.name]
+	if !ok {
+		limiter = newRequestLimiter(client.limit)
+		a.limiters[client.name] = limiter
+	}
+	return limiter.reserve(time.Now())
+}
+
+// requestLimiter is a token bucket allowing bursts of a minute's worth of requests
+type requestLimiter struct {
+	// perSecond is the refill rate of the bucket
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+func newRequestLimiter(perMinute int) *requestLimiter {
+	return &requestLimiter{
+		perSecond: float64(perMinute) / 60,
+		burst:     float64(perMinute),
+		tokens:    float64(perMinute),
+	}
+}
+
+// reserve takes a token, it returns how long until the next token if the bucket is empty
+func (l *requestLimiter) reserve(now time.Time) time.Duration {
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.perSecond)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.perSecond * float64(time.Second))
+}
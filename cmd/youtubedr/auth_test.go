@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServeAuth(t *testing.T) {
+	tests := []struct {
+		name      string
+		apiKeys   []string
+		basicAuth []string
+		wantErr   bool
+	}{
+		{"key", []string{"secret"}, nil, false},
+		{"named key with limit", []string{"alice:secret=10"}, nil, false},
+		{"user", nil, []string{"bob:password"}, false},
+		{"empty key", []string{"alice:"}, nil, true},
+		{"invalid limit", []string{"secret=fast"}, nil, true},
+		{"negative limit", []string{"secret=-1"}, nil, true},
+		{"invalid name", []string{"../alice:secret"}, nil, true},
+		{"user without password", nil, []string{"bob"}, true},
+		{"invalid user", nil, []string{"b/ob:password"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newServeAuth(tt.apiKeys, tt.basicAuth, 0)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestServeAuth_handler(t *testing.T) {
+	auth, err := newServeAuth([]string{"alice:secret", "limited:slow=1"}, []string{"bob:password"}, 0)
+	require.NoError(t, err)
+
+	var client string
+	handler := auth.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client = serveClientName(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		setup  func(r *http.Request)
+		status int
+		client string
+	}{
+		{"no credentials", func(r *http.Request) {}, http.StatusUnauthorized, ""},
+		{"bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") }, http.StatusOK, "alice"},
+		{"header", func(r *http.Request) { r.Header.Set("X-API-Key", "secret") }, http.StatusOK, "alice"},
+		{"query", func(r *http.Request) { r.URL.RawQuery = "api_key=secret" }, http.StatusOK, "alice"},
+		{"wrong key", func(r *http.Request) { r.Header.Set("X-API-Key", "secre") }, http.StatusUnauthorized, ""},
+		{"basic auth", func(r *http.Request) { r.SetBasicAuth("bob", "password") }, http.StatusOK, "bob"},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("bob", "secret") }, http.StatusUnauthorized, ""},
+		{"rate limit", func(r *http.Request) { r.Header.Set("X-API-Key", "slow") }, http.StatusOK, "limited"},
+		{"rate limit exceeded", func(r *http.Request) { r.Header.Set("X-API-Key", "slow") }, http.StatusTooManyRequests, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client = ""
+			r := httptest.NewRequest(http.MethodGet, "http://localhost/videos/BaW_jenozKc", nil)
+			tt.setup(r)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.client, client)
+		})
+	}
+}
+
+func TestServeAuth_disabled(t *testing.T) {
+	auth, err := newServeAuth(nil, nil, 0)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	auth.handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequestLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRequestLimiter(60)
+
+	// a minute's worth of requests is allowed at once
+	for i := 0; i < 60; i++ {
+		require.Zero(t, l.reserve(now), "request %d", i)
+	}
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		wait  time.Duration
+	}{
+		{"empty", 0, time.Second},
+		{"half a token", 500 * time.Millisecond, 500 * time.Millisecond},
+		{"refilled", 500 * time.Millisecond, 0},
+		{"empty again", 0, time.Second},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.after)
+		assert.InDelta(t, float64(tt.wait), float64(l.reserve(now)), float64(time.Millisecond), tt.name)
+	}
+}
+
+func TestNewServeAuth_colons(t *testing.T) {
+	// only the first colon separates the name, keys and passwords may contain colons
+	auth, err := newServeAuth([]string{"name:ke:y=5"}, []string{"bob:pass:word"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]serveClient{"ke:y": {name: "name", limit: 5}}, auth.keys)
+	assert.Equal(t, map[string]string{"bob": "pass:word"}, auth.users)
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	r.Header.Set("X-API-Key", "ke:y")
+	client, ok := auth.authenticate(r)
+	assert.True(t, ok)
+	assert.Equal(t, "name", client.name)
+
+	r = httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	r.SetBasicAuth("bob", "pass:word")
+	_, ok = auth.authenticate(r)
+	assert.True(t, ok)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var benchmarkCmdOpts struct {
+	budgetMiB     int
+	connections   []int
+	chunkSizesKiB []int
+	outputFormat  string
+}
+
+// benchmarkResult is the throughput of a single download strategy
+type benchmarkResult struct {
+	Strategy       string  `json:"strategy"`
+	Connections    int     `json:"connections"`
+	ChunkSize      int64   `json:"chunkSize"`
+	Bytes          int64   `json:"bytes"`
+	Seconds        float64 `json:"seconds"`
+	BytesPerSecond float64 `json:"bytesPerSecond"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// benchmarkCmd measures the throughput of download strategies
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark",
+	Short: "Measure the download throughput of different strategies",
+	Long: `Downloads the beginning of a stream with a single connection and with parallel range requests
+of different chunk sizes, and reports the throughput of each strategy.
+The results help to pick --connections and the transport flags for your network.`,
+	Example:      `benchmark --budget 32 --connections 2,4 https://www.youtube.com/watch?v=BaW_jenozKc`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if benchmarkCmdOpts.outputFormat != "table" && benchmarkCmdOpts.outputFormat != "json" {
+			return fmt.Errorf("output format %s is not valid", benchmarkCmdOpts.outputFormat)
+		}
+		if benchmarkCmdOpts.budgetMiB < 1 {
+			return fmt.Errorf("--budget must be at least 1 MiB")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		video, format, err := getVideoWithFormat(args[0])
+		if err != nil {
+			return err
+		}
+
+		budget := int64(benchmarkCmdOpts.budgetMiB) << 20
+		if size, err := strconv.ParseInt(format.ContentLength, 10, 64); err == nil && size > 0 && size < budget {
+			budget = size
+		}
+
+		client := &getDownloader().Client
+		results := []benchmarkResult{runBenchmark(client, video, format, budget, 1, budget)}
+		for _, connections := range benchmarkCmdOpts.connections {
+			for _, chunkSizeKiB := range benchmarkCmdOpts.chunkSizesKiB {
+				if connections < 1 || chunkSizeKiB < 1 {
+					continue
+				}
+				results = append(results, runBenchmark(client, video, format, budget, connections, int64(chunkSizeKiB)<<10))
+			}
+		}
+
+		if benchmarkCmdOpts.outputFormat == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(results)
+		}
+
+		table := newTable(os.Stdout, []string{"strategy", "connections", "chunk size", "bytes", "seconds", "MiB/s", "error"})
+		for _, r := range results {
+			row := []string{
+				r.Strategy,
+				strconv.Itoa(r.Connections),
+				fmt.Sprintf("%d KiB", r.ChunkSize>>10),
+				strconv.FormatInt(r.Bytes, 10),
+				fmt.Sprintf("%.2f", r.Seconds),
+				fmt.Sprintf("%.2f", r.BytesPerSecond/(1<<20)),
+				r.Error,
+			}
+			var colors []tablewriter.Colors
+			if r.Error != "" {
+				colors = rowColor(len(row), tablewriter.Colors{tablewriter.FgRedColor})
+			}
+			table.AppendColored(row, colors)
+		}
+		table.Render()
+		return nil
+	},
+}
+
+// runBenchmark downloads the first budget bytes of the format in chunks over parallel connections
+func runBenchmark(client *youtube.Client, video *youtube.Video, format *youtube.Format, budget int64, connections int, chunkSize int64) benchmarkResult {
+	result := benchmarkResult{Strategy: "ranges", Connections: connections, ChunkSize: chunkSize}
+	if connections == 1 && chunkSize >= budget {
+		result.Strategy = "single"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	offsets := make(chan int64)
+	var transferred int64
+	var once sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for i := 0; i < connections; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range offsets {
+				end := offset + chunkSize
+				if end > budget {
+					end = budget
+				}
+
+				n, err := downloadRange(ctx, client, video, format, offset, end-1)
+				atomic.AddInt64(&transferred, n)
+				if err != nil {
+					once.Do(func() { firstErr = err })
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for offset := int64(0); offset < budget; offset += chunkSize {
+		select {
+		case offsets <- offset:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(offsets)
+	wg.Wait()
+
+	result.Seconds = time.Since(start).Seconds()
+	result.Bytes = transferred
+	if result.Seconds > 0 {
+		result.BytesPerSecond = float64(result.Bytes) / result.Seconds
+	}
+	if firstErr != nil {
+		result.Error = firstErr.Error()
+	}
+	return result
+}
+
+// downloadRange requests the byte window [start, end] and discards it
+func downloadRange(ctx context.Context, client *youtube.Client, video *youtube.Video, format *youtube.Format, start, end int64) (int64, error) {
+	resp, err := client.GetStreamRange(ctx, video, format, start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return io.Copy(ioutil.Discard, resp.Body)
+}
+
+func init() {
+	rootCmd.AddCommand(benchmarkCmd)
+
+	benchmarkCmd.Flags().IntVar(&benchmarkCmdOpts.budgetMiB, "budget", 16, "MiB to download with every strategy")
+	benchmarkCmd.Flags().IntSliceVar(&benchmarkCmdOpts.connections, "connections", []int{2, 4, 8}, "Numbers of parallel connections to compare with a single connection")
+	benchmarkCmd.Flags().IntSliceVar(&benchmarkCmdOpts.chunkSizesKiB, "chunk-sizes", []int{1024, 4096}, "Chunk sizes in KiB of the parallel range requests")
+	benchmarkCmd.Flags().StringVarP(&benchmarkCmdOpts.outputFormat, "output", "o", "table", "table, json")
+	addQualityFlag(benchmarkCmd.Flags())
+	addStreamFlags(benchmarkCmd.Flags())
+	addCodecFlag(benchmarkCmd.Flags())
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/spf13/cobra"
+)
+
+var castCmdOpts struct {
+	device   string
+	discover time.Duration
+	proxy    bool
+	port     int
+}
+
+// castCmd represents the cast command
+var castCmd = &cobra.Command{
+	Use:   "cast",
+	Short: "Plays a video on a DLNA media renderer on the LAN, e.g. a smart TV",
+	Long: `Plays a video on a DLNA media renderer on the LAN, e.g. a smart TV.
+The stream URL is passed to the renderer directly if the video has an H.264 format with audio.
+Otherwise, or with --proxy, the video is served by a local server, which keeps running until interrupted.
+Chromecast devices are only supported if they expose a DLNA renderer.`,
+	Example:      `cast https://www.youtube.com/watch\?v\=BaW_jenozKc --device "Living Room"`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         cast,
+}
+
+func init() {
+	rootCmd.AddCommand(castCmd)
+
+	castCmd.Flags().StringVar(&castCmdOpts.device, "device", "", "Name of the renderer, the first one found is used if empty")
+	castCmd.Flags().DurationVar(&castCmdOpts.discover, "discover-timeout", 3*time.Second, "How long to search for renderers")
+	castCmd.Flags().BoolVar(&castCmdOpts.proxy, "proxy", false, "Always proxy the video through the local server")
+	castCmd.Flags().IntVar(&castCmdOpts.port, "port", 8090, "Port of the local server")
+}
+
+func cast(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	renderer, err := findRenderer(ctx, castCmdOpts.device)
+	if err != nil {
+		return err
+	}
+
+	server := newVideoServer()
+	video, err := server.getVideo(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	// renderers play MP4 with H.264 and AAC, everything else is re-packaged as HLS by the local server
+	format, err := video.GetFormat(youtube.FormatOptions{Muxed: true, Codecs: []string{"avc1", "mp4a"}})
+	if err == nil && !castCmdOpts.proxy {
+		log.Printf("Casting '%s' (itag %d) to %s", video.Title, format.ItagNo, renderer.Name)
+		return renderer.Play(ctx, format.URL, video.Title)
+	}
+
+	localIP, err := localAddrFor(renderer.ControlURL)
+	if err != nil {
+		return err
+	}
+	base := "http://" + net.JoinHostPort(localIP.String(), strconv.Itoa(castCmdOpts.port))
+
+	mediaURL := base + "/hls/" + url.PathEscape(video.ID) + "/best/index.m3u8"
+	if format != nil {
+		mediaURL = base + "/videos/" + url.PathEscape(video.ID) + "?quality=" + strconv.Itoa(format.ItagNo)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/videos/", http.StripPrefix("/videos/", http.HandlerFunc(server.serveVideo)))
+	mux.Handle("/hls/", http.StripPrefix("/hls/", http.HandlerFunc(newHLSServer(server).serveHLS)))
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", castCmdOpts.port))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Casting '%s' to %s through %s", video.Title, renderer.Name, mediaURL)
+	if err := renderer.Play(ctx, mediaURL, video.Title); err != nil {
+		listener.Close()
+		return err
+	}
+
+	log.Println("Serving the video, press Ctrl+C to stop")
+	return http.Serve(listener, mux)
+}
+
+// findRenderer returns the renderer whose name contains the given one, ignoring case
+func findRenderer(ctx context.Context, name string) (*dlnaRenderer, error) {
+	renderers, err := discoverRenderers(ctx, castCmdOpts.discover)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for i := range renderers {
+		if strings.Contains(strings.ToLower(renderers[i].Name), strings.ToLower(name)) {
+			return &renderers[i], nil
+		}
+		names = append(names, renderers[i].Name)
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no media renderer found on the LAN")
+	}
+	return nil, fmt.Errorf("no media renderer named %q found, available: %s", name, strings.Join(names, ", "))
+}
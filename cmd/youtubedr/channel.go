@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// channelInfoCmd prints the about tab of a channel as JSON
+var channelInfoCmd = &cobra.Command{
+	Use:          "channel-info",
+	Short:        "Print metadata of a channel in json format",
+	Example:      `channel-info https://www.youtube.com/channel/UCdN4aXTrHAtfgbVG9HjBmxQ`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info, err := getDownloader().GetChannelInfo(context.Background(), args[0])
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(channelInfoCmd)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/kkdai/youtube/v2/cmd/youtubedr/i18n"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var checkCmdOpts struct {
+	timeout time.Duration
+}
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check which extraction paths currently work against YouTube",
+	Long: `Runs live checks against known videos and a playlist and prints which extraction paths work.
+Failing checks on a working network usually mean that YouTube has changed and an update is needed.`,
+	Example:      `check --timeout 30s`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := &getDownloader().Client
+
+		table := newTable(os.Stdout, []string{"check", "target", "result", "time", "details"})
+
+		failed := 0
+		for _, c := range extractionChecks {
+			ctx, cancel := context.WithTimeout(context.Background(), checkCmdOpts.timeout)
+			start := time.Now()
+			details, err := c.run(ctx, client, c.target)
+			cancel()
+
+			result, color := i18n.T("OK"), tablewriter.Colors{tablewriter.FgGreenColor}
+			if err != nil {
+				result, details = i18n.T("FAIL"), err.Error()
+				color = tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor}
+				failed++
+			}
+			table.AppendColored(
+				[]string{c.name, c.target, result, time.Since(start).Round(time.Millisecond).String(), details},
+				[]tablewriter.Colors{nil, nil, color, nil, nil},
+			)
+		}
+		table.Render()
+
+		if failed > 0 {
+			return errors.New(i18n.T("%d of %d checks failed", failed, len(extractionChecks)))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().DurationVar(&checkCmdOpts.timeout, "timeout", 30*time.Second, "Timeout of each check")
+}
+
+// extractionCheck verifies a single extraction path, run returns details about the result
+type extractionCheck struct {
+	name   string
+	target string
+	run    func(ctx context.Context, client *youtube.Client, target string) (string, error)
+}
+
+var extractionChecks = []extractionCheck{
+	{"public video", "BaW_jenozKc", checkVideo},
+	{"age-gated video", "HtVdAasjOgU", checkVideo},
+	{"cipher-protected video", "UxxajLWwzqY", checkCipheredVideo},
+	{"playlist", "PLqAfPOrmacr963ATEroh67fbvjmTzTEx5", checkPlaylist},
+}
+
+// checkVideo fetches the metadata of a video and the first bytes of its best format
+func checkVideo(ctx context.Context, client *youtube.Client, id string) (string, error) {
+	video, err := client.GetVideoContext(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	format, err := video.GetFormat(youtube.FormatOptions{})
+	if err != nil {
+		return "", err
+	}
+	if err := checkStream(ctx, client, video, format); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d formats, streamed itag %d", len(video.Formats), format.ItagNo), nil
+}
+
+// checkCipheredVideo deciphers the URL of a format protected by a signature cipher
+func checkCipheredVideo(ctx context.Context, client *youtube.Client, id string) (string, error) {
+	video, err := client.GetVideoContext(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range video.Formats {
+		format := &video.Formats[i]
+		if format.Cipher == "" {
+			continue
+		}
+		if err := checkStream(ctx, client, video, format); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("deciphered and streamed itag %d", format.ItagNo), nil
+	}
+
+	return "no ciphered formats offered, deciphering not tested", nil
+}
+
+// checkStream reads the first bytes of the format
+func checkStream(ctx context.Context, client *youtube.Client, video *youtube.Video, format *youtube.Format) error {
+	resp, err := client.GetStreamRange(ctx, video, format, 0, 1023)
+	if err == youtube.ErrRangeNotSupported {
+		resp, err = client.GetStreamContext(ctx, video, format)
+	}
+	if err != nil {
+		return fmt.Errorf("stream of itag %d: %w", format.ItagNo, err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.CopyN(ioutil.Discard, resp.Body, 1024); err != nil && err != io.EOF {
+		return fmt.Errorf("stream of itag %d: %w", format.ItagNo, err)
+	}
+	return nil
+}
+
+// checkPlaylist fetches the playlist and the first page of its entries
+func checkPlaylist(ctx context.Context, client *youtube.Client, id string) (string, error) {
+	playlist, err := client.GetPlaylistContext(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if len(playlist.Videos) == 0 {
+		return "", fmt.Errorf("no entries found in playlist %q", playlist.Title)
+	}
+
+	return fmt.Sprintf("%q with %d entries on the first page", playlist.Title, len(playlist.Videos)), nil
+}
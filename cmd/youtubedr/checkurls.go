@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/spf13/cobra"
+)
+
+var checkURLsCmdOpts struct {
+	batchFile    string
+	concurrency  int
+	outputFormat string
+}
+
+// checkURLsCmd represents the check-urls command
+var checkURLsCmd = &cobra.Command{
+	Use:   "check-urls",
+	Short: "Classify videos as public, unlisted, private, deleted, age restricted or geo blocked",
+	Long: `Checks the availability of videos with a single cheap request per video, without resolving formats.
+Results are printed in the order of the input.`,
+	Example:      `check-urls --batch-file links.txt --concurrency 8 -o json`,
+	SilenceUsage: true,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if checkURLsCmdOpts.outputFormat != "csv" && checkURLsCmdOpts.outputFormat != "json" {
+			return fmt.Errorf("output format %s is not valid", checkURLsCmdOpts.outputFormat)
+		}
+		if checkURLsCmdOpts.concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		if len(args) == 0 && checkURLsCmdOpts.batchFile == "" {
+			return fmt.Errorf("requires URLs as arguments or --batch-file")
+		}
+		return nil
+	},
+	RunE: checkURLs,
+}
+
+func init() {
+	rootCmd.AddCommand(checkURLsCmd)
+
+	checkURLsCmd.Flags().StringVarP(&checkURLsCmdOpts.batchFile, "batch-file", "a", "", "File with one URL per line, # starts a comment (- for stdin)")
+	checkURLsCmd.Flags().IntVar(&checkURLsCmdOpts.concurrency, "concurrency", 4, "Number of videos checked in parallel")
+	checkURLsCmd.Flags().StringVarP(&checkURLsCmdOpts.outputFormat, "output", "o", "csv", "csv, json")
+}
+
+// availabilityResult is the result of a single URL of check-urls
+type availabilityResult struct {
+	URL          string               `json:"url"`
+	Availability youtube.Availability `json:"availability"`
+	Error        string               `json:"error,omitempty"`
+}
+
+func checkURLs(cmd *cobra.Command, args []string) error {
+	// readErr is safe to read once all results are written, as the URLs are exhausted by then
+	var readErr error
+	urls := make(chan string)
+	go func() {
+		defer close(urls)
+		for _, arg := range args {
+			urls <- arg
+		}
+		if checkURLsCmdOpts.batchFile != "" {
+			readErr = readBatchFile(checkURLsCmdOpts.batchFile, urls)
+		}
+	}()
+
+	// every URL gets its own channel, so the results can be written in order while checks run in parallel
+	client := &getDownloader().Client
+	sem := make(chan struct{}, checkURLsCmdOpts.concurrency)
+	pending := make(chan chan availabilityResult, checkURLsCmdOpts.concurrency)
+	go func() {
+		defer close(pending)
+		for u := range urls {
+			result := make(chan availabilityResult, 1)
+			pending <- result
+			sem <- struct{}{}
+			go func(u string) {
+				defer func() { <-sem }()
+				availability, err := client.CheckAvailability(context.Background(), u)
+				r := availabilityResult{URL: u, Availability: availability}
+				if err != nil {
+					r.Error = err.Error()
+				}
+				result <- r
+			}(u)
+		}
+	}()
+
+	csvWriter := csv.NewWriter(os.Stdout)
+	encoder := json.NewEncoder(os.Stdout)
+	if checkURLsCmdOpts.outputFormat == "csv" {
+		csvWriter.Write([]string{"url", "availability", "error"}) //nolint:errcheck
+	}
+
+	failed := 0
+	for result := range pending {
+		r := <-result
+		if r.Error != "" {
+			failed++
+		}
+		if checkURLsCmdOpts.outputFormat == "json" {
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+			continue
+		}
+		csvWriter.Write([]string{r.URL, string(r.Availability), r.Error}) //nolint:errcheck
+		csvWriter.Flush()
+	}
+
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d URLs could not be checked", failed)
+	}
+	return nil
+}
+
+// readBatchFile sends the URLs of the file, empty lines and comments are skipped
+func readBatchFile(name string, urls chan<- string) error {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls <- line
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// completionCmd prints the shell completion scripts
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generates the shell completion script",
+	Long: `Generates the completion script for the shell. Besides commands and flags, the scripts complete
+the values of --quality and --codec and the IDs of the videos recorded in the download archive.
+
+Bash:
+  $ source <(youtubedr completion bash)
+  # or permanently
+  $ youtubedr completion bash > /etc/bash_completion.d/youtubedr
+
+Zsh:
+  $ youtubedr completion zsh > "${fpath[1]}/_youtubedr"
+
+Fish:
+  $ youtubedr completion fish > ~/.config/fish/completions/youtubedr.fish
+
+PowerShell:
+  PS> youtubedr completion powershell | Out-String | Invoke-Expression`,
+	Example:               `completion bash > /etc/bash_completion.d/youtubedr`,
+	Args:                  cobra.ExactValidArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	SilenceUsage:          true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletion(os.Stdout)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletion(os.Stdout)
+		}
+		return fmt.Errorf("unsupported shell %q", args[0])
+	},
+}
+
+// qualityCompletions are the quality labels of --quality, itag numbers are accepted as well
+var qualityCompletions = []string{
+	"hd720\tvideo only 720p, merged with the best audio",
+	"hdr720\tvideo only 720p HDR, merged with the best audio",
+	"hd1080\tvideo only 1080p, merged with the best audio",
+	"hdr1080\tvideo only 1080p HDR, merged with the best audio",
+	"hdr2060\tvideo only 2160p HDR, merged with the best audio",
+	"medium\taudio and video 360p",
+	"small\taudio and video 240p",
+	"tiny\taudio only",
+	"best\tbest video, merged with the best audio",
+	"worst\tworst video, merged with the best audio",
+	"audio-only\tbest audio without video",
+	"4k\t2160p video, merged with the best audio",
+	"1080p60\t1080p at 60 fps, merged with the best audio",
+	"360p", "480p", "720p", "1080p", "1440p", "2160p",
+}
+
+// codecCompletions are the terms of --codec
+var codecCompletions = []string{
+	"mp4\tMP4 container",
+	"webm\tWebM container",
+	"avc1\tH.264 video",
+	"vp9\tVP9 video",
+	"av01\tAV1 video",
+	"mp4a\tAAC audio",
+	"opus\tOpus audio",
+	"audio\taudio formats",
+	"video\tvideo formats",
+}
+
+// videoArgCommands take video URLs or IDs as arguments, which are completed from the download archive
+var videoArgCommands = []*cobra.Command{downloadCmd, infoCmd, urlCmd, benchmarkCmd, castCmd}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// registerCompletions adds the dynamic completions of flag values and arguments to all commands.
+// It is called once all commands are set up, as the init functions of the other commands may run later.
+func registerCompletions() {
+	for _, cmd := range videoArgCommands {
+		cmd.ValidArgsFunction = completeArchivedVideos
+	}
+
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		if cmd.LocalNonPersistentFlags().Lookup("quality") != nil {
+			cmd.RegisterFlagCompletionFunc("quality", completeValues(qualityCompletions))
+		}
+		if cmd.LocalNonPersistentFlags().Lookup("codec") != nil {
+			cmd.RegisterFlagCompletionFunc("codec", completeValues(codecCompletions))
+		}
+		for _, child := range cmd.Commands() {
+			walk(child)
+		}
+	}
+	walk(rootCmd)
+}
+
+func completeValues(values []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeArchivedVideos completes the IDs recorded in the archive of the output directory given by -d,
+// or the file given by --archive
+func completeArchivedVideos(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	name := filepath.Join(outputDir, ".youtubedr-archive")
+	if flag := cmd.Flags().Lookup("archive"); flag != nil && flag.Value.String() != "" {
+		name = flag.Value.String()
+	}
+
+	ids, err := readArchiveIDs(name)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, id := range ids {
+		if strings.HasPrefix(id, toComplete) {
+			completions = append(completions, id)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// readArchiveIDs returns the sorted IDs of an archive without creating it like openArchive
+func readArchiveIDs(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	seen := make(map[string]bool)
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, scanner.Err()
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ssdpAddr            = "239.255.255.250:1900"
+	avTransportService  = "urn:schemas-upnp-org:service:AVTransport:1"
+	dlnaResponseTimeout = 10 * time.Second
+)
+
+// dlnaRenderer is a media renderer on the LAN which can be controlled through UPnP AVTransport
+type dlnaRenderer struct {
+	Name       string
+	ControlURL string
+}
+
+// discoverRenderers searches the LAN for media renderers for the given duration
+func discoverRenderers(ctx context.Context, wait time.Duration) ([]dlnaRenderer, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	addr, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	search := strings.Join([]string{
+		"M-SEARCH * HTTP/1.1",
+		"HOST: " + ssdpAddr,
+		`MAN: "ssdp:discover"`,
+		"MX: 2",
+		"ST: " + avTransportService,
+		"", "",
+	}, "\r\n")
+	if _, err := conn.WriteTo([]byte(search), addr); err != nil {
+		return nil, err
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+		return nil, err
+	}
+
+	locations := make(map[string]bool)
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			// the deadline ends the search
+			break
+		}
+
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if location := resp.Header.Get("Location"); location != "" {
+			locations[location] = true
+		}
+	}
+
+	var renderers []dlnaRenderer
+	for location := range locations {
+		renderer, err := describeRenderer(ctx, location)
+		if err != nil {
+			log.Printf("ignoring device at %s: %v", location, err)
+			continue
+		}
+		renderers = append(renderers, *renderer)
+	}
+
+	return renderers, nil
+}
+
+// deviceDescription is the part of a UPnP device description needed to control playback
+type deviceDescription struct {
+	URLBase string `xml:"URLBase"`
+	Device  struct {
+		FriendlyName string `xml:"friendlyName"`
+		Services     []struct {
+			ServiceType string `xml:"serviceType"`
+			ControlURL  string `xml:"controlURL"`
+		} `xml:"serviceList>service"`
+	} `xml:"device"`
+}
+
+func describeRenderer(ctx context.Context, location string) (*dlnaRenderer, error) {
+	ctx, cancel := context.WithTimeout(ctx, dlnaResponseTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var desc deviceDescription
+	if err := xml.NewDecoder(resp.Body).Decode(&desc); err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if desc.URLBase != "" {
+		if base, err = url.Parse(desc.URLBase); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, service := range desc.Device.Services {
+		if service.ServiceType != avTransportService {
+			continue
+		}
+		controlURL, err := base.Parse(service.ControlURL)
+		if err != nil {
+			return nil, err
+		}
+		return &dlnaRenderer{Name: desc.Device.FriendlyName, ControlURL: controlURL.String()}, nil
+	}
+
+	return nil, fmt.Errorf("no AVTransport service")
+}
+
+// Play loads the media URL and starts playback
+func (r *dlnaRenderer) Play(ctx context.Context, mediaURL, title string) error {
+	if err := r.call(ctx, "SetAVTransportURI", fmt.Sprintf(
+		"<InstanceID>0</InstanceID><CurrentURI>%s</CurrentURI><CurrentURIMetaData>%s</CurrentURIMetaData>",
+		html.EscapeString(mediaURL), html.EscapeString(didlMetadata(mediaURL, title)),
+	)); err != nil {
+		return err
+	}
+
+	return r.call(ctx, "Play", "<InstanceID>0</InstanceID><Speed>1</Speed>")
+}
+
+func (r *dlnaRenderer) call(ctx context.Context, action, args string) error {
+	ctx, cancel := context.WithTimeout(ctx, dlnaResponseTimeout)
+	defer cancel()
+
+	body := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body><u:%s xmlns:u="%s">%s</u:%s></s:Body>
+</s:Envelope>`, action, avTransportService, args, action)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.ControlURL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, avTransportService, action))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s on %s: %s", action, r.Name, resp.Status)
+	}
+	return nil
+}
+
+// didlMetadata describes the media for renderers which require metadata to start playback
+func didlMetadata(mediaURL, title string) string {
+	return fmt.Sprintf(`<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/">`+
+		`<item id="0" parentID="-1" restricted="1"><dc:title>%s</dc:title><upnp:class>object.item.videoItem</upnp:class>`+
+		`<res protocolInfo="http-get:*:video/mp4:*">%s</res></item></DIDL-Lite>`,
+		html.EscapeString(title), html.EscapeString(mediaURL))
+}
+
+// localAddrFor returns the local IP address used to reach the given URL, which the renderer can connect back to
+func localAddrFor(rawURL string) (net.IP, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Hostname()
+
+	conn, err := net.Dial("udp", net.JoinHostPort(host, "9"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
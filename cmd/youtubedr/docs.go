@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// programName is the name of the binary in the generated documentation, the root command is named after os.Args[0]
+const programName = "youtubedr"
+
+var docsCmdOpts struct {
+	dir string
+}
+
+// docsCmd generates the manual of all commands
+var docsCmd = &cobra.Command{
+	Use:   "docs man|markdown",
+	Short: "Generates man pages or markdown documentation of all commands",
+	Long: `Generates a man page (section 1) or a markdown file for every command, including its flags and examples.
+
+The date of the man pages is taken from SOURCE_DATE_EPOCH if set, so packages can be built reproducibly.`,
+	Example:      `docs man -d /usr/share/man/man1`,
+	Args:         cobra.ExactValidArgs(1),
+	ValidArgs:    []string{"man", "markdown"},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := os.MkdirAll(docsCmdOpts.dir, 0o755); err != nil {
+			return err
+		}
+
+		generate, ext := genManPage, ".1"
+		if args[0] == "markdown" {
+			generate, ext = genMarkdown, ".md"
+		}
+
+		for _, c := range documentedCommands(rootCmd) {
+			name := filepath.Join(docsCmdOpts.dir, strings.ReplaceAll(commandPath(c), " ", docsSeparator(ext))+ext)
+			if err := ioutil.WriteFile(name, generate(c), 0o644); err != nil {
+				return err
+			}
+			log.Println("Wrote", name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(docsCmd)
+	docsCmd.Flags().StringVarP(&docsCmdOpts.dir, "directory", "d", ".", "The output directory")
+}
+
+// docsSeparator joins the command names in file names: youtubedr-service-install.1 and youtubedr_service_install.md
+func docsSeparator(ext string) string {
+	if ext == ".md" {
+		return "_"
+	}
+	return "-"
+}
+
+// documentedCommands returns the command and its subcommands, leaving out hidden and help commands
+func documentedCommands(cmd *cobra.Command) []*cobra.Command {
+	commands := []*cobra.Command{cmd}
+	for _, c := range cmd.Commands() {
+		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		commands = append(commands, documentedCommands(c)...)
+	}
+	return commands
+}
+
+// commandPath is the path of the command starting with the program name instead of os.Args[0]
+func commandPath(cmd *cobra.Command) string {
+	return programName + strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name())
+}
+
+// useLine is the synopsis of the command
+func useLine(cmd *cobra.Command) string {
+	line := commandPath(cmd)
+	if parts := strings.SplitN(cmd.Use, " ", 2); len(parts) == 2 {
+		line += " " + parts[1]
+	}
+	if cmd.HasAvailableFlags() {
+		line += " [flags]"
+	}
+	return line
+}
+
+// examples prefixes the examples of the command with the program name, they are given relative to the root command
+func examples(cmd *cobra.Command) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(cmd.Example), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, programName+" ") {
+			trimmed = programName + " " + trimmed
+		}
+		lines = append(lines, trimmed)
+	}
+	return lines
+}
+
+// description is the long description of the command, or the short one if missing
+func description(cmd *cobra.Command) string {
+	if cmd.Long != "" {
+		return strings.TrimSpace(cmd.Long)
+	}
+	return cmd.Short
+}
+
+// seeAlso returns the parent and the subcommands
+func seeAlso(cmd *cobra.Command) []*cobra.Command {
+	var commands []*cobra.Command
+	if cmd.HasParent() {
+		commands = append(commands, cmd.Parent())
+	}
+	for _, c := range cmd.Commands() {
+		if c.IsAvailableCommand() && !c.IsAdditionalHelpTopicCommand() {
+			commands = append(commands, c)
+		}
+	}
+	return commands
+}
+
+// genMarkdown renders the markdown documentation of the command
+func genMarkdown(cmd *cobra.Command) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "## %s\n\n%s\n\n", commandPath(cmd), cmd.Short)
+	fmt.Fprintf(&buf, "### Synopsis\n\n%s\n\n```\n%s\n```\n\n", description(cmd), useLine(cmd))
+
+	if lines := examples(cmd); len(lines) > 0 {
+		fmt.Fprintf(&buf, "### Examples\n\n```\n%s\n```\n\n", strings.Join(lines, "\n"))
+	}
+	if flags := cmd.NonInheritedFlags(); flags.HasAvailableFlags() {
+		fmt.Fprintf(&buf, "### Options\n\n```\n%s```\n\n", flags.FlagUsages())
+	}
+	if flags := cmd.InheritedFlags(); flags.HasAvailableFlags() {
+		fmt.Fprintf(&buf, "### Options inherited from parent commands\n\n```\n%s```\n\n", flags.FlagUsages())
+	}
+
+	if commands := seeAlso(cmd); len(commands) > 0 {
+		buf.WriteString("### See also\n\n")
+		for _, c := range commands {
+			path := commandPath(c)
+			fmt.Fprintf(&buf, "* [%s](%s.md) - %s\n", path, strings.ReplaceAll(path, " ", "_"), c.Short)
+		}
+	}
+	return buf.Bytes()
+}
+
+// genManPage renders the man page of the command in roff
+func genManPage(cmd *cobra.Command) []byte {
+	path := commandPath(cmd)
+	title := strings.ToUpper(strings.ReplaceAll(path, " ", "-"))
+	source := strings.TrimSpace(programName + " " + version)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, ".TH %q 1 %q %q \"User Commands\"\n", title, manDate().Format("January 2006"), source)
+	fmt.Fprintf(&buf, ".SH NAME\n%s \\- %s\n", roffEscape(strings.ReplaceAll(path, " ", "-")), roffEscape(cmd.Short))
+	fmt.Fprintf(&buf, ".SH SYNOPSIS\n.B %s\n", roffEscape(useLine(cmd)))
+	fmt.Fprintf(&buf, ".SH DESCRIPTION\n%s\n", roffText(description(cmd)))
+
+	if flags := cmd.NonInheritedFlags(); flags.HasAvailableFlags() {
+		buf.WriteString(".SH OPTIONS\n")
+		writeManFlags(&buf, flags)
+	}
+	if flags := cmd.InheritedFlags(); flags.HasAvailableFlags() {
+		buf.WriteString(".SH OPTIONS INHERITED FROM PARENT COMMANDS\n")
+		writeManFlags(&buf, flags)
+	}
+
+	if lines := examples(cmd); len(lines) > 0 {
+		buf.WriteString(".SH EXAMPLES\n")
+		for _, line := range lines {
+			fmt.Fprintf(&buf, ".PP\n.RS 4\n.nf\n%s\n.fi\n.RE\n", roffEscape(line))
+		}
+	}
+
+	if commands := seeAlso(cmd); len(commands) > 0 {
+		buf.WriteString(".SH SEE ALSO\n")
+		for i, c := range commands {
+			sep := ","
+			if i == len(commands)-1 {
+				sep = ""
+			}
+			fmt.Fprintf(&buf, ".BR %s (1)%s\n", roffEscape(strings.ReplaceAll(commandPath(c), " ", "-")), sep)
+		}
+	}
+	return buf.Bytes()
+}
+
+func writeManFlags(buf *bytes.Buffer, flags *pflag.FlagSet) {
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+
+		name := "--" + flag.Name
+		if flag.Shorthand != "" {
+			name = "-" + flag.Shorthand + ", " + name
+		}
+		varname, usage := pflag.UnquoteUsage(flag)
+		if varname != "" {
+			name += " " + varname
+		}
+		if flag.DefValue != "" && flag.DefValue != "false" && flag.DefValue != "0" && flag.DefValue != "0s" && flag.DefValue != "[]" {
+			usage += fmt.Sprintf(" (default %s)", flag.DefValue)
+		}
+		fmt.Fprintf(buf, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(name), roffText(usage))
+	})
+}
+
+// manDate is the date of the man pages, SOURCE_DATE_EPOCH makes it reproducible
+func manDate() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now()
+}
+
+// roffEscape escapes backslashes and dashes of a single line
+func roffEscape(s string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+}
+
+// roffText escapes text of several lines, empty lines start new paragraphs
+// and lines starting with a dot or quote must not be read as requests
+func roffText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.TrimSpace(line) == "":
+			lines[i] = ".PP"
+		case strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'"):
+			lines[i] = `\&` + roffEscape(line)
+		default:
+			lines[i] = roffEscape(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
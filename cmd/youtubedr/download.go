@@ -3,10 +3,19 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/kkdai/youtube/v2"
+	"github.com/kkdai/youtube/v2/cmd/youtubedr/i18n"
+	ytdl "github.com/kkdai/youtube/v2/downloader"
 	"github.com/spf13/cobra"
 )
 
@@ -14,8 +23,7 @@ import (
 var downloadCmd = &cobra.Command{
 	Use:     "download",
 	Short:   "Downloads a video from youtube",
-	Example: `download https://www.youtube.com/watch\?v\=XbNghLqsVwU https://www.youtube.com/watch\?v\=XbNghLqsVwU`,
-	Args:    cobra.MinimumNArgs(1),
+	Example: `download https://www.youtube.com/watch\?v\=XbNghLqsVwU https://www.youtube.com/playlist\?list\=PLqAfPOrmacr963ATEroh67fbvjmTzTEx5`,
 	RunE:    download,
 }
 
@@ -24,54 +32,392 @@ var (
 	ffmpegCheckInitialized bool
 	outputFile             string
 	outputDir              string
+	downloadSections       string
+	ffmpegLocation         string
+	mergeOutputFormat      string
+	audioLanguage          string
+	allAudioTracks         bool
+	pipeMerge              bool
+	skippedReportFile      string
+	sessionFile            string
+	resumeSession          bool
+	writeChat              bool
+	liveFromStart          bool
+	noFaststart            bool
+	recodeProfile          string
+	audioNormalize         bool
+	connections            int
+	paced                  bool
+	dashDownload           bool
+	autoChapters           string
+	clipSection            bool
 )
 
 func init() {
 	rootCmd.AddCommand(downloadCmd)
 
 	downloadCmd.Flags().StringVarP(&outputDir, "directory", "d", ".", "The output directory.")
+	downloadCmd.Flags().StringVar(&downloadSections, "download-sections", "", "Only download a time range of the video, e.g. \"*00:10:00-00:20:00\" (requires ffmpeg)")
+	downloadCmd.Flags().StringVar(&ffmpegLocation, "ffmpeg-location", "", "Location of the ffmpeg binary or its containing directory")
+	downloadCmd.Flags().StringVar(&mergeOutputFormat, "merge-output-format", "", "Container of merged downloads: mkv, mp4 or webm (default picks one compatible with the codecs)")
+	downloadCmd.Flags().StringVar(&audioLanguage, "audio-lang", "", "Preferred audio track language of dubbed videos, e.g. en (default is the original track)")
+	downloadCmd.Flags().BoolVar(&allAudioTracks, "all-audio-tracks", false, "Merge all audio tracks of dubbed videos into one file")
+	downloadCmd.Flags().BoolVar(&pipeMerge, "pipe-merge", false, "Stream video and audio directly into ffmpeg without temporary files (not supported on Windows)")
+	downloadCmd.Flags().StringVar(&skippedReportFile, "skipped-report", "", "Write a JSON report of skipped playlist entries to this file (- for stdout)")
+	downloadCmd.Flags().StringVar(&sessionFile, "session-file", "", "File persisting the download queue (default is .youtubedr-session.json in the output directory)")
+	downloadCmd.Flags().BoolVar(&resumeSession, "resume-session", false, "Continue the interrupted downloads of the session file instead of the arguments")
+	downloadCmd.Flags().BoolVar(&writeChat, "write-chat", false, "Capture the live chat into a .live_chat.jsonl file while recording live streams")
+	downloadCmd.Flags().BoolVar(&liveFromStart, "live-from-start", false, "Record live streams from the start of their DVR window instead of the live edge")
+	downloadCmd.Flags().BoolVar(&noFaststart, "no-faststart", false, "Keep the index at the end of merged MP4 files instead of moving it to the front for streaming")
+	downloadCmd.Flags().StringVar(&recodeProfile, "recode", "", "Transcode downloads with a profile: h264-1080p, h264-720p, webm-vp9, mp3 or one of the recode-profiles of the config file (requires ffmpeg)")
+	downloadCmd.Flags().BoolVar(&audioNormalize, "audio-normalize", false, "Normalize the loudness of downloads to EBU R128 with the ffmpeg loudnorm filter, re-encoding the audio")
+	downloadCmd.Flags().IntVar(&connections, "connections", 0, "Download streams in chunks over up to this many parallel connections, adapting to the throughput (0 disables chunking)")
+	downloadCmd.Flags().BoolVar(&paced, "paced", false, "Download streams in sequential 10 MiB ranges like the web player, which avoids mid-stream throttling of some CDNs")
+	downloadCmd.Flags().StringVar(&autoChapters, "auto-chapters", "", "Generate chapters from the \"most replayed\" heatmap, detected silences or the timestamps of the description: heatmap, silence, description (requires ffmpeg)")
+	downloadCmd.Flags().BoolVar(&clipSection, "clip-section", false, "Only download the clipped section of youtube.com/clip URLs instead of the whole video (requires ffmpeg)")
+	downloadCmd.Flags().BoolVar(&audioOnly, "audio-only", false, "Only select formats without video, like itag 140 or 251")
+	downloadCmd.Flags().BoolVar(&videoOnly, "video-only", false, "Only select formats without audio and download them without merging, so ffmpeg is not required")
+	downloadCmd.Flags().BoolVar(&dashDownload, "dash", false, "Download the DASH manifest segment by segment, re-fetching truncated or out of order segments (-q selects the itag)")
 	addQualityFlag(downloadCmd.Flags())
+	addStreamFlags(downloadCmd.Flags())
 	addCodecFlag(downloadCmd.Flags())
+	addFilterFlags(downloadCmd.Flags())
+	addCollisionFlags(downloadCmd.Flags())
+	addTempDirFlag(downloadCmd.Flags())
+	addIgnoreErrorsFlag(downloadCmd.Flags())
+	addReportFlag(downloadCmd.Flags())
+	addPlaylistFileFlag(downloadCmd.Flags())
 }
 
 func download(cmd *cobra.Command, args []string) error {
 	log.Println("download to directory", outputDir)
 
-	if strings.HasPrefix(outputQuality, "hd") {
+	if audioOnly && videoOnly {
+		return fmt.Errorf("--audio-only and --video-only exclude each other")
+	}
+	if paced && connections > 0 {
+		return fmt.Errorf("--paced and --connections exclude each other")
+	}
+	mergeHighQuality := strings.HasPrefix(outputQuality, "hd") && !videoOnly
+
+	var section *ytdl.Section
+	if downloadSections != "" {
+		if mergeHighQuality {
+			return fmt.Errorf("--download-sections is not supported with quality %s", outputQuality)
+		}
+
+		s, err := ytdl.ParseSection(downloadSections)
+		if err != nil {
+			return err
+		}
+		section = &s
+	}
+
+	switch autoChapters {
+	case "", ytdl.ChaptersFromHeatmap, ytdl.ChaptersFromSilence:
+	case ytdl.ChaptersFromDescription:
+		getDownloader().ChaptersFromDescription = true
+	default:
+		return fmt.Errorf("--auto-chapters must be %s, %s or %s", ytdl.ChaptersFromHeatmap, ytdl.ChaptersFromSilence, ytdl.ChaptersFromDescription)
+	}
+
+	if mergeHighQuality || section != nil || recodeProfile != "" || audioNormalize || autoChapters != "" || clipSection {
 		if err := checkFFMPEG(); err != nil {
 			return err
 		}
 	}
 
-	var errors []string
-	for _, videoURL := range args {
-		video, format, err := getVideoWithFormat(videoURL)
+	if err := filterOpts.prepare(); err != nil {
+		return err
+	}
+	if err := checkPlaylistFileFormats(); err != nil {
+		return err
+	}
+	policy, err := collisionPolicy()
+	if err != nil {
+		return err
+	}
+
+	if sessionFile == "" {
+		sessionFile = defaultSessionFile()
+	}
+
+	downloader.OnCollision = policy
+	downloader.FFmpegPath = ffmpegPath()
+	downloader.MergeOutputFormat = mergeOutputFormat
+	downloader.AudioLanguage = audioLanguage
+	downloader.AllAudioTracks = allAudioTracks
+	downloader.PipeMerge = pipeMerge
+	downloader.WriteLiveChat = writeChat
+	downloader.LiveFromStart = liveFromStart
+	downloader.NoFaststart = noFaststart
+	if connections > 0 {
+		downloader.Chunks = &ytdl.ChunkConfig{MaxConnections: connections}
+	}
+	if paced {
+		downloader.Pacing = &ytdl.PacingConfig{}
+	}
+	ffmpeg := ytdl.FFmpeg{Path: downloader.FFmpegPath, ExtraArgs: downloader.FFmpegExtraArgs}
+	if recodeProfile != "" {
+		processor, err := recodeProcessor(recodeProfile, ffmpeg)
 		if err != nil {
-			errors = append(errors, err.Error())
-			continue
+			return err
+		}
+		downloader.PostProcessors = append(downloader.PostProcessors, processor)
+		if transcode, ok := processor.(*ytdl.TranscodeProcessor); ok && isAudioExtension(transcode.Extension) {
+			// extracted audio is tagged, with the metadata of YouTube Music for its tracks
+			downloader.PostProcessors = append(downloader.PostProcessors, &ytdl.TagProcessor{FFmpeg: ffmpeg})
 		}
+	}
+	if audioNormalize {
+		downloader.PostProcessors = append(downloader.PostProcessors, &ytdl.AudioNormalizeProcessor{FFmpeg: ffmpeg})
+	}
+	if autoChapters != "" {
+		downloader.PostProcessors = append(downloader.PostProcessors, &ytdl.AutoChapterProcessor{FFmpeg: ffmpeg, Source: autoChapters})
+	}
 
-		if strings.HasPrefix(outputQuality, "hd") {
-			if err := downloader.DownloadWithHighQuality(context.Background(), outputFile, video, outputQuality); err != nil {
-				errors = append(errors, err.Error())
-			}
-		} else if err := downloader.Download(context.Background(), video, format, outputFile); err != nil {
-			errors = append(errors, err.Error())
+	var errors []error
+	var records []*reportRecord
+	var sess *session
+	if resumeSession {
+		s, err := loadSession(sessionFile)
+		if err != nil {
+			return fmt.Errorf("unable to resume session: %w", err)
+		}
+		sess = s
+		log.Printf("Resuming session with %d remaining videos", len(sess.remaining()))
+	} else {
+		if len(args) == 0 {
+			return fmt.Errorf("requires at least 1 arg(s), only received 0")
+		}
+		sess = &session{file: sessionFile}
+		errors, records = queueDownloads(sess, args)
+	}
+	if err := sess.save(); err != nil {
+		return err
+	}
+
+	finished := make(map[*sessionItem]*reportRecord)
+	for _, item := range sess.remaining() {
+		if len(errors) > 0 && !ignoreErrors {
+			// the remaining videos stay in the session for --resume-session
+			break
+		}
+
+		item.Status, item.BytesCompleted = itemActive, 0
+		if err := sess.save(); err != nil {
+			return err
+		}
+
+		filter := &filterOpts
+		if item.Playlist == "" {
+			filter = nil
+		}
+		record := &reportRecord{}
+		downloader.OnFileCompleted = record.fileCompleted
+		downloader.OnBytesDownloaded = func(n int) { sess.progress(item, n) }
+		start := time.Now()
+		rejected, err := downloadVideo(item, section, filter)
+		record.finish(start, err)
+		finished[item] = record
+
+		entry := &youtube.PlaylistEntry{ID: item.URL, Title: item.Title}
+		switch reason := skipReason(entry, err); {
+		case rejected != "":
+			log.Printf("Skipping %s: %s", item.URL, rejected)
+			item.Status, item.Reason, item.Filtered = itemSkipped, rejected, true
+		case item.Playlist != "" && reason != "":
+			log.Printf("Skipping %s: %s", item.URL, reason)
+			item.Status, item.Reason = itemSkipped, reason
+		case err != nil:
+			errors = append(errors, err)
+			item.Status, item.Reason = itemFailed, err.Error()
+		default:
+			item.Status, item.Reason = itemDone, ""
+			item.OutputFile = record.OutputFile
 		}
+
+		if err := sess.save(); err != nil {
+			return err
+		}
+	}
+
+	downloader.OnFileCompleted = nil
+	downloader.OnBytesDownloaded = nil
+
+	for _, item := range sess.Items {
+		records = append(records, item.report(finished[item]))
+	}
+	if err := writeReport(records); err != nil {
+		errors = append(errors, err)
+	}
+	if err := writeSkippedReport(sess.skipped()); err != nil {
+		errors = append(errors, err)
+	}
+	if err := writePlaylistFiles(sess); err != nil {
+		errors = append(errors, err)
 	}
 	if len(errors) > 0 {
-		return fmt.Errorf("failure to process videos:\n" + strings.Join(errors, "\n"))
+		return &batchError{msg: i18n.T("failure to process videos"), errs: errors}
+	}
+	if sess.complete() {
+		return sess.remove()
 	}
 	return nil
 }
 
+// queueDownloads adds the videos of the arguments to the session, playlists are expanded.
+// Arguments which cannot be expanded are returned as failed report records.
+func queueDownloads(sess *session, args []string) (errors []error, failures []*reportRecord) {
+	for _, arg := range args {
+		playlist, err := getPlaylist(arg)
+		if err != nil {
+			errors = append(errors, err)
+			record := &reportRecord{Input: arg}
+			record.finish(time.Now(), err)
+			failures = append(failures, record)
+			continue
+		}
+		if playlist == nil {
+			sess.add(&sessionItem{URL: arg})
+			continue
+		}
+
+		log.Printf("Playlist '%s' by %s", playlist.Title, playlist.Author)
+		sess.addPlaylist(playlist)
+		it := playlist.Entries(context.Background())
+		for track := 1; ; track++ {
+			entry, err := it.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				errors = append(errors, err)
+				break
+			}
+
+			item := &sessionItem{URL: entry.ID, Title: entry.Title, Playlist: playlist.ID}
+			if youtube.IsAlbumPlaylist(playlist.ID) {
+				item.Track = track
+			}
+			if reason := skipReason(entry, nil); reason != "" {
+				log.Printf("Skipping %s: %s", entry.ID, reason)
+				item.Status, item.Reason = itemSkipped, reason
+			} else if reason := filterOpts.rejectEntry(entry); reason != "" {
+				log.Printf("Skipping %s: %s", entry.ID, reason)
+				continue
+			}
+			sess.add(item)
+		}
+	}
+
+	return errors, failures
+}
+
+// downloadVideo downloads a single video of the queue, unless it is rejected by the filter.
+// The reason of a rejection is returned, the video is skipped then.
+// The chosen format is recorded in the item, so a resumed session downloads the same one.
+func downloadVideo(item *sessionItem, section *ytdl.Section, filter *videoFilter) (rejected string, err error) {
+	video, format, err := getVideoWithFormat(item.URL)
+	if err != nil {
+		return "", err
+	}
+
+	if filter != nil {
+		if reason := filter.rejectVideo(video); reason != "" {
+			return reason, nil
+		}
+	}
+
+	if item.Track > 0 && video.Music != nil {
+		video.Music.TrackNumber = item.Track
+	}
+
+	if video.IsLive {
+		return "", recordLive(video)
+	}
+
+	if !videoOnly && ytdl.NeedsAudio(outputQuality, format) {
+		if section != nil {
+			return "", fmt.Errorf("--download-sections is not supported with quality %s", outputQuality)
+		}
+		if err := checkFFMPEG(); err != nil {
+			return "", err
+		}
+		return "", downloader.DownloadWithHighQuality(context.Background(), outputFile, video, outputQuality)
+	}
+
+	if dashDownload {
+		// qualities other than an itag pick the representation with the highest bandwidth
+		itag, _ := strconv.Atoi(outputQuality)
+		return "", downloader.DownloadDASH(context.Background(), video, itag, outputFile)
+	}
+
+	if section == nil && clipSection && video.Clip != nil {
+		section = &ytdl.Section{From: video.Clip.Start, To: video.Clip.End}
+	}
+
+	if item.Itag > 0 {
+		if format, err = video.GetFormat(youtube.FormatOptions{Quality: strconv.Itoa(item.Itag)}); err != nil {
+			return "", err
+		}
+	}
+	item.Itag = format.ItagNo
+
+	if section != nil {
+		err = downloader.DownloadSection(context.Background(), video, format, outputFile, *section)
+	} else {
+		err = downloader.Download(context.Background(), video, format, outputFile)
+	}
+	return "", err
+}
+
+// recordLive records a live stream until it ends, an interrupt stops the recording and keeps the file
+func recordLive(video *youtube.Video) error {
+	if !liveFromStart {
+		if err := checkFFMPEG(); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			log.Println("Stopping the recording")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return downloader.DownloadLive(ctx, video, outputFile)
+}
+
 func checkFFMPEG() error {
 	if !ffmpegCheckInitialized {
-		fmt.Println("check ffmpeg is installed....")
-		if err := exec.Command("ffmpeg", "-version").Run(); err != nil {
-			ffmpegCheck = fmt.Errorf("please check ffmpegCheck is installed correctly")
+		log.Println("check ffmpeg is installed....")
+		if err := exec.Command(ffmpegPath(), "-version").Run(); err != nil {
+			ffmpegCheck = fmt.Errorf("please check ffmpeg is installed correctly or set --ffmpeg-location: %w", &ytdl.FFmpegError{Err: err})
 		}
+		ffmpegCheckInitialized = true
 	}
 
 	return ffmpegCheck
 }
+
+// ffmpegPath returns the ffmpeg binary to use, --ffmpeg-location may point to the binary or its directory
+func ffmpegPath() string {
+	if ffmpegLocation == "" {
+		return "ffmpeg"
+	}
+
+	if info, err := os.Stat(ffmpegLocation); err == nil && info.IsDir() {
+		return filepath.Join(ffmpegLocation, "ffmpeg")
+	}
+
+	return ffmpegLocation
+}
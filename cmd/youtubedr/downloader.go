@@ -1,14 +1,16 @@
 package main
 
 import (
-	"crypto/tls"
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
-	"strconv"
-	"time"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
 
 	"github.com/kkdai/youtube/v2"
 	ytdl "github.com/kkdai/youtube/v2/downloader"
@@ -16,84 +18,222 @@ import (
 )
 
 var (
-	insecureSkipVerify bool     // skip TLS server validation
+	insecureSkipVerify bool // skip TLS server validation
+	transportConfig    youtube.TransportConfig
+	streamHostRewrites []string // host=target mappings of stream URLs
+	httpCacheDir       string   // directory for revalidated thumbnails and feeds
+	stateDir           string   // directory for the state of the session, like the visitor data
+	androidClient      bool     // prefer the stream URLs of the Android app
+	lenientParsing     bool     // tolerate player responses deviating from the expected schema
+	poToken            string   // proof-of-origin token of stream requests
+	poTokenCommand     string   // command printing the proof-of-origin token of a video
 	outputQuality      string   // itag number or quality string
+	maxHeight          int      // highest resolution of video formats
+	maxFPS             int      // highest frame rate of video formats
+	preferFPS          int      // preferred frame rate of video formats
+	audioOnly          bool     // only select formats without video
+	videoOnly          bool     // only select formats without audio, which are not merged
 	codec              []string // codec
+	streamHeaders      []string // "Name: value" headers of stream requests
+	streamParams       []string // key=value query parameters of stream URLs
 	downloader         *ytdl.Downloader
+	overwrite          bool   // replace existing files
+	autoNumber         bool   // number new files instead of skipping existing ones
+	tempDir            string // directory for intermediate files
+	ignoreErrors       bool   // continue batches after failed videos
+	dedupByID          bool   // skip videos with an info.json anywhere in the output directory
+	writeInfoJSON      bool   // write info.json sidecar files
 )
 
 func addQualityFlag(flagSet *pflag.FlagSet) {
-	flagSet.StringVarP(&outputQuality, "quality", "q", "", "The itag number or quality label (hd720, medium)")
+	flagSet.StringVarP(&outputQuality, "quality", "q", "", "The itag number, quality label (hd720, medium) or alias (best, worst, 4k, 1080p60, audio-only), video only formats are merged with audio")
+	flagSet.IntVar(&maxHeight, "max-height", 0, "Only select video formats up to this height, e.g. 1080 (the width of portrait videos)")
+	flagSet.IntVar(&maxFPS, "max-fps", 0, "Only select video formats up to this frame rate, e.g. 30")
+	flagSet.IntVar(&preferFPS, "prefer-fps", 0, "Prefer video formats with this frame rate over others of the same resolution, e.g. 30")
+}
+
+func addStreamFlags(flagSet *pflag.FlagSet) {
+	flagSet.StringArrayVar(&streamHeaders, "stream-header", nil, "Add a header to the stream requests, e.g. \"Referer: https://www.youtube.com/\" (repeatable)")
+	flagSet.StringArrayVar(&streamParams, "stream-param", nil, "Set a query parameter of the stream URLs, e.g. rn=1, an empty value removes it (repeatable)")
+}
+
+// streamOptions parses the stream flags into the headers and query parameters of formats
+func streamOptions() (http.Header, url.Values, error) {
+	var headers http.Header
+	for _, header := range streamHeaders {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, nil, fmt.Errorf("invalid stream header %q, expected \"Name: value\"", header)
+		}
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	var params url.Values
+	for _, param := range streamParams {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, nil, fmt.Errorf("invalid stream parameter %q, expected key=value", param)
+		}
+		if params == nil {
+			params = make(url.Values)
+		}
+		params.Add(parts[0], parts[1])
+	}
+	return headers, params, nil
 }
 
 func addCodecFlag(flagSet *pflag.FlagSet) {
 	flagSet.StringArrayVarP(&codec, "codec", "c", []string{}, "Codec to filter (mp4, webm, av01, avc1) - when multiple terms are given, all terms must be present in mime-type")
 }
 
+func addCollisionFlags(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&overwrite, "overwrite", false, "Overwrite existing files instead of skipping them")
+	flagSet.BoolVar(&autoNumber, "auto-number", false, "Append a number to the file name instead of skipping existing files")
+	flagSet.BoolVar(&writeInfoJSON, "write-info-json", false, "Write the metadata of every video into an .info.json file next to the download")
+	flagSet.BoolVar(&dedupByID, "dedup-by-id", false, "Skip videos whose .info.json exists anywhere in the output directory, implies --write-info-json")
+}
+
+func addTempDirFlag(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&tempDir, "temp-dir", "", "Directory for intermediate video and audio files (default is the output directory)")
+}
+
+func addIgnoreErrorsFlag(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&ignoreErrors, "ignore-errors", false, "Continue with the remaining videos if a video fails, instead of stopping")
+}
+
+// collisionPolicy maps the collision flags to the policy of the downloader, existing files are skipped by default
+func collisionPolicy() (ytdl.CollisionPolicy, error) {
+	switch {
+	case overwrite && autoNumber:
+		return 0, errors.New("--overwrite and --auto-number are mutually exclusive")
+	case overwrite:
+		return ytdl.CollisionOverwrite, nil
+	case autoNumber:
+		return ytdl.CollisionAutoNumber, nil
+	default:
+		return ytdl.CollisionSkip, nil
+	}
+}
+
 func getDownloader() *ytdl.Downloader {
 	if downloader != nil {
 		return downloader
 	}
 
-	httpTransport := &http.Transport{
-		Proxy:                 http.ProxyFromEnvironment,
-		IdleConnTimeout:       60 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
+	for _, address := range transportConfig.SourceAddresses {
+		if net.ParseIP(address) == nil {
+			exitOnError(fmt.Errorf("invalid source address: %s", address))
+		}
 	}
-
 	if insecureSkipVerify {
 		log.Println("Skip server certificate verification")
-		httpTransport.TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
-		}
+		transportConfig.InsecureSkipVerify = true
 	}
 
 	downloader = &ytdl.Downloader{
-		OutputDir: outputDir,
+		OutputDir:     outputDir,
+		TempDir:       tempDir,
+		DedupByID:     dedupByID,
+		WriteInfoJSON: writeInfoJSON || dedupByID,
+	}
+	downloader.CacheDir = stateDir
+	if androidClient {
+		downloader.Extractor = youtube.AndroidExtractor{}
+	}
+	if lenientParsing {
+		downloader.ParseMode = youtube.ParseLenient
+	}
+	transport := transportConfig.NewRoundTripper()
+	if httpCacheDir != "" {
+		transport = &youtube.ConditionalTransport{Base: transport, Dir: httpCacheDir}
+	}
+	downloader.HTTPClient = &http.Client{Transport: transport}
+	if cookiesFile != "" {
+		jar, err := loadCookies(cookiesFile)
+		exitOnError(err)
+		downloader.HTTPClient.Jar = jar
+	}
+
+	if len(streamHostRewrites) > 0 {
+		mapping := make(map[string]string, len(streamHostRewrites))
+		for _, rewrite := range streamHostRewrites {
+			parts := strings.SplitN(rewrite, "=", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				exitOnError(fmt.Errorf("invalid stream host rewrite %q, expected host=target", rewrite))
+			}
+			mapping[parts[0]] = parts[1]
+		}
+		downloader.StreamHostRewrite = youtube.StreamHostMapping(mapping)
+	}
+
+	switch {
+	case poToken != "" && poTokenCommand != "":
+		exitOnError(errors.New("--po-token and --po-token-command are mutually exclusive"))
+	case poToken != "":
+		downloader.POTokenProvider = youtube.StaticPOToken(poToken)
+	case poTokenCommand != "":
+		downloader.POTokenProvider = youtube.CachePOTokens(commandPOToken(poTokenCommand))
 	}
-	downloader.HTTPClient = &http.Client{Transport: httpTransport}
 
 	return downloader
 }
 
+// commandPOToken runs the command with the video ID as last argument, it prints the token
+func commandPOToken(command string) youtube.POTokenProvider {
+	return func(ctx context.Context, videoID string) (string, error) {
+		args := append(strings.Fields(command), videoID)
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", args[0], err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+}
+
 func getVideoWithFormat(id string) (*youtube.Video, *youtube.Format, error) {
 	dl := getDownloader()
-	itag, _ := strconv.Atoi(outputQuality)
 
 	video, err := dl.GetVideo(id)
 	if err != nil {
 		return nil, nil, err
 	}
+	for _, warning := range video.ParseWarnings {
+		log.Println("warning:", id, warning)
+	}
 
-	if len(codec) > 0 {
-		filterCodecs(video, codec)
+	headers, params, err := streamOptions()
+	if err != nil {
+		return nil, nil, err
 	}
-	if len(video.Formats) == 0 {
-		return nil, nil, errors.New("no formats found")
+	for i := range video.Formats {
+		video.Formats[i].Headers = headers
+		video.Formats[i].QueryParams = params
 	}
 
-	var format *youtube.Format
-	switch {
-	case itag > 0:
-		format = video.Formats.FindByItag(itag)
-		if format == nil {
-			return nil, nil, fmt.Errorf("unable to find format with itag %d", itag)
-		}
-
-	case outputQuality != "":
-		format = video.Formats.FindByQuality(outputQuality)
-		if format == nil {
-			return nil, nil, fmt.Errorf("unable to find format with quality %s", outputQuality)
-		}
+	// later selections, like the one of the video format to merge, only see the remaining formats
+	if maxHeight > 0 {
+		video.Formats = video.Formats.FilterResolution(0, maxHeight)
+	}
+	if maxFPS > 0 {
+		video.Formats = video.Formats.FilterFPS(maxFPS)
+	}
+	if preferFPS > 0 {
+		video.Formats = video.Formats.PreferFPS(preferFPS)
+	}
 
-	default:
-		// select the first format
-		format = &video.Formats[0]
+	format, err := video.GetFormat(youtube.FormatOptions{
+		Quality:   outputQuality,
+		Codecs:    codec,
+		AudioOnly: audioOnly,
+		VideoOnly: videoOnly,
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	return video, format, nil
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/kkdai/youtube/v2"
+	ytdl "github.com/kkdai/youtube/v2/downloader"
+)
+
+// Exit codes telling scripts why youtubedr failed
+const (
+	exitFailure       = 1
+	exitInvalidURL    = 2
+	exitUnavailable   = 3
+	exitAgeRestricted = 4
+	exitNetwork       = 5
+	exitFFmpeg        = 6
+)
+
+// exitCode maps an error to the exit code of its category
+func exitCode(err error) int {
+	var batch *batchError
+	if errors.As(err, &batch) {
+		return batch.exitCode()
+	}
+
+	var ffmpegErr *ytdl.FFmpegError
+	var playability *youtube.ErrPlayabiltyStatus
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, youtube.ErrInvalidCharactersInVideoID),
+		errors.Is(err, youtube.ErrVideoIDMinLength),
+		errors.Is(err, youtube.ErrInvalidPlaylist),
+		errors.Is(err, youtube.ErrInvalidChannel):
+		return exitInvalidURL
+	case errors.Is(err, youtube.ErrAgeRestricted):
+		return exitAgeRestricted
+	case errors.Is(err, youtube.ErrNotFound),
+		errors.Is(err, youtube.ErrPrivate),
+		errors.Is(err, youtube.ErrGeoBlocked),
+		errors.Is(err, youtube.ErrNotPlayableInEmbed),
+		errors.As(err, &playability):
+		return exitUnavailable
+	case errors.Is(err, youtube.ErrRateLimited),
+		errors.Is(err, youtube.ErrTransient),
+		errors.As(err, &netErr):
+		return exitNetwork
+	case errors.As(err, &ffmpegErr):
+		return exitFFmpeg
+	}
+	return exitFailure
+}
+
+// errorCategory names the category of an error for reports
+func errorCategory(err error) string {
+	switch exitCode(err) {
+	case exitInvalidURL:
+		return "invalid_url"
+	case exitUnavailable:
+		return "unavailable"
+	case exitAgeRestricted:
+		return "age_restricted"
+	case exitNetwork:
+		return "network"
+	case exitFFmpeg:
+		return "ffmpeg"
+	default:
+		return "other"
+	}
+}
+
+// batchError collects the failures of several videos
+type batchError struct {
+	msg  string
+	errs []error
+}
+
+func (err *batchError) Error() string {
+	lines := make([]string, 0, len(err.errs)+1)
+	lines = append(lines, err.msg+":")
+	for _, e := range err.errs {
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exitCode returns the code shared by all failures, or exitFailure if they differ
+func (err *batchError) exitCode() int {
+	code := 0
+	for _, e := range err.errs {
+		switch c := exitCode(e); {
+		case code == 0:
+			code = c
+		case code != c:
+			return exitFailure
+		}
+	}
+	if code == 0 {
+		return exitFailure
+	}
+	return code
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/spf13/pflag"
+)
+
+// videoFilter selects the entries of playlists and channels to download
+type videoFilter struct {
+	dateAfter   string
+	dateBefore  string
+	minDuration time.Duration
+	maxDuration time.Duration
+	matchTitle  string
+
+	after      time.Time
+	before     time.Time
+	titleRegex *regexp.Regexp
+}
+
+var filterOpts videoFilter
+
+func addFilterFlags(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&filterOpts.dateAfter, "date-after", "", "Only download videos published on or after this date (YYYY-MM-DD)")
+	flagSet.StringVar(&filterOpts.dateBefore, "date-before", "", "Only download videos published on or before this date (YYYY-MM-DD)")
+	flagSet.DurationVar(&filterOpts.minDuration, "min-duration", 0, "Only download videos lasting at least this long, e.g. 30s")
+	flagSet.DurationVar(&filterOpts.maxDuration, "max-duration", 0, "Only download videos lasting at most this long, e.g. 10m")
+	flagSet.StringVar(&filterOpts.matchTitle, "match-title", "", "Only download videos whose title matches this regular expression")
+}
+
+// prepare parses the flag values
+func (f *videoFilter) prepare() error {
+	var err error
+	if f.dateAfter != "" {
+		if f.after, err = time.Parse("2006-01-02", f.dateAfter); err != nil {
+			return fmt.Errorf("invalid --date-after: %w", err)
+		}
+	}
+	if f.dateBefore != "" {
+		if f.before, err = time.Parse("2006-01-02", f.dateBefore); err != nil {
+			return fmt.Errorf("invalid --date-before: %w", err)
+		}
+	}
+	if f.matchTitle != "" {
+		if f.titleRegex, err = regexp.Compile(f.matchTitle); err != nil {
+			return fmt.Errorf("invalid --match-title: %w", err)
+		}
+	}
+	return nil
+}
+
+// rejectEntry returns why a playlist entry is filtered out, based on the information available without fetching the video
+func (f *videoFilter) rejectEntry(entry *youtube.PlaylistEntry) string {
+	return f.reject(entry.Title, entry.Duration)
+}
+
+// rejectVideo returns why a video is filtered out
+func (f *videoFilter) rejectVideo(video *youtube.Video) string {
+	if reason := f.reject(video.Title, video.Duration); reason != "" {
+		return reason
+	}
+
+	// videos without publish date are kept
+	if video.PublishDate.IsZero() {
+		return ""
+	}
+	if !f.after.IsZero() && video.PublishDate.Before(f.after) {
+		return "published before " + f.dateAfter
+	}
+	if !f.before.IsZero() && video.PublishDate.After(f.before) {
+		return "published after " + f.dateBefore
+	}
+	return ""
+}
+
+func (f *videoFilter) reject(title string, duration time.Duration) string {
+	if f.titleRegex != nil && !f.titleRegex.MatchString(title) {
+		return "title does not match " + f.matchTitle
+	}
+
+	// unknown durations are kept
+	if duration > 0 && f.minDuration > 0 && duration < f.minDuration {
+		return "shorter than " + f.minDuration.String()
+	}
+	if duration > 0 && f.maxDuration > 0 && duration > f.maxDuration {
+		return "longer than " + f.maxDuration.String()
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	pb "github.com/kkdai/youtube/v2/api/youtubedr"
+	ytdl "github.com/kkdai/youtube/v2/downloader"
+)
+
+// grpcService implements the gRPC API of serve with the video cache and the download queue of the HTTP API.
+// Downloads are queued in the namespace of the client like POST /jobs.
+type grpcService struct {
+	videos *videoServer
+	jobs   *jobServer
+}
+
+func (s *grpcService) video(ctx context.Context, id string) (*youtube.Video, error) {
+	if id == "" {
+		return nil, pb.Errorf(pb.InvalidArgument, "video is missing")
+	}
+	video, err := s.videos.getVideo(ctx, id)
+	if err != nil {
+		return nil, pb.Errorf(pb.Unavailable, "%v", err)
+	}
+	return video, nil
+}
+
+func (s *grpcService) GetVideo(ctx context.Context, req *pb.GetVideoRequest) (*pb.Video, error) {
+	video, err := s.video(ctx, req.Video)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.Video{
+		ID:              video.ID,
+		Title:           video.Title,
+		Author:          video.Author,
+		Description:     video.Description,
+		DurationSeconds: int64(video.Duration / time.Second),
+	}
+	if !video.PublishDate.IsZero() {
+		resp.PublishDate = video.PublishDate.Format("2006-01-02")
+	}
+	return resp, nil
+}
+
+func (s *grpcService) ListFormats(ctx context.Context, req *pb.ListFormatsRequest) (*pb.ListFormatsResponse, error) {
+	video, err := s.video(ctx, req.Video)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListFormatsResponse{}
+	for _, info := range video.Formats.Infos(video.Duration) {
+		resp.Formats = append(resp.Formats, &pb.Format{
+			Itag:         int32(info.Itag),
+			VideoQuality: info.VideoQuality,
+			AudioQuality: info.AudioQuality,
+			Size:         info.Size,
+			Bitrate:      int32(info.Bitrate),
+			MimeType:     info.MimeType,
+			Container:    info.Container,
+			VideoCodec:   info.VideoCodec,
+			AudioCodec:   info.AudioCodec,
+			Width:        int32(info.Width),
+			Height:       int32(info.Height),
+			FPS:          int32(info.FPS),
+		})
+	}
+	return resp, nil
+}
+
+// StartDownload resolves the video first, so invalid videos fail here instead of in the queue
+func (s *grpcService) StartDownload(ctx context.Context, req *pb.StartDownloadRequest) (*pb.Download, error) {
+	if name := filepath.Clean(req.OutputFile); filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return nil, pb.Errorf(pb.InvalidArgument, "output file %q is outside of the output directory", req.OutputFile)
+	}
+	video, err := s.video(ctx, req.Video)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobs.queue(s.jobs.clientNamespace(ctx), video.ID, ytdl.JobOptions{
+		Format:     youtube.FormatOptions{Quality: req.Quality, Codecs: req.Codecs},
+		OutputFile: req.OutputFile,
+	})
+	if errors.Is(err, errQuotaExceeded) {
+		return nil, pb.Errorf(pb.ResourceExhausted, "%v", err)
+	}
+	if err != nil {
+		return nil, pb.Errorf(pb.Internal, "%v", err)
+	}
+	return newDownload(job, job.Progress()), nil
+}
+
+func (s *grpcService) StreamProgress(req *pb.StreamProgressRequest, stream pb.ProgressStream) error {
+	manager := s.jobs.clientNamespace(stream.Context()).manager
+	job, err := manager.Job(req.DownloadID)
+	if err != nil {
+		return pb.Errorf(pb.NotFound, "%v", err)
+	}
+
+	// subscribe first, so no update between the snapshot and the subscription is lost
+	updates, unsubscribe := manager.Subscribe()
+	defer unsubscribe()
+
+	p := job.Progress()
+	if err := stream.Send(newProgress(p)); err != nil || jobFinished(p.State) {
+		return err
+	}
+
+	state, sentAt := p.State, time.Now()
+	for {
+		select {
+		case p, ok := <-updates:
+			if !ok {
+				return pb.Errorf(pb.Unavailable, "server is shutting down")
+			}
+			if p.JobID != job.ID || p.State == state && time.Since(sentAt) < progressInterval {
+				continue
+			}
+			if err := stream.Send(newProgress(p)); err != nil || jobFinished(p.State) {
+				return err
+			}
+			state, sentAt = p.State, time.Now()
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func (s *grpcService) CancelDownload(ctx context.Context, req *pb.CancelDownloadRequest) (*pb.Download, error) {
+	manager := s.jobs.clientNamespace(ctx).manager
+	if err := manager.Cancel(req.DownloadID); errors.Is(err, ytdl.ErrJobNotFound) {
+		return nil, pb.Errorf(pb.NotFound, "%v", err)
+	} else if err != nil {
+		return nil, pb.Errorf(pb.Internal, "%v", err)
+	}
+
+	job, err := manager.Job(req.DownloadID)
+	if err != nil {
+		return nil, pb.Errorf(pb.NotFound, "%v", err)
+	}
+	return newDownload(job, job.Progress()), nil
+}
+
+func newDownload(job *ytdl.Job, p ytdl.Progress) *pb.Download {
+	download := &pb.Download{ID: job.ID, VideoID: job.VideoURL, State: downloadState(p.State)}
+	if p.Err != nil {
+		download.Error = p.Err.Error()
+	}
+	return download
+}
+
+func newProgress(p ytdl.Progress) *pb.Progress {
+	return &pb.Progress{
+		DownloadID:     p.JobID,
+		State:          downloadState(p.State),
+		BytesCompleted: p.BytesCompleted,
+		BytesTotal:     p.BytesTotal,
+	}
+}
+
+func downloadState(state ytdl.JobState) pb.DownloadState {
+	switch state {
+	case ytdl.JobQueued:
+		return pb.DownloadStateQueued
+	case ytdl.JobRunning:
+		return pb.DownloadStateRunning
+	case ytdl.JobPaused:
+		return pb.DownloadStatePaused
+	case ytdl.JobDone:
+		return pb.DownloadStateDone
+	case ytdl.JobFailed:
+		return pb.DownloadStateFailed
+	case ytdl.JobCanceled:
+		return pb.DownloadStateCanceled
+	default:
+		return pb.DownloadStateUnspecified
+	}
+}
+
+// jobFinished checks whether the job will not change anymore
+func jobFinished(state ytdl.JobState) bool {
+	return state == ytdl.JobDone || state == ytdl.JobFailed || state == ytdl.JobCanceled
+}
@@ -0,0 +1,13 @@
+//go:build go1.24
+// +build go1.24
+
+package main
+
+import "net/http"
+
+// newGRPCServer serves the handler with unencrypted HTTP/2, which gRPC clients use for plaintext connections
+func newGRPCServer(handler http.Handler) (*http.Server, error) {
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Server{Handler: handler, Protocols: protocols}, nil
+}
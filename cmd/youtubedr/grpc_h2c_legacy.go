@@ -0,0 +1,14 @@
+//go:build !go1.24
+// +build !go1.24
+
+package main
+
+import (
+	"errors"
+	"net/http"
+)
+
+// newGRPCServer fails, net/http only serves unencrypted HTTP/2 since Go 1.24
+func newGRPCServer(handler http.Handler) (*http.Server, error) {
+	return nil, errors.New("--grpc-listen requires youtubedr to be built with Go 1.24 or later")
+}
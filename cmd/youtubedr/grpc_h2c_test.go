@@ -0,0 +1,48 @@
+//go:build go1.24
+// +build go1.24
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/kkdai/youtube/v2/api/youtubedr"
+)
+
+func TestNewGRPCServer(t *testing.T) {
+	server, err := newGRPCServer(pb.NewHandler(newTestGRPCService(t)))
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Close()
+
+	// like gRPC clients, which use HTTP/2 with prior knowledge on plaintext connections
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	data := (&pb.CancelDownloadRequest{DownloadID: "1"}).Marshal()
+	body := append([]byte{0, 0, 0, 0, byte(len(data))}, data...)
+	req, err := http.NewRequest(http.MethodPost, "http://"+listener.Addr().String()+"/"+pb.ServiceName+"/CancelDownload", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	_, err = ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, "5", resp.Trailer.Get("Grpc-Status"))
+	assert.Equal(t, "job not found: 1", resp.Trailer.Get("Grpc-Message"))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/kkdai/youtube/v2/api/youtubedr"
+	ytdl "github.com/kkdai/youtube/v2/downloader"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// progressRecorder collects the messages of StreamProgress
+type progressRecorder struct {
+	ctx      context.Context
+	progress []*pb.Progress
+}
+
+func (r *progressRecorder) Context() context.Context {
+	return r.ctx
+}
+
+func (r *progressRecorder) Send(p *pb.Progress) error {
+	r.progress = append(r.progress, p)
+	return nil
+}
+
+func newTestGRPCService(t *testing.T) *grpcService {
+	dl := &ytdl.Downloader{}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("offline")
+	})}
+	return &grpcService{videos: newVideoServer(), jobs: newJobServer(dl, t.TempDir(), 1)}
+}
+
+func statusCode(err error) pb.Code {
+	var status *pb.Status
+	if errors.As(err, &status) {
+		return status.Code
+	}
+	return pb.Unknown
+}
+
+func TestGRPCService_errors(t *testing.T) {
+	s := newTestGRPCService(t)
+	ctx := context.Background()
+
+	_, err := s.GetVideo(ctx, &pb.GetVideoRequest{})
+	assert.Equal(t, pb.InvalidArgument, statusCode(err))
+
+	_, err = s.ListFormats(ctx, &pb.ListFormatsRequest{Video: "BaW_jenozKc"})
+	assert.Equal(t, pb.Unavailable, statusCode(err))
+
+	for _, outputFile := range []string{"/etc/passwd", "..", "../video.mp4", "a/../../video.mp4"} {
+		_, err = s.StartDownload(ctx, &pb.StartDownloadRequest{Video: "BaW_jenozKc", OutputFile: outputFile})
+		assert.Equal(t, pb.InvalidArgument, statusCode(err), outputFile)
+	}
+
+	_, err = s.CancelDownload(ctx, &pb.CancelDownloadRequest{DownloadID: "1"})
+	assert.Equal(t, pb.NotFound, statusCode(err))
+
+	err = s.StreamProgress(&pb.StreamProgressRequest{DownloadID: "1"}, &progressRecorder{ctx: ctx})
+	assert.Equal(t, pb.NotFound, statusCode(err))
+}
+
+func TestGRPCService_StreamProgress(t *testing.T) {
+	s := newTestGRPCService(t)
+	ctx := context.Background()
+	job := s.jobs.clientNamespace(ctx).manager.Add("BaW_jenozKc", ytdl.JobOptions{})
+
+	// the stream ends once the download failed
+	stream := &progressRecorder{ctx: ctx}
+	require.NoError(t, s.StreamProgress(&pb.StreamProgressRequest{DownloadID: job.ID}, stream))
+	require.NotEmpty(t, stream.progress)
+	last := stream.progress[len(stream.progress)-1]
+	assert.Equal(t, job.ID, last.DownloadID)
+	assert.Equal(t, pb.DownloadStateFailed, last.State)
+
+	download, err := s.CancelDownload(ctx, &pb.CancelDownloadRequest{DownloadID: job.ID})
+	require.NoError(t, err)
+	assert.Equal(t, &pb.Download{ID: job.ID, VideoID: "BaW_jenozKc", State: pb.DownloadStateFailed, Error: download.Error}, download)
+	assert.Contains(t, download.Error, "offline")
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+const (
+	// hlsIdleTimeout stops ffmpeg and removes the segments of sessions which are no longer watched
+	hlsIdleTimeout = 10 * time.Minute
+	// hlsStartTimeout is the maximum time to wait for the first playlist written by ffmpeg
+	hlsStartTimeout = 30 * time.Second
+)
+
+// hlsSession is an ffmpeg process segmenting a video into a temporary directory
+type hlsSession struct {
+	dir        string
+	cancel     context.CancelFunc
+	done       chan struct{}
+	err        error
+	lastAccess time.Time
+}
+
+// hlsServer re-packages videos as HLS on the fly, one session per video and quality
+type hlsServer struct {
+	videos *videoServer
+
+	mu       sync.Mutex
+	sessions map[string]*hlsSession
+}
+
+func newHLSServer(videos *videoServer) *hlsServer {
+	s := &hlsServer{videos: videos, sessions: make(map[string]*hlsSession)}
+	go s.removeIdleSessions()
+	return s
+}
+
+// serveHLS handles /<video id>/<quality>/<file>, the quality "best" selects the highest one
+func (s *hlsServer) serveHLS(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 || strings.Contains(parts[2], "..") {
+		http.NotFound(w, r)
+		return
+	}
+	id, quality, file := parts[0], parts[1], parts[2]
+
+	session, err := s.getSession(r.Context(), id, quality)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	path := filepath.Join(session.dir, file)
+	if file == "index.m3u8" {
+		if err := session.waitForPlaylist(r.Context(), path); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "video/mp2t")
+	}
+
+	http.ServeFile(w, r, path)
+}
+
+func (s *hlsServer) getSession(ctx context.Context, id, quality string) (*hlsSession, error) {
+	key := id + "/" + quality
+
+	s.mu.Lock()
+	session, ok := s.sessions[key]
+	if ok {
+		session.lastAccess = time.Now()
+		s.mu.Unlock()
+		return session, nil
+	}
+	s.mu.Unlock()
+
+	video, err := s.videos.getVideo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// another request may have started the session in the meantime
+	if session, ok := s.sessions[key]; ok {
+		return session, nil
+	}
+
+	session, err = startHLSSession(video, quality)
+	if err != nil {
+		return nil, err
+	}
+	s.sessions[key] = session
+	return session, nil
+}
+
+// startHLSSession starts ffmpeg segmenting the H.264/AAC streams of the video without re-encoding
+func startHLSSession(video *youtube.Video, quality string) (*hlsSession, error) {
+	if err := checkFFMPEG(); err != nil {
+		return nil, err
+	}
+
+	opts := youtube.FormatOptions{Quality: quality, Codecs: []string{"avc1"}}
+	if quality == "best" {
+		opts.Quality = ""
+	}
+	videoFormat, err := video.GetFormat(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-i", videoFormat.URL}
+	if videoFormat.AudioCodec() == "" {
+		audioFormat, err := video.GetFormat(youtube.FormatOptions{AudioOnly: true, Codecs: []string{"mp4a"}})
+		if err != nil {
+			return nil, fmt.Errorf("no audio format for HLS: %w", err)
+		}
+		args = append(args, "-i", audioFormat.URL, "-map", "0:v", "-map", "1:a")
+	}
+
+	dir, err := ioutil.TempDir("", "youtubedr_hls_")
+	if err != nil {
+		return nil, err
+	}
+
+	args = append(args,
+		"-c", "copy",
+		"-f", "hls",
+		"-hls_time", "6",
+		"-hls_list_size", "0",
+		"-hls_playlist_type", "event",
+		"-hls_segment_filename", filepath.Join(dir, "segment_%05d.ts"),
+		"-loglevel", "warning",
+		filepath.Join(dir, "index.m3u8"),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, ffmpegPath(), args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		cancel()
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	session := &hlsSession{
+		dir:        dir,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+		lastAccess: time.Now(),
+	}
+	go func() {
+		session.err = cmd.Wait()
+		close(session.done)
+	}()
+
+	log.Printf("HLS session for %s (itag %d) in %s", video.ID, videoFormat.ItagNo, dir)
+	return session, nil
+}
+
+// waitForPlaylist waits until ffmpeg has written the playlist
+func (session *hlsSession) waitForPlaylist(ctx context.Context, path string) error {
+	timeout := time.NewTimer(hlsStartTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-session.done:
+			if _, err := os.Stat(path); err == nil {
+				return nil
+			}
+			return fmt.Errorf("ffmpeg exited without writing a playlist: %v", session.err)
+		case <-timeout.C:
+			return fmt.Errorf("timeout waiting for the playlist")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (session *hlsSession) stop() {
+	session.cancel()
+	<-session.done
+	os.RemoveAll(session.dir)
+}
+
+func (s *hlsServer) removeIdleSessions() {
+	for range time.Tick(time.Minute) {
+		s.mu.Lock()
+		for key, session := range s.sessions {
+			if time.Since(session.lastAccess) > hlsIdleTimeout {
+				delete(s.sessions, key)
+				go session.stop()
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,179 @@
+// Package i18n holds the message catalogs of youtubedr.
+//
+// Messages are the English fmt format strings used by the CLI, a catalog maps them to their translation.
+// English is the fallback for unknown languages and missing translations. Packagers can add languages with
+// Register in their build or with JSON files like locales/en.json in a directory loaded by LoadDir.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Catalog maps English messages to their translation, which has to keep the verbs of the message in order
+type Catalog map[string]string
+
+//go:embed locales/*.json
+var builtin embed.FS
+
+var (
+	mu       sync.RWMutex
+	catalogs = make(map[string]Catalog)
+	current  Catalog
+)
+
+func init() {
+	entries, err := builtin.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		data, err := builtin.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		if err := registerJSON(strings.TrimSuffix(entry.Name(), ".json"), data); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func registerJSON(lang string, data []byte) error {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return fmt.Errorf("invalid catalog %s: %w", lang, err)
+	}
+	Register(lang, catalog)
+	return nil
+}
+
+// Register adds the translations to the catalog of the language, e.g. "de" or "pt-BR"
+func Register(lang string, catalog Catalog) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	lang = normalize(lang)
+	existing, ok := catalogs[lang]
+	if !ok {
+		existing = make(Catalog, len(catalog))
+		catalogs[lang] = existing
+	}
+	for message, translation := range catalog {
+		if translation != "" {
+			existing[message] = translation
+		}
+	}
+}
+
+// LoadDir registers the catalogs of all <language>.json files in the directory
+func LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := registerJSON(strings.TrimSuffix(filepath.Base(file), ".json"), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetLanguage selects the catalog of the language, falling back to its base language, e.g. "de" for "de-AT".
+// Locale names like "de_AT.UTF-8" are accepted. It returns false if there is no catalog and English is used.
+func SetLanguage(lang string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	lang = normalize(lang)
+	if catalog, ok := catalogs[lang]; ok {
+		current = catalog
+		return true
+	}
+	if i := strings.IndexByte(lang, '-'); i > 0 {
+		if catalog, ok := catalogs[lang[:i]]; ok {
+			current = catalog
+			return true
+		}
+	}
+	current = nil
+	return lang == "" || lang == "en" || strings.HasPrefix(lang, "en-") || lang == "c" || lang == "posix"
+}
+
+// DetectLanguage returns the language of the environment from LC_ALL, LC_MESSAGES or LANG
+func DetectLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// normalize maps locale names like "de_AT.UTF-8" to language tags like "de-at"
+func normalize(lang string) string {
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	return strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+}
+
+// Languages returns the languages with a catalog
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Template returns all messages of the CLI, mapped to themselves, as starting point of a new catalog
+func Template() Catalog {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	template := make(Catalog, len(catalogs["en"]))
+	for message, translation := range catalogs["en"] {
+		template[message] = translation
+	}
+	return template
+}
+
+// T translates the message into the selected language and formats it with the arguments
+func T(message string, args ...interface{}) string {
+	mu.RLock()
+	if translation, ok := current[message]; ok {
+		message = translation
+	}
+	mu.RUnlock()
+
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Strings translates every message, e.g. the header of a table
+func Strings(messages []string) []string {
+	translated := make([]string, len(messages))
+	for i, message := range messages {
+		translated[i] = T(message)
+	}
+	return translated
+}
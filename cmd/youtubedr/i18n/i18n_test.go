@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLanguage(t *testing.T) {
+	defer SetLanguage("en")
+
+	assert.True(t, SetLanguage("de_AT.UTF-8"))
+	assert.Equal(t, "Rang", T("rank"))
+	assert.Equal(t, "3 von 4 Prüfungen fehlgeschlagen", T("%d of %d checks failed", 3, 4))
+	assert.Equal(t, "untranslated", T("untranslated"))
+
+	assert.False(t, SetLanguage("xx"))
+	assert.Equal(t, "rank", T("rank"))
+	assert.True(t, SetLanguage("C.UTF-8"))
+}
+
+func TestBuiltinCatalogsAreComplete(t *testing.T) {
+	template := Template()
+	require.NotEmpty(t, template)
+
+	entries, err := builtin.ReadDir("locales")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		lang := strings.TrimSuffix(entry.Name(), ".json")
+		mu.RLock()
+		catalog := catalogs[lang]
+		mu.RUnlock()
+		for message := range template {
+			assert.Contains(t, catalog, message, "%s lacks a translation", lang)
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	defer SetLanguage("en")
+
+	dir, err := ioutil.TempDir("", "i18n")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pt-BR.json"), []byte(`{"rank": "posição"}`), 0o644))
+
+	require.NoError(t, LoadDir(dir))
+	assert.Contains(t, Languages(), "pt-br")
+	assert.True(t, SetLanguage("pt_BR"))
+	assert.Equal(t, "posição", T("rank"))
+	assert.Equal(t, []string{"posição", "title"}, Strings([]string{"rank", "title"}))
+}
@@ -3,18 +3,19 @@ package main
 import (
 	"encoding/csv"
 	"fmt"
+	"log"
 	"os"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/kkdai/youtube/v2"
-	"github.com/olekukonko/tablewriter"
+	"github.com/kkdai/youtube/v2/cmd/youtubedr/i18n"
 	"github.com/spf13/cobra"
 )
 
 var infoCmdOpts struct {
 	outputFormat string
+	describe     bool
 }
 
 // infoCmd represents the info command
@@ -25,7 +26,7 @@ var infoCmd = &cobra.Command{
 	Args:         cobra.MinimumNArgs(1),
 	SilenceUsage: true,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if !strings.Contains("|full|media|media-csv|", fmt.Sprintf("|%s|", infoCmdOpts.outputFormat)) {
+		if !strings.Contains("|full|media|media-csv|markdown|html|", fmt.Sprintf("|%s|", infoCmdOpts.outputFormat)) {
 			return fmt.Errorf("output format %s is not valid", infoCmdOpts.outputFormat)
 		}
 		return nil
@@ -37,22 +38,32 @@ var infoCmd = &cobra.Command{
 				if infoCmdOpts.outputFormat == "media-csv" {
 					fmt.Printf("-2,%s,%s\n", videoURL, err)
 				} else {
-					fmt.Printf("ERROR: %s - %s\n", videoURL, err)
+					fmt.Println(i18n.T("ERROR: %s - %s", videoURL, err))
 				}
 				continue
 			}
+			for _, warning := range video.ParseWarnings {
+				log.Println("warning:", videoURL, warning)
+			}
 			if len(video.Formats) == 0 {
 				if infoCmdOpts.outputFormat == "media-csv" {
 					fmt.Printf("-3,%s,%s\n", videoURL, "no formats found")
 				} else {
-					fmt.Printf("ERROR: %s - %s\n", videoURL, "no formats found")
+					fmt.Println(i18n.T("ERROR: %s - %s", videoURL, i18n.T("no formats found")))
 				}
 				continue
 			} else if len(codec) > 0 {
 				filterCodecs(video, codec)
 			}
 
-			data := buildFormats(video)
+			header := youtube.FormatTableHeader
+			data := video.Formats.MarshalTable(video.Duration)
+			if infoCmdOpts.describe {
+				header = append(header[:len(header):len(header)], "description")
+				for i := range data {
+					data[i] = append(data[i], video.Formats[i].Describe())
+				}
+			}
 
 			if infoCmdOpts.outputFormat == "media-csv" {
 				fmt.Printf("0,%s,%s\n", videoURL, video.Title)
@@ -70,30 +81,35 @@ var infoCmd = &cobra.Command{
 			}
 
 			if infoCmdOpts.outputFormat == "full" || infoCmdOpts.outputFormat == "media" {
-				fmt.Println("Title:      ", video.Title)
+				fmt.Printf("%-12s %s\n", i18n.T("Title:"), video.Title)
 				if infoCmdOpts.outputFormat == "full" {
-					fmt.Println("Author:     ", video.Author)
-					fmt.Println("Duration:   ", video.Duration)
-					fmt.Println("Description:", video.Description)
+					fmt.Printf("%-12s %s\n", i18n.T("Author:"), video.Author)
+					fmt.Printf("%-12s %s\n", i18n.T("Duration:"), video.Duration)
+					fmt.Printf("%-12s %s\n", i18n.T("Description:"), video.Description)
 					fmt.Println()
 				}
-				table := tablewriter.NewWriter(os.Stdout)
-				table.SetAutoWrapText(false)
-				table.SetHeader([]string{"itag", "video quality", "audio quality", "size [MB]", "bitrate", "MimeType"})
-				table.AppendBulk(data)
+				table := newTable(os.Stdout, header)
+				table.appendFormats(video.Formats, data)
 				table.Render()
 			}
+
+			switch infoCmdOpts.outputFormat {
+			case "markdown":
+				writeMarkdown(os.Stdout, video, header, data)
+			case "html":
+				writeHTML(os.Stdout, video, header, data)
+			}
 		}
 	},
 }
 
-// filterCodecs filters out codec with strings.Contains and uses AND operator
+// filterCodecs keeps the formats matching all codecs, containers or media types
 func filterCodecs(video *youtube.Video, codec []string) {
 	var formats youtube.FormatList
 VideoFormat:
 	for _, f := range video.Formats {
 		for _, c := range codec {
-			if !strings.Contains(f.MimeType, c) {
+			if !f.HasCodec(c) {
 				continue VideoFormat
 			}
 		}
@@ -103,36 +119,10 @@ VideoFormat:
 	sort.SliceStable(video.Formats, video.SortBitrateDesc)
 }
 
-func buildFormats(video *youtube.Video) (data [][]string) {
-	for _, format := range video.Formats {
-		bitrate := format.AverageBitrate
-		if bitrate == 0 {
-			// Some formats don't have the average bitrate
-			bitrate = format.Bitrate
-		}
-
-		size, _ := strconv.ParseInt(format.ContentLength, 10, 64)
-		if size == 0 {
-			// Some formats don't have this information
-			size = int64(float64(bitrate) * video.Duration.Seconds() / 8)
-		}
-
-		data = append(data, []string{
-			strconv.Itoa(format.ItagNo),
-			format.QualityLabel,
-			strings.ToLower(strings.TrimPrefix(format.AudioQuality, "AUDIO_QUALITY_")),
-			fmt.Sprintf("%0.1f", float64(size)/1024/1024),
-			strconv.Itoa(bitrate),
-			format.MimeType,
-		})
-	}
-
-	return
-}
-
 func init() {
 	rootCmd.AddCommand(infoCmd)
 
-	infoCmd.Flags().StringVarP(&infoCmdOpts.outputFormat, "output", "o", "full", "full, media, media-csv")
+	infoCmd.Flags().StringVarP(&infoCmdOpts.outputFormat, "output", "o", "full", "full, media, media-csv, markdown, html")
+	infoCmd.Flags().BoolVar(&infoCmdOpts.describe, "describe", false, "Add a column describing each format with container, codecs, resolution and notes on its itag")
 	addCodecFlag(infoCmd.Flags())
 }
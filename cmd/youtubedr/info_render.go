@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// writeMarkdown writes the metadata and the format table of a video as Markdown
+func writeMarkdown(w io.Writer, video *youtube.Video, header []string, data [][]string) {
+	fmt.Fprintf(w, "## %s\n\n", markdownEscape(video.Title))
+	fmt.Fprintf(w, "- **Author:** %s\n", markdownEscape(video.Author))
+	fmt.Fprintf(w, "- **Duration:** %s\n", video.Duration)
+	if video.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", video.Description)
+	}
+	fmt.Fprintln(w)
+
+	writeMarkdownRow(w, header)
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	writeMarkdownRow(w, separator)
+	for _, row := range data {
+		writeMarkdownRow(w, row)
+	}
+	fmt.Fprintln(w)
+}
+
+func writeMarkdownRow(w io.Writer, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = markdownEscape(cell)
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+}
+
+var markdownReplacer = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "\n", " ")
+
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+// writeHTML writes the metadata and the format table of a video as an HTML fragment
+func writeHTML(w io.Writer, video *youtube.Video, header []string, data [][]string) {
+	fmt.Fprintf(w, "<h2>%s</h2>\n", html.EscapeString(video.Title))
+	fmt.Fprintln(w, "<dl>")
+	fmt.Fprintf(w, "  <dt>Author</dt><dd>%s</dd>\n", html.EscapeString(video.Author))
+	fmt.Fprintf(w, "  <dt>Duration</dt><dd>%s</dd>\n", video.Duration)
+	if video.Description != "" {
+		fmt.Fprintf(w, "  <dt>Description</dt><dd>%s</dd>\n", strings.ReplaceAll(html.EscapeString(video.Description), "\n", "<br>"))
+	}
+	fmt.Fprintln(w, "</dl>")
+
+	fmt.Fprintln(w, "<table>")
+	writeHTMLRow(w, "th", header)
+	for _, row := range data {
+		writeHTMLRow(w, "td", row)
+	}
+	fmt.Fprintln(w, "</table>")
+}
+
+func writeHTMLRow(w io.Writer, tag string, cells []string) {
+	fmt.Fprint(w, "  <tr>")
+	for _, cell := range cells {
+		fmt.Fprintf(w, "<%s>%s</%s>", tag, html.EscapeString(cell), tag)
+	}
+	fmt.Fprintln(w, "</tr>")
+}
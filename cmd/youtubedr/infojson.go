@@ -3,61 +3,28 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"strconv"
-	"strings"
 
+	"github.com/kkdai/youtube/v2"
 	"github.com/spf13/cobra"
 )
 
 // infoJsonCmd represents the info command but output it as JSON for other application to read
 var infoJSONCmd = &cobra.Command{
-	Use:   "infojson",
-	Short: "Print metadata of the desired video in json format",
-	Args:  cobra.ExactArgs(1),
+	Use:     "infojson",
+	Short:   "Print metadata of the desired video in json format",
+	Example: `infojson https://www.youtube.com/watch\?v\=XbNghLqsVwU > video.json`,
+	Args:    cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		//Define two new struct in local scope
-		type VideoFormat struct {
-			Itag         int
-			VideoQuality string
-			AudioQuality string
-			Size         float64
-			Bitrate      int
-			MimeType     string
-		}
-
 		type VideoInfo struct {
 			Title        string
 			Author       string
 			Duration     string
 			Description  string
-			VideoFormats []VideoFormat
+			VideoFormats []youtube.FormatInfo
+			Heatmap      []youtube.HeatmapPoint `json:",omitempty"`
 		}
 		video, err := getDownloader().GetVideo(args[0])
 		exitOnError(err)
-		thisVideoFormats := []VideoFormat{}
-		for _, format := range video.Formats {
-
-			bitrate := format.AverageBitrate
-			if bitrate == 0 {
-				// Some formats don't have the average bitrate
-				bitrate = format.Bitrate
-			}
-
-			size, _ := strconv.ParseInt(format.ContentLength, 10, 64)
-			if size == 0 {
-				// Some formats don't have this information
-				size = int64(float64(bitrate) * video.Duration.Seconds() / 8)
-			}
-
-			thisVideoFormats = append(thisVideoFormats, VideoFormat{
-				Itag:         format.ItagNo,
-				VideoQuality: format.QualityLabel,
-				AudioQuality: strings.ToLower(strings.TrimPrefix(format.AudioQuality, "AUDIO_QUALITY_")),
-				Size:         float64(size) / 1024 / 1024,
-				Bitrate:      bitrate,
-				MimeType:     format.MimeType,
-			})
-		}
 
 		//Prase the output struct
 		videoInfo := VideoInfo{
@@ -65,7 +32,8 @@ var infoJSONCmd = &cobra.Command{
 			Author:       video.Author,
 			Duration:     video.Duration.String(),
 			Description:  video.Description,
-			VideoFormats: thisVideoFormats,
+			VideoFormats: video.Formats.Infos(video.Duration),
+			Heatmap:      video.Heatmap,
 		}
 
 		//Output it as json
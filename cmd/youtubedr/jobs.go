@@ -0,0 +1,461 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	ytdl "github.com/kkdai/youtube/v2/downloader"
+)
+
+// errQuotaExceeded is returned when a download is queued in a namespace without storage left
+var errQuotaExceeded = errors.New("storage quota exceeded")
+
+const (
+	// progressInterval limits the byte count updates sent per job, state changes are sent immediately
+	progressInterval = 250 * time.Millisecond
+	// quotaCheckInterval limits how often the storage of a namespace with running downloads is measured
+	quotaCheckInterval = time.Second
+)
+
+// jobView is the JSON representation of a download job and its progress
+type jobView struct {
+	ID             string  `json:"id"`
+	URL            string  `json:"url"`
+	Quality        string  `json:"quality,omitempty"`
+	State          string  `json:"state"`
+	BytesCompleted int64   `json:"bytesCompleted"`
+	BytesTotal     int64   `json:"bytesTotal,omitempty"`
+	BytesPerSecond float64 `json:"bytesPerSecond,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+func newJobView(job *ytdl.Job, p ytdl.Progress) jobView {
+	view := jobView{
+		ID:             job.ID,
+		URL:            job.VideoURL,
+		Quality:        job.Options.Format.Quality,
+		State:          p.State.String(),
+		BytesCompleted: p.BytesCompleted,
+		BytesTotal:     p.BytesTotal,
+	}
+	if p.Stats != nil {
+		view.BytesPerSecond = p.Stats.BytesPerSecond
+	}
+	if p.Err != nil {
+		view.Error = p.Err.Error()
+	}
+	return view
+}
+
+// jobRequest queues a download
+type jobRequest struct {
+	URL     string   `json:"url"`
+	Quality string   `json:"quality"`
+	Codecs  []string `json:"codecs"`
+}
+
+// jobServer exposes the download queue of serve:
+//
+//	GET  /jobs                     lists the jobs
+//	POST /jobs                     queues a download, the body is a jobRequest
+//	POST /jobs/<id>/pause|resume|cancel
+//	GET  /ws/progress              WebSocket streaming a jobView for every update
+//	GET  /files                    lists the downloaded files
+//	GET  /files/<name>             downloads a file
+//
+// With perClient every authenticated client has its own namespace, a subdirectory of dir named after the client
+// with its own queue and storage quota. Clients only see the jobs and files of their namespace.
+type jobServer struct {
+	// downloader is copied for the manager of every namespace
+	downloader *ytdl.Downloader
+	// dir is the output directory of the downloads
+	dir string
+	// workers is the number of parallel downloads of a namespace
+	workers int
+	// perClient separates the downloads of the clients
+	perClient bool
+	// quota is the storage of a namespace in bytes, 0 is unlimited
+	quota int64
+
+	mu         sync.Mutex
+	namespaces map[string]*jobNamespace
+}
+
+// jobNamespace is the download queue and the directory of a client
+type jobNamespace struct {
+	dir     string
+	manager *ytdl.Manager
+}
+
+func newJobServer(dl *ytdl.Downloader, dir string, workers int) *jobServer {
+	return &jobServer{downloader: dl, dir: dir, workers: workers, namespaces: make(map[string]*jobNamespace)}
+}
+
+// namespace returns the namespace of the client of the request, it is created on first use
+func (s *jobServer) namespace(r *http.Request) *jobNamespace {
+	return s.clientNamespace(r.Context())
+}
+
+// clientNamespace returns the namespace of the client authenticated in the context
+func (s *jobServer) clientNamespace(ctx context.Context) *jobNamespace {
+	name := ""
+	if s.perClient {
+		name = serveClientName(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ns, ok := s.namespaces[name]
+	if !ok {
+		dl := *s.downloader
+		dl.OutputDir = filepath.Join(s.dir, name)
+		ns = &jobNamespace{dir: dl.OutputDir, manager: ytdl.NewManager(&dl, s.workers)}
+		s.namespaces[name] = ns
+		if s.quota > 0 {
+			go s.enforceQuota(ns)
+		}
+	}
+	return ns
+}
+
+func (s *jobServer) serveJobs(w http.ResponseWriter, r *http.Request) {
+	ns := s.namespace(r)
+
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			jobs := ns.manager.Jobs()
+			views := make([]jobView, 0, len(jobs))
+			for _, job := range jobs {
+				views = append(views, newJobView(job, job.Progress()))
+			}
+			writeJSON(w, http.StatusOK, views)
+		case http.MethodPost:
+			s.addJob(w, r, ns)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+	switch id, action := parts[0], parts[1]; action {
+	case "pause":
+		err = ns.manager.Pause(id)
+	case "resume":
+		err = ns.manager.Resume(id)
+	case "cancel":
+		err = ns.manager.Cancel(id)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if errors.Is(err, ytdl.ErrJobNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addJob queues a download.
+// Only JSON bodies of the same origin are accepted, browsers send cross-origin forms without preflight.
+func (s *jobServer) addJob(w http.ResponseWriter, r *http.Request, ns *jobNamespace) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request", http.StatusForbidden)
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		http.Error(w, "invalid job: Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req jobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid job: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		http.Error(w, "invalid job: url is missing", http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.queue(ns, req.URL, ytdl.JobOptions{
+		Format: youtube.FormatOptions{Quality: req.Quality, Codecs: req.Codecs},
+	})
+	if errors.Is(err, errQuotaExceeded) {
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newJobView(job, job.Progress()))
+}
+
+// queue adds a download to the namespace unless its storage quota is used up
+func (s *jobServer) queue(ns *jobNamespace, videoURL string, opts ytdl.JobOptions) (*ytdl.Job, error) {
+	if s.quota > 0 {
+		used, err := dirSize(ns.dir)
+		if err != nil {
+			return nil, err
+		}
+		if used >= s.quota {
+			return nil, fmt.Errorf("%w: %d of %d MiB used", errQuotaExceeded, used>>20, s.quota>>20)
+		}
+	}
+	return ns.manager.Add(videoURL, opts), nil
+}
+
+// enforceQuota cancels the running downloads of the namespace once its storage exceeds the quota.
+// The size of downloads is not known when they are queued, so queued downloads may exceed the remaining quota.
+func (s *jobServer) enforceQuota(ns *jobNamespace) {
+	updates, unsubscribe := ns.manager.Subscribe()
+	defer unsubscribe()
+
+	var checked time.Time
+	for p := range updates {
+		if p.State != ytdl.JobRunning || time.Since(checked) < quotaCheckInterval {
+			continue
+		}
+		checked = time.Now()
+		s.checkQuota(ns)
+	}
+}
+
+// checkQuota cancels the running downloads of the namespace if its storage exceeds the quota
+func (s *jobServer) checkQuota(ns *jobNamespace) {
+	used, err := dirSize(ns.dir)
+	if err != nil || used <= s.quota {
+		return
+	}
+	for _, job := range ns.manager.Jobs() {
+		if job.State() == ytdl.JobRunning {
+			log.Printf("Canceling download %s in %s: storage quota exceeded, %d of %d MiB used", job.ID, ns.dir, used>>20, s.quota>>20)
+			ns.manager.Cancel(job.ID)
+		}
+	}
+}
+
+// serveProgress sends the current state of all jobs of the namespace and then their updates over a WebSocket
+func (s *jobServer) serveProgress(w http.ResponseWriter, r *http.Request) {
+	manager := s.namespace(r).manager
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	// subscribe first, so no update between the snapshot and the subscription is lost
+	updates, unsubscribe := manager.Subscribe()
+	defer unsubscribe()
+
+	send := func(view jobView) bool {
+		data, err := json.Marshal(view)
+		return err == nil && ws.WriteText(data) == nil
+	}
+
+	for _, job := range manager.Jobs() {
+		if !send(newJobView(job, job.Progress())) {
+			return
+		}
+	}
+
+	type sent struct {
+		state ytdl.JobState
+		at    time.Time
+	}
+	last := make(map[string]sent)
+	for {
+		select {
+		case p, ok := <-updates:
+			if !ok {
+				return
+			}
+			if previous, ok := last[p.JobID]; ok && previous.state == p.State && time.Since(previous.at) < progressInterval {
+				continue
+			}
+			job, err := manager.Job(p.JobID)
+			if err != nil {
+				continue
+			}
+			if !send(newJobView(job, p)) {
+				return
+			}
+			last[p.JobID] = sent{state: p.State, at: time.Now()}
+		case <-ws.done:
+			return
+		}
+	}
+}
+
+// sameOrigin checks whether the Origin header of the request, if any, is the host of the server.
+// Browsers send it with cross-origin requests and WebSocket handshakes, other clients usually do not.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// fileView is a file in the output directory
+type fileView struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// serveFileList lists the files of the namespace, newest first.
+// The storage used and the quota are sent in the X-Storage-Used and X-Storage-Quota headers.
+func (s *jobServer) serveFileList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	files, err := listFiles(s.namespace(r).dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Storage-Used", strconv.FormatInt(filesSize(files), 10))
+	if s.quota > 0 {
+		w.Header().Set("X-Storage-Quota", strconv.FormatInt(s.quota, 10))
+	}
+	writeJSON(w, http.StatusOK, files)
+}
+
+// serveFile downloads a file of the namespace. Only the files of listFiles are served,
+// the output directory may be the working directory with hidden files like .git or .ssh.
+func (s *jobServer) serveFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !listedFileName(r.URL.Path) {
+		http.NotFound(w, r)
+		return
+	}
+
+	file := filepath.Join(s.namespace(r).dir, filepath.FromSlash(r.URL.Path))
+	if info, err := os.Lstat(file); err != nil || !info.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, file)
+}
+
+// listedFileName checks whether the slash separated name is neither hidden nor temporary,
+// no part of it may start with a dot
+func listedFileName(name string) bool {
+	parts := strings.Split(name, "/")
+	for _, part := range parts {
+		if part == "" || strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	// temporary files of running downloads start with youtube_
+	return !strings.HasPrefix(parts[len(parts)-1], "youtube_")
+}
+
+// listFiles returns the files in the directory and its subdirectories, newest first.
+// Hidden and temporary files and links are left out, a missing directory has no files.
+func listFiles(dir string) ([]fileView, error) {
+	files := []fileView{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// temporary files of running downloads start with youtube_, links are not served
+		if !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), "youtube_") {
+			return nil
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, fileView{Name: filepath.ToSlash(name), Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Modified.After(files[j].Modified) })
+	return files, nil
+}
+
+// dirSize returns the size of all files in the directory and its subdirectories, including temporary files
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	return size, nil
+}
+
+func filesSize(files []fileView) int64 {
+	var size int64
+	for _, f := range files {
+		size += f.Size
+	}
+	return size
+}
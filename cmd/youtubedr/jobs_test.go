@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ytdl "github.com/kkdai/youtube/v2/downloader"
+)
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://localhost:8080", true},
+		{"https://LOCALHOST:8080", true},
+		{"http://localhost:8081", false},
+		{"http://evil.example", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8080/jobs", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			assert.Equal(t, tt.want, sameOrigin(r))
+		})
+	}
+}
+
+func TestJobServer_addJobRejects(t *testing.T) {
+	s := newJobServer(&ytdl.Downloader{}, t.TempDir(), 1)
+
+	tests := []struct {
+		name        string
+		contentType string
+		origin      string
+		status      int
+	}{
+		{"form", "application/x-www-form-urlencoded", "", http.StatusUnsupportedMediaType},
+		{"text", "text/plain", "", http.StatusUnsupportedMediaType},
+		{"no content type", "", "", http.StatusUnsupportedMediaType},
+		{"cross origin", "application/json", "http://evil.example", http.StatusForbidden},
+		// passes the checks, but has no url
+		{"json", "application/json; charset=utf-8", "http://example.com", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// serve strips the /jobs prefix
+			r := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader(`{}`))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			s.serveJobs(w, r)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Empty(t, s.namespace(r).manager.Jobs())
+		})
+	}
+}
+
+func TestUpgradeWebSocket_crossOrigin(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "http://localhost:8080/ws/progress", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	r.Header.Set("Origin", "http://evil.example")
+
+	w := httptest.NewRecorder()
+	_, err := upgradeWebSocket(w, r)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestJobServer_quota(t *testing.T) {
+	// downloads run until they are canceled
+	dl := &ytdl.Downloader{}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})}
+	s := newJobServer(dl, t.TempDir(), 1)
+	s.quota = 100
+	ns := s.clientNamespace(context.Background())
+
+	job, err := s.queue(ns, "BaW_jenozKc", ytdl.JobOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return job.State() == ytdl.JobRunning }, time.Second, time.Millisecond)
+
+	// temporary files of running downloads count
+	require.NoError(t, ioutil.WriteFile(filepath.Join(ns.dir, "youtube_1.mp4"), make([]byte, 101), 0o644))
+	s.checkQuota(ns)
+	assert.Eventually(t, func() bool { return job.State() == ytdl.JobCanceled }, time.Second, time.Millisecond)
+
+	_, err = s.queue(ns, "BaW_jenozKc", ytdl.JobOptions{})
+	assert.True(t, errors.Is(err, errQuotaExceeded))
+	assert.Len(t, ns.manager.Jobs(), 1)
+}
+
+func TestJobServer_namespaces(t *testing.T) {
+	alice := context.WithValue(context.Background(), serveClientKey{}, "alice")
+	bob := context.WithValue(context.Background(), serveClientKey{}, "bob")
+
+	tests := []struct {
+		name      string
+		perClient bool
+		aliceDir  string
+		shared    bool
+	}{
+		{"shared", false, "", true},
+		{"per client", true, "alice", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := newJobServer(&ytdl.Downloader{}, dir, 1)
+			s.perClient = tt.perClient
+
+			ns := s.clientNamespace(alice)
+			assert.Equal(t, filepath.Join(dir, tt.aliceDir), ns.dir)
+			assert.Same(t, ns, s.clientNamespace(alice))
+			assert.Equal(t, tt.shared, ns == s.clientNamespace(bob))
+		})
+	}
+}
+
+func TestWSConn_writeTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { wsWriteTimeout = timeout }(wsWriteTimeout)
+	wsWriteTimeout = 10 * time.Millisecond
+
+	// the client never reads
+	client, server := net.Pipe()
+	defer client.Close()
+	ws := &wsConn{conn: server, done: make(chan struct{})}
+
+	err := ws.WriteText([]byte(`{"id":"1"}`))
+	require.Error(t, err)
+	var netErr net.Error
+	assert.True(t, errors.As(err, &netErr) && netErr.Timeout())
+
+	// the client is dropped
+	_, err = client.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestJobServer_serveFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"video.mp4", "author/video.mp4", ".git/config", ".env", "youtube_1.mp4"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0o644))
+	}
+	secret := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, ioutil.WriteFile(secret, []byte("secret"), 0o644))
+	if err := os.Symlink(secret, filepath.Join(dir, "link.mp4")); err != nil {
+		t.Log("no symlinks:", err)
+	}
+
+	s := newJobServer(&ytdl.Downloader{}, dir, 1)
+	handler := http.StripPrefix("/files/", http.HandlerFunc(s.serveFile))
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/files/video.mp4", http.StatusOK},
+		{"/files/author/video.mp4", http.StatusOK},
+		{"/files/author", http.StatusNotFound},
+		{"/files/author/", http.StatusNotFound},
+		{"/files/.git/config", http.StatusNotFound},
+		{"/files/.env", http.StatusNotFound},
+		{"/files/youtube_1.mp4", http.StatusNotFound},
+		{"/files/link.mp4", http.StatusNotFound},
+		{"/files/../secret", http.StatusNotFound},
+		{"/files/missing.mp4", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost"+tt.path, nil))
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, strings.TrimPrefix(tt.path, "/files/"), w.Body.String())
+			}
+		})
+	}
+
+	files, err := listFiles(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	assert.ElementsMatch(t, []string{"video.mp4", "author/video.mp4"}, names)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// ledgerCategories are the failure categories of the ledger, errorCategory split by rate limits
+var ledgerCategories = []string{"invalid_url", "unavailable", "age_restricted", "network", "rate_limited", "ffmpeg", "other"}
+
+// defaultRetryCategories are retried by later runs, the others are hard failures like deleted or private videos
+var defaultRetryCategories = []string{"network", "rate_limited", "ffmpeg", "other"}
+
+// ledgerEntry is the last failure of a video
+type ledgerEntry struct {
+	Category    string    `json:"category"`
+	Error       string    `json:"error"`
+	Attempts    int       `json:"attempts"`
+	FirstFailed time.Time `json:"firstFailed"`
+	LastFailed  time.Time `json:"lastFailed"`
+}
+
+// failureLedger records why videos failed to sync, so later runs retry transient failures
+// and skip hard ones. It is stored as JSON object keyed by video ID.
+type failureLedger struct {
+	file  string
+	retry map[string]bool
+
+	mu      sync.Mutex
+	entries map[string]*ledgerEntry
+}
+
+// openLedger reads the ledger, a missing file is an empty ledger
+func openLedger(name string, retryCategories []string) (*failureLedger, error) {
+	l := &failureLedger{file: name, retry: make(map[string]bool), entries: make(map[string]*ledgerEntry)}
+	for _, category := range retryCategories {
+		if !isLedgerCategory(category) {
+			return nil, fmt.Errorf("unknown failure category %q, valid are %s", category, strings.Join(ledgerCategories, ", "))
+		}
+		l.retry[category] = true
+	}
+
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &l.entries); err != nil {
+		return nil, fmt.Errorf("invalid failure ledger %s: %w", name, err)
+	}
+	return l, nil
+}
+
+func isLedgerCategory(category string) bool {
+	for _, c := range ledgerCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// ledgerCategory names the category of a failure
+func ledgerCategory(err error) string {
+	if errors.Is(err, youtube.ErrRateLimited) {
+		return "rate_limited"
+	}
+	return errorCategory(err)
+}
+
+// skip returns why a previously failed video is not retried, or "" if it is to be downloaded
+func (l *failureLedger) skip(id string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[id]
+	if !ok || l.retry[entry.Category] {
+		return ""
+	}
+	return fmt.Sprintf("failed %d times (%s): %s", entry.Attempts, entry.Category, entry.Error)
+}
+
+// record updates the ledger with the outcome of a download, successful downloads are removed from it
+func (l *failureLedger) record(id string, err error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err == nil {
+		if _, ok := l.entries[id]; !ok {
+			return nil
+		}
+		delete(l.entries, id)
+		return l.save()
+	}
+
+	now := time.Now().UTC()
+	entry, ok := l.entries[id]
+	if !ok {
+		entry = &ledgerEntry{FirstFailed: now}
+		l.entries[id] = entry
+	}
+	entry.Category = ledgerCategory(err)
+	entry.Error = err.Error()
+	entry.Attempts++
+	entry.LastFailed = now
+	return l.save()
+}
+
+// save writes the ledger atomically, the caller holds the lock
+func (l *failureLedger) save() error {
+	data, err := json.MarshalIndent(l.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.file), 0o755); err != nil {
+		return err
+	}
+
+	tmpFile := l.file + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, l.file)
+}
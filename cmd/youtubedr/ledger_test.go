@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerCategory(t *testing.T) {
+	tests := []struct {
+		err      error
+		category string
+	}{
+		{youtube.ErrRateLimited, "rate_limited"},
+		{fmt.Errorf("fetch: %w", youtube.ErrTransient), "network"},
+		{youtube.ErrPrivate, "unavailable"},
+		{youtube.ErrAgeRestricted, "age_restricted"},
+		{youtube.ErrInvalidCharactersInVideoID, "invalid_url"},
+		{errors.New("disk full"), "other"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.category, ledgerCategory(tt.err), tt.err.Error())
+	}
+}
+
+func TestFailureLedger(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state", "failures.json")
+
+	_, err := openLedger(file, []string{"network", "timeout"})
+	assert.Error(t, err)
+
+	l, err := openLedger(file, defaultRetryCategories)
+	require.NoError(t, err)
+	require.NoError(t, l.record("private", youtube.ErrPrivate))
+	require.NoError(t, l.record("private", youtube.ErrPrivate))
+	require.NoError(t, l.record("limited", youtube.ErrRateLimited))
+	require.NoError(t, l.record("ok", nil))
+
+	// the ledger is read by later runs
+	l, err = openLedger(file, defaultRetryCategories)
+	require.NoError(t, err)
+	assert.Equal(t, "failed 2 times (unavailable): "+youtube.ErrPrivate.Error(), l.skip("private"))
+	assert.Empty(t, l.skip("limited"), "transient failures are retried")
+	assert.Empty(t, l.skip("ok"))
+
+	// successful downloads are removed
+	require.NoError(t, l.record("private", nil))
+	l, err = openLedger(file, nil)
+	require.NoError(t, err)
+	assert.Empty(t, l.skip("private"))
+	assert.NotEmpty(t, l.skip("limited"), "only the given categories are retried")
+}
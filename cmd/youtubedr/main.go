@@ -6,15 +6,17 @@ import (
 )
 
 func main() {
+	registerCompletions()
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		printUpdateNotice(err)
+		os.Exit(exitCode(err))
 	}
 }
 
 func exitOnError(err error) {
 	if err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// skippedEntry describes a playlist entry which has not been downloaded
+type skippedEntry struct {
+	ID       string `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Playlist string `json:"playlist"`
+	Reason   string `json:"reason"`
+}
+
+// getPlaylist fetches the playlist of the argument, it returns nil if the argument refers to a single video
+func getPlaylist(arg string) (*youtube.Playlist, error) {
+	// watch URLs with a list parameter refer to the video
+	if u, err := url.Parse(arg); err == nil && u.Query().Get("v") != "" {
+		return nil, nil
+	}
+
+	// albums of YouTube Music are referenced by their browse ID
+	if youtube.IsMusicURL(arg) {
+		resolved, err := getDownloader().ResolveMusicURL(context.Background(), arg)
+		if err != nil {
+			return nil, err
+		}
+		arg = resolved
+	}
+
+	playlist, err := getDownloader().GetPlaylist(arg)
+	if err == youtube.ErrInvalidPlaylist {
+		return nil, nil
+	}
+	return playlist, err
+}
+
+// skipReason returns why a playlist entry is skipped instead of failing the whole run
+func skipReason(entry *youtube.PlaylistEntry, err error) string {
+	switch {
+	case entry.IsDeleted:
+		return "deleted"
+	case entry.IsPrivate:
+		return "private"
+	}
+
+	var playability *youtube.ErrPlayabiltyStatus
+	if errors.As(err, &playability) {
+		return fmt.Sprintf("%s: %s", playability.Status, playability.Reason)
+	}
+
+	return ""
+}
+
+func writeSkippedReport(skipped []skippedEntry) error {
+	if skippedReportFile == "" {
+		return nil
+	}
+	if skipped == nil {
+		skipped = []skippedEntry{}
+	}
+
+	data, err := json.MarshalIndent(skipped, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if skippedReportFile == "-" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return ioutil.WriteFile(skippedReportFile, data, 0o644)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	ytdl "github.com/kkdai/youtube/v2/downloader"
+	"github.com/spf13/pflag"
+)
+
+// playlistFileFormats are the formats of --write-playlist
+var playlistFileFormats []string
+
+func addPlaylistFileFlag(flagSet *pflag.FlagSet) {
+	flagSet.StringSliceVar(&playlistFileFormats, "write-playlist", nil, "Write playlist files referencing the downloaded videos of each playlist in order: m3u8, xspf")
+}
+
+func checkPlaylistFileFormats() error {
+	for _, format := range playlistFileFormats {
+		if format != "m3u8" && format != "xspf" {
+			return fmt.Errorf("unknown playlist file format %q, use m3u8 or xspf", format)
+		}
+	}
+	return nil
+}
+
+// writePlaylistFiles writes a playlist file per format and playlist of the session into the output directory.
+// Files are referenced relative to the output directory, videos which are not downloaded are left out.
+func writePlaylistFiles(sess *session) error {
+	if len(playlistFileFormats) == 0 {
+		return nil
+	}
+
+	for id, title := range sess.Playlists {
+		var entries []ytdl.PlaylistFileEntry
+		for _, item := range sess.Items {
+			if item.Playlist != id || item.Status != itemDone || item.OutputFile == "" {
+				continue
+			}
+
+			file := item.OutputFile
+			if rel, err := filepath.Rel(outputDir, file); err == nil {
+				file = rel
+			}
+			entries = append(entries, ytdl.PlaylistFileEntry{Title: item.Title, File: file})
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		name := title
+		if name == "" {
+			name = id
+		}
+		for _, format := range playlistFileFormats {
+			file := filepath.Join(outputDir, ytdl.SanitizeFilename(name)+"."+format)
+			if err := writePlaylistFile(file, format, title, entries); err != nil {
+				return err
+			}
+			log.Println("Wrote playlist", file)
+		}
+	}
+
+	return nil
+}
+
+func writePlaylistFile(file, format, title string, entries []ytdl.PlaylistFileEntry) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	if format == "xspf" {
+		err = ytdl.WriteXSPF(out, title, entries)
+	} else {
+		err = ytdl.WriteM3U8(out, entries)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
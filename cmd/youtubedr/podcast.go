@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/spf13/cobra"
+)
+
+var podcastCmdOpts struct {
+	baseURL  string
+	feedFile string
+	maxItems int
+}
+
+// podcastCmd downloads the audio of a playlist or channel and writes a podcast feed for it
+var podcastCmd = &cobra.Command{
+	Use:   "podcast",
+	Short: "Downloads the audio of a playlist or channel and generates a podcast RSS feed",
+	Long: `Downloads the AAC audio of all videos of a playlist or of the uploads of a channel into the output directory
+and writes a podcast RSS feed with enclosures, durations and artwork. Publish the output directory at --base-url
+and subscribe to the feed in any podcast app. Running it again only downloads new episodes.`,
+	Example:      `podcast -d /srv/files --base-url https://files.example.com/ https://www.youtube.com/playlist\?list\=PLqAfPOrmacr963ATEroh67fbvjmTzTEx5`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		u, err := url.Parse(podcastCmdOpts.baseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("--base-url must be an absolute URL, got %q", podcastCmdOpts.baseURL)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		playlist, err := getPlaylist(uploadsPlaylist(args[0]))
+		if err != nil {
+			return err
+		}
+		if playlist == nil {
+			return youtube.ErrInvalidPlaylist
+		}
+
+		dl := getDownloader()
+		dl.OutputDir = outputDir
+		dl.AudioLanguage = audioLanguage
+		return dl.DownloadPodcast(context.Background(), playlist, podcastCmdOpts.baseURL, podcastCmdOpts.feedFile, podcastCmdOpts.maxItems)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(podcastCmd)
+
+	podcastCmd.Flags().StringVarP(&outputDir, "directory", "d", ".", "The output directory.")
+	podcastCmd.Flags().StringVar(&podcastCmdOpts.baseURL, "base-url", "", "URL at which the output directory is published (required)")
+	podcastCmd.Flags().StringVar(&podcastCmdOpts.feedFile, "feed-file", "feed.xml", "Name of the feed in the output directory")
+	podcastCmd.Flags().IntVar(&podcastCmdOpts.maxItems, "max-items", 0, "Only include the first n videos of the playlist or channel")
+	podcastCmd.Flags().StringVar(&audioLanguage, "audio-lang", "", "Preferred audio track language of dubbed videos, e.g. en (default is the original track)")
+	podcastCmd.MarkFlagRequired("base-url") //nolint:errcheck
+	addTempDirFlag(podcastCmd.Flags())
+}
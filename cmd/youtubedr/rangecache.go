@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// byteRange is an inclusive window of a stream
+type byteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// rangeIndex lists the cached ranges of a stream, it is stored next to the data file
+type rangeIndex struct {
+	Size   int64       `json:"size"`
+	Ranges []byteRange `json:"ranges"`
+}
+
+// rangeCache keeps the byte ranges of served streams on disk, keyed by video ID, itag and audio track.
+// The data file of a stream is sparse, only the fetched ranges are written.
+type rangeCache struct {
+	dir string
+	// maxSize limits the cached bytes, the least recently used streams are removed first. 0 is unlimited.
+	maxSize int64
+
+	mu sync.Mutex
+	// streams are the streams in use, their data file is closed after the last request
+	streams map[string]*cachedStream
+}
+
+func newRangeCache(dir string, maxSize int64) *rangeCache {
+	return &rangeCache{dir: dir, maxSize: maxSize, streams: make(map[string]*cachedStream)}
+}
+
+// cachedStream is the data file and the index of a single stream
+type cachedStream struct {
+	key       string
+	file      *os.File
+	indexFile string
+	users     int // guarded by rangeCache.mu
+
+	mu    sync.Mutex
+	index rangeIndex
+
+	// saveMu keeps an older index from replacing a newer one
+	saveMu sync.Mutex
+}
+
+// streamName is the file name of a format in the directory of the video
+func streamName(format *youtube.Format) string {
+	name := strconv.Itoa(format.ItagNo)
+	if format.AudioTrack != nil && format.AudioTrack.ID != "" {
+		name += "-" + url.PathEscape(format.AudioTrack.ID)
+	}
+	return name
+}
+
+// open returns the cached stream of the format, an index of another size is discarded.
+// The stream has to be released after use.
+func (c *rangeCache) open(videoID string, format *youtube.Format, size int64) (*cachedStream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := streamName(format)
+	key := videoID + "/" + name
+	stream, ok := c.streams[key]
+	if !ok {
+		dir := filepath.Join(c.dir, videoID)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(filepath.Join(dir, name+".data"), os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, err
+		}
+
+		stream = &cachedStream{key: key, file: file, indexFile: filepath.Join(dir, name+".ranges.json")}
+		if data, err := ioutil.ReadFile(stream.indexFile); err == nil {
+			json.Unmarshal(data, &stream.index)
+		}
+		c.streams[key] = stream
+	}
+	stream.users++
+
+	stream.mu.Lock()
+	if stream.index.Size != size {
+		stream.index = rangeIndex{Size: size}
+	}
+	stream.mu.Unlock()
+
+	// the modification time of the index orders the eviction
+	now := time.Now()
+	os.Chtimes(stream.indexFile, now, now)
+	return stream, nil
+}
+
+// release closes the data file after the last user of the stream
+func (c *rangeCache) release(stream *cachedStream) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stream.users--
+	if stream.users == 0 {
+		delete(c.streams, stream.key)
+		stream.file.Close()
+	}
+}
+
+// evict removes the least recently used streams until the cached bytes fit into maxSize.
+// Streams in use are kept, the sizes are taken from the indexes on disk.
+func (c *rangeCache) evict() {
+	if c.maxSize <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type entry struct {
+		key  string
+		size int64
+		used time.Time
+	}
+	var entries []entry
+	var total int64
+	videos, _ := ioutil.ReadDir(c.dir)
+	for _, video := range videos {
+		if !video.IsDir() {
+			continue
+		}
+		files, _ := ioutil.ReadDir(filepath.Join(c.dir, video.Name()))
+		for _, file := range files {
+			name := file.Name()
+			if !strings.HasSuffix(name, ".ranges.json") {
+				continue
+			}
+			data, err := ioutil.ReadFile(filepath.Join(c.dir, video.Name(), name))
+			if err != nil {
+				continue
+			}
+			var index rangeIndex
+			json.Unmarshal(data, &index)
+
+			e := entry{key: video.Name() + "/" + strings.TrimSuffix(name, ".ranges.json"), used: file.ModTime()}
+			for _, r := range index.Ranges {
+				e.size += r.End - r.Start + 1
+			}
+			entries = append(entries, e)
+			total += e.size
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+	for _, e := range entries {
+		if total <= c.maxSize {
+			break
+		}
+		if _, ok := c.streams[e.key]; ok {
+			continue
+		}
+		base := filepath.Join(c.dir, filepath.FromSlash(e.key))
+		os.Remove(base + ".ranges.json")
+		os.Remove(base + ".data")
+		// fails unless it was the last stream of the video
+		os.Remove(filepath.Dir(base))
+		total -= e.size
+	}
+}
+
+// span returns whether the byte at pos is cached and the end of the window with the same state
+func (s *cachedStream) span(pos int64) (cached bool, end int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	end = s.index.Size - 1
+	for _, r := range s.index.Ranges {
+		switch {
+		case pos < r.Start:
+			return false, r.Start - 1
+		case pos <= r.End:
+			return true, r.End
+		}
+	}
+	return false, end
+}
+
+// add marks a window as cached, merging it with adjacent ranges
+// Windows of a stream with another size are ignored, its index has been discarded.
+func (s *cachedStream) add(size, start, end int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index.Size != size {
+		return
+	}
+
+	ranges := append(s.index.Ranges, byteRange{Start: start, End: end})
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End+1 {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	s.index.Ranges = merged
+}
+
+// saveIndex persists the cached ranges, failing to do so only loses them for the next run
+func (s *cachedStream) saveIndex() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	data, err := json.Marshal(s.index)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(s.indexFile), filepath.Base(s.indexFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), s.indexFile)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+	}
+	return err
+}
+
+// cacheWriter writes upstream data into the data file and marks it as cached
+type cacheWriter struct {
+	stream *cachedStream
+	size   int64
+	offset int64
+}
+
+func (w *cacheWriter) Write(p []byte) (int, error) {
+	n, err := w.stream.file.WriteAt(p, w.offset)
+	if n > 0 {
+		w.stream.add(w.size, w.offset, w.offset+int64(n)-1)
+		w.offset += int64(n)
+	}
+	return n, err
+}
+
+// copyRange writes the window [start, end] of a stream to w, cached parts are read from disk
+// and missing parts are written by fetch to the cache and w
+func (c *rangeCache) copyRange(w io.Writer, videoID string, format *youtube.Format, size, start, end int64, fetch func(w io.Writer, start, end int64) error) error {
+	stream, err := c.open(videoID, format, size)
+	if err != nil {
+		return err
+	}
+	defer c.evict()
+	defer c.release(stream)
+
+	for pos := start; pos <= end; {
+		cached, until := stream.span(pos)
+		if until > end {
+			until = end
+		}
+
+		if cached {
+			if _, err := io.Copy(w, io.NewSectionReader(stream.file, pos, until-pos+1)); err != nil {
+				return err
+			}
+		} else {
+			err := fetch(io.MultiWriter(&cacheWriter{stream: stream, size: size, offset: pos}, w), pos, until)
+			stream.saveIndex()
+			if err != nil {
+				return err
+			}
+		}
+		pos = until + 1
+	}
+	return nil
+}
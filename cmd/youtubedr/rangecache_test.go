@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fetchFrom returns a fetch function serving windows of data and counting the fetched bytes
+func fetchFrom(data string, fetched *int64) func(w io.Writer, start, end int64) error {
+	return func(w io.Writer, start, end int64) error {
+		*fetched += end - start + 1
+		_, err := io.WriteString(w, data[start:end+1])
+		return err
+	}
+}
+
+func TestRangeCache_copyRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangecache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cache := newRangeCache(dir, 0)
+	format := &youtube.Format{ItagNo: 140}
+	dubbed := &youtube.Format{ItagNo: 140, AudioTrack: &youtube.AudioTrack{ID: "de.3"}}
+	var fetched int64
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.copyRange(&buf, "video", format, 10, 2, 5, fetchFrom("0123456789", &fetched)))
+	assert.Equal(t, "2345", buf.String())
+
+	buf.Reset()
+	require.NoError(t, cache.copyRange(&buf, "video", format, 10, 0, 9, fetchFrom("0123456789", &fetched)))
+	assert.Equal(t, "0123456789", buf.String())
+	assert.EqualValues(t, 10, fetched, "the cached window is not fetched again")
+	assert.Empty(t, cache.streams, "released streams are closed")
+
+	buf.Reset()
+	require.NoError(t, cache.copyRange(&buf, "video", dubbed, 10, 0, 9, fetchFrom("abcdefghij", &fetched)))
+	assert.Equal(t, "abcdefghij", buf.String(), "audio tracks are cached separately")
+
+	buf.Reset()
+	require.NoError(t, cache.copyRange(&buf, "video", format, 8, 0, 7, fetchFrom("ABCDEFGH", &fetched)))
+	assert.Equal(t, "ABCDEFGH", buf.String(), "a stream of another size is fetched again")
+
+	files, err := filepath.Glob(filepath.Join(dir, "video", "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestRangeCache_openSizeInUse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangecache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cache := newRangeCache(dir, 0)
+	format := &youtube.Format{ItagNo: 18}
+	stream, err := cache.open("video", format, 10)
+	require.NoError(t, err)
+	defer cache.release(stream)
+	stream.add(10, 0, 9)
+
+	other, err := cache.open("video", format, 20)
+	require.NoError(t, err)
+	defer cache.release(other)
+	cached, end := other.span(0)
+	assert.False(t, cached, "the index of another size is discarded while the stream is open")
+	assert.EqualValues(t, 19, end)
+}
+
+func TestRangeCache_evict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rangecache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cache := newRangeCache(dir, 0)
+	format := &youtube.Format{ItagNo: 18}
+	var fetched int64
+	for i, id := range []string{"old", "used", "new"} {
+		require.NoError(t, cache.copyRange(ioutil.Discard, id, format, 10, 0, 9, fetchFrom(strings.Repeat("x", 10), &fetched)))
+		// the eviction orders by the modification time of the index
+		used := time.Now().Add(time.Duration(i-10) * time.Minute)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, id, "18.ranges.json"), used, used))
+	}
+	stream, err := cache.open("old", format, 10)
+	require.NoError(t, err)
+	cache.release(stream)
+
+	cache.maxSize = 15
+	cache.evict()
+
+	_, err = os.Stat(filepath.Join(dir, "old", "18.ranges.json"))
+	assert.NoError(t, err, "opening marks a stream as used")
+	_, err = os.Stat(filepath.Join(dir, "used"))
+	assert.True(t, os.IsNotExist(err), "the least recently used stream is removed")
+	_, err = os.Stat(filepath.Join(dir, "new"))
+	assert.True(t, os.IsNotExist(err), "streams are removed until the cache fits")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	ytdl "github.com/kkdai/youtube/v2/downloader"
+	"github.com/spf13/viper"
+)
+
+// recodeProfilesKey is the config file section with user-defined transcode profiles, e.g.
+//
+//	recode-profiles:
+//	  tv:
+//	    extension: .mp4
+//	    args: ["-c:v", "libx264", "-c:a", "aac"]
+const recodeProfilesKey = "recode-profiles"
+
+// transcodeProfiles returns the built-in profiles overridden by the ones of the config file
+func transcodeProfiles() (map[string]ytdl.TranscodeProfile, error) {
+	profiles := make(map[string]ytdl.TranscodeProfile, len(ytdl.TranscodeProfiles))
+	for name, profile := range ytdl.TranscodeProfiles {
+		profiles[name] = profile
+	}
+
+	var configured map[string]ytdl.TranscodeProfile
+	if err := viper.UnmarshalKey(recodeProfilesKey, &configured); err != nil {
+		return nil, fmt.Errorf("invalid %s in config file: %w", recodeProfilesKey, err)
+	}
+	for name, profile := range configured {
+		if len(profile.Args) == 0 {
+			return nil, fmt.Errorf("recode profile %q has no args", name)
+		}
+		if profile.Extension != "" && !strings.HasPrefix(profile.Extension, ".") {
+			profile.Extension = "." + profile.Extension
+		}
+		profiles[name] = profile
+	}
+
+	return profiles, nil
+}
+
+// recodeProcessor returns the post processor of the named transcode profile
+func recodeProcessor(name string, ffmpeg ytdl.FFmpeg) (ytdl.PostProcessor, error) {
+	profiles, err := transcodeProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown recode profile %q, available: %s", name, strings.Join(names, ", "))
+	}
+
+	return profile.Processor(ffmpeg), nil
+}
+
+// isAudioExtension checks whether a transcode profile extracts the audio
+func isAudioExtension(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".mp3", ".m4a", ".opus", ".ogg", ".flac", ".wav":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/spf13/pflag"
+)
+
+var (
+	reportFile       string
+	reportTombstones bool
+)
+
+func addReportFlag(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&reportFile, "report", "", "Write a JSON report with the result of every video to this file (- for stdout)")
+	flagSet.BoolVar(&reportTombstones, "tombstones", false, "Recover title and uploader of unavailable videos from the Wayback Machine into the report")
+}
+
+// reportRecord is the result of a single input of a batch run
+type reportRecord struct {
+	Input         string  `json:"input"`
+	Title         string  `json:"title,omitempty"`
+	Status        string  `json:"status"`
+	Reason        string  `json:"reason,omitempty"`
+	OutputFile    string  `json:"outputFile,omitempty"`
+	Itag          int     `json:"itag,omitempty"`
+	Bytes         int64   `json:"bytes,omitempty"`
+	Duration      float64 `json:"durationSeconds,omitempty"`
+	ErrorCategory string  `json:"errorCategory,omitempty"`
+	Error         string  `json:"error,omitempty"`
+
+	// Tombstone is the archived metadata of an unavailable video, see --tombstones
+	Tombstone *youtube.Tombstone `json:"tombstone,omitempty"`
+
+	// unavailable is set for deleted, private or blocked videos
+	unavailable bool
+}
+
+// finish records the outcome of a download which started at the given time
+func (r *reportRecord) finish(start time.Time, err error) {
+	r.Duration = time.Since(start).Seconds()
+	if err != nil {
+		r.Status = itemFailed
+		r.ErrorCategory = errorCategory(err)
+		r.Error = err.Error()
+		r.unavailable = exitCode(err) == exitUnavailable
+	} else if r.Status == "" {
+		r.Status = itemDone
+	}
+}
+
+// fileCompleted records the output file and its size
+func (r *reportRecord) fileCompleted(file string) {
+	r.OutputFile = file
+	if info, err := os.Stat(file); err == nil {
+		r.Bytes = info.Size()
+	}
+}
+
+func writeReport(records []*reportRecord) error {
+	if reportFile == "" {
+		return nil
+	}
+	if records == nil {
+		records = []*reportRecord{}
+	}
+	if reportTombstones {
+		addTombstones(records)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if reportFile == "-" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return ioutil.WriteFile(reportFile, data, 0o644)
+}
+
+// addTombstones looks up the archived metadata of the unavailable videos
+func addTombstones(records []*reportRecord) {
+	client := &getDownloader().Client
+	for _, r := range records {
+		if !r.unavailable || r.Tombstone != nil {
+			continue
+		}
+
+		tombstone, err := client.GetTombstone(context.Background(), r.Input)
+		if err != nil {
+			log.Printf("No tombstone for %s: %v", r.Input, err)
+			continue
+		}
+		r.Tombstone = tombstone
+		if r.Title == "" {
+			r.Title = tombstone.Title
+		}
+	}
+}
@@ -5,7 +5,12 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/kkdai/youtube/v2"
+	"github.com/kkdai/youtube/v2/cmd/youtubedr/i18n"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -13,8 +18,12 @@ import (
 
 var (
 	cfgFile           string
-	verbose           bool
+	verbosity         int
+	quiet             bool
 	verboseHTTPClient bool
+	debugDumpDir      string
+	language          string
+	localeDir         string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -24,30 +33,93 @@ var rootCmd = &cobra.Command{
 	Long: `This tool is meant to be used to download CC0 licenced content, we do not support nor recommend using it for illegal activities.
 
 Use the HTTP_PROXY environment variable to set a HTTP or SOCSK5 proxy. The proxy type is determined by the URL scheme.
-"http", "https", and "socks5" are supported. If the scheme is empty, "http" is assumed.`,
+"http", "https", and "socks5" are supported. If the scheme is empty, "http" is assumed.
+
+Every flag can also be set with an environment variable named YOUTUBEDR_ and the flag name in upper case
+with underscores, e.g. YOUTUBEDR_LISTEN=:8080 for --listen. Flags on the command line take precedence.`,
+	Example: `download -q 18 https://www.youtube.com/watch\?v\=rFejpH_tAHM`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// the flags have to be complete before the logging and the downloader are set up
+		if err := applyEnvironment(cmd); err != nil {
+			return err
+		}
+		if err := initLanguage(); err != nil {
+			return err
+		}
+		initLogging()
+		initConfig()
+		return nil
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	//	Run: func(cmd *cobra.Command, args []string) { },
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
-	cobra.OnInitialize(func() {
-		getDownloader().DebugHTTPClient = verboseHTTPClient
-		getDownloader().Debug = verbose
-		if !verbose {
-			log.SetOutput(ioutil.Discard)
-		}
-	})
-
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.youtubedr.yaml)")
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", true, "Enable verbose output")
+	rootCmd.PersistentFlags().StringVar(&language, "lang", "", "Language of the output, e.g. de (default is the language of the environment)")
+	rootCmd.PersistentFlags().StringVar(&localeDir, "locale-dir", "", "Directory with additional <language>.json message catalogs, see the translations command")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity, -v for debug output, -vv also logs HTTP requests")
+	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "Only print errors, no progress bars (no -q shorthand, -q is --quality)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Print tables without colors, also set by the NO_COLOR environment variable")
 	rootCmd.PersistentFlags().BoolVar(&verboseHTTPClient, "log-http", false, "Enable Log HTTP Client")
+	rootCmd.PersistentFlags().StringVar(&debugDumpDir, "debug-dump", "", "Record all HTTP requests and responses (without credentials) to a JSON lines file in this directory")
 	rootCmd.PersistentFlags().BoolVar(&insecureSkipVerify, "insecure", false, "Skip TLS server certificate verification")
+	rootCmd.PersistentFlags().IntVar(&transportConfig.MaxIdleConnsPerHost, "max-idle-conns-per-host", 0, "Number of idle connections kept open per host (default 2)")
+	rootCmd.PersistentFlags().BoolVar(&transportConfig.DisableHTTP2, "no-http2", false, "Use HTTP/1.1 only")
+	rootCmd.PersistentFlags().DurationVar(&transportConfig.KeepAlive, "tcp-keepalive", 0, "Interval of TCP keep-alive probes, negative to disable (default 30s)")
+	rootCmd.PersistentFlags().StringArrayVar(&transportConfig.SourceAddresses, "source-address", nil, "Local IP address to send requests from, repeat to rotate between several addresses per request")
+	rootCmd.PersistentFlags().BoolVar(&transportConfig.RotatePerDownload, "rotate-source-per-download", false, "Use the same source address for all requests of a download instead of rotating per request")
+	rootCmd.PersistentFlags().StringArrayVar(&streamHostRewrites, "stream-host", nil, "Rewrite stream hosts, e.g. \"*.googlevideo.com=http://cache.example.edu:3128/{host}\" (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&cookiesFile, "cookies", "", "Cookies file in the Netscape format (cookies.txt) exported from a browser, to sign in to an account")
+	rootCmd.PersistentFlags().StringVar(&stateDir, "state-dir", "", "Keep the visitor data in this directory, so consecutive runs continue the same session")
+	rootCmd.PersistentFlags().BoolVar(&androidClient, "android-client", false, "Prefer the unthrottled stream URLs of the Android app, formats it doesn't offer are downloaded from the website")
+	rootCmd.PersistentFlags().BoolVar(&lenientParsing, "lenient", false, "Tolerate player responses with values of unexpected types or renamed sections, printing warnings instead of failing")
+	rootCmd.PersistentFlags().StringVar(&poToken, "po-token", "", "Proof-of-origin token added to stream requests, some videos answer 403 Forbidden without it")
+	rootCmd.PersistentFlags().StringVar(&poTokenCommand, "po-token-command", "", "Command printing the proof-of-origin token of a video, run with the video ID as last argument")
+	rootCmd.PersistentFlags().StringVar(&httpCacheDir, "http-cache-dir", "", "Keep thumbnails, avatars and feeds in this directory and only fetch them again if they changed (ETag/Last-Modified)")
+	rootCmd.PersistentFlags().DurationVar(&transportConfig.DialTimeout, "dial-timeout", 0, "Timeout for establishing TCP connections (default 30s)")
+}
+
+// initLanguage loads the additional catalogs and selects the language of the output
+func initLanguage() error {
+	if localeDir != "" {
+		if err := i18n.LoadDir(localeDir); err != nil {
+			return err
+		}
+	}
+
+	if language == "" {
+		i18n.SetLanguage(i18n.DetectLanguage())
+		return nil
+	}
+	if !i18n.SetLanguage(language) {
+		log.Printf("No translation for language %s, available are %s", language, strings.Join(i18n.Languages(), ", "))
+	}
+	return nil
+}
+
+// initLogging maps --quiet and --verbose to the log output of the downloader
+func initLogging() {
+	dl := getDownloader()
+	dl.Debug = verbosity >= 1 && !quiet
+	dl.DebugHTTPClient = verboseHTTPClient || (verbosity >= 2 && !quiet)
+	dl.NoProgress = quiet
+	if quiet {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	if debugDumpDir != "" {
+		exitOnError(os.MkdirAll(debugDumpDir, 0o755))
+		file, err := os.Create(filepath.Join(debugDumpDir, time.Now().Format("youtubedr-20060102-150405.jsonl")))
+		exitOnError(err)
+		dl.DebugRecorder = youtube.NewJSONLRecorder(file)
+		log.Println("recording HTTP exchanges to", file.Name())
+	}
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -68,10 +140,13 @@ func initConfig() {
 		viper.SetConfigName(".youtubedr")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// read in environment variables that match, e.g. YOUTUBEDR_UPDATE_NOTICE
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		log.Println("Using config file:", viper.ConfigFileUsed())
 	}
 }
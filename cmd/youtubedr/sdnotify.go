@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net"
+	"os"
+)
+
+// sdNotify sends a state like "READY=1" to systemd for units of Type=notify.
+// It does nothing if the process was not started by systemd.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	// names starting with @ are abstract sockets, which net maps on its own
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	pb "github.com/kkdai/youtube/v2/api/youtubedr"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// videoCacheTTL is well below the expiry of stream URLs
+	videoCacheTTL = time.Hour
+	// upstreamRetries limits the reconnects of a client request without progress
+	upstreamRetries = 3
+)
+
+var serveCmdOpts struct {
+	listen      string
+	cacheDir    string
+	cacheMaxMiB int
+	workers     int
+	ui          bool
+
+	apiKeys    []string
+	basicAuth  []string
+	rateLimit  int
+	namespaces bool
+	quotaMiB   int
+	healthPort int
+	grpcListen string
+}
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Proxies videos over HTTP, with seeking support for browsers, smart TVs and DLNA renderers",
+	Long: `Proxies videos over HTTP. Videos are available at /videos/<video id>, the format can be chosen with
+the quality and codec query parameters, e.g. /videos/BaW_jenozKc?quality=22.
+Range requests are mapped to the upstream stream, so clients can seek without downloading the whole video.
+Expired stream URLs are refreshed and interrupted transfers resumed, so clients can play for hours.
+
+HLS playlists are available at /hls/<video id>/<quality>/index.m3u8, where quality is an itag, a quality label
+or "best". The H.264 and AAC streams are segmented on the fly by ffmpeg, which has to be installed.
+
+With --cache-dir the served byte ranges are kept on disk per video, format and audio track. Repeated requests
+and seeks are served from the cache and only the missing ranges are fetched upstream. --cache-max limits the
+size of the cache, the least recently used streams are removed first.
+
+Downloads into the output directory are queued with POST /jobs and a body like {"url": "BaW_jenozKc", "quality": "hd720"}.
+GET /jobs lists the jobs, POST /jobs/<id>/pause, /resume and /cancel control them. The WebSocket /ws/progress
+streams a JSON event with the state and byte counts of a job for every update.
+GET /formats?url=<video> lists the formats of a video, GET /files the files in the output directory,
+which are downloaded from /files/<name>.
+
+A web UI at / resolves URLs, queues downloads, shows their progress and lists the downloaded files.
+Disable it with --ui=false to only serve the API.
+
+Without credentials everyone able to connect can use the server, so only listen on localhost or add them
+before exposing it. With --api-key requests need the key as bearer token, in the X-API-Key header or as
+api_key query parameter, e.g. /videos/BaW_jenozKc?api_key=KEY for players and /?api_key=KEY for the web UI.
+--basic-auth accepts users with a password instead. --rate-limit limits the requests per minute of every key
+and user, a key can have its own limit with --api-key KEY=REQUESTS_PER_MINUTE.
+
+With --namespaces every user and key downloads into its own subdirectory of the output directory, with its own
+queue of --workers parallel downloads and a storage quota of --quota MiB. Clients only see their own jobs and files.
+Downloads are refused once the quota is used up, running downloads are canceled when they exceed it.
+Keys are named with --api-key NAME:KEY, keys and users of the same name share the namespace.
+
+--health-port serves GET /healthz without authentication on a separate port for container health checks.
+
+--grpc-listen serves the gRPC API of api/proto/youtubedr.proto on a separate address with unencrypted HTTP/2.
+It shares the downloads, namespaces and credentials of the HTTP API, clients send the API key as
+"authorization: Bearer KEY" metadata. It is only available in builds with Go 1.24 or later,
+which serve unencrypted HTTP/2, older builds refuse to start with --grpc-listen.`,
+	Example:      `serve --listen :8080 --api-key 5dc8a1f3e2b74f0c=120`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		auth, err := newServeAuth(serveCmdOpts.apiKeys, serveCmdOpts.basicAuth, serveCmdOpts.rateLimit)
+		if err != nil {
+			return err
+		}
+		if serveCmdOpts.namespaces && !auth.enabled() {
+			return fmt.Errorf("--namespaces requires --api-key or --basic-auth")
+		}
+		if !auth.enabled() && !isLoopbackAddress(serveCmdOpts.listen) {
+			log.Printf("Warning: listening on %s without --api-key or --basic-auth, everyone able to connect can use the server", serveCmdOpts.listen)
+		}
+
+		server := newVideoServer()
+		if serveCmdOpts.cacheDir != "" {
+			server.cache = newRangeCache(serveCmdOpts.cacheDir, int64(serveCmdOpts.cacheMaxMiB)<<20)
+		}
+
+		dl := *getDownloader()
+		dl.OutputDir = outputDir
+		dl.NoProgress = true
+		jobs := newJobServer(&dl, outputDir, serveCmdOpts.workers)
+		jobs.perClient = serveCmdOpts.namespaces
+		jobs.quota = int64(serveCmdOpts.quotaMiB) << 20
+
+		mux := http.NewServeMux()
+		mux.Handle("/videos/", http.StripPrefix("/videos/", http.HandlerFunc(server.serveVideo)))
+		mux.Handle("/hls/", http.StripPrefix("/hls/", http.HandlerFunc(newHLSServer(server).serveHLS)))
+		jobsHandler := http.StripPrefix("/jobs", http.HandlerFunc(jobs.serveJobs))
+		mux.Handle("/jobs", jobsHandler)
+		mux.Handle("/jobs/", jobsHandler)
+		mux.HandleFunc("/ws/progress", jobs.serveProgress)
+		mux.HandleFunc("/formats", server.serveFormats)
+		mux.HandleFunc("/files", jobs.serveFileList)
+		mux.Handle("/files/", http.StripPrefix("/files/", http.HandlerFunc(jobs.serveFile)))
+		if serveCmdOpts.ui {
+			mux.Handle("/", webUIHandler())
+		}
+
+		listener, err := net.Listen("tcp", serveCmdOpts.listen)
+		if err != nil {
+			return err
+		}
+		if serveCmdOpts.grpcListen != "" {
+			grpcServer, err := newGRPCServer(auth.handler(pb.NewHandler(&grpcService{videos: server, jobs: jobs})))
+			if err != nil {
+				return err
+			}
+			grpcListener, err := net.Listen("tcp", serveCmdOpts.grpcListen)
+			if err != nil {
+				return err
+			}
+			log.Println("serving gRPC on", serveCmdOpts.grpcListen)
+			go func() {
+				log.Println(grpcServer.Serve(grpcListener))
+			}()
+		}
+		if serveCmdOpts.healthPort > 0 {
+			health, err := net.Listen("tcp", ":"+strconv.Itoa(serveCmdOpts.healthPort))
+			if err != nil {
+				return err
+			}
+			go func() {
+				log.Println(http.Serve(health, http.HandlerFunc(serveHealth)))
+			}()
+		}
+		log.Println("listening on", serveCmdOpts.listen)
+		if err := sdNotify("READY=1"); err != nil {
+			log.Printf("Unable to notify systemd: %v", err)
+		}
+		return http.Serve(listener, auth.handler(mux))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveCmdOpts.listen, "listen", "localhost:8080", "Address to listen on")
+	serveCmd.Flags().StringVarP(&outputDir, "directory", "d", ".", "The output directory of queued downloads")
+	serveCmd.Flags().IntVar(&serveCmdOpts.workers, "workers", 2, "Number of queued downloads running in parallel, per namespace with --namespaces")
+	serveCmd.Flags().BoolVar(&serveCmdOpts.ui, "ui", true, "Serve the web UI at /")
+	serveCmd.Flags().StringArrayVar(&serveCmdOpts.apiKeys, "api-key", nil, "Require this API key, as [NAME:]KEY[=REQUESTS_PER_MINUTE] (repeatable)")
+	serveCmd.Flags().StringArrayVar(&serveCmdOpts.basicAuth, "basic-auth", nil, "Accept basic auth with these credentials, as USER:PASSWORD (repeatable)")
+	serveCmd.Flags().IntVar(&serveCmdOpts.rateLimit, "rate-limit", 0, "Requests per minute of every API key and user, 0 is unlimited")
+	serveCmd.Flags().BoolVar(&serveCmdOpts.namespaces, "namespaces", false, "Give every API key and user its own subdirectory, queue and quota")
+	serveCmd.Flags().IntVar(&serveCmdOpts.quotaMiB, "quota", 0, "Storage in MiB of the output directory, or of every namespace with --namespaces, 0 is unlimited")
+	serveCmd.Flags().IntVar(&serveCmdOpts.healthPort, "health-port", 0, "Serve /healthz on this port for health checks, without authentication")
+	serveCmd.Flags().StringVar(&serveCmdOpts.grpcListen, "grpc-listen", "", "Serve the gRPC API on this address, e.g. localhost:9090 (requires a build with Go 1.24 or later)")
+	serveCmd.Flags().StringVar(&serveCmdOpts.cacheDir, "cache-dir", "", "Cache the served byte ranges in this directory and only fetch missing ranges upstream")
+	serveCmd.Flags().IntVar(&serveCmdOpts.cacheMaxMiB, "cache-max", 0, "Size in MiB of the range cache, the least recently used streams are removed first, 0 is unlimited")
+}
+
+type cachedVideo struct {
+	video     *youtube.Video
+	fetchedAt time.Time
+}
+
+// videoServer proxies video streams, the metadata of recently requested videos is cached
+type videoServer struct {
+	mu     sync.Mutex
+	videos map[string]cachedVideo
+	// cache keeps the served ranges on disk if set
+	cache *rangeCache
+}
+
+func newVideoServer() *videoServer {
+	return &videoServer{videos: make(map[string]cachedVideo)}
+}
+
+// getVideo returns the video with deciphered stream URLs. The client is not safe for concurrent use,
+// so every request uses its own copy of the downloader and the lock only guards the cache.
+func (s *videoServer) getVideo(ctx context.Context, id string) (*youtube.Video, error) {
+	s.mu.Lock()
+	cached, ok := s.videos[id]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < videoCacheTTL {
+		return cached.video, nil
+	}
+
+	dl := *getDownloader()
+	video, err := dl.GetVideoContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := dl.DecipherFormats(ctx, video); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.videos[id] = cachedVideo{video: video, fetchedAt: time.Now()}
+	s.mu.Unlock()
+	return video, nil
+}
+
+func (s *videoServer) serveVideo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	video, err := s.getVideo(r.Context(), r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	// default to formats with audio and video, which all renderers can play
+	opts := youtube.FormatOptions{
+		Quality: r.URL.Query().Get("quality"),
+		Codecs:  r.URL.Query()["codec"],
+		Muxed:   r.URL.Query().Get("quality") == "",
+	}
+	format, err := video.GetFormat(opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	s.serveStream(w, r, video, format)
+}
+
+// serveFormats lists the formats of the video given by the url query parameter
+func (s *videoServer) serveFormats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	videoURL := r.URL.Query().Get("url")
+	if videoURL == "" {
+		http.Error(w, "url is missing", http.StatusBadRequest)
+		return
+	}
+	video, err := s.getVideo(r.Context(), videoURL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		ID       string               `json:"id"`
+		Title    string               `json:"title"`
+		Author   string               `json:"author"`
+		Duration float64              `json:"durationSeconds"`
+		Formats  []youtube.FormatInfo `json:"formats"`
+	}{
+		ID:       video.ID,
+		Title:    video.Title,
+		Author:   video.Author,
+		Duration: video.Duration.Seconds(),
+		Formats:  video.Formats.Infos(video.Duration),
+	})
+}
+
+// serveStream writes the stream of the format, a client range is mapped to the same upstream range
+func (s *videoServer) serveStream(w http.ResponseWriter, r *http.Request, video *youtube.Video, format *youtube.Format) {
+	header := w.Header()
+	if mediaType, _, err := mime.ParseMediaType(format.MimeType); err == nil {
+		header.Set("Content-Type", mediaType)
+	}
+	header.Set("contentFeatures.dlna.org", "DLNA.ORG_OP=01;DLNA.ORG_CI=0")
+	header.Set("transferMode.dlna.org", "Streaming")
+
+	size, _ := strconv.ParseInt(format.ContentLength, 10, 64)
+	if size <= 0 {
+		// without the size ranges cannot be mapped, the whole stream is served
+		proxyStream(w, r, http.StatusOK, func() (*http.Response, error) {
+			dl := *getDownloader()
+			resp, err := dl.GetStreamContext(r.Context(), video, format)
+			if isForbidden(err) {
+				if video, format, err = s.refreshFormat(r.Context(), video, format); err != nil {
+					return nil, err
+				}
+				resp, err = dl.GetStreamContext(r.Context(), video, format)
+			}
+			return resp, err
+		})
+		return
+	}
+	header.Set("Accept-Ranges", "bytes")
+
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader == "" {
+		header.Set("Content-Length", strconv.FormatInt(size, 10))
+		s.serveRange(w, r, http.StatusOK, video, format, size, 0, size-1)
+		return
+	}
+
+	start, end, err := parseByteRange(rangeHeader, size)
+	if err != nil {
+		header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	header.Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	s.serveRange(w, r, http.StatusPartialContent, video, format, size, start, end)
+}
+
+// proxyStream writes the status and copies the upstream body, HEAD requests are answered without contacting upstream
+func proxyStream(w http.ResponseWriter, r *http.Request, status int, open func() (*http.Response, error)) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+
+	resp, err := open()
+	if err != nil {
+		w.Header().Del("Content-Length")
+		w.Header().Del("Content-Range")
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.WriteHeader(status)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		// clients close the connection when seeking
+		log.Printf("proxying %s: %v", r.URL.Path, err)
+	}
+}
+
+// serveRange writes the status and the window [start, end] of the stream, from the range cache if enabled.
+// The status is sent with the first byte, so a failing upstream is still answered with 502 Bad Gateway.
+func (s *videoServer) serveRange(w http.ResponseWriter, r *http.Request, status int, video *youtube.Video, format *youtube.Format, size, start, end int64) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+
+	sw := &statusWriter{ResponseWriter: w, status: status}
+	var err error
+	if s.cache != nil {
+		err = s.cache.copyRange(sw, video.ID, format, size, start, end, func(w io.Writer, start, end int64) error {
+			return s.copyUpstream(r.Context(), w, video, format, start, end)
+		})
+	} else {
+		err = s.copyUpstream(r.Context(), sw, video, format, start, end)
+	}
+
+	switch {
+	case err == nil:
+	case !sw.wroteHeader:
+		w.Header().Del("Content-Length")
+		w.Header().Del("Content-Range")
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		// clients close the connection when seeking, otherwise they notice the short body
+		log.Printf("serving %s: %v", r.URL.Path, err)
+	}
+}
+
+// copyUpstream copies the window [start, end] of the stream to w. Interrupted transfers are resumed at the
+// current offset, and expired stream URLs are refreshed, so long playback sessions survive the expiry.
+func (s *videoServer) copyUpstream(ctx context.Context, w io.Writer, video *youtube.Video, format *youtube.Format, start, end int64) error {
+	dl := *getDownloader()
+	failures := 0
+	for pos := start; pos <= end; {
+		resp, err := dl.GetStreamRange(ctx, video, format, pos, end)
+		if err == nil {
+			cw := &countingWriter{w: w}
+			_, err = io.Copy(cw, resp.Body)
+			resp.Body.Close()
+			pos += cw.n
+			if cw.err != nil {
+				// the client is gone
+				return cw.err
+			}
+			if err == nil && pos <= end {
+				err = io.ErrUnexpectedEOF
+			}
+			if err == nil {
+				return nil
+			}
+			if cw.n > 0 {
+				failures = 0
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if failures++; failures > upstreamRetries {
+			return err
+		}
+
+		log.Printf("upstream of %s itag %d interrupted at %d: %v", video.ID, format.ItagNo, pos, err)
+		if isForbidden(err) {
+			if video, format, err = s.refreshFormat(ctx, video, format); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// refreshFormat fetches the video again and returns the format with the same itag and audio track.
+// The cached video is only dropped if no other request has refreshed it already.
+func (s *videoServer) refreshFormat(ctx context.Context, video *youtube.Video, format *youtube.Format) (*youtube.Video, *youtube.Format, error) {
+	s.mu.Lock()
+	if cached, ok := s.videos[video.ID]; ok && cached.video == video {
+		delete(s.videos, video.ID)
+	}
+	s.mu.Unlock()
+
+	fresh, err := s.getVideo(ctx, video.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range fresh.Formats {
+		candidate := &fresh.Formats[i]
+		if candidate.ItagNo != format.ItagNo {
+			continue
+		}
+		if format.AudioTrack != nil && (candidate.AudioTrack == nil || candidate.AudioTrack.ID != format.AudioTrack.ID) {
+			continue
+		}
+		return fresh, candidate, nil
+	}
+	return nil, nil, fmt.Errorf("%w: itag %d", youtube.ErrFormatNotFound, format.ItagNo)
+}
+
+// isForbidden reports whether upstream rejected the request, which happens when stream URLs expire
+func isForbidden(err error) bool {
+	var statusErr youtube.ErrUnexpectedStatusCode
+	return errors.As(err, &statusErr) && int(statusErr) == http.StatusForbidden
+}
+
+// statusWriter sends the status with the first write
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.wroteHeader = true
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// countingWriter counts the written bytes and keeps the write error apart from read errors
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
+
+// parseByteRange parses a single range like "bytes=0-499", "bytes=500-" or "bytes=-500".
+// The returned end is inclusive and capped at the size.
+func parseByteRange(header string, size int64) (start, end int64, err error) {
+	spec := strings.TrimPrefix(header, "bytes=")
+	if spec == header || strings.Contains(spec, ",") {
+		return 0, 0, fmt.Errorf("unsupported range %q", header)
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(spec), "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid range %q", header)
+	}
+
+	if parts[0] == "" {
+		// suffix range: the last n bytes
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("invalid range %q", header)
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, nil
+	}
+
+	start, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, fmt.Errorf("invalid range %q", header)
+	}
+
+	end = size - 1
+	if parts[1] != "" {
+		end, err = strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid range %q", header)
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return start, end, nil
+}
+
+// isLoopbackAddress checks whether the listen address only accepts local connections
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// serveHealth answers health checks, the server is healthy as long as it answers
+func serveHealth(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/healthz" {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		header     string
+		start, end int64
+		wantErr    bool
+	}{
+		{"bytes=0-499", 0, 499, false},
+		{"bytes=500-", 500, 999, false},
+		{"bytes=-200", 800, 999, false},
+		{"bytes=-2000", 0, 999, false},
+		{"bytes=900-2000", 900, 999, false},
+		{"bytes=999-999", 999, 999, false},
+		{"bytes=1000-", 0, 0, true},
+		{"bytes=500-400", 0, 0, true},
+		{"bytes=-0", 0, 0, true},
+		{"bytes=0-1,5-6", 0, 0, true},
+		{"items=0-1", 0, 0, true},
+		{"bytes=a-b", 0, 0, true},
+		{"bytes=", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, end, err := parseByteRange(tt.header, 1000)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var serviceCmdOpts struct {
+	name   string
+	user   bool
+	dryRun bool
+}
+
+// serviceCmd installs youtubedr as a service of the operating system
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Installs serve or another command as a systemd, launchd or Windows service",
+	Long: `Installs a command, by default serve, as a service which starts automatically and restarts on failure:
+a systemd unit on Linux, a launchd plist on macOS and a scheduled task started at logon of the current user on Windows.
+
+The arguments after -- are the command of the service. It runs with the working directory and config file
+of the install command, so relative paths like -d ./videos keep working. serve notifies systemd once it listens.`,
+	Example: `service install -- serve --listen :8080`,
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:          "install [-- command args]",
+	Short:        "Installs and starts the service",
+	Example:      `service install --user -- serve --listen :8080 -d ./videos`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := newServiceDefinition(args)
+		if err != nil {
+			return err
+		}
+		manager, err := getServiceManager()
+		if err != nil {
+			return err
+		}
+
+		if serviceCmdOpts.dryRun {
+			definition, err := manager.definition(svc)
+			if err != nil {
+				return err
+			}
+			fmt.Print(definition)
+			return nil
+		}
+		return manager.install(svc)
+	},
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:          "uninstall",
+	Short:        "Stops and removes the service",
+	Example:      `service uninstall --user --name youtubedr`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := getServiceManager()
+		if err != nil {
+			return err
+		}
+		return manager.uninstall(serviceCmdOpts.name)
+	},
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:          "status",
+	Short:        "Shows the status of the service",
+	Example:      `service status --user`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := getServiceManager()
+		if err != nil {
+			return err
+		}
+		return manager.status(serviceCmdOpts.name)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serviceCmd)
+	serviceCmd.AddCommand(serviceInstallCmd, serviceUninstallCmd, serviceStatusCmd)
+
+	serviceCmd.PersistentFlags().StringVar(&serviceCmdOpts.name, "name", "youtubedr", "Name of the service")
+	serviceCmd.PersistentFlags().BoolVar(&serviceCmdOpts.user, "user", false, "Install for the current user instead of system wide (systemd --user, LaunchAgents)")
+	serviceInstallCmd.Flags().BoolVar(&serviceCmdOpts.dryRun, "dry-run", false, "Print the unit, plist or task instead of installing it")
+}
+
+// serviceDefinition is the command run by a service
+type serviceDefinition struct {
+	Name string
+	// Command is the name of the youtubedr command, e.g. serve
+	Command    string
+	Executable string
+	Args       []string
+	WorkingDir string
+	// Notify is set for commands sending the systemd readiness notification
+	Notify bool
+}
+
+// newServiceDefinition runs the arguments, by default serve, with the executable, config file and working directory of this process
+func newServiceDefinition(args []string) (serviceDefinition, error) {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	command, _, err := rootCmd.Find(args)
+	if err != nil || command == rootCmd {
+		return serviceDefinition{}, fmt.Errorf("unknown command of the service: %s", strings.Join(args, " "))
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return serviceDefinition{}, err
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return serviceDefinition{}, err
+	}
+	if config := viper.ConfigFileUsed(); config != "" {
+		if config, err = filepath.Abs(config); err != nil {
+			return serviceDefinition{}, err
+		}
+		args = append([]string{"--config", config}, args...)
+	}
+
+	return serviceDefinition{
+		Name:       serviceCmdOpts.name,
+		Command:    command.Name(),
+		Executable: executable,
+		Args:       args,
+		WorkingDir: workingDir,
+		Notify:     command == serveCmd,
+	}, nil
+}
+
+// serviceManager installs services with the service manager of the operating system
+type serviceManager interface {
+	// definition returns the unit, plist or task of the service
+	definition(svc serviceDefinition) (string, error)
+	install(svc serviceDefinition) error
+	uninstall(name string) error
+	status(name string) error
+}
+
+func getServiceManager() (serviceManager, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return &systemdManager{user: serviceCmdOpts.user}, nil
+	case "darwin":
+		return &launchdManager{user: serviceCmdOpts.user}, nil
+	case "windows":
+		return &windowsTaskManager{}, nil
+	default:
+		return nil, fmt.Errorf("services are not supported on %s", runtime.GOOS)
+	}
+}
+
+// runServiceCommand runs a command of the service manager with the output attached to the terminal
+func runServiceCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// systemdManager installs systemd units, system units need root
+type systemdManager struct {
+	user bool
+}
+
+func (m *systemdManager) unitFile(name string) (string, error) {
+	if !m.user {
+		return filepath.Join("/etc/systemd/system", name+".service"), nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "systemd", "user", name+".service"), nil
+}
+
+func (m *systemdManager) systemctl(args ...string) error {
+	if m.user {
+		args = append([]string{"--user"}, args...)
+	}
+	return runServiceCommand("systemctl", args...)
+}
+
+func (m *systemdManager) definition(svc serviceDefinition) (string, error) {
+	serviceType, wantedBy := "simple", "multi-user.target"
+	if svc.Notify {
+		serviceType = "notify"
+	}
+	if m.user {
+		wantedBy = "default.target"
+	}
+
+	execStart := make([]string, 0, len(svc.Args)+1)
+	for _, arg := range append([]string{svc.Executable}, svc.Args...) {
+		execStart = append(execStart, quoteSystemdArg(arg))
+	}
+
+	return fmt.Sprintf(`[Unit]
+Description=youtubedr %s
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=%s
+ExecStart=%s
+WorkingDirectory=%s
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=%s
+`, svc.Command, serviceType, strings.Join(execStart, " "), strings.ReplaceAll(svc.WorkingDir, "%", "%%"), wantedBy), nil
+}
+
+// quoteSystemdArg quotes an argument of ExecStart, specifiers and variables are escaped
+func quoteSystemdArg(arg string) string {
+	arg = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(arg)
+	return `"` + arg + `"`
+}
+
+// writeServiceFile writes the definition readable by its owner only, it contains the arguments like --api-key
+func writeServiceFile(file, definition string) error {
+	if err := ioutil.WriteFile(file, []byte(definition), 0o600); err != nil {
+		return err
+	}
+	// WriteFile keeps the permissions of an existing file
+	return os.Chmod(file, 0o600)
+}
+
+func (m *systemdManager) install(svc serviceDefinition) error {
+	definition, err := m.definition(svc)
+	if err != nil {
+		return err
+	}
+	file, err := m.unitFile(svc.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	if err := writeServiceFile(file, definition); err != nil {
+		return err
+	}
+	fmt.Println("Installed", file)
+
+	if err := m.systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return m.systemctl("enable", "--now", svc.Name+".service")
+}
+
+func (m *systemdManager) uninstall(name string) error {
+	file, err := m.unitFile(name)
+	if err != nil {
+		return err
+	}
+	if err := m.systemctl("disable", "--now", name+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil {
+		return err
+	}
+	fmt.Println("Removed", file)
+	return m.systemctl("daemon-reload")
+}
+
+func (m *systemdManager) status(name string) error {
+	return m.systemctl("status", "--no-pager", name+".service")
+}
+
+// launchdManager installs launchd jobs, LaunchDaemons need root
+type launchdManager struct {
+	user bool
+}
+
+func launchdLabel(name string) string {
+	return "com.github.kkdai." + name
+}
+
+func (m *launchdManager) plistFile(name string) (string, error) {
+	if !m.user {
+		return filepath.Join("/Library/LaunchDaemons", launchdLabel(name)+".plist"), nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel(name)+".plist"), nil
+}
+
+func (m *launchdManager) definition(svc serviceDefinition) (string, error) {
+	escape := func(s string) string {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(s))
+		return buf.String()
+	}
+
+	var args strings.Builder
+	for _, arg := range append([]string{svc.Executable}, svc.Args...) {
+		args.WriteString("\t\t<string>" + escape(arg) + "</string>\n")
+	}
+
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + escape(launchdLabel(svc.Name)) + `</string>
+	<key>ProgramArguments</key>
+	<array>
+` + args.String() + `	</array>
+	<key>WorkingDirectory</key>
+	<string>` + escape(svc.WorkingDir) + `</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+</dict>
+</plist>
+`, nil
+}
+
+func (m *launchdManager) install(svc serviceDefinition) error {
+	definition, err := m.definition(svc)
+	if err != nil {
+		return err
+	}
+	file, err := m.plistFile(svc.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	if err := writeServiceFile(file, definition); err != nil {
+		return err
+	}
+	fmt.Println("Installed", file)
+	return runServiceCommand("launchctl", "load", "-w", file)
+}
+
+func (m *launchdManager) uninstall(name string) error {
+	file, err := m.plistFile(name)
+	if err != nil {
+		return err
+	}
+	if err := runServiceCommand("launchctl", "unload", "-w", file); err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil {
+		return err
+	}
+	fmt.Println("Removed", file)
+	return nil
+}
+
+func (m *launchdManager) status(name string) error {
+	return runServiceCommand("launchctl", "list", launchdLabel(name))
+}
+
+// windowsTaskManager runs the service as scheduled task of the current user started at logon.
+// Real Windows services have to answer the service control manager, which youtubedr does not.
+type windowsTaskManager struct{}
+
+func (m *windowsTaskManager) definition(svc serviceDefinition) (string, error) {
+	// schtasks has no working directory, the command changes into it
+	var command strings.Builder
+	command.WriteString(`cmd /c cd /d "` + svc.WorkingDir + `" && "` + svc.Executable + `"`)
+	for _, arg := range svc.Args {
+		if strings.ContainsAny(arg, `"`) {
+			return "", fmt.Errorf("argument %q of the service must not contain quotes", arg)
+		}
+		command.WriteString(` "` + arg + `"`)
+	}
+	return command.String() + "\n", nil
+}
+
+func (m *windowsTaskManager) install(svc serviceDefinition) error {
+	command, err := m.definition(svc)
+	if err != nil {
+		return err
+	}
+	err = runServiceCommand("schtasks", "/Create", "/F", "/TN", svc.Name, "/SC", "ONLOGON",
+		"/TR", strings.TrimSpace(command))
+	if err != nil {
+		return err
+	}
+	return runServiceCommand("schtasks", "/Run", "/TN", svc.Name)
+}
+
+func (m *windowsTaskManager) uninstall(name string) error {
+	// the task may not be running
+	runServiceCommand("schtasks", "/End", "/TN", name)
+	return runServiceCommand("schtasks", "/Delete", "/F", "/TN", name)
+}
+
+func (m *windowsTaskManager) status(name string) error {
+	return runServiceCommand("schtasks", "/Query", "/V", "/FO", "LIST", "/TN", name)
+}
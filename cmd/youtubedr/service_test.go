@@ -0,0 +1,25 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteServiceFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	file := filepath.Join(t.TempDir(), "youtubedr.service")
+	require.NoError(t, ioutil.WriteFile(file, []byte("old"), 0o644))
+
+	require.NoError(t, writeServiceFile(file, "ExecStart=youtubedr serve --api-key secret\n"))
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// Statuses of the items of a download session
+const (
+	itemPending = "pending"
+	itemActive  = "active"
+	itemDone    = "done"
+	itemFailed  = "failed"
+	itemSkipped = "skipped"
+)
+
+// sessionSaveInterval limits how often the session is saved to record the progress of a download
+const sessionSaveInterval = time.Second
+
+// sessionItem is a single video of the download queue
+type sessionItem struct {
+	URL      string `json:"url"`
+	Title    string `json:"title,omitempty"`
+	Playlist string `json:"playlist,omitempty"`
+	// Track is the position on an album of YouTube Music, 0 for other playlists
+	Track int `json:"track,omitempty"`
+	// Itag of the chosen format, 0 if not chosen yet or merged from several formats
+	Itag   int    `json:"itag,omitempty"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	// BytesCompleted counts the downloaded bytes of all streams of the video, it is saved while downloading
+	BytesCompleted int64 `json:"bytesCompleted,omitempty"`
+	// OutputFile is the path of the finished download
+	OutputFile string `json:"outputFile,omitempty"`
+	// Filtered is set for videos skipped by the filter, other skipped videos are unavailable
+	Filtered bool `json:"filtered,omitempty"`
+}
+
+// report returns the report record of the item, completing the record of a finished download if given
+func (item *sessionItem) report(record *reportRecord) *reportRecord {
+	if record == nil {
+		record = &reportRecord{}
+	}
+	record.Input = item.URL
+	record.Title = item.Title
+	record.Itag = item.Itag
+	if record.Bytes == 0 {
+		record.Bytes = item.BytesCompleted
+	}
+	record.Status = item.Status
+	if item.Status == itemFailed {
+		return record
+	}
+	// skipped playlist entries fail without being an error of the run
+	record.Reason, record.Error, record.ErrorCategory = item.Reason, "", ""
+	record.unavailable = item.Status == itemSkipped && !item.Filtered
+	return record
+}
+
+// session persists the download queue, so an interrupted batch can be resumed with --resume-session
+type session struct {
+	file  string
+	Items []*sessionItem `json:"items"`
+
+	// mu guards the saving and the progress of downloads, which is reported from the download goroutines
+	mu      sync.Mutex
+	savedAt time.Time
+
+	// Playlists maps the IDs of the queued playlists to their titles
+	Playlists map[string]string `json:"playlists,omitempty"`
+}
+
+// defaultSessionFile returns the session file used if --session-file is not given
+func defaultSessionFile() string {
+	return filepath.Join(outputDir, ".youtubedr-session.json")
+}
+
+func loadSession(file string) (*session, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &session{file: file}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+
+	// downloads interrupted while active start over, the bytes they completed are kept until then
+	for _, item := range s.Items {
+		if item.Status == itemActive {
+			item.Status = itemPending
+		}
+	}
+
+	return s, nil
+}
+
+func (s *session) add(item *sessionItem) {
+	if item.Status == "" {
+		item.Status = itemPending
+	}
+	s.Items = append(s.Items, item)
+}
+
+// addPlaylist records the title of a queued playlist
+func (s *session) addPlaylist(playlist *youtube.Playlist) {
+	if s.Playlists == nil {
+		s.Playlists = make(map[string]string)
+	}
+	s.Playlists[playlist.ID] = playlist.Title
+}
+
+// progress adds downloaded bytes to the item, the session is saved at most every sessionSaveInterval
+func (s *session) progress(item *sessionItem, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item.BytesCompleted += int64(n)
+	if time.Since(s.savedAt) >= sessionSaveInterval {
+		// a failure is noticed by the next regular save
+		s.saveLocked() //nolint:errcheck
+	}
+}
+
+// save writes the session atomically, a crash never leaves a truncated file behind
+func (s *session) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.saveLocked()
+}
+
+func (s *session) saveLocked() error {
+	s.savedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.file), 0o755); err != nil {
+		return err
+	}
+
+	tmpFile := s.file + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, s.file)
+}
+
+// remaining returns the items which still have to be downloaded, including failed ones
+func (s *session) remaining() []*sessionItem {
+	var items []*sessionItem
+	for _, item := range s.Items {
+		if item.Status == itemPending || item.Status == itemFailed {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// complete checks whether all items are done or skipped
+func (s *session) complete() bool {
+	return len(s.remaining()) == 0
+}
+
+// skipped returns the playlist entries which have been skipped
+func (s *session) skipped() []skippedEntry {
+	var skipped []skippedEntry
+	for _, item := range s.Items {
+		if item.Status == itemSkipped {
+			skipped = append(skipped, skippedEntry{
+				ID:       item.URL,
+				Title:    item.Title,
+				Playlist: item.Playlist,
+				Reason:   item.Reason,
+			})
+		}
+	}
+	return skipped
+}
+
+func (s *session) remove() error {
+	return os.Remove(s.file)
+}
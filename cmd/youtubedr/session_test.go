@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionItem_report(t *testing.T) {
+	tests := []struct {
+		name        string
+		item        sessionItem
+		status      string
+		reason      string
+		unavailable bool
+	}{
+		{"done", sessionItem{Status: itemDone}, itemDone, "", false},
+		{"unavailable", sessionItem{Status: itemSkipped, Reason: "private"}, itemSkipped, "private", true},
+		{"filtered", sessionItem{Status: itemSkipped, Reason: "shorter than 1m0s", Filtered: true},
+			itemSkipped, "shorter than 1m0s", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the record of a finished download without error is done until the item says otherwise
+			record := &reportRecord{Status: itemDone}
+			record = tt.item.report(record)
+			assert.Equal(t, tt.status, record.Status)
+			assert.Equal(t, tt.reason, record.Reason)
+			assert.Equal(t, tt.unavailable, record.unavailable)
+		})
+	}
+}
+
+func TestSession_progress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "session")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	item := &sessionItem{URL: "BaW_jenozKc", Status: itemActive}
+	sess := &session{file: filepath.Join(dir, "session.json")}
+	sess.add(item)
+	sess.progress(item, 100)
+	sess.progress(item, 50)
+	assert.EqualValues(t, 150, item.BytesCompleted)
+
+	// an interrupted download keeps the bytes it completed
+	loaded, err := loadSession(sess.file)
+	require.NoError(t, err)
+	require.Len(t, loaded.Items, 1)
+	assert.Equal(t, itemPending, loaded.Items[0].Status)
+	assert.EqualValues(t, 100, loaded.Items[0].BytesCompleted, "the session is saved at most every second")
+}
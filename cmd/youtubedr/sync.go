@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2/cmd/youtubedr/i18n"
+	ytdl "github.com/kkdai/youtube/v2/downloader"
+	"github.com/spf13/cobra"
+)
+
+var syncCmdOpts struct {
+	archiveFile    string
+	since          string
+	maxItems       int
+	concurrency    int
+	outputTemplate string
+	schedule       string
+	ledgerFile     string
+	retryFailed    []string
+}
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Downloads all new videos of a channel or playlist",
+	Long: `Downloads all videos of a channel or playlist which are not yet recorded in the archive file.
+Running it again only downloads new videos, which makes it suitable for cron jobs.
+Failed videos are recorded with the reason in a failure ledger. Later runs retry the categories given by
+--retry-failed, by default network, rate limit, ffmpeg and other failures, and skip the others, e.g. deleted or private videos.`,
+	Example:      `sync -d ./videos --since 2023-01-01 https://www.youtube.com/channel/UCdN4aXTrHAtfgbVG9HjBmxQ`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         syncVideos,
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().StringVarP(&outputDir, "directory", "d", ".", "The output directory.")
+	syncCmd.Flags().StringVar(&syncCmdOpts.archiveFile, "archive", "", "File recording the IDs of downloaded videos (default is .youtubedr-archive in the output directory)")
+	syncCmd.Flags().StringVar(&syncCmdOpts.since, "since", "", "Only download videos published on or after this date (YYYY-MM-DD), same as --date-after")
+	syncCmd.Flags().IntVar(&syncCmdOpts.maxItems, "max-items", 0, "Only consider the first n entries of the channel or playlist")
+	syncCmd.Flags().IntVar(&syncCmdOpts.concurrency, "concurrency", 1, "Number of videos downloaded in parallel")
+	syncCmd.Flags().StringVar(&syncCmdOpts.outputTemplate, "output-template", "", "Template for file names, e.g. \"{{.Author}}/{{.PublishDate}} {{.Title}}\"")
+	syncCmd.Flags().StringVar(&syncCmdOpts.schedule, "schedule", "", "Download rates by time of day, e.g. \"22:00-07:00=unlimited,else=1M\" (bytes per second and download)")
+	syncCmd.Flags().StringVar(&syncCmdOpts.ledgerFile, "failure-ledger", "", "File recording the failed videos (default is .youtubedr-failures.json in the output directory)")
+	syncCmd.Flags().StringSliceVar(&syncCmdOpts.retryFailed, "retry-failed", defaultRetryCategories, "Failure categories retried by later runs: "+strings.Join(ledgerCategories, ", "))
+	addQualityFlag(syncCmd.Flags())
+	addStreamFlags(syncCmd.Flags())
+	addCodecFlag(syncCmd.Flags())
+	addFilterFlags(syncCmd.Flags())
+	addCollisionFlags(syncCmd.Flags())
+	addTempDirFlag(syncCmd.Flags())
+	addIgnoreErrorsFlag(syncCmd.Flags())
+	addReportFlag(syncCmd.Flags())
+}
+
+func syncVideos(cmd *cobra.Command, args []string) error {
+	// --since is a shorthand for --date-after
+	if syncCmdOpts.since != "" {
+		filterOpts.dateAfter = syncCmdOpts.since
+	}
+	if err := filterOpts.prepare(); err != nil {
+		return err
+	}
+	policy, err := collisionPolicy()
+	if err != nil {
+		return err
+	}
+	if syncCmdOpts.concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	if strings.HasPrefix(outputQuality, "hd") {
+		if err := checkFFMPEG(); err != nil {
+			return err
+		}
+	}
+
+	dl := getDownloader()
+	dl.OutputDir = outputDir
+	dl.OutputTemplate = syncCmdOpts.outputTemplate
+	dl.OnCollision = policy
+	if syncCmdOpts.schedule != "" {
+		if dl.Bandwidth, err = ytdl.ParseBandwidthSchedule(syncCmdOpts.schedule); err != nil {
+			return err
+		}
+	}
+
+	archiveFile := syncCmdOpts.archiveFile
+	if archiveFile == "" {
+		archiveFile = filepath.Join(outputDir, ".youtubedr-archive")
+	}
+	archive, err := openArchive(archiveFile)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	ledgerFile := syncCmdOpts.ledgerFile
+	if ledgerFile == "" {
+		ledgerFile = filepath.Join(outputDir, ".youtubedr-failures.json")
+	}
+	ledger, err := openLedger(ledgerFile, syncCmdOpts.retryFailed)
+	if err != nil {
+		return err
+	}
+
+	playlist, err := dl.GetPlaylist(uploadsPlaylist(args[0]))
+	if err != nil {
+		return err
+	}
+	log.Printf("Syncing playlist '%s' by %s", playlist.Title, playlist.Author)
+
+	ids := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errors []error
+	var records []*reportRecord
+	for i := 0; i < syncCmdOpts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every worker uses its own copy, the client is not safe for concurrent use
+			dl := *getDownloader()
+			for id := range ids {
+				record := &reportRecord{Input: id}
+				dl.OnFileCompleted = record.fileCompleted
+				start := time.Now()
+				err := syncVideo(&dl, id, archive, record)
+				record.finish(start, err)
+				if ledgerErr := ledger.record(id, err); ledgerErr != nil {
+					log.Printf("Unable to update the failure ledger: %v", ledgerErr)
+				}
+
+				mu.Lock()
+				records = append(records, record)
+				if err != nil {
+					errors = append(errors, fmt.Errorf("%s: %w", id, err))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	it := playlist.Entries(context.Background())
+	for n := 0; syncCmdOpts.maxItems <= 0 || n < syncCmdOpts.maxItems; n++ {
+		mu.Lock()
+		failed := len(errors) > 0
+		mu.Unlock()
+		if failed && !ignoreErrors {
+			// videos already handed to the workers are finished
+			break
+		}
+
+		entry, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			mu.Lock()
+			errors = append(errors, err)
+			mu.Unlock()
+			break
+		}
+
+		if !entry.IsAvailable() || archive.Has(entry.ID) {
+			continue
+		}
+		if reason := ledger.skip(entry.ID); reason != "" {
+			log.Printf("Skipping %s: %s", entry.ID, reason)
+			continue
+		}
+		if reason := filterOpts.rejectEntry(entry); reason != "" {
+			log.Printf("Skipping %s: %s", entry.ID, reason)
+			continue
+		}
+		ids <- entry.ID
+	}
+	close(ids)
+	wg.Wait()
+
+	if err := writeReport(records); err != nil {
+		errors = append(errors, err)
+	}
+	if len(errors) > 0 {
+		return &batchError{msg: i18n.T("failure to sync videos"), errs: errors}
+	}
+	return nil
+}
+
+// syncVideo downloads a single video unless it is rejected by the filters, the record is completed with its details
+func syncVideo(dl *ytdl.Downloader, id string, archive *archive, record *reportRecord) error {
+	video, format, err := getVideoWithFormat(id)
+	if err != nil {
+		return err
+	}
+	record.Title = video.Title
+
+	if reason := filterOpts.rejectVideo(video); reason != "" {
+		log.Printf("Skipping %s: %s", id, reason)
+		record.Status, record.Reason = itemSkipped, reason
+		return nil
+	}
+	if ytdl.NeedsAudio(outputQuality, format) {
+		err = dl.DownloadWithHighQuality(context.Background(), "", video, outputQuality)
+	} else {
+		record.Itag = format.ItagNo
+		err = dl.Download(context.Background(), video, format, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	return archive.Add(id)
+}
+
+var channelIDRegex = regexp.MustCompile(`(?:^|/channel/)UC([A-Za-z0-9_-]{22})(?:[/?]|$)`)
+
+// uploadsPlaylist maps a channel to the playlist of its uploads, other arguments are returned as is
+func uploadsPlaylist(arg string) string {
+	if matches := channelIDRegex.FindStringSubmatch(arg); matches != nil {
+		return "UU" + matches[1]
+	}
+	return arg
+}
+
+// archive records the IDs of downloaded videos, one per line
+type archive struct {
+	mu   sync.Mutex
+	file *os.File
+	ids  map[string]bool
+}
+
+func openArchive(name string) (*archive, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &archive{file: file, ids: make(map[string]bool)}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			a.ids[id] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Has checks whether the video has already been downloaded
+func (a *archive) Has(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ids[id]
+}
+
+// Add records a downloaded video
+func (a *archive) Add(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.ids[id] {
+		return nil
+	}
+	if _, err := fmt.Fprintln(a.file, id); err != nil {
+		return err
+	}
+	a.ids[id] = true
+	return nil
+}
+
+func (a *archive) Close() error {
+	return a.file.Close()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/kkdai/youtube/v2/cmd/youtubedr/i18n"
+	"github.com/olekukonko/tablewriter"
+)
+
+// noColor turns off the colors of tables, see colorOutput
+var noColor bool
+
+// colorOutput checks whether tables are colored: only on terminals, and not with --no-color,
+// the NO_COLOR environment variable (https://no-color.org) or TERM=dumb
+func colorOutput(w io.Writer) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// table renders rows as text table with translated headers, colored if the output allows it
+type table struct {
+	*tablewriter.Table
+	color bool
+}
+
+func newTable(w io.Writer, header []string) *table {
+	t := &table{Table: tablewriter.NewWriter(w), color: colorOutput(w)}
+	t.SetAutoWrapText(false)
+	t.SetHeader(i18n.Strings(header))
+	if t.color {
+		colors := make([]tablewriter.Colors, len(header))
+		for i := range colors {
+			colors[i] = tablewriter.Colors{tablewriter.Bold}
+		}
+		t.SetHeaderColor(colors...)
+	}
+	return t
+}
+
+// AppendColored appends a row with a color per cell, empty colors leave cells uncolored
+func (t *table) AppendColored(row []string, colors []tablewriter.Colors) {
+	if t.color {
+		t.Rich(row, colors)
+	} else {
+		t.Append(row)
+	}
+}
+
+// rowColor colors all cells of a row
+func rowColor(n int, color tablewriter.Colors) []tablewriter.Colors {
+	colors := make([]tablewriter.Colors, n)
+	for i := range colors {
+		colors[i] = color
+	}
+	return colors
+}
+
+// codecColors are the colors of the codec families in the format table
+var codecColors = map[string]tablewriter.Colors{
+	"avc1": {tablewriter.FgYellowColor},
+	"vp9":  {tablewriter.FgBlueColor},
+	"vp09": {tablewriter.FgBlueColor},
+	"av01": {tablewriter.FgMagentaColor},
+	"mp4a": {tablewriter.FgCyanColor},
+	"opus": {tablewriter.FgCyanColor},
+}
+
+// mimeTypeColumn is the column of the mime type in youtube.FormatTableHeader
+const mimeTypeColumn = 5
+
+// appendFormats appends the rows of youtube.FormatList.MarshalTable, which may have additional columns.
+// The best video format, by resolution and then bitrate, is highlighted green,
+// the best audio only format cyan, and the mime types are colored by codec.
+func (t *table) appendFormats(formats youtube.FormatList, data [][]string) {
+	bestVideo, bestAudio := -1, -1
+	for i := range formats {
+		f := &formats[i]
+		if f.Width > 0 {
+			if bestVideo < 0 || f.Height > formats[bestVideo].Height ||
+				f.Height == formats[bestVideo].Height && f.Bitrate > formats[bestVideo].Bitrate {
+				bestVideo = i
+			}
+		} else if f.AudioChannels > 0 && (bestAudio < 0 || f.Bitrate > formats[bestAudio].Bitrate) {
+			bestAudio = i
+		}
+	}
+
+	// colored cells are no longer recognized as numbers, so their alignment is set explicitly
+	alignment := []int{
+		tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT,
+	}
+	if len(data) > 0 {
+		for len(alignment) < len(data[0]) {
+			alignment = append(alignment, tablewriter.ALIGN_LEFT)
+		}
+	}
+	t.SetColumnAlignment(alignment)
+
+	for i, row := range data {
+		var colors []tablewriter.Colors
+		switch i {
+		case bestVideo:
+			colors = rowColor(len(row), tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor})
+		case bestAudio:
+			colors = rowColor(len(row), tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor})
+		default:
+			colors = make([]tablewriter.Colors, len(row))
+			codec := formats[i].VideoCodec()
+			if codec == "" {
+				codec = formats[i].AudioCodec()
+			}
+			colors[mimeTypeColumn] = codecColors[youtube.CodecFamily(codec)]
+		}
+		t.AppendColored(row, colors)
+	}
+}
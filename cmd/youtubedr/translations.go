@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kkdai/youtube/v2/cmd/youtubedr/i18n"
+	"github.com/spf13/cobra"
+)
+
+var translationsCmdOpts struct {
+	template bool
+}
+
+// translationsCmd lists the languages of the output and prints the template of new catalogs
+var translationsCmd = &cobra.Command{
+	Use:   "translations",
+	Short: "Lists the languages of the output or prints the message catalog template",
+	Long: `Lists the languages of the output, which are selected with --lang or the LANG environment variable.
+
+To add a language, translate the values of the template and save it as <language>.json, e.g. pt-BR.json,
+in the directory given by --locale-dir. Translations have to keep the %s and %d verbs of the messages in order.`,
+	Example:      `translations --template > fr.json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if translationsCmdOpts.template {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false)
+			return encoder.Encode(i18n.Template())
+		}
+
+		for _, lang := range i18n.Languages() {
+			fmt.Println(lang)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(translationsCmd)
+	translationsCmd.Flags().BoolVar(&translationsCmdOpts.template, "template", false, "Print all messages as JSON catalog to translate")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/spf13/cobra"
+)
+
+var trendingCmdOpts struct {
+	region       string
+	category     string
+	outputFormat string
+}
+
+// trendingCmd represents the trending command
+var trendingCmd = &cobra.Command{
+	Use:          "trending",
+	Short:        "Print the trending videos of a region",
+	Example:      `trending --region DE --category music -o json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if trendingCmdOpts.outputFormat != "table" && trendingCmdOpts.outputFormat != "json" {
+			return fmt.Errorf("output format %s is not valid", trendingCmdOpts.outputFormat)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		videos, err := getDownloader().GetTrending(context.Background(), trendingCmdOpts.region, youtube.TrendingCategory(trendingCmdOpts.category))
+		if err != nil {
+			return err
+		}
+
+		if trendingCmdOpts.outputFormat == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(videos)
+		}
+
+		table := newTable(os.Stdout, []string{"rank", "id", "title", "author", "views", "duration"})
+		for _, video := range videos {
+			table.Append([]string{
+				strconv.Itoa(video.Rank),
+				video.ID,
+				video.Title,
+				video.Author,
+				strconv.FormatInt(video.ViewCount, 10),
+				video.Duration.String(),
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trendingCmd)
+
+	trendingCmd.Flags().StringVar(&trendingCmdOpts.region, "region", "", "Country code of the region, e.g. DE (default is derived from the IP address)")
+	trendingCmd.Flags().StringVar(&trendingCmdOpts.category, "category", "now", "now, music, gaming, movies")
+	trendingCmd.Flags().StringVarP(&trendingCmdOpts.outputFormat, "output", "o", "table", "table, json")
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const latestReleaseURL = "https://api.github.com/repos/kkdai/youtube/releases/latest"
+
+// updateClient fetches releases with the default TLS verification,
+// --insecure, --stream-host and --cookies of the downloader must not apply to them
+var updateClient = &http.Client{Timeout: 5 * time.Minute}
+
+var updateCmdOpts struct {
+	checkOnly bool
+}
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:          "update",
+	Short:        "Update youtubedr to the latest release",
+	Example:      `update --check`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		release, err := latestRelease(ctx)
+		if err != nil {
+			return err
+		}
+
+		if version == "" {
+			fmt.Printf("This is a development build, the latest release is %s\n", release.TagName)
+			return nil
+		}
+		if !isNewerVersion(release.TagName, version) {
+			fmt.Printf("youtubedr %s is up to date\n", version)
+			return nil
+		}
+
+		fmt.Printf("youtubedr %s is available, this is %s\n", release.TagName, version)
+		if updateCmdOpts.checkOnly {
+			fmt.Println(release.HTMLURL)
+			return nil
+		}
+
+		if err := selfUpdate(ctx, release); err != nil {
+			return fmt.Errorf("update failed, download it from %s: %w", release.HTMLURL, err)
+		}
+		fmt.Printf("Updated to %s\n", release.TagName)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().BoolVar(&updateCmdOpts.checkOnly, "check", false, "Only check for a newer release")
+
+	rootCmd.PersistentFlags().Bool("update-notice", false, "Tell about newer releases if extraction fails (also update-notice in the config file)")
+	viper.BindPFlag("update-notice", rootCmd.PersistentFlags().Lookup("update-notice")) //nolint:errcheck
+}
+
+// githubRelease is the part of a GitHub release needed for updating
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+	Assets  []struct {
+		Name        string `json:"name"`
+		DownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func latestRelease(ctx context.Context) (*githubRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := updateClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching the latest release: %s", resp.Status)
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// isNewerVersion compares versions like "v2.7.1" by their numeric components
+func isNewerVersion(latest, current string) bool {
+	parse := func(v string) []int {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v = v[:i]
+		}
+		var parts []int
+		for _, part := range strings.Split(v, ".") {
+			n, _ := strconv.Atoi(part)
+			parts = append(parts, n)
+		}
+		return parts
+	}
+
+	l, c := parse(latest), parse(current)
+	for i := 0; i < len(l) || i < len(c); i++ {
+		var a, b int
+		if i < len(l) {
+			a = l[i]
+		}
+		if i < len(c) {
+			b = c[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return false
+}
+
+// selfUpdate replaces the running binary with the one of the release archive for this platform.
+// The archive must match its SHA-256 in the checksums of the release.
+func selfUpdate(ctx context.Context, release *githubRelease) error {
+	// archives are named like youtube_2.7.1_linux_amd64.tar.gz by goreleaser
+	platform := "_" + runtime.GOOS + "_" + runtime.GOARCH
+	if runtime.GOARCH == "arm" {
+		platform += "v6"
+	}
+
+	var assetURL, assetName, checksumsURL string
+	for _, asset := range release.Assets {
+		name := strings.TrimSuffix(strings.TrimSuffix(asset.Name, ".tar.gz"), ".zip")
+		switch {
+		case assetURL == "" && strings.HasSuffix(name, platform):
+			assetURL, assetName = asset.DownloadURL, asset.Name
+		case strings.HasSuffix(asset.Name, "checksums.txt"):
+			// goreleaser publishes them as youtube_2.7.1_checksums.txt
+			checksumsURL = asset.DownloadURL
+		}
+	}
+	if assetURL == "" {
+		return fmt.Errorf("no release archive for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	if checksumsURL == "" {
+		return errors.New("no checksums in the release, the archive cannot be verified")
+	}
+
+	checksums, err := downloadAsset(ctx, checksumsURL)
+	if err != nil {
+		return err
+	}
+	archive, err := downloadAsset(ctx, assetURL)
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(checksums, assetName, archive); err != nil {
+		return err
+	}
+
+	binary, err := extractBinary(assetName, archive)
+	if err != nil {
+		return err
+	}
+
+	return replaceExecutable(binary)
+}
+
+// downloadAsset returns the content of a release asset
+func downloadAsset(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := updateClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// verifyChecksum compares the SHA-256 of the archive with its line in the checksums file of goreleaser:
+//
+//	<hex sha256>  youtube_2.7.1_linux_amd64.tar.gz
+func verifyChecksum(checksums []byte, name string, archive []byte) error {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[1] != name {
+			continue
+		}
+		expected, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid checksum of %s: %w", name, err)
+		}
+		actual := sha256.Sum256(archive)
+		if !bytes.Equal(actual[:], expected) {
+			return fmt.Errorf("checksum mismatch of %s", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum of %s in the release", name)
+}
+
+// extractBinary returns the youtubedr binary of a release archive
+func extractBinary(name string, archive []byte) ([]byte, error) {
+	binaryName := "youtubedr"
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+
+	if strings.HasSuffix(name, ".zip") {
+		r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range r.File {
+			if filepath.Base(file.Name) != binaryName {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return ioutil.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("%s not found in %s", binaryName, name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in %s", binaryName, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if filepath.Base(header.Name) == binaryName {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
+
+// replaceExecutable writes the binary next to the running executable and moves it into place
+func replaceExecutable(binary []byte) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+
+	tmp := exe + ".new"
+	if err := ioutil.WriteFile(tmp, binary, 0o755); err != nil {
+		return err
+	}
+
+	// a running executable cannot be overwritten on Windows, but it can be renamed
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Rename(old, exe) //nolint:errcheck
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		os.Remove(old)
+	}
+	return nil
+}
+
+// printUpdateNotice tells about a newer release after extraction failures, if enabled by update-notice
+func printUpdateNotice(err error) {
+	if !viper.GetBool("update-notice") || version == "" || errors.Is(err, context.Canceled) {
+		return
+	}
+	if code := exitCode(err); code == exitInvalidURL || code == exitFFmpeg {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	release, lookupErr := latestRelease(ctx)
+	if lookupErr != nil || !isNewerVersion(release.TagName, version) {
+		return
+	}
+	fmt.Printf("youtubedr %s is available and may fix this failure, run \"youtubedr update\" (this is %s)\n", release.TagName, version)
+}
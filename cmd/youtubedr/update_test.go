@@ -0,0 +1,35 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	archive := []byte("archive")
+	sum := sha256.Sum256(archive)
+	checksums := []byte("0000  youtube_2.7.1_darwin_amd64.tar.gz\n" +
+		hex.EncodeToString(sum[:]) + "  youtube_2.7.1_linux_amd64.tar.gz\n")
+
+	tests := []struct {
+		name    string
+		archive []byte
+		err     string
+	}{
+		{name: "youtube_2.7.1_linux_amd64.tar.gz", archive: archive},
+		{name: "youtube_2.7.1_linux_amd64.tar.gz", archive: []byte("tampered"), err: "checksum mismatch of youtube_2.7.1_linux_amd64.tar.gz"},
+		{name: "youtube_2.7.1_darwin_amd64.tar.gz", archive: archive, err: "checksum mismatch of youtube_2.7.1_darwin_amd64.tar.gz"},
+		{name: "youtube_2.7.1_windows_amd64.zip", archive: archive, err: "no checksum of youtube_2.7.1_windows_amd64.zip in the release"},
+	}
+	for _, tt := range tests {
+		err := verifyChecksum(checksums, tt.name, tt.archive)
+		if tt.err == "" {
+			assert.NoError(t, err, tt.name)
+		} else {
+			assert.EqualError(t, err, tt.err, tt.name)
+		}
+	}
+}
@@ -9,9 +9,10 @@ import (
 
 // urlCmd represents the url command
 var urlCmd = &cobra.Command{
-	Use:   "url",
-	Short: "Only output the stream-url to desired video",
-	Args:  cobra.MinimumNArgs(1),
+	Use:     "url",
+	Short:   "Only output the stream-url to desired video",
+	Example: `url -q 18 https://www.youtube.com/watch\?v\=XbNghLqsVwU`,
+	Args:    cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var errors []string
 		for _, videoURL := range args {
@@ -38,6 +39,7 @@ var urlCmd = &cobra.Command{
 
 func init() {
 	addQualityFlag(urlCmd.Flags())
+	addStreamFlags(urlCmd.Flags())
 	addCodecFlag(urlCmd.Flags())
 	rootCmd.AddCommand(urlCmd)
 }
@@ -16,8 +16,9 @@ var (
 
 // versionCmd represents the version command
 var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Prints version information",
+	Use:     "version",
+	Short:   "Prints version information",
+	Example: `version`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Version:    ", version)
 		fmt.Println("Commit:     ", commit)
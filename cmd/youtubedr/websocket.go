@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is appended to the key of the client to compute the accept header, see RFC 6455
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsWriteTimeout drops clients which do not read their messages, so they cannot hold up the updates
+var wsWriteTimeout = 10 * time.Second
+
+// WebSocket opcodes
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+)
+
+// wsConn is the server side of a WebSocket which sends messages to the client.
+// Messages of the client are discarded, pings are answered and a close frame ends the connection.
+type wsConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	mu sync.Mutex
+	// done is closed once the client closed the connection or it failed
+	done chan struct{}
+}
+
+// upgradeWebSocket switches the request to the WebSocket protocol, failures are answered with 400.
+// Handshakes of other origins are answered with 403, browsers do not apply the same-origin policy to WebSockets.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !sameOrigin(r) {
+		err := errors.New("cross-origin WebSocket handshake")
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil, err
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	switch {
+	case r.Method != http.MethodGet,
+		!headerContainsToken(r.Header, "Connection", "upgrade"),
+		!headerContainsToken(r.Header, "Upgrade", "websocket"),
+		r.Header.Get("Sec-WebSocket-Version") != "13",
+		key == "":
+		err := errors.New("not a WebSocket handshake")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, err
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		err := errors.New("connection does not support WebSocket")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, err
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n")
+	if err := buf.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ws := &wsConn{conn: conn, reader: buf.Reader, done: make(chan struct{})}
+	go ws.readLoop()
+	return ws, nil
+}
+
+// headerContainsToken checks whether a comma separated header contains the token, ignoring case
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteText sends a text message
+func (ws *wsConn) WriteText(data []byte) error {
+	return ws.writeFrame(wsText, data)
+}
+
+// Close sends a close frame and closes the connection
+func (ws *wsConn) Close() error {
+	ws.writeFrame(wsClose, nil)
+	return ws.conn.Close()
+}
+
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	// server frames are not masked
+	header := []byte{0x80 | opcode, 0}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header[1] = 127
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+
+	ws.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	_, err := ws.conn.Write(header)
+	if err == nil {
+		_, err = ws.conn.Write(payload)
+	}
+	if err != nil {
+		// the frame may be incomplete, the connection is unusable
+		ws.conn.Close()
+	}
+	return err
+}
+
+// readLoop handles the frames of the client until the connection is closed
+func (ws *wsConn) readLoop() {
+	defer close(ws.done)
+
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(ws.reader, header[:]); err != nil {
+			return
+		}
+		opcode := header[0] & 0x0F
+		masked := header[1]&0x80 != 0
+
+		length := uint64(header[1] & 0x7F)
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(ws.reader, ext[:]); err != nil {
+				return
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(ws.reader, ext[:]); err != nil {
+				return
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(ws.reader, mask[:]); err != nil {
+				return
+			}
+		}
+
+		// only control frames are of interest, their payload is at most 125 bytes
+		if opcode < wsClose {
+			if _, err := io.CopyN(ioutil.Discard, ws.reader, int64(length)); err != nil {
+				return
+			}
+			continue
+		}
+		if length > 125 {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(ws.reader, payload); err != nil {
+			return
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch opcode {
+		case wsClose:
+			ws.writeFrame(wsClose, payload)
+			return
+		case wsPing:
+			if err := ws.writeFrame(wsPong, payload); err != nil {
+				return
+			}
+		}
+	}
+}
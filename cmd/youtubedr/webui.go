@@ -0,0 +1,20 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webUIFiles is the single page UI of serve, it only uses the HTTP API and /ws/progress
+//
+//go:embed webui
+var webUIFiles embed.FS
+
+func webUIHandler() http.Handler {
+	files, err := fs.Sub(webUIFiles, "webui")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
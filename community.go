@@ -0,0 +1,174 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// channelCommunityParams selects the community tab of a channel in browse requests
+const channelCommunityParams = "Egljb21tdW5pdHk="
+
+// CommunityPost is a post of the community tab of a channel
+type CommunityPost struct {
+	ID     string `json:"id"`
+	Author string `json:"author"`
+	Text   string `json:"text"`
+	// PublishedTime is relative as displayed by YouTube, e.g. "2 days ago"
+	PublishedTime string `json:"publishedTime"`
+	// LikeCount is approximate, YouTube rounds it to three significant digits
+	LikeCount int64 `json:"likeCount"`
+	// Images holds the best thumbnail of each attached image
+	Images []Thumbnail    `json:"images,omitempty"`
+	Poll   *CommunityPoll `json:"poll,omitempty"`
+	// VideoID is the ID of an attached video
+	VideoID string `json:"videoId,omitempty"`
+}
+
+// CommunityPoll is a poll attached to a community post
+type CommunityPoll struct {
+	Choices    []string `json:"choices"`
+	TotalVotes int64    `json:"totalVotes"`
+}
+
+// CommunityPostIterator lazily pages through the community posts of a channel, newest first
+type CommunityPostIterator struct {
+	ctx          context.Context
+	client       *Client
+	channelID    string
+	posts        []*CommunityPost
+	continuation string
+	started      bool
+}
+
+// GetCommunityPosts returns an iterator over the community posts of a channel, given by its ID or URL.
+// No request is sent before the first call of Next.
+func (c *Client) GetCommunityPosts(ctx context.Context, channel string) (*CommunityPostIterator, error) {
+	id, err := extractChannelID(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommunityPostIterator{
+		ctx:       ctx,
+		client:    c,
+		channelID: id,
+	}, nil
+}
+
+// Next returns the next post, or io.EOF once all posts have been returned
+func (it *CommunityPostIterator) Next() (*CommunityPost, error) {
+	for len(it.posts) == 0 {
+		if it.started && it.continuation == "" {
+			return nil, io.EOF
+		}
+		if err := it.fetch(); err != nil {
+			return nil, err
+		}
+	}
+
+	post := it.posts[0]
+	it.posts = it.posts[1:]
+	return post, nil
+}
+
+// fetch requests the first page of the community tab or the continuation of the previous page
+func (it *CommunityPostIterator) fetch() error {
+	var response interface{}
+	var err error
+	if it.started {
+		response, err = it.client.browseContinuation(it.ctx, it.continuation)
+	} else {
+		response, err = it.client.browse(it.ctx, it.channelID, channelCommunityParams, "")
+	}
+	if err != nil {
+		return err
+	}
+	it.started = true
+
+	posts, err := parseCommunityPosts(response)
+	if err != nil {
+		return err
+	}
+	it.posts = append(it.posts, posts...)
+	it.continuation = findContinuation(response)
+
+	return nil
+}
+
+type backstageImageRenderer struct {
+	Image struct {
+		Thumbnails Thumbnails `json:"thumbnails"`
+	} `json:"image"`
+}
+
+func parseCommunityPosts(response interface{}) ([]*CommunityPost, error) {
+	var renderers []struct {
+		PostID            string        `json:"postId"`
+		AuthorText        innertubeText `json:"authorText"`
+		ContentText       innertubeText `json:"contentText"`
+		PublishedTimeText innertubeText `json:"publishedTimeText"`
+		VoteCount         innertubeText `json:"voteCount"`
+		Attachment        struct {
+			Image      *backstageImageRenderer `json:"backstageImageRenderer"`
+			MultiImage *struct {
+				Images []struct {
+					Image backstageImageRenderer `json:"backstageImageRenderer"`
+				} `json:"images"`
+			} `json:"postMultiImageRenderer"`
+			Poll *struct {
+				Choices []struct {
+					Text innertubeText `json:"text"`
+				} `json:"choices"`
+				TotalVotes innertubeText `json:"totalVotes"`
+			} `json:"pollRenderer"`
+			Video *struct {
+				VideoID string `json:"videoId"`
+			} `json:"videoRenderer"`
+		} `json:"backstageAttachment"`
+	}
+	if err := findRenderers(response, "backstagePostRenderer", &renderers); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParse, err)
+	}
+
+	posts := make([]*CommunityPost, 0, len(renderers))
+	for _, r := range renderers {
+		post := &CommunityPost{
+			ID:            r.PostID,
+			Author:        r.AuthorText.String(),
+			Text:          r.ContentText.String(),
+			PublishedTime: r.PublishedTimeText.String(),
+			LikeCount:     parseApproxCount(r.VoteCount.String()),
+		}
+
+		var images []backstageImageRenderer
+		if r.Attachment.Image != nil {
+			images = append(images, *r.Attachment.Image)
+		}
+		if r.Attachment.MultiImage != nil {
+			for _, image := range r.Attachment.MultiImage.Images {
+				images = append(images, image.Image)
+			}
+		}
+		for _, image := range images {
+			if best := absoluteThumbnails(image.Image.Thumbnails).Best(); best != nil {
+				post.Images = append(post.Images, *best)
+			}
+		}
+
+		if poll := r.Attachment.Poll; poll != nil {
+			post.Poll = &CommunityPoll{TotalVotes: parseApproxCount(poll.TotalVotes.String())}
+			for _, choice := range poll.Choices {
+				post.Poll.Choices = append(post.Poll.Choices, choice.Text.String())
+			}
+		}
+
+		if r.Attachment.Video != nil {
+			post.VideoID = r.Attachment.Video.VideoID
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
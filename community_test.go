@@ -0,0 +1,61 @@
+package youtube
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetCommunityPosts(t *testing.T) {
+	pages := map[string]string{
+		"": `{"contents":{"twoColumnBrowseResultsRenderer":{"tabs":[{"tabRenderer":{"content":{"sectionListRenderer":{"contents":[{"itemSectionRenderer":{"contents":[
+{"backstagePostThreadRenderer":{"post":{"backstagePostRenderer":{"postId":"p1","authorText":{"runs":[{"text":"Test Channel"}]},
+"contentText":{"runs":[{"text":"New video "},{"text":"out now"}]},"publishedTimeText":{"runs":[{"text":"2 days ago"}]},
+"voteCount":{"simpleText":"1.5K"},"backstageAttachment":{"backstageImageRenderer":{"image":{"thumbnails":[
+{"url":"//yt3.ggpht.com/small","width":288,"height":288},{"url":"//yt3.ggpht.com/large","width":1080,"height":1080}]}}}}}}},
+{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"page2"}}}}]}}]}}}}]}}}`,
+		"page2": `{"onResponseReceivedEndpoints":[{"appendContinuationItemsAction":{"continuationItems":[
+{"backstagePostThreadRenderer":{"post":{"backstagePostRenderer":{"postId":"p2","contentText":{"runs":[{"text":"Which one?"}]},
+"backstageAttachment":{"pollRenderer":{"choices":[{"text":{"runs":[{"text":"A"}]}},{"text":{"runs":[{"text":"B"}]}}],"totalVotes":{"simpleText":"1,024 votes"}}}}}}},
+{"backstagePostThreadRenderer":{"post":{"backstagePostRenderer":{"postId":"p3",
+"backstageAttachment":{"videoRenderer":{"videoId":"BaW_jenozKc"}}}}}}]}}]}`,
+	}
+
+	var requests []string
+	client := newInnertubeTestClient(func(endpoint string, payload map[string]interface{}) string {
+		token, _ := payload["continuation"].(string)
+		if token == "" {
+			assert.Equal(t, channelCommunityParams, payload["params"])
+		}
+		requests = append(requests, token)
+		return pages[token]
+	})
+
+	it, err := client.GetCommunityPosts(context.Background(), "UCdN4aXTrHAtfgbVG9HjBmxQ")
+	require.NoError(t, err)
+
+	var posts []*CommunityPost
+	for {
+		post, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		posts = append(posts, post)
+	}
+
+	require.Len(t, posts, 3)
+	assert.Equal(t, []string{"", "page2"}, requests)
+
+	assert.Equal(t, "New video out now", posts[0].Text)
+	assert.Equal(t, "Test Channel", posts[0].Author)
+	assert.Equal(t, "2 days ago", posts[0].PublishedTime)
+	assert.Equal(t, int64(1500), posts[0].LikeCount)
+	assert.Equal(t, []Thumbnail{{URL: "https://yt3.ggpht.com/large", Width: 1080, Height: 1080}}, posts[0].Images)
+
+	assert.Equal(t, &CommunityPoll{Choices: []string{"A", "B"}, TotalVotes: 1024}, posts[1].Poll)
+	assert.Equal(t, "BaW_jenozKc", posts[2].VideoID)
+}
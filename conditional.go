@@ -0,0 +1,123 @@
+package youtube
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultConditionalHosts serve thumbnails, avatars and feeds, which rarely change between polls
+var defaultConditionalHosts = []string{
+	"i.ytimg.com",
+	"i9.ytimg.com",
+	"yt3.ggpht.com",
+	"yt3.googleusercontent.com",
+	"www.youtube.com",
+}
+
+// ConditionalTransport keeps responses with an ETag or Last-Modified header on disk
+// and revalidates them with If-None-Match and If-Modified-Since when they are requested again.
+// A 304 Not Modified answer is replaced by the cached response, so callers don't have to handle it.
+type ConditionalTransport struct {
+	// Base sends the requests, defaults to http.DefaultTransport
+	Base http.RoundTripper
+	// Dir holds the cached responses
+	Dir string
+	// Hosts whose GET requests are cached, defaults to the thumbnail and avatar hosts and www.youtube.com
+	Hosts []string
+}
+
+func (t *ConditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || !t.cacheable(req.URL.Hostname()) {
+		return base.RoundTrip(req)
+	}
+
+	file := t.file(req.URL.String())
+	cached := readCachedResponse(file, req)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		return cached, nil
+	case resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		return storeResponse(file, resp)
+	default:
+		return resp, nil
+	}
+}
+
+func (t *ConditionalTransport) cacheable(host string) bool {
+	hosts := t.Hosts
+	if hosts == nil {
+		hosts = defaultConditionalHosts
+	}
+	for _, h := range hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// file returns the cache file of an URL
+func (t *ConditionalTransport) file(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(t.Dir, hex.EncodeToString(hash[:]))
+}
+
+// readCachedResponse returns the response stored in the file, or nil if there is none
+func readCachedResponse(file string, req *http.Request) *http.Response {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+// storeResponse writes the response into the file and returns a copy of it.
+// Failing to write the cache only loses the revalidation.
+func storeResponse(file string, resp *http.Response) (*http.Response, error) {
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err == nil {
+		tmpFile := file + ".tmp"
+		if ioutil.WriteFile(tmpFile, data, 0o644) == nil {
+			os.Rename(tmpFile, file)
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,61 @@
+package youtube
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalTransport(t *testing.T) {
+	var full, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("thumbnail")) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &ConditionalTransport{Dir: t.TempDir(), Hosts: []string{"127.0.0.1"}}}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL + "/vi/BaW_jenozKc/hqdefault.jpg")
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "thumbnail", string(body))
+	}
+
+	assert.Equal(t, 1, full)
+	assert.Equal(t, 2, notModified)
+}
+
+func TestConditionalTransport_OtherHosts(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &ConditionalTransport{Dir: t.TempDir()}}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, 2, requests)
+}
@@ -2,20 +2,59 @@ package youtube
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"log"
 	"net/url"
 	"regexp"
 	"strconv"
 )
 
-func (c *Client) decipherURL(ctx context.Context, videoID string, cipher string) (string, error) {
-	queryParams, err := url.ParseQuery(cipher)
-	if err != nil {
-		return "", err
+// DecipherFormats resolves the URLs of all formats of the video which only have a cipher.
+// The player is fetched at most once, which is cheaper than calling GetStreamURL for every format.
+// With a DecipherStrategy, the n parameter is only transformed by GetStreamURL.
+func (c *Client) DecipherFormats(ctx context.Context, video *Video) error {
+	if c.DecipherStrategy != nil {
+		for i := range video.Formats {
+			format := &video.Formats[i]
+			if format.URL != "" || format.Cipher == "" {
+				continue
+			}
+			decipheredURL, err := c.decipherURL(ctx, video.ID, format.Cipher)
+			if err != nil {
+				return fmt.Errorf("itag %d: %w", format.ItagNo, err)
+			}
+			format.URL = decipheredURL
+		}
+		return nil
+	}
+
+	var operations []DecipherOperation
+	var parsed bool
+	for i := range video.Formats {
+		format := &video.Formats[i]
+		if format.URL != "" || format.Cipher == "" {
+			continue
+		}
+
+		if !parsed {
+			var err error
+			operations, err = c.parseDecipherOpsWithCache(ctx, video.ID)
+			if err != nil {
+				return err
+			}
+			parsed = true
+		}
+
+		decipheredURL, err := applyDecipherOps(format.Cipher, operations)
+		if err != nil {
+			return fmt.Errorf("itag %d: %w", format.ItagNo, err)
+		}
+		format.URL = decipheredURL
 	}
 
+	return nil
+}
+
+func (c *Client) decipherURL(ctx context.Context, videoID string, cipher string) (string, error) {
 	/* eg:
 	    extract decipher from  https://youtube.com/s/player/4fbb4d5b/player_ias.vflset/en_US/base.js
 
@@ -37,18 +76,49 @@ func (c *Client) decipherURL(ctx context.Context, videoID string, cipher string)
 		return a.join("")
 	*/
 
+	if c.DecipherStrategy != nil {
+		player, err := c.getPlayerJS(ctx, videoID)
+		if err != nil {
+			return "", err
+		}
+		return cipherURL(cipher, func(signature string) (string, error) {
+			return c.DecipherStrategy.Signature(player.Body, player.Version, signature)
+		})
+	}
+
 	operations, err := c.parseDecipherOpsWithCache(ctx, videoID)
 	if err != nil {
 		return "", err
 	}
 
-	// apply operations
-	bs := []byte(queryParams.Get("s"))
-	for _, op := range operations {
-		bs = op(bs)
+	return applyDecipherOps(cipher, operations)
+}
+
+// applyDecipherOps builds the stream URL from a signature cipher
+func applyDecipherOps(cipher string, operations []DecipherOperation) (string, error) {
+	return cipherURL(cipher, func(signature string) (string, error) {
+		// apply operations
+		bs := []byte(signature)
+		for _, op := range operations {
+			bs = op(bs)
+		}
+		return string(bs), nil
+	})
+}
+
+// cipherURL builds the stream URL from a signature cipher with the deciphered signature
+func cipherURL(cipher string, decipher func(signature string) (string, error)) (string, error) {
+	queryParams, err := url.ParseQuery(cipher)
+	if err != nil {
+		return "", err
 	}
 
-	decipheredURL := fmt.Sprintf("%s&%s=%s", queryParams.Get("url"), queryParams.Get("sp"), string(bs))
+	signature, err := decipher(queryParams.Get("s"))
+	if err != nil {
+		return "", err
+	}
+
+	decipheredURL := fmt.Sprintf("%s&%s=%s", queryParams.Get("url"), queryParams.Get("sp"), signature)
 	return decipheredURL, nil
 }
 
@@ -83,29 +153,24 @@ var (
 	swapRegexp    = regexp.MustCompile(fmt.Sprintf("(?m)(?:^|,)(%s)%s", jsvarStr, swapStr))
 )
 
-func (c *Client) parseDecipherOps(ctx context.Context, videoID string) (operations []DecipherOperation, err error) {
-	embedURL := fmt.Sprintf("https://youtube.com/embed/%s?hl=en", videoID)
-	embedBody, err := c.httpGetBodyBytes(ctx, embedURL)
-	if err != nil {
-		return nil, err
-	}
-
-	// example: /s/player/f676c671/player_ias.vflset/en_US/base.js
-	escapedBasejsURL := string(basejsPattern.Find(embedBody))
-	if escapedBasejsURL == "" {
-		log.Println("playerConfig:", string(embedBody))
-		return nil, errors.New("unable to find basejs URL in playerConfig")
+// decipherOpsFromJS finds the operations of the signature function in the player.
+// The regular expressions are tried first, then the more tolerant scanner of decipherOpsByScanning.
+func decipherOpsFromJS(basejsBody []byte, version string) ([]DecipherOperation, error) {
+	if ops := decipherOpsByRegexp(basejsBody); len(ops) > 0 {
+		return ops, nil
 	}
-
-	basejsBody, err := c.httpGetBodyBytes(ctx, "https://youtube.com"+escapedBasejsURL)
-	if err != nil {
-		return nil, err
+	if ops := decipherOpsByScanning(basejsBody); len(ops) > 0 {
+		return ops, nil
 	}
+	return nil, &ErrDecipherOpsNotFound{PlayerVersion: version}
+}
 
+// decipherOpsByRegexp matches the signature function and the object of its operations, nil if not found
+func decipherOpsByRegexp(basejsBody []byte) []DecipherOperation {
 	objResult := actionsObjRegexp.FindSubmatch(basejsBody)
 	funcResult := actionsFuncRegexp.FindSubmatch(basejsBody)
 	if len(objResult) < 3 || len(funcResult) < 2 {
-		return nil, fmt.Errorf("error parsing signature tokens (#obj=%d, #func=%d)", len(objResult), len(funcResult))
+		return nil
 	}
 
 	obj := objResult[1]
@@ -124,9 +189,10 @@ func (c *Client) parseDecipherOps(ctx context.Context, videoID string) (operatio
 		swapKey = string(result[1])
 	}
 
-	regex, err := regexp.Compile(fmt.Sprintf("(?:a=)?%s\\.(%s|%s|%s)\\(a,(\\d+)\\)", obj, reverseKey, spliceKey, swapKey))
+	regex, err := regexp.Compile(fmt.Sprintf("(?:a=)?%s\\.(%s|%s|%s)\\(a,(\\d+)\\)", regexp.QuoteMeta(string(obj)),
+		regexp.QuoteMeta(reverseKey), regexp.QuoteMeta(spliceKey), regexp.QuoteMeta(swapKey)))
 	if err != nil {
-		return nil, err
+		return nil
 	}
 
 	var ops []DecipherOperation
@@ -142,23 +208,29 @@ func (c *Client) parseDecipherOps(ctx context.Context, videoID string) (operatio
 			ops = append(ops, newSpliceFunc(arg))
 		}
 	}
-	return ops, nil
+	return ops
 }
 
+// parseDecipherOpsWithCache returns the operations of the current player, they are cached per player version
 func (c *Client) parseDecipherOpsWithCache(ctx context.Context, videoID string) (operations []DecipherOperation, err error) {
 	if c.decipherOpsCache == nil {
 		c.decipherOpsCache = NewSimpleCache()
 	}
 
-	if ops := c.decipherOpsCache.Get(videoID); ops != nil {
+	player, err := c.getPlayerJS(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ops := c.decipherOpsCache.Get(player.Version); ops != nil {
 		return ops, nil
 	}
 
-	ops, err := c.parseDecipherOps(ctx, videoID)
+	ops, err := decipherOpsFromJS(player.Body, player.Version)
 	if err != nil {
 		return nil, err
 	}
 
-	c.decipherOpsCache.Set(videoID, ops)
+	c.decipherOpsCache.Set(player.Version, ops)
 	return ops, err
 }
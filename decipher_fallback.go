@@ -0,0 +1,238 @@
+package youtube
+
+import (
+	"strconv"
+	"strings"
+)
+
+// decipherOpsByScanning finds the signature operations without regular expressions.
+// It tolerates what the patterns of decipherOpsByRegexp don't expect after routine player refactors,
+// like other argument names, whitespace, quoted keys or bracket calls.
+// The signature function looks like:
+//
+//	function(a){a=a.split("");Mt.splice(a,3);Mt["EQ"](a,39);Mt.reverse(a,52);return a.join("")}
+func decipherOpsByScanning(basejsBody []byte) []DecipherOperation {
+	js := string(basejsBody)
+	const split = `.split("")`
+
+	for offset := 0; ; {
+		i := strings.Index(js[offset:], split)
+		if i < 0 {
+			return nil
+		}
+		i += offset
+		offset = i + len(split)
+
+		// the argument is split into itself: a=a.split("")
+		arg := identifierBefore(js, i)
+		if arg == "" || !isAssignment(js[:i-len(arg)], arg) {
+			continue
+		}
+
+		end := strings.Index(js[offset:], "return "+arg+`.join("")`)
+		if end < 0 {
+			continue
+		}
+
+		if ops := scanDecipherFunc(js, js[offset:offset+end], arg); len(ops) > 0 {
+			return ops
+		}
+	}
+}
+
+// decipherCall is a statement of the signature function like Mt.splice(a,3)
+type decipherCall struct {
+	obj, key string
+	arg      int
+}
+
+// scanDecipherFunc resolves the statements of the signature function to operations, nil if one isn't understood
+func scanDecipherFunc(js, statements, arg string) []DecipherOperation {
+	var calls []decipherCall
+	for _, statement := range strings.Split(statements, ";") {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		call, ok := parseDecipherCall(statement, arg)
+		if !ok || len(calls) > 0 && call.obj != calls[0].obj {
+			return nil
+		}
+		calls = append(calls, call)
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+
+	kinds := scanDecipherObject(js, calls[0].obj)
+	var ops []DecipherOperation
+	for _, call := range calls {
+		switch kinds[call.key] {
+		case "reverse":
+			ops = append(ops, reverseFunc)
+		case "splice":
+			ops = append(ops, newSpliceFunc(call.arg))
+		case "swap":
+			ops = append(ops, newSwapFunc(call.arg))
+		default:
+			return nil
+		}
+	}
+	return ops
+}
+
+// parseDecipherCall parses statements like Mt.EQ(a,39), a=Mt["EQ"](a,39) or Mt.reverse(a)
+func parseDecipherCall(statement, arg string) (call decipherCall, ok bool) {
+	if rest := strings.TrimPrefix(statement, arg); rest != statement {
+		if rest = strings.TrimSpace(rest); strings.HasPrefix(rest, "=") {
+			statement = strings.TrimSpace(rest[1:])
+		}
+	}
+
+	open := strings.IndexByte(statement, '(')
+	if open < 0 || !strings.HasSuffix(statement, ")") {
+		return call, false
+	}
+	callee := strings.TrimSpace(statement[:open])
+	params := strings.Split(statement[open+1:len(statement)-1], ",")
+	if strings.TrimSpace(params[0]) != arg || len(params) > 2 {
+		return call, false
+	}
+	if len(params) == 2 {
+		n, err := strconv.Atoi(strings.TrimSpace(params[1]))
+		if err != nil {
+			return call, false
+		}
+		call.arg = n
+	}
+
+	if bracket := strings.IndexByte(callee, '['); bracket > 0 && strings.HasSuffix(callee, "]") {
+		call.obj, call.key = callee[:bracket], unquote(callee[bracket+1:len(callee)-1])
+	} else if dot := strings.LastIndexByte(callee, '.'); dot > 0 {
+		call.obj, call.key = callee[:dot], callee[dot+1:]
+	} else {
+		return call, false
+	}
+	return call, isIdentifier(call.obj) && call.key != ""
+}
+
+// scanDecipherObject classifies the functions of the object definition obj={...} by their bodies,
+// the result maps the keys to "reverse", "splice" or "swap"
+func scanDecipherObject(js, obj string) map[string]string {
+	for offset := 0; ; {
+		i := strings.Index(js[offset:], obj+"={")
+		if i < 0 {
+			return nil
+		}
+		i += offset
+		offset = i + len(obj)
+
+		if i > 0 && isIdentifierByte(js[i-1]) {
+			continue
+		}
+		body, ok := braceBlock(js, i+len(obj)+1)
+		if !ok {
+			continue
+		}
+
+		kinds := make(map[string]string)
+		for _, entry := range splitTopLevel(body) {
+			colon := strings.IndexByte(entry, ':')
+			if colon < 0 {
+				continue
+			}
+			key, function := unquote(strings.TrimSpace(entry[:colon])), entry[colon+1:]
+			switch {
+			case strings.Contains(function, ".reverse("):
+				kinds[key] = "reverse"
+			case strings.Contains(function, ".splice(0,"):
+				kinds[key] = "splice"
+			case strings.Contains(function, "[0]="):
+				kinds[key] = "swap"
+			}
+		}
+		if len(kinds) > 0 {
+			return kinds
+		}
+	}
+}
+
+// braceBlock returns the content of the block opened at js[start], which must be a '{'
+func braceBlock(js string, start int) (string, bool) {
+	depth := 0
+	for i := start; i < len(js); i++ {
+		switch js[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return js[start+1 : i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitTopLevel splits the entries of an object literal at the commas outside of nested brackets
+func splitTopLevel(body string) []string {
+	var entries []string
+	depth, last := 0, 0
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '{', '(', '[':
+			depth++
+		case '}', ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				entries = append(entries, body[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(entries, body[last:])
+}
+
+// isAssignment checks whether js ends with an assignment to the variable, like "a=" or "a = "
+func isAssignment(js, variable string) bool {
+	js = strings.TrimRight(js, " ")
+	if !strings.HasSuffix(js, "=") {
+		return false
+	}
+	js = strings.TrimRight(js[:len(js)-1], " ")
+	return strings.HasSuffix(js, variable) && identifierBefore(js, len(js)) == variable
+}
+
+// identifierBefore returns the JS identifier ending at js[end-1]
+func identifierBefore(js string, end int) string {
+	start := end
+	for start > 0 && isIdentifierByte(js[start-1]) {
+		start--
+	}
+	return js[start:end]
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isIdentifierByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifierByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b == '$'
+}
+
+// unquote removes the quotes of a quoted object key
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
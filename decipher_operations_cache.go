@@ -1,6 +1,9 @@
 package youtube
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 var (
 	_ DecipherOperationsCache = NewSimpleCache()
@@ -8,29 +11,36 @@ var (
 
 const defaultCacheExpiration = time.Minute * time.Duration(5)
 
+// DecipherOperationsCache holds the signature operations of a player, the key is the version of the player
 type DecipherOperationsCache interface {
-	Get(videoID string) []DecipherOperation
-	Set(video string, operations []DecipherOperation)
+	Get(playerVersion string) []DecipherOperation
+	Set(playerVersion string, operations []DecipherOperation)
 }
 
+// SimpleCache holds the operations of the last player, it is safe for concurrent use
+// by the copies of a client like the ones of the download Manager
 type SimpleCache struct {
-	videoID    string
-	expiredAt  time.Time
-	operations []DecipherOperation
+	mu            sync.Mutex
+	playerVersion string
+	expiredAt     time.Time
+	operations    []DecipherOperation
 }
 
 func NewSimpleCache() *SimpleCache {
 	return &SimpleCache{}
 }
 
-// Get : get cache  when it has same video id and not expired
-func (s SimpleCache) Get(videoID string) []DecipherOperation {
-	return s.GetCacheBefore(videoID, time.Now())
+// Get : get cache when it has same player version and not expired
+func (s *SimpleCache) Get(playerVersion string) []DecipherOperation {
+	return s.GetCacheBefore(playerVersion, time.Now())
 }
 
 // GetCacheBefore : can pass time for testing
-func (s SimpleCache) GetCacheBefore(videoID string, time time.Time) []DecipherOperation {
-	if videoID == s.videoID && s.expiredAt.After(time) {
+func (s *SimpleCache) GetCacheBefore(playerVersion string, time time.Time) []DecipherOperation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if playerVersion == s.playerVersion && s.expiredAt.After(time) {
 		operations := make([]DecipherOperation, len(s.operations))
 		copy(operations, s.operations)
 		return operations
@@ -39,12 +49,15 @@ func (s SimpleCache) GetCacheBefore(videoID string, time time.Time) []DecipherOp
 }
 
 // Set : set cache with default expiration
-func (s *SimpleCache) Set(videoID string, operations []DecipherOperation) {
-	s.setWithExpiredTime(videoID, operations, time.Now().Add(defaultCacheExpiration))
+func (s *SimpleCache) Set(playerVersion string, operations []DecipherOperation) {
+	s.setWithExpiredTime(playerVersion, operations, time.Now().Add(defaultCacheExpiration))
 }
 
-func (s *SimpleCache) setWithExpiredTime(videoID string, operations []DecipherOperation, time time.Time) {
-	s.videoID = videoID
+func (s *SimpleCache) setWithExpiredTime(playerVersion string, operations []DecipherOperation, time time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.playerVersion = playerVersion
 	s.operations = make([]DecipherOperation, len(operations))
 	copy(s.operations, operations)
 	s.expiredAt = time
@@ -1,6 +1,8 @@
 package youtube
 
 import (
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -66,3 +68,21 @@ func TestSimpleCache(t *testing.T) {
 		})
 	}
 }
+
+func TestSimpleCache_concurrent(t *testing.T) {
+	// run with -race, copies of a client share the cache
+	s := NewSimpleCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			version := strconv.Itoa(i % 2)
+			for j := 0; j < 100; j++ {
+				s.Set(version, []DecipherOperation{func(bytes []byte) []byte { return bytes }})
+				s.Get(version)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
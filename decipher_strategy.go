@@ -0,0 +1,266 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DecipherStrategy runs the challenges of the player: the scrambling of the signatures of ciphers,
+// and the transform of the n parameter of stream URLs, without which the servers throttle downloads.
+// If Client.DecipherStrategy is not set, the signature operations are parsed from the player
+// and the n parameter is left as it is.
+type DecipherStrategy interface {
+	// Signature returns the signature expected by the stream servers for the scrambled one of a cipher
+	Signature(basejs []byte, playerVersion, signature string) (string, error)
+	// N returns the transformed n parameter of a stream URL
+	N(basejs []byte, playerVersion, n string) (string, error)
+}
+
+var _ DecipherStrategy = &JSDecipherStrategy{}
+
+// JSEvaluator runs a JavaScript program and returns the value of its last expression as string.
+// CommandEvaluator runs the programs with node or another JavaScript runtime. An embedded interpreter
+// like goja can be connected as well:
+//
+//	func(program string) (string, error) {
+//		value, err := goja.New().RunString(program)
+//		if err != nil {
+//			return "", err
+//		}
+//		return value.String(), nil
+//	}
+type JSEvaluator func(program string) (string, error)
+
+// jsEvalTimeout limits the runtime of a program run by CommandEvaluator
+const jsEvalTimeout = 10 * time.Second
+
+// CommandEvaluator runs the programs with a JavaScript runtime which reads the script from its standard input
+// and supports console.log, like node, or deno and bun with the arguments "run", "-".
+func CommandEvaluator(name string, args ...string) JSEvaluator {
+	return func(program string) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), jsEvalTimeout)
+		defer cancel()
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdin = strings.NewReader("console.log(String(" + program + "));\n")
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSuffix(stdout.String(), "\n"), nil
+	}
+}
+
+// JSDecipherStrategy runs the transform functions of the player in a JavaScript interpreter,
+// so changes of the obfuscation don't break the extraction as long as the functions can be located.
+// Eval defaults to running node, see CommandEvaluator.
+type JSDecipherStrategy struct {
+	Eval JSEvaluator
+
+	mu sync.Mutex
+	// functions holds the extracted sources by player version
+	functions map[string]*jsFunctions
+}
+
+// jsFunctions are the sources of the transform functions of a player, empty if not found
+type jsFunctions struct {
+	signature string
+	n         string
+}
+
+// Signature runs the signature function of the player
+func (s *JSDecipherStrategy) Signature(basejs []byte, playerVersion, signature string) (string, error) {
+	program := s.extract(basejs, playerVersion).signature
+	if program == "" {
+		return "", &ErrDecipherOpsNotFound{PlayerVersion: playerVersion}
+	}
+	return s.eval(program, signature)
+}
+
+// N runs the n function of the player
+func (s *JSDecipherStrategy) N(basejs []byte, playerVersion, n string) (string, error) {
+	program := s.extract(basejs, playerVersion).n
+	if program == "" {
+		return "", fmt.Errorf("%w: n function not found in player %s", ErrDecipher, playerVersion)
+	}
+	return s.eval(program, n)
+}
+
+func (s *JSDecipherStrategy) eval(function, arg string) (string, error) {
+	eval := s.Eval
+	if eval == nil {
+		eval = CommandEvaluator("node")
+	}
+	result, err := eval(function + "(" + strconv.Quote(arg) + ")")
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecipher, err)
+	}
+	return result, nil
+}
+
+// extract returns the cached functions of the player, they are extracted on first use
+func (s *JSDecipherStrategy) extract(basejs []byte, playerVersion string) *jsFunctions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if functions := s.functions[playerVersion]; functions != nil {
+		return functions
+	}
+	if s.functions == nil {
+		s.functions = make(map[string]*jsFunctions)
+	}
+
+	js := string(basejs)
+	functions := &jsFunctions{
+		signature: signatureFuncSource(js),
+		n:         nFuncSource(js),
+	}
+	s.functions[playerVersion] = functions
+	return functions
+}
+
+// signatureFuncSource returns the signature function together with the object of its operations as
+// expression which can be called, e.g. (function(){var Mt={...};return function(a){...}})(), empty if not found
+func signatureFuncSource(js string) string {
+	const split = `.split("")`
+	for offset := 0; ; {
+		i := strings.Index(js[offset:], split)
+		if i < 0 {
+			return ""
+		}
+		i += offset
+		offset = i + len(split)
+
+		arg := identifierBefore(js, i)
+		if arg == "" || !isAssignment(js[:i-len(arg)], arg) {
+			continue
+		}
+		start := strings.LastIndex(js[:i], "function("+arg+"){")
+		if start < 0 {
+			continue
+		}
+		body, ok := braceBlock(js, start+len("function("+arg+")"))
+		if !ok || !strings.Contains(body, "return "+arg+`.join("")`) {
+			continue
+		}
+
+		// the first call after the split names the object of the operations
+		statements := strings.Split(body, ";")
+		if len(statements) < 2 {
+			continue
+		}
+		call, ok := parseDecipherCall(strings.TrimSpace(statements[1]), arg)
+		if !ok {
+			continue
+		}
+		obj, ok := objectSource(js, call.obj)
+		if !ok {
+			continue
+		}
+		return fmt.Sprintf("(function(){var %s=%s;return function(%s){%s}})()", call.obj, obj, arg, body)
+	}
+}
+
+// objectSource returns the object literal assigned to the variable
+func objectSource(js, variable string) (string, bool) {
+	for offset := 0; ; {
+		i := strings.Index(js[offset:], variable+"={")
+		if i < 0 {
+			return "", false
+		}
+		i += offset
+		offset = i + len(variable)
+
+		if i > 0 && isIdentifierByte(js[i-1]) {
+			continue
+		}
+		if body, ok := braceBlock(js, i+len(variable)+1); ok {
+			return "{" + body + "}", true
+		}
+	}
+}
+
+var (
+	// nFuncCallPattern matches the call of the n function, e.g. .get("n"))&&(b=Xy[0](b) or .get("n"))&&(b=Xy(b)
+	nFuncCallPattern = regexp.MustCompile(`\.get\("n"\)\)&&\([a-zA-Z0-9_$]+=([a-zA-Z0-9_$]+)(?:\[(\d+)\])?\([a-zA-Z0-9_$]+\)`)
+)
+
+// nFuncSource returns the n function as expression which can be called, empty if not found
+func nFuncSource(js string) string {
+	m := nFuncCallPattern.FindStringSubmatch(js)
+	if m == nil {
+		return ""
+	}
+
+	name := m[1]
+	if m[2] != "" {
+		// the function is an element of an array: var Xy=[Ab]
+		index, _ := strconv.Atoi(m[2])
+		array := regexp.MustCompile(`var ` + regexp.QuoteMeta(name) + `\s*=\s*\[([^\]]*)\]`).FindStringSubmatch(js)
+		if array == nil {
+			return ""
+		}
+		elements := strings.Split(array[1], ",")
+		if index >= len(elements) {
+			return ""
+		}
+		name = strings.TrimSpace(elements[index])
+	}
+
+	for offset := 0; ; {
+		i := strings.Index(js[offset:], name+"=function(")
+		if i < 0 {
+			return ""
+		}
+		i += offset
+		offset = i + len(name)
+
+		if i > 0 && isIdentifierByte(js[i-1]) {
+			continue
+		}
+		start := i + len(name) + 1
+		open := strings.IndexByte(js[start:], '{')
+		if open < 0 {
+			return ""
+		}
+		if body, ok := braceBlock(js, start+open); ok {
+			return "(" + js[start:start+open] + "{" + body + "})"
+		}
+	}
+}
+
+// transformN replaces the n parameter of the stream URL by the result of the decipher strategy
+func (c *Client) transformN(ctx context.Context, videoID, streamURL string) (string, error) {
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	n := query.Get("n")
+	if n == "" {
+		return streamURL, nil
+	}
+
+	player, err := c.getPlayerJS(ctx, videoID)
+	if err != nil {
+		return "", err
+	}
+	transformed, err := c.DecipherStrategy.N(player.Body, player.Version, n)
+	if err != nil {
+		return "", err
+	}
+
+	query.Set("n", transformed)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
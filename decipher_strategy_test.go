@@ -0,0 +1,98 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNFuncBasejs = `var Xy=[Ab];
+Ab=function(a){var b=a.split(""),c=[function(d){d.reverse()}];c[0](b);return b.join("")};
+g.k=function(a){a.D&&(b=a.get("n"))&&(b=Xy[0](b),a.set("n",b))};`
+
+func TestSignatureFuncSource(t *testing.T) {
+	assert.Equal(t, `(function(){var Mt={splice:function(a,b){a.splice(0,b)},
+reverse:function(a){a.reverse()},
+EQ:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c}};`+
+		`return function(a){a=a.split("");Mt.splice(a,3);Mt.EQ(a,5);Mt.reverse(a,52);return a.join("")}})()`,
+		signatureFuncSource(testBasejs))
+	assert.Empty(t, signatureFuncSource(`var a=1;`))
+}
+
+func TestNFuncSource(t *testing.T) {
+	assert.Equal(t, `(function(a){var b=a.split(""),c=[function(d){d.reverse()}];c[0](b);return b.join("")})`,
+		nFuncSource(testNFuncBasejs))
+	assert.Empty(t, nFuncSource(testBasejs))
+}
+
+func TestJSDecipherStrategy(t *testing.T) {
+	playerCache.player = &playerJS{
+		Version:   "f676c671",
+		Body:      []byte(testBasejs + testNFuncBasejs),
+		expiredAt: time.Now().Add(time.Minute),
+	}
+	defer func() { playerCache.player = nil }()
+
+	var programs []string
+	client := Client{DecipherStrategy: &JSDecipherStrategy{Eval: func(program string) (string, error) {
+		programs = append(programs, program)
+		switch {
+		case strings.HasSuffix(program, `("abc")`):
+			return "cba", nil
+		case strings.HasSuffix(program, `("nnn")`):
+			return "NNN", nil
+		}
+		return "", errors.New("unexpected program")
+	}}}
+
+	cipher := url.Values{
+		"url": {"https://example.com/videoplayback?itag=18&n=nnn"},
+		"sp":  {"sig"},
+		"s":   {"abc"},
+	}.Encode()
+	streamURL, err := client.GetStreamURLContext(context.Background(), &Video{ID: "BaW_jenozKc"}, &Format{ItagNo: 18, Cipher: cipher})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/videoplayback?itag=18&n=NNN&sig=cba", streamURL)
+	require.Len(t, programs, 2)
+	assert.True(t, strings.HasPrefix(programs[0], "(function(){var Mt="))
+	assert.True(t, strings.HasPrefix(programs[1], "(function(a){var b="))
+
+	_, err = (&JSDecipherStrategy{Eval: CommandEvaluator("no-such-runtime")}).Signature([]byte(testBasejs), "f676c671", "abc")
+	assert.True(t, errors.Is(err, ErrDecipher))
+
+	_, err = (&JSDecipherStrategy{}).Signature([]byte(`var a=1;`), "f676c671", "abc")
+	var notFound *ErrDecipherOpsNotFound
+	assert.True(t, errors.As(err, &notFound))
+}
+
+func TestJSDecipherStrategy_node(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not installed")
+	}
+
+	// the interpreter gets the same result as the parsed operations
+	const signature = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	operations, err := decipherOpsFromJS([]byte(testBasejs), "f676c671")
+	require.NoError(t, err)
+	bs := []byte(signature)
+	for _, op := range operations {
+		bs = op(bs)
+	}
+
+	basejs := []byte(testBasejs + testNFuncBasejs)
+	strategy := &JSDecipherStrategy{}
+	deciphered, err := strategy.Signature(basejs, "f676c671", signature)
+	require.NoError(t, err)
+	assert.Equal(t, string(bs), deciphered)
+
+	n, err := strategy.N(basejs, "f676c671", "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "cba", n)
+}
@@ -0,0 +1,85 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_DecipherFormats(t *testing.T) {
+	playerCache.player = &playerJS{Version: "f676c671", expiredAt: time.Now().Add(time.Minute)}
+	defer func() { playerCache.player = nil }()
+
+	cache := NewSimpleCache()
+	cache.Set("f676c671", []DecipherOperation{reverseFunc})
+	client := Client{decipherOpsCache: cache}
+
+	cipher := url.Values{
+		"url": {"https://example.com/videoplayback?itag=18"},
+		"sp":  {"sig"},
+		"s":   {"abc"},
+	}.Encode()
+
+	video := &Video{
+		ID: "BaW_jenozKc",
+		Formats: FormatList{
+			{ItagNo: 18, Cipher: cipher},
+			{ItagNo: 22, URL: "https://example.com/videoplayback?itag=22"},
+			{ItagNo: 140},
+		},
+	}
+
+	require.NoError(t, client.DecipherFormats(context.Background(), video))
+	assert.Equal(t, "https://example.com/videoplayback?itag=18&sig=cba", video.Formats[0].URL)
+	assert.Equal(t, "https://example.com/videoplayback?itag=22", video.Formats[1].URL)
+	assert.Empty(t, video.Formats[2].URL)
+}
+
+// testBasejs has the signature function in the form matched by the regular expressions
+const testBasejs = `var Mt={splice:function(a,b){a.splice(0,b)},
+reverse:function(a){a.reverse()},
+EQ:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c}};
+Xy=function(a){a=a.split("");Mt.splice(a,3);Mt.EQ(a,5);Mt.reverse(a,52);return a.join("")};`
+
+// testRefactoredBasejs has the same signature function after a refactor the regular expressions don't match
+const testRefactoredBasejs = `var $q={"Sp":function(b,c){b.splice(0,c)},
+Rv:function(b){return b.reverse()}, EQ : function(b,c){var d=b[0];b[0]=b[c%b.length];b[c%b.length]=d}};
+Xy=function(b){b = b.split("");$q["Sp"](b,3); b=$q.EQ(b, 5);$q.Rv(b,52);return b.join("")};`
+
+func TestDecipherOpsFromJS(t *testing.T) {
+	signature := "0123456789abcdef"
+	// splice 3, swap 5, reverse
+	want := "fedcba9376548"
+
+	for name, js := range map[string]string{"regexp": testBasejs, "scanning": testRefactoredBasejs} {
+		t.Run(name, func(t *testing.T) {
+			ops, err := decipherOpsFromJS([]byte(js), "f676c671")
+			require.NoError(t, err)
+			require.Len(t, ops, 3)
+
+			bs := []byte(signature)
+			for _, op := range ops {
+				bs = op(bs)
+			}
+			assert.Equal(t, want, string(bs))
+		})
+	}
+
+	assert.Empty(t, decipherOpsByRegexp([]byte(testRefactoredBasejs)))
+
+	_, err := decipherOpsFromJS([]byte(`var a=1;`), "f676c671")
+	assert.True(t, errors.Is(err, ErrDecipher))
+	var notFound *ErrDecipherOpsNotFound
+	require.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "f676c671", notFound.PlayerVersion)
+	assert.EqualError(t, err, "unable to decipher: signature operations not found in player f676c671")
+}
+
+func TestPlayerVersion(t *testing.T) {
+	assert.Equal(t, "f676c671", playerVersion("/s/player/f676c671/player_ias.vflset/en_US/base.js"))
+}
@@ -0,0 +1,139 @@
+package youtube
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of DescriptionEntity
+const (
+	EntityURL       = "url"
+	EntityHashtag   = "hashtag"
+	EntityTimestamp = "timestamp"
+)
+
+// DescriptionEntity is a link, hashtag or timestamp in the description of a video
+type DescriptionEntity struct {
+	Type   string // EntityURL, EntityHashtag or EntityTimestamp
+	Text   string // as written in the description
+	Offset int    // byte offset of Text in the description
+
+	// URL is the target of links, with the redirects of YouTube resolved
+	URL string
+	// Hashtag is the tag without #
+	Hashtag string
+	// Time is the position a timestamp refers to
+	Time time.Duration
+	// Title is the rest of the line of a timestamp, which names the chapter starting there
+	Title string
+}
+
+var (
+	descriptionURLPattern       = regexp.MustCompile(`https?://[^\s<>"]+`)
+	descriptionHashtagPattern   = regexp.MustCompile(`(?:^|\s)(#[\p{L}\p{N}_]*[\p{L}_][\p{L}\p{N}_]*)`)
+	descriptionTimestampPattern = regexp.MustCompile(`(?:^|[^\d:])((?:(\d{1,2}):)?(\d{1,2}):(\d{2}))`)
+)
+
+// ParseDescription finds the links, hashtags and timestamps in a description, ordered by their offset
+func ParseDescription(description string) []DescriptionEntity {
+	var entities []DescriptionEntity
+
+	for _, m := range descriptionURLPattern.FindAllStringIndex(description, -1) {
+		text := strings.TrimRight(description[m[0]:m[1]], ".,;:!?)]'")
+		entities = append(entities, DescriptionEntity{
+			Type:   EntityURL,
+			Text:   text,
+			Offset: m[0],
+			URL:    resolveRedirect(text),
+		})
+	}
+	// hashtags and timestamps within links are part of them
+	inURL := func(offset int) bool {
+		for _, e := range entities {
+			if e.Type == EntityURL && offset >= e.Offset && offset < e.Offset+len(e.Text) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, m := range descriptionHashtagPattern.FindAllStringSubmatchIndex(description, -1) {
+		if inURL(m[2]) {
+			continue
+		}
+		text := description[m[2]:m[3]]
+		entities = append(entities, DescriptionEntity{
+			Type:    EntityHashtag,
+			Text:    text,
+			Offset:  m[2],
+			Hashtag: text[1:],
+		})
+	}
+
+	for _, m := range descriptionTimestampPattern.FindAllStringSubmatchIndex(description, -1) {
+		if inURL(m[2]) || m[3] < len(description) && (description[m[3]] == ':' || description[m[3]] >= '0' && description[m[3]] <= '9') {
+			continue
+		}
+		var hours, minutes, seconds int
+		if m[4] >= 0 {
+			hours, _ = strconv.Atoi(description[m[4]:m[5]])
+		}
+		minutes, _ = strconv.Atoi(description[m[6]:m[7]])
+		seconds, _ = strconv.Atoi(description[m[8]:m[9]])
+		if seconds >= 60 || hours > 0 && minutes >= 60 {
+			continue
+		}
+
+		entities = append(entities, DescriptionEntity{
+			Type:   EntityTimestamp,
+			Text:   description[m[2]:m[3]],
+			Offset: m[2],
+			Time:   time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second,
+			Title:  timestampTitle(description, m[2], m[3]),
+		})
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool { return entities[i].Offset < entities[j].Offset })
+	return entities
+}
+
+// resolveRedirect returns the target of links through the redirect page of YouTube, other links as they are
+func resolveRedirect(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Path != "/redirect" || !isYouTubeHost(u.Hostname()) {
+		return link
+	}
+	if target := u.Query().Get("q"); target != "" {
+		return target
+	}
+	return link
+}
+
+func isYouTubeHost(host string) bool {
+	return host == "youtube.com" || strings.HasSuffix(host, ".youtube.com")
+}
+
+// timestampTitle returns the line of the timestamp without it and the separators around it,
+// like "Intro" of "0:00 - Intro" or "(0:00) Intro"
+func timestampTitle(description string, start, end int) string {
+	lineStart := strings.LastIndexByte(description[:start], '\n') + 1
+	lineEnd := len(description)
+	if i := strings.IndexByte(description[end:], '\n'); i >= 0 {
+		lineEnd = end + i
+	}
+
+	const separators = " \t\r-–—:|()[]"
+	before := strings.Trim(description[lineStart:start], separators)
+	after := strings.Trim(description[end:lineEnd], separators)
+	switch {
+	case after == "":
+		return before
+	case before == "":
+		return after
+	}
+	return before + " " + after
+}
@@ -0,0 +1,32 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDescription(t *testing.T) {
+	const description = "Slides: https://www.youtube.com/redirect?event=video_description&q=https%3A%2F%2Fexample.com%2Fslides (PDF).\n" +
+		"Code at https://github.com/kkdai/youtube#readme, thanks! #golang #dotGo2015 #2015\n" +
+		"\n" +
+		"0:00 Intro\n" +
+		"(1:05) The problem\n" +
+		"Questions - 1:02:03\n" +
+		"Not a time: 9:75, 12:345 or https://youtu.be/x?t=1:23"
+
+	assert.Equal(t, []DescriptionEntity{
+		{Type: EntityURL, Text: "https://www.youtube.com/redirect?event=video_description&q=https%3A%2F%2Fexample.com%2Fslides",
+			Offset: 8, URL: "https://example.com/slides"},
+		{Type: EntityURL, Text: "https://github.com/kkdai/youtube#readme", Offset: 117, URL: "https://github.com/kkdai/youtube#readme"},
+		{Type: EntityHashtag, Text: "#golang", Offset: 166, Hashtag: "golang"},
+		{Type: EntityHashtag, Text: "#dotGo2015", Offset: 174, Hashtag: "dotGo2015"},
+		{Type: EntityTimestamp, Text: "0:00", Offset: 192, Time: 0, Title: "Intro"},
+		{Type: EntityTimestamp, Text: "1:05", Offset: 204, Time: time.Minute + 5*time.Second, Title: "The problem"},
+		{Type: EntityTimestamp, Text: "1:02:03", Offset: 234, Time: time.Hour + 2*time.Minute + 3*time.Second, Title: "Questions"},
+		{Type: EntityURL, Text: "https://youtu.be/x?t=1:23", Offset: 270, URL: "https://youtu.be/x?t=1:23"},
+	}, ParseDescription(description))
+
+	assert.Empty(t, ParseDescription("no entities here: 100% #"))
+}
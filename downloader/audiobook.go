@@ -0,0 +1,243 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// Containers of audiobooks
+const (
+	AudiobookM4B = "m4b" // AAC audio, chapters are shown by most audiobook players
+	AudiobookMKA = "mka" // Opus audio
+)
+
+// Chapter is a titled part of a media file
+type Chapter = youtube.Chapter
+
+// ConcatProcessor concatenates all files into a single one without re-encoding.
+// The files must have the same codecs, e.g. the same audio format of several videos.
+type ConcatProcessor struct {
+	FFmpeg
+	// Extension of the concatenated file, defaults to the extension of the first file
+	Extension string
+	// Chapters are written into the concatenated file
+	Chapters []Chapter
+	// Faststart moves the index of MP4 files to the front, so they can be played while downloading
+	Faststart bool
+}
+
+func (p *ConcatProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("nothing to concatenate")
+	}
+
+	ext := p.Extension
+	if ext == "" {
+		ext = filepath.Ext(files[0])
+	}
+
+	list, err := writeTempFile(files[0], ".txt", concatList(files))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(list)
+
+	metadata, err := writeTempFile(files[0], ".txt", ffmetadata(v, p.Chapters))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(metadata)
+
+	output, err := tempOutput(files[0], ext)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-y",
+		"-f", "concat", "-safe", "0", "-i", list,
+		"-i", metadata,
+		"-map", "0:a",
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+		"-c", "copy",
+	}
+	if p.Faststart && isMP4(ext) {
+		args = append(args, "-movflags", "+faststart")
+	}
+	if err := p.run(ctx, output, args...); err != nil {
+		return []string{output}, err
+	}
+
+	return []string{output}, nil
+}
+
+// concatList returns the input list of the ffmpeg concat demuxer
+func concatList(files []string) string {
+	var list strings.Builder
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			abs = file
+		}
+		list.WriteString("file '" + strings.ReplaceAll(abs, "'", `'\''`) + "'\n")
+	}
+	return list.String()
+}
+
+// ffmetadata returns the title, artist and chapters in the ffmpeg metadata format
+func ffmetadata(v *youtube.Video, chapters []Chapter) string {
+	var metadata strings.Builder
+	metadata.WriteString(";FFMETADATA1\n")
+	if v != nil {
+		metadata.WriteString("title=" + escapeFFMetadata(v.Title) + "\n")
+		metadata.WriteString("artist=" + escapeFFMetadata(v.Author) + "\n")
+	}
+	for _, chapter := range chapters {
+		metadata.WriteString("[CHAPTER]\nTIMEBASE=1/1000\n")
+		metadata.WriteString("START=" + strconv.FormatInt(chapter.Start.Milliseconds(), 10) + "\n")
+		metadata.WriteString("END=" + strconv.FormatInt(chapter.End.Milliseconds(), 10) + "\n")
+		metadata.WriteString("title=" + escapeFFMetadata(chapter.Title) + "\n")
+	}
+	return metadata.String()
+}
+
+var ffmetadataEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+func escapeFFMetadata(value string) string {
+	return ffmetadataEscaper.Replace(value)
+}
+
+// writeTempFile writes the content into a new file with the given extension next to the file
+func writeTempFile(file, ext, content string) (string, error) {
+	name, err := tempOutput(file, ext)
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(name, []byte(content), 0o644); err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
+
+// DownloadAudiobook downloads the audio of all videos of the playlist and concatenates it into a single file
+// with a chapter per video. The container is picked by the extension of outputFile, m4b or mka,
+// without outputFile the playlist title is used with the m4b extension.
+// Unavailable videos are skipped.
+func (dl *Downloader) DownloadAudiobook(ctx context.Context, playlist *youtube.Playlist, outputFile string) error {
+	if outputFile == "" {
+		outputFile = SanitizeFilename(playlist.Title) + "." + AudiobookM4B
+	}
+
+	codec := "mp4a"
+	switch container := strings.TrimPrefix(strings.ToLower(filepath.Ext(outputFile)), "."); container {
+	case AudiobookM4B:
+	case AudiobookMKA:
+		codec = "opus"
+	default:
+		return fmt.Errorf("unsupported audiobook container %q, use %s or %s", container, AudiobookM4B, AudiobookMKA)
+	}
+
+	if dl.OutputDir != "" {
+		if err := os.MkdirAll(dl.OutputDir, 0o755); err != nil {
+			return err
+		}
+		outputFile = filepath.Join(dl.OutputDir, outputFile)
+	}
+	destFile, err := dl.resolveCollision(outputFile)
+	if err != nil || destFile == "" {
+		return err
+	}
+	tempDir := dl.tempDir(destFile)
+
+	var files []string
+	defer func() {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}()
+
+	var chapters []Chapter
+	var offset time.Duration
+	it := playlist.Entries(ctx)
+	for {
+		entry, err := it.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !entry.IsAvailable() {
+			dl.logf("Skipping unavailable video %s", entry.ID)
+			continue
+		}
+
+		v, err := dl.GetVideoContext(ctx, entry.ID)
+		var playability *youtube.ErrPlayabiltyStatus
+		if errors.As(err, &playability) {
+			dl.logf("Skipping %s: %v", entry.ID, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		format := selectAudiobookFormat(v.Formats, codec, dl.AudioLanguage)
+		if format == nil {
+			return fmt.Errorf("%w: no %s audio for %s", youtube.ErrFormatNotFound, codec, v.ID)
+		}
+
+		dl.logf("Downloading audio of '%s' (%d)", v.Title, len(chapters)+1)
+		file, err := dl.downloadToTempFile(youtube.WithDownloadKey(ctx, v.ID), tempDir, v, format)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+
+		duration := v.Duration
+		if ms, err := strconv.ParseInt(format.ApproxDurationMs, 10, 64); err == nil && ms > 0 {
+			duration = time.Duration(ms) * time.Millisecond
+		}
+		chapters = append(chapters, Chapter{Title: v.Title, Start: offset, End: offset + duration})
+		offset += duration
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no downloadable videos in playlist %s", playlist.ID)
+	}
+
+	dl.logf("Concatenating %d videos into %s", len(files), destFile)
+	return dl.postProcess(ctx, &youtube.Video{Title: playlist.Title, Author: playlist.Author}, files, destFile,
+		&ConcatProcessor{FFmpeg: dl.ffmpeg(), Extension: filepath.Ext(destFile), Chapters: chapters, Faststart: !dl.NoFaststart},
+	)
+}
+
+// selectAudiobookFormat picks the audio only format with the codec and the highest bitrate,
+// preferring the track of the language
+func selectAudiobookFormat(formats youtube.FormatList, codec, language string) *youtube.Format {
+	var best *youtube.Format
+	var bestLanguage bool
+	for i := range formats {
+		format := &formats[i]
+		if format.VideoCodec() != "" || !format.HasCodec(codec) {
+			continue
+		}
+
+		matches := matchesLanguage(format, language)
+		if best == nil || (matches && !bestLanguage) || (matches == bestLanguage && format.Bitrate > best.Bitrate) {
+			best, bestLanguage = format, matches
+		}
+	}
+	return best
+}
@@ -0,0 +1,49 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFFMetadata(t *testing.T) {
+	v := &youtube.Video{Title: "Lectures; part=1", Author: "Prof"}
+	chapters := []Chapter{
+		{Title: "Intro", Start: 0, End: 90 * time.Second},
+		{Title: "#2", Start: 90 * time.Second, End: 200500 * time.Millisecond},
+	}
+
+	assert.Equal(t, `;FFMETADATA1
+title=Lectures\; part\=1
+artist=Prof
+[CHAPTER]
+TIMEBASE=1/1000
+START=0
+END=90000
+title=Intro
+[CHAPTER]
+TIMEBASE=1/1000
+START=90000
+END=200500
+title=\#2
+`, ffmetadata(v, chapters))
+}
+
+func TestConcatList(t *testing.T) {
+	assert.Equal(t, "file '/tmp/a.m4a'\nfile '/tmp/it'\\''s.m4a'\n", concatList([]string{"/tmp/a.m4a", "/tmp/it's.m4a"}))
+}
+
+func TestSelectAudiobookFormat(t *testing.T) {
+	formats := youtube.FormatList{
+		{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, Bitrate: 130000},
+		{ItagNo: 139, MimeType: `audio/mp4; codecs="mp4a.40.5"`, Bitrate: 50000},
+		{ItagNo: 251, MimeType: `audio/webm; codecs="opus"`, Bitrate: 160000},
+		{ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, Bitrate: 500000},
+	}
+
+	assert.Equal(t, 140, selectAudiobookFormat(formats, "mp4a", "").ItagNo)
+	assert.Equal(t, 251, selectAudiobookFormat(formats, "opus", "").ItagNo)
+	assert.Nil(t, selectAudiobookFormat(formats, "flac", ""))
+}
@@ -0,0 +1,257 @@
+package downloader
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// Sources of generated chapters, see AutoChapterProcessor
+const (
+	ChaptersFromHeatmap     = "heatmap"     // peaks of the "most replayed" graph
+	ChaptersFromSilence     = "silence"     // pauses detected by the ffmpeg silencedetect filter
+	ChaptersFromDescription = "description" // timestamps in the description, see youtube.DescriptionChapters
+)
+
+// AutoChapterProcessor generates chapters from the heatmap peaks of the video, from silences in the audio
+// or from the timestamps of the description and writes them into every file without re-encoding.
+// Files are passed on unchanged if no chapters are found.
+type AutoChapterProcessor struct {
+	FFmpeg
+	// Source of the chapters, ChaptersFromHeatmap, ChaptersFromSilence or ChaptersFromDescription
+	Source string
+	// MinLength is the shortest generated chapter, defaults to 30 seconds
+	MinLength time.Duration
+	// MaxChapters limits the chapters generated from the heatmap, defaults to 10
+	MaxChapters int
+	// SilenceNoise is the volume in dB below which audio counts as silence, defaults to -35
+	SilenceNoise float64
+	// SilenceDuration is the shortest pause between chapters, defaults to 1 second
+	SilenceDuration time.Duration
+}
+
+func (p *AutoChapterProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	if p.Source != ChaptersFromHeatmap && p.Source != ChaptersFromSilence && p.Source != ChaptersFromDescription {
+		return files, fmt.Errorf("unknown chapter source %q", p.Source)
+	}
+
+	outputs := make([]string, 0, len(files))
+	for _, input := range files {
+		chapters, err := p.chapters(ctx, v, input)
+		if err != nil {
+			return outputs, err
+		}
+		if len(chapters) == 0 {
+			outputs = append(outputs, input)
+			continue
+		}
+
+		output, err := p.writeChapters(ctx, input, chapters)
+		if output != "" {
+			outputs = append(outputs, output)
+		}
+		if err != nil {
+			return outputs, err
+		}
+	}
+	return outputs, nil
+}
+
+// writeChapters copies the file with the chapters into a new file next to it
+func (p *AutoChapterProcessor) writeChapters(ctx context.Context, input string, chapters []Chapter) (string, error) {
+	metadata, err := writeTempFile(input, ".txt", ffmetadata(nil, chapters))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(metadata)
+
+	output, err := tempOutput(input, filepath.Ext(input))
+	if err != nil {
+		return "", err
+	}
+	return output, p.run(ctx, output, "-y",
+		"-i", input,
+		"-i", metadata,
+		"-map", "0",
+		"-map_metadata", "0",
+		"-map_chapters", "1",
+		"-c", "copy",
+	)
+}
+
+// chapters returns the generated chapters of the file, nil if the source has none
+func (p *AutoChapterProcessor) chapters(ctx context.Context, v *youtube.Video, input string) ([]Chapter, error) {
+	if p.Source == ChaptersFromDescription {
+		return youtube.DescriptionChapters(v.DescriptionEntities, v.Duration), nil
+	}
+
+	minLength := p.MinLength
+	if minLength <= 0 {
+		minLength = 30 * time.Second
+	}
+
+	if p.Source == ChaptersFromHeatmap {
+		maxChapters := p.MaxChapters
+		if maxChapters <= 0 {
+			maxChapters = 10
+		}
+		return heatmapChapters(v.Heatmap, v.Duration, minLength, maxChapters), nil
+	}
+
+	if v.Duration <= 0 {
+		return nil, nil
+	}
+	silences, err := p.detectSilences(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return silenceChapters(silences, v.Duration, minLength), nil
+}
+
+// detectSilences returns the ends of the silences in the audio of the file
+func (p *AutoChapterProcessor) detectSilences(ctx context.Context, input string) ([]time.Duration, error) {
+	noise := p.SilenceNoise
+	if noise == 0 {
+		noise = -35
+	}
+	duration := p.SilenceDuration
+	if duration <= 0 {
+		duration = time.Second
+	}
+
+	// the detected silences are logged at info level, ametadata writes them to a file instead
+	detected, err := tempOutput(input, ".txt")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(detected)
+	output, err := tempOutput(input, ".nut")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(output)
+
+	filter := "silencedetect=noise=" + formatFilterValue(noise) + "dB:d=" + formatFilterValue(duration.Seconds()) +
+		",ametadata=mode=print:key=lavfi.silence_end:file=" + escapeFilterPath(detected)
+	if err := p.run(ctx, output, "-y", "-i", input, "-vn", "-af", filter, "-f", "null"); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(detected)
+	if err != nil {
+		return nil, err
+	}
+	return parseSilenceEnds(string(data)), nil
+}
+
+// escapeFilterPath escapes a file name for an option of a filter in a filter graph,
+// once for the option value and once more for the graph
+func escapeFilterPath(path string) string {
+	option := strings.NewReplacer(`\`, `\\`, "'", `\'`, ":", `\:`).Replace(path)
+	return strings.NewReplacer(`\`, `\\`, "'", `\'`, "[", `\[`, "]", `\]`, ",", `\,`, ";", `\;`).Replace(option)
+}
+
+// parseSilenceEnds reads the lavfi.silence_end values printed by the ametadata filter
+func parseSilenceEnds(data string) []time.Duration {
+	var ends []time.Duration
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		value := strings.TrimPrefix(scanner.Text(), "lavfi.silence_end=")
+		if value == scanner.Text() {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		ends = append(ends, time.Duration(seconds*float64(time.Second)))
+	}
+	return ends
+}
+
+// silenceChapters starts a chapter at the end of every silence, skipping those which would make a chapter shorter than minLength
+func silenceChapters(silenceEnds []time.Duration, duration, minLength time.Duration) []Chapter {
+	starts := []time.Duration{0}
+	for _, end := range silenceEnds {
+		if end-starts[len(starts)-1] >= minLength && duration-end >= minLength {
+			starts = append(starts, end)
+		}
+	}
+	if len(starts) == 1 {
+		return nil
+	}
+	return chaptersFromStarts(starts, duration, func(i int) string {
+		return "Chapter " + strconv.Itoa(i+1)
+	})
+}
+
+// heatmapChapters starts a chapter at the most intense peaks of the heatmap, at least minLength apart
+func heatmapChapters(heatmap []youtube.HeatmapPoint, duration, minLength time.Duration, maxChapters int) []Chapter {
+	if len(heatmap) == 0 {
+		return nil
+	}
+	if duration <= 0 {
+		last := heatmap[len(heatmap)-1]
+		duration = last.Start + last.Duration
+	}
+
+	// local maxima of the graph, strongest first
+	var peaks []youtube.HeatmapPoint
+	for i, point := range heatmap {
+		if (i == 0 || point.Intensity > heatmap[i-1].Intensity) && (i == len(heatmap)-1 || point.Intensity >= heatmap[i+1].Intensity) {
+			peaks = append(peaks, point)
+		}
+	}
+	sort.SliceStable(peaks, func(i, j int) bool { return peaks[i].Intensity > peaks[j].Intensity })
+
+	var starts []time.Duration
+Peaks:
+	for _, peak := range peaks {
+		if len(starts) == maxChapters {
+			break
+		}
+		if peak.Start < minLength || duration-peak.Start < minLength {
+			continue
+		}
+		for _, start := range starts {
+			if d := peak.Start - start; d < minLength && d > -minLength {
+				continue Peaks
+			}
+		}
+		starts = append(starts, peak.Start)
+	}
+	if len(starts) == 0 {
+		return nil
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	starts = append([]time.Duration{0}, starts...)
+	return chaptersFromStarts(starts, duration, func(i int) string {
+		if i == 0 {
+			return "Start"
+		}
+		return "Highlight " + strconv.Itoa(i)
+	})
+}
+
+// chaptersFromStarts returns chapters beginning at the ascending starts and ending at the next one or the duration
+func chaptersFromStarts(starts []time.Duration, duration time.Duration, title func(i int) string) []Chapter {
+	chapters := make([]Chapter, len(starts))
+	for i, start := range starts {
+		end := duration
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		chapters[i] = Chapter{Title: title(i), Start: start, End: end}
+	}
+	return chapters
+}
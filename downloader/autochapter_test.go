@@ -0,0 +1,74 @@
+package downloader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeatmapChapters(t *testing.T) {
+	var heatmap []youtube.HeatmapPoint
+	for i, intensity := range []float64{0.2, 1, 0.3, 0.2, 0.4, 0.5, 0.1, 0.6, 0.2, 0.1} {
+		heatmap = append(heatmap, youtube.HeatmapPoint{
+			Start:     time.Duration(i) * 10 * time.Second,
+			Duration:  10 * time.Second,
+			Intensity: intensity,
+		})
+	}
+
+	// the peak at 10s is too close to the start, the one at 50s too close to the one at 70s
+	chapters := heatmapChapters(heatmap, 0, 25*time.Second, 10)
+	assert.Equal(t, []Chapter{
+		{Title: "Start", Start: 0, End: 70 * time.Second},
+		{Title: "Highlight 1", Start: 70 * time.Second, End: 100 * time.Second},
+	}, chapters)
+
+	chapters = heatmapChapters(heatmap, 100*time.Second, 10*time.Second, 2)
+	assert.Equal(t, []Chapter{
+		{Title: "Start", Start: 0, End: 10 * time.Second},
+		{Title: "Highlight 1", Start: 10 * time.Second, End: 70 * time.Second},
+		{Title: "Highlight 2", Start: 70 * time.Second, End: 100 * time.Second},
+	}, chapters)
+
+	assert.Nil(t, heatmapChapters(nil, time.Minute, time.Second, 10))
+}
+
+func TestSilenceChapters(t *testing.T) {
+	ends := parseSilenceEnds("frame:12 pts:1 pts_time:2.5\nlavfi.silence_end=2.5\n" +
+		"frame:80 pts:2 pts_time:40\nlavfi.silence_end=40.25\n" +
+		"lavfi.silence_end=55\n" +
+		"lavfi.silence_end=95\n")
+	require.Equal(t, []time.Duration{2500 * time.Millisecond, 40250 * time.Millisecond, 55 * time.Second, 95 * time.Second}, ends)
+
+	assert.Equal(t, []Chapter{
+		{Title: "Chapter 1", Start: 0, End: 40250 * time.Millisecond},
+		{Title: "Chapter 2", Start: 40250 * time.Millisecond, End: 100 * time.Second},
+	}, silenceChapters(ends, 100*time.Second, 30*time.Second))
+
+	assert.Nil(t, silenceChapters(nil, 100*time.Second, 30*time.Second))
+}
+
+func TestAutoChapterProcessor_WithoutChapters(t *testing.T) {
+	p := &AutoChapterProcessor{Source: ChaptersFromHeatmap}
+	files, err := p.Process(context.Background(), &youtube.Video{Duration: time.Minute}, []string{"video.mp4"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"video.mp4"}, files)
+
+	// a description without chapters
+	p = &AutoChapterProcessor{Source: ChaptersFromDescription}
+	v := &youtube.Video{Duration: time.Minute, DescriptionEntities: youtube.ParseDescription("0:00 Intro\n0:30 End")}
+	files, err = p.Process(context.Background(), v, []string{"video.mp4"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"video.mp4"}, files)
+
+	_, err = (&AutoChapterProcessor{Source: "scenes"}).Process(context.Background(), &youtube.Video{}, []string{"video.mp4"})
+	assert.EqualError(t, err, `unknown chapter source "scenes"`)
+}
+
+func TestEscapeFilterPath(t *testing.T) {
+	assert.Equal(t, `C\\:/tmp/it\\\'s.txt`, escapeFilterPath(`C:/tmp/it's.txt`))
+}
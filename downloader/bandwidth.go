@@ -0,0 +1,157 @@
+package downloader
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BandwidthSchedule limits the download rate depending on the time of day
+type BandwidthSchedule struct {
+	Windows []BandwidthWindow
+	// Default is the rate outside of all windows in bytes per second, 0 means unlimited
+	Default int64
+}
+
+// BandwidthWindow is a daily time window with its own rate.
+// Windows ending before they start span midnight, e.g. 22:00-07:00.
+type BandwidthWindow struct {
+	// From and To are offsets since midnight
+	From time.Duration
+	To   time.Duration
+	// Rate in bytes per second, 0 means unlimited
+	Rate int64
+}
+
+// ParseBandwidthSchedule parses a schedule like "22:00-07:00=unlimited,else=1M".
+// Rates are bytes per second with an optional K, M or G suffix, or "unlimited".
+func ParseBandwidthSchedule(s string) (*BandwidthSchedule, error) {
+	schedule := &BandwidthSchedule{}
+	for _, rule := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(rule), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid schedule rule %q: expected WINDOW=RATE", rule)
+		}
+
+		rate, err := parseRate(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule rule %q: %w", rule, err)
+		}
+
+		if parts[0] == "else" {
+			schedule.Default = rate
+			continue
+		}
+
+		window := strings.SplitN(parts[0], "-", 2)
+		if len(window) != 2 {
+			return nil, fmt.Errorf("invalid schedule rule %q: expected HH:MM-HH:MM", rule)
+		}
+		from, err := parseTimeOfDay(window[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule rule %q: %w", rule, err)
+		}
+		to, err := parseTimeOfDay(window[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule rule %q: %w", rule, err)
+		}
+
+		schedule.Windows = append(schedule.Windows, BandwidthWindow{From: from, To: to, Rate: rate})
+	}
+
+	return schedule, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func parseRate(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "unlimited" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("missing rate")
+	}
+
+	unit := int64(1)
+	switch strings.ToUpper(s[len(s)-1:]) {
+	case "K":
+		unit = 1 << 10
+	case "M":
+		unit = 1 << 20
+	case "G":
+		unit = 1 << 30
+	}
+	if unit > 1 {
+		s = s[:len(s)-1]
+	}
+
+	rate, err := strconv.ParseFloat(s, 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	return int64(rate * float64(unit)), nil
+}
+
+// RateAt returns the rate at the given time in bytes per second, 0 means unlimited
+func (s *BandwidthSchedule) RateAt(t time.Time) int64 {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	for _, w := range s.Windows {
+		if w.contains(offset) {
+			return w.Rate
+		}
+	}
+	return s.Default
+}
+
+func (w BandwidthWindow) contains(offset time.Duration) bool {
+	if w.From <= w.To {
+		return offset >= w.From && offset < w.To
+	}
+	return offset >= w.From || offset < w.To
+}
+
+// throttledReader limits reads to the rate of the schedule at the time of reading
+type throttledReader struct {
+	r        io.Reader
+	schedule *BandwidthSchedule
+
+	rate  int64
+	start time.Time
+	read  int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	now := time.Now()
+	rate := t.schedule.RateAt(now)
+	if rate != t.rate {
+		// the rate changed, start measuring again
+		t.rate, t.start, t.read = rate, now, 0
+	}
+	if rate == 0 {
+		return t.r.Read(p)
+	}
+
+	// read at most a tenth of a second worth of data at once
+	if max := rate/10 + 1; int64(len(p)) > max {
+		p = p[:max]
+	}
+
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+
+	expected := time.Duration(float64(t.read) / float64(rate) * float64(time.Second))
+	if wait := expected - time.Since(t.start); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	return n, err
+}
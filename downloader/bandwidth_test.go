@@ -0,0 +1,33 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBandwidthSchedule(t *testing.T) {
+	schedule, err := ParseBandwidthSchedule("22:00-07:00=unlimited,12:00-13:00=512K,else=1M")
+	require.NoError(t, err)
+
+	at := func(clock string) time.Time {
+		tm, err := time.Parse("15:04", clock)
+		require.NoError(t, err)
+		return tm
+	}
+
+	assert.EqualValues(t, 0, schedule.RateAt(at("23:30")))
+	assert.EqualValues(t, 0, schedule.RateAt(at("06:59")))
+	assert.EqualValues(t, 1<<20, schedule.RateAt(at("07:00")))
+	assert.EqualValues(t, 512<<10, schedule.RateAt(at("12:30")))
+	assert.EqualValues(t, 1<<20, schedule.RateAt(at("18:00")))
+}
+
+func TestParseBandwidthSchedule_Invalid(t *testing.T) {
+	for _, s := range []string{"", "22:00=1M", "22:00-25:00=1M", "else=", "else=fast", "22:00-07:00"} {
+		_, err := ParseBandwidthSchedule(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,319 @@
+package downloader
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// Defaults of ChunkConfig
+const (
+	defaultMinChunkSize   = 1 << 20
+	defaultMaxChunkSize   = 16 << 20
+	defaultMaxConnections = 4
+
+	// chunkDuration is the time a chunk should take to download at the measured throughput
+	chunkDuration = 2 * time.Second
+	// throughputGain is the relative gain of the total throughput for which another connection is kept
+	throughputGain = 1.1
+)
+
+// ChunkConfig enables downloading streams with range requests over parallel connections, see Downloader.Chunks.
+// The chunk size and the number of connections adapt to the measured throughput within the bounds.
+type ChunkConfig struct {
+	// MinChunkSize is the smallest chunk in bytes, defaults to 1 MiB
+	MinChunkSize int64
+	// MaxChunkSize is the largest chunk in bytes, defaults to 16 MiB
+	MaxChunkSize int64
+	// MaxConnections limits the parallel requests per stream, defaults to 4
+	MaxConnections int
+}
+
+// TransferStats are the throughput measurements of a chunked download
+type TransferStats struct {
+	// BytesPerSecond is the total throughput of all connections
+	BytesPerSecond float64
+	// ConnectionBytesPerSecond is the average throughput of a single connection
+	ConnectionBytesPerSecond float64
+	// Connections is the current number of parallel requests
+	Connections int
+	// ChunkSize is the current size of requested chunks in bytes
+	ChunkSize int64
+}
+
+// statsObserver is implemented by stream observers interested in the stats of chunked downloads
+type statsObserver interface {
+	transferStats(stats TransferStats)
+}
+
+func (c ChunkConfig) withDefaults() ChunkConfig {
+	if c.MinChunkSize <= 0 {
+		c.MinChunkSize = defaultMinChunkSize
+	}
+	if c.MaxChunkSize < c.MinChunkSize {
+		c.MaxChunkSize = defaultMaxChunkSize
+		if c.MaxChunkSize < c.MinChunkSize {
+			c.MaxChunkSize = c.MinChunkSize
+		}
+	}
+	if c.MaxConnections <= 0 {
+		c.MaxConnections = defaultMaxConnections
+	}
+	return c
+}
+
+// chunk is a byte window of the stream, done is closed once data or err is set
+type chunk struct {
+	offset int64
+	length int64
+	data   []byte
+	err    error
+	done   chan struct{}
+}
+
+// chunkedReader reads a stream in order while the following chunks are fetched in parallel
+type chunkedReader struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	dl     *Downloader
+	video  *youtube.Video
+	size   int64
+	config ChunkConfig
+
+	mu          sync.Mutex
+	format      *youtube.Format
+	next        int64 // offset of the next chunk to schedule
+	pending     []*chunk
+	current     []byte
+	chunkSize   int64
+	connections int
+	connRate    float64 // moving average of the throughput per connection
+
+	// total throughput since the last change of the connections
+	windowStart time.Time
+	windowBytes int64
+	lastRate    float64
+	increased   bool
+}
+
+func (dl *Downloader) newChunkedReader(ctx context.Context, v *youtube.Video, format *youtube.Format, size int64) *chunkedReader {
+	config := dl.Chunks.withDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+	return &chunkedReader{
+		ctx:         ctx,
+		cancel:      cancel,
+		dl:          dl,
+		video:       v,
+		format:      format,
+		size:        size,
+		config:      config,
+		chunkSize:   config.MinChunkSize,
+		connections: 1,
+		windowStart: time.Now(),
+	}
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	for len(r.current) == 0 {
+		r.mu.Lock()
+		r.schedule()
+		if len(r.pending) == 0 {
+			r.mu.Unlock()
+			return 0, io.EOF
+		}
+		c := r.pending[0]
+		r.mu.Unlock()
+
+		select {
+		case <-c.done:
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		}
+		if c.err != nil {
+			return 0, c.err
+		}
+
+		r.mu.Lock()
+		r.pending = r.pending[1:]
+		r.mu.Unlock()
+		r.current = c.data
+	}
+
+	n := copy(p, r.current)
+	r.current = r.current[n:]
+	return n, nil
+}
+
+func (r *chunkedReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// schedule starts fetching chunks until as many are pending as connections are allowed, r.mu must be held
+func (r *chunkedReader) schedule() {
+	for len(r.pending) < r.connections && r.next < r.size {
+		length := r.chunkSize
+		if r.next+length > r.size {
+			length = r.size - r.next
+		}
+		c := &chunk{offset: r.next, length: length, done: make(chan struct{})}
+		r.next += length
+		r.pending = append(r.pending, c)
+		go r.fetch(c)
+	}
+}
+
+// fetch downloads a chunk, resuming it and refreshing an expired format up to StreamRetries times
+func (r *chunkedReader) fetch(c *chunk) {
+	defer close(c.done)
+
+	retries := r.dl.StreamRetries
+	if retries == 0 {
+		retries = defaultStreamRetries
+	}
+
+	start := time.Now()
+	data := make([]byte, 0, c.length)
+	for {
+		err := r.fetchRange(c, &data)
+		if err == nil {
+			break
+		}
+		if retries <= 0 || r.ctx.Err() != nil {
+			c.err = err
+			return
+		}
+		retries--
+
+		r.dl.logf("chunk at %d interrupted after %d bytes: %v", c.offset, len(data), err)
+		if isForbidden(err) {
+			if err := r.refreshFormat(); err != nil {
+				c.err = err
+				return
+			}
+		}
+	}
+
+	c.data = data
+	r.completed(c.length, time.Since(start))
+}
+
+// fetchRange appends the missing bytes of the chunk to data
+func (r *chunkedReader) fetchRange(c *chunk, data *[]byte) error {
+	r.mu.Lock()
+	format := r.format
+	r.mu.Unlock()
+
+	start := c.offset + int64(len(*data))
+	resp, err := r.dl.GetStreamRange(r.ctx, r.video, format, start, c.offset+c.length-1)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf := *data
+	for {
+		if len(buf) == cap(buf) {
+			// the server sent more than requested
+			_, err := io.Copy(ioutil.Discard, resp.Body)
+			*data = buf
+			return err
+		}
+		n, err := resp.Body.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF {
+			*data = buf
+			if int64(len(buf)) < c.length {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			*data = buf
+			return err
+		}
+	}
+}
+
+func (r *chunkedReader) refreshFormat() error {
+	r.mu.Lock()
+	format := r.format
+	r.mu.Unlock()
+
+	fresh, err := r.dl.refreshFormat(r.ctx, r.video, format)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.format = fresh
+	r.mu.Unlock()
+	return nil
+}
+
+// completed adapts the chunk size and the connections to the throughput of a finished chunk
+func (r *chunkedReader) completed(length int64, elapsed time.Duration) {
+	if elapsed <= 0 {
+		elapsed = time.Millisecond
+	}
+	rate := float64(length) / elapsed.Seconds()
+
+	r.mu.Lock()
+	if r.connRate == 0 {
+		r.connRate = rate
+	} else {
+		r.connRate = 0.7*r.connRate + 0.3*rate
+	}
+
+	// chunks should neither end too fast to amortize the request nor take too long to adapt
+	r.chunkSize = int64(r.connRate * chunkDuration.Seconds())
+	if r.chunkSize < r.config.MinChunkSize {
+		r.chunkSize = r.config.MinChunkSize
+	}
+	if r.chunkSize > r.config.MaxChunkSize {
+		r.chunkSize = r.config.MaxChunkSize
+	}
+
+	// judge the connections after a chunk per connection has completed
+	r.windowBytes += length
+	total := float64(r.windowBytes) / time.Since(r.windowStart).Seconds()
+	if r.windowBytes >= int64(r.connections)*length {
+		switch {
+		case r.increased && total < r.lastRate*throughputGain && r.connections > 1:
+			// the last connection didn't help, the link is saturated
+			r.connections--
+			r.increased = false
+		case total >= r.lastRate*throughputGain && r.connections < r.config.MaxConnections:
+			r.connections++
+			r.increased = true
+		default:
+			r.increased = false
+		}
+		r.lastRate = total
+		r.windowStart, r.windowBytes = time.Now(), 0
+	}
+
+	stats := TransferStats{
+		BytesPerSecond:           total,
+		ConnectionBytesPerSecond: r.connRate,
+		Connections:              r.connections,
+		ChunkSize:                r.chunkSize,
+	}
+	r.mu.Unlock()
+
+	r.dl.transferStats(stats)
+}
+
+// transferStats passes the stats of a chunked download to the observer and OnTransferStats
+func (dl *Downloader) transferStats(stats TransferStats) {
+	if observer, ok := dl.observer.(statsObserver); ok {
+		observer.transferStats(stats)
+	}
+	if dl.OnTransferStats != nil {
+		dl.OnTransferStats(stats)
+	}
+}
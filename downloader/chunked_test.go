@@ -0,0 +1,85 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoDLWorker_Chunked(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+
+	var mu sync.Mutex
+	var requests int
+	dl := &Downloader{NoProgress: true, Chunks: &ChunkConfig{MinChunkSize: 500, MaxChunkSize: 2000, MaxConnections: 3}}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var start, end int
+		if _, err := fmt.Sscanf(req.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+			return nil, fmt.Errorf("range expected: %w", err)
+		}
+
+		mu.Lock()
+		requests++
+		failing := requests == 2
+		mu.Unlock()
+
+		body := ioutil.NopCloser(strings.NewReader(content[start : end+1]))
+		if failing {
+			// the second chunk breaks after 100 bytes
+			body = ioutil.NopCloser(&failingReader{strings.NewReader(content[start : start+100])})
+		}
+		return &http.Response{
+			StatusCode:    http.StatusPartialContent,
+			ContentLength: int64(end - start + 1),
+			Header:        http.Header{"Content-Range": {fmt.Sprintf("bytes %d-%d/%d", start, end, len(content))}},
+			Body:          body,
+		}, nil
+	})}
+
+	var stats []TransferStats
+	dl.OnTransferStats = func(s TransferStats) {
+		mu.Lock()
+		stats = append(stats, s)
+		mu.Unlock()
+	}
+	var downloaded int
+	dl.OnBytesDownloaded = func(n int) {
+		mu.Lock()
+		downloaded += n
+		mu.Unlock()
+	}
+
+	format := &youtube.Format{URL: "http://example.com/stream", ContentLength: strconv.Itoa(len(content))}
+	var out bytes.Buffer
+	require.NoError(t, dl.videoDLWorker(context.Background(), &out, &youtube.Video{}, format))
+	assert.Equal(t, content, out.String())
+	assert.Equal(t, len(content), downloaded)
+
+	require.NotEmpty(t, stats)
+	for _, s := range stats {
+		assert.True(t, s.Connections >= 1 && s.Connections <= 3, "connections: %d", s.Connections)
+		assert.True(t, s.ChunkSize >= 500 && s.ChunkSize <= 2000, "chunk size: %d", s.ChunkSize)
+	}
+	// fast links use the largest chunks
+	assert.Equal(t, int64(2000), stats[len(stats)-1].ChunkSize)
+}
+
+func TestChunkConfig_Defaults(t *testing.T) {
+	config := ChunkConfig{}.withDefaults()
+	assert.Equal(t, int64(defaultMinChunkSize), config.MinChunkSize)
+	assert.Equal(t, int64(defaultMaxChunkSize), config.MaxChunkSize)
+	assert.Equal(t, defaultMaxConnections, config.MaxConnections)
+
+	config = ChunkConfig{MinChunkSize: 32 << 20}.withDefaults()
+	assert.Equal(t, int64(32<<20), config.MaxChunkSize)
+}
@@ -0,0 +1,115 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// ErrFileExists is returned by CollisionError if the output file already exists
+var ErrFileExists = errors.New("output file already exists")
+
+// CollisionPolicy defines what happens if an output file already exists
+type CollisionPolicy int
+
+const (
+	// CollisionOverwrite replaces the existing file
+	CollisionOverwrite CollisionPolicy = iota
+	// CollisionSkip keeps the existing file and skips the download
+	CollisionSkip
+	// CollisionAutoNumber appends a number to the new file, e.g. "title (1).mp4"
+	CollisionAutoNumber
+	// CollisionError fails with ErrFileExists
+	CollisionError
+)
+
+// fileOwners maps the output files claimed in this process to the IDs of their videos
+var fileOwners sync.Map
+
+// resolveOutput applies DedupByID, disambiguates the output file of the video
+// and applies the collision policy. It returns an empty name if the download should be skipped.
+func (dl *Downloader) resolveOutput(v *youtube.Video, file string) (string, error) {
+	if dl.DedupByID && v.ID != "" {
+		dir := dl.OutputDir
+		if dir == "" {
+			dir = "."
+		}
+		existing, err := findInfoJSON(dir, v.ID)
+		if err != nil {
+			return "", err
+		}
+		if existing != "" {
+			dl.logf("%s already downloaded according to %s, skipping", v.ID, existing)
+			return "", nil
+		}
+	}
+
+	if owner := fileOwner(file); owner != "" && owner != v.ID {
+		ext := filepath.Ext(file)
+		disambiguated := fmt.Sprintf("%s [%s]%s", strings.TrimSuffix(file, ext), v.ID, ext)
+		dl.logf("%s belongs to video %s, using %s", file, owner, disambiguated)
+		file = disambiguated
+	}
+
+	file, err := dl.resolveCollision(file)
+	if file != "" && v.ID != "" {
+		if abs, absErr := filepath.Abs(file); absErr == nil {
+			fileOwners.Store(abs, v.ID)
+		}
+	}
+	return file, err
+}
+
+// fileOwner returns the ID of the video an output file belongs to,
+// known from this process or from the sidecar info.json of the file
+func fileOwner(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		if id, ok := fileOwners.Load(abs); ok {
+			return id.(string)
+		}
+	}
+	if !fileExists(file) {
+		return ""
+	}
+	return readInfoJSONID(infoJSONFile(file))
+}
+
+// resolveCollision applies the collision policy to the output file.
+// It returns an empty name if the download should be skipped.
+func (dl *Downloader) resolveCollision(file string) (string, error) {
+	if !fileExists(file) {
+		return file, nil
+	}
+
+	switch dl.OnCollision {
+	case CollisionOverwrite:
+		dl.logf("overwriting %s", file)
+		return file, nil
+	case CollisionSkip:
+		dl.logf("%s already exists, skipping", file)
+		return "", nil
+	case CollisionAutoNumber:
+		ext := filepath.Ext(file)
+		base := strings.TrimSuffix(file, ext)
+		for i := 1; ; i++ {
+			numbered := fmt.Sprintf("%s (%d)%s", base, i, ext)
+			if !fileExists(numbered) {
+				return numbered, nil
+			}
+		}
+	case CollisionError:
+		return "", fmt.Errorf("%w: %s", ErrFileExists, file)
+	default:
+		return "", fmt.Errorf("unknown collision policy: %d", dl.OnCollision)
+	}
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
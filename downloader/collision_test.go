@@ -0,0 +1,97 @@
+package downloader
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCollision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collision")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	existing := filepath.Join(dir, "video.mp4")
+	require.NoError(t, ioutil.WriteFile(existing, nil, 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "video (1).mp4"), nil, 0o644))
+	missing := filepath.Join(dir, "other.mp4")
+
+	tests := []struct {
+		name   string
+		policy CollisionPolicy
+		file   string
+		want   string
+		err    error
+	}{
+		{"missing file", CollisionError, missing, missing, nil},
+		{"overwrite", CollisionOverwrite, existing, existing, nil},
+		{"skip", CollisionSkip, existing, "", nil},
+		{"auto number", CollisionAutoNumber, existing, filepath.Join(dir, "video (2).mp4"), nil},
+		{"error", CollisionError, existing, "", ErrFileExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dl := Downloader{OnCollision: tt.policy}
+			got, err := dl.resolveCollision(tt.file)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "unexpected error: %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	existing := filepath.Join(dir, "video.mp4")
+	require.NoError(t, ioutil.WriteFile(existing, nil, 0o644))
+	require.NoError(t, writeInfoJSON(&youtube.Video{ID: "aaaaaaaaaaa", Title: "video"}, existing))
+
+	dl := Downloader{OutputDir: dir, OnCollision: CollisionSkip}
+
+	// same video
+	got, err := dl.resolveOutput(&youtube.Video{ID: "aaaaaaaaaaa"}, existing)
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	// different video with the same title
+	got, err = dl.resolveOutput(&youtube.Video{ID: "bbbbbbbbbbb"}, existing)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "video [bbbbbbbbbbb].mp4"), got)
+
+	// claimed in this process without info.json
+	other := filepath.Join(dir, "other.mp4")
+	got, err = dl.resolveOutput(&youtube.Video{ID: "ccccccccccc"}, other)
+	require.NoError(t, err)
+	assert.Equal(t, other, got)
+	got, err = dl.resolveOutput(&youtube.Video{ID: "ddddddddddd"}, other)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "other [ddddddddddd].mp4"), got)
+}
+
+func TestResolveOutput_DedupByID(t *testing.T) {
+	dir := t.TempDir()
+
+	existing := filepath.Join(dir, "Author", "renamed.mp4")
+	require.NoError(t, os.MkdirAll(filepath.Dir(existing), 0o755))
+	require.NoError(t, writeInfoJSON(&youtube.Video{ID: "aaaaaaaaaaa"}, existing))
+
+	dl := Downloader{OutputDir: dir, DedupByID: true}
+
+	got, err := dl.resolveOutput(&youtube.Video{ID: "aaaaaaaaaaa"}, filepath.Join(dir, "video.mp4"))
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	got, err = dl.resolveOutput(&youtube.Video{ID: "bbbbbbbbbbb"}, filepath.Join(dir, "video.mp4"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "video.mp4"), got)
+}
@@ -0,0 +1,245 @@
+package downloader
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// dashSequencePattern matches the sequence number in the segment URLs of DASH manifests, e.g. "sq/12/dur/5.000"
+var dashSequencePattern = regexp.MustCompile(`(?:^|/)sq/(\d+)`)
+
+// dashManifest is the part of an MPD needed to download the segments of a representation
+type dashManifest struct {
+	Periods []struct {
+		AdaptationSets []struct {
+			MimeType        string               `xml:"mimeType,attr"`
+			Representations []dashRepresentation `xml:"Representation"`
+		} `xml:"AdaptationSet"`
+	} `xml:"Period"`
+}
+
+type dashRepresentation struct {
+	ID          string `xml:"id,attr"`
+	Bandwidth   int    `xml:"bandwidth,attr"`
+	Codecs      string `xml:"codecs,attr"`
+	MimeType    string `xml:"mimeType,attr"`
+	BaseURL     string `xml:"BaseURL"`
+	SegmentList struct {
+		Initialization struct {
+			SourceURL string `xml:"sourceURL,attr"`
+		} `xml:"Initialization"`
+		SegmentURLs []struct {
+			Media string `xml:"media,attr"`
+		} `xml:"SegmentURL"`
+	} `xml:"SegmentList"`
+}
+
+// dashSummary reconciles the segments of a DASH download
+type dashSummary struct {
+	Segments   int
+	Refetched  int
+	Truncated  int
+	OutOfOrder int
+	// Gaps are sequence numbers missing in the manifest
+	Gaps []int
+}
+
+func (s *dashSummary) String() string {
+	return fmt.Sprintf("%d segments, %d re-fetched (%d truncated, %d out of order), %d gaps in the manifest",
+		s.Segments, s.Refetched, s.Truncated, s.OutOfOrder, len(s.Gaps))
+}
+
+// errSegmentTruncated and errSegmentOutOfOrder are the problems of segments which are fetched again
+var (
+	errSegmentTruncated  = errors.New("segment truncated")
+	errSegmentOutOfOrder = errors.New("segment out of order")
+)
+
+// DownloadDASH downloads a representation of the DASH manifest of the video segment by segment.
+// itag selects the representation, 0 selects the one with the highest bandwidth.
+// Every segment is checked against its Content-Length and sequence number, truncated or out of order segments
+// are fetched again up to StreamRetries times. A reconciliation summary is logged at the end.
+func (dl *Downloader) DownloadDASH(ctx context.Context, v *youtube.Video, itag int, outputFile string) error {
+	ctx = youtube.WithDownloadKey(ctx, v.ID)
+	if v.DASHManifestURL == "" {
+		return fmt.Errorf("%w: video has no DASH manifest", youtube.ErrFormatNotFound)
+	}
+
+	data, err := dl.httpGetBytes(ctx, v.DASHManifestURL)
+	if err != nil {
+		return err
+	}
+	representation, mimeType, err := selectDASHRepresentation(data, itag)
+	if err != nil {
+		return err
+	}
+	// BaseURL may be relative to the manifest
+	representation.BaseURL = resolveReference(v.DASHManifestURL, representation.BaseURL)
+
+	format := &youtube.Format{ItagNo: itag, MimeType: mimeType}
+	if representation.Codecs != "" {
+		format.MimeType += `; codecs="` + representation.Codecs + `"`
+	}
+	dl.logf("Video '%s' - DASH representation %s - Codec '%s'", v.Title, representation.ID, format.MimeType)
+
+	destFile, err := dl.getOutputFile(v, format, outputFile)
+	if err != nil {
+		return err
+	}
+	destFile, err = dl.resolveOutput(v, destFile)
+	if err != nil || destFile == "" {
+		return err
+	}
+
+	out, err := ioutil.TempFile(dl.tempDir(destFile), "youtube_*"+pickIdealFileExtension(format.MimeType))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	summary, err := dl.downloadDASHSegments(ctx, representation, out)
+	out.Close()
+	dl.logf("DASH download of %s: %s", v.ID, summary)
+	if err != nil {
+		return err
+	}
+
+	return dl.postProcess(ctx, v, []string{out.Name()}, destFile)
+}
+
+// selectDASHRepresentation returns the representation of the itag, or the one with the highest bandwidth for 0
+func selectDASHRepresentation(data []byte, itag int) (*dashRepresentation, string, error) {
+	var manifest dashManifest
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		return nil, "", fmt.Errorf("%w: unable to parse DASH manifest: %v", youtube.ErrParse, err)
+	}
+
+	var best *dashRepresentation
+	var bestMimeType string
+	for _, period := range manifest.Periods {
+		for _, set := range period.AdaptationSets {
+			for i := range set.Representations {
+				r := &set.Representations[i]
+				if itag != 0 && r.ID != strconv.Itoa(itag) {
+					continue
+				}
+				if best == nil || r.Bandwidth > best.Bandwidth {
+					best, bestMimeType = r, set.MimeType
+					if r.MimeType != "" {
+						bestMimeType = r.MimeType
+					}
+				}
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, "", fmt.Errorf("%w: itag %d in DASH manifest", youtube.ErrFormatNotFound, itag)
+	}
+	return best, bestMimeType, nil
+}
+
+// downloadDASHSegments writes the initialization and all media segments of the representation to out
+func (dl *Downloader) downloadDASHSegments(ctx context.Context, r *dashRepresentation, out *os.File) (*dashSummary, error) {
+	summary := &dashSummary{}
+
+	if init := r.SegmentList.Initialization.SourceURL; init != "" {
+		data, err := dl.fetchDASHSegment(ctx, resolveReference(r.BaseURL, init), -1, summary)
+		if err != nil {
+			return summary, fmt.Errorf("initialization segment: %w", err)
+		}
+		if _, err := out.Write(data); err != nil {
+			return summary, err
+		}
+	}
+
+	previous := -1
+	for _, segment := range r.SegmentList.SegmentURLs {
+		sq := -1
+		if m := dashSequencePattern.FindStringSubmatch(segment.Media); m != nil {
+			sq, _ = strconv.Atoi(m[1])
+			for missing := previous + 1; previous >= 0 && missing < sq; missing++ {
+				summary.Gaps = append(summary.Gaps, missing)
+			}
+			previous = sq
+		}
+
+		data, err := dl.fetchDASHSegment(ctx, resolveReference(r.BaseURL, segment.Media), sq, summary)
+		if err != nil {
+			return summary, fmt.Errorf("segment %s: %w", segment.Media, err)
+		}
+		if _, err := out.Write(data); err != nil {
+			return summary, err
+		}
+		summary.Segments++
+	}
+
+	return summary, nil
+}
+
+// fetchDASHSegment downloads a segment, fetching it again while it is truncated or has another sequence number.
+// sq is -1 if the segment has no sequence number.
+func (dl *Downloader) fetchDASHSegment(ctx context.Context, rawURL string, sq int, summary *dashSummary) ([]byte, error) {
+	retries := dl.StreamRetries
+	if retries == 0 {
+		retries = defaultStreamRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		data, err := dl.tryDASHSegment(ctx, rawURL, sq)
+		if err == nil {
+			return data, nil
+		}
+		if attempt >= retries || ctx.Err() != nil {
+			return nil, err
+		}
+
+		switch {
+		case errors.Is(err, errSegmentTruncated):
+			summary.Truncated++
+		case errors.Is(err, errSegmentOutOfOrder):
+			summary.OutOfOrder++
+		}
+		summary.Refetched++
+		dl.logf("fetching segment %d again: %v", sq, err)
+	}
+}
+
+// tryDASHSegment downloads a segment once and verifies its length and sequence number
+func (dl *Downloader) tryDASHSegment(ctx context.Context, rawURL string, sq int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dl.RewriteStreamURL(rawURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := dl.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, youtube.ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", errSegmentTruncated, err)
+	case len(data) == 0 || (resp.ContentLength >= 0 && int64(len(data)) != resp.ContentLength):
+		return nil, fmt.Errorf("%w: got %d of %d bytes", errSegmentTruncated, len(data), resp.ContentLength)
+	}
+
+	if header := resp.Header.Get("X-Sequence-Num"); sq >= 0 && header != "" && header != strconv.Itoa(sq) {
+		return nil, fmt.Errorf("%w: got %s instead of %d", errSegmentOutOfOrder, header, sq)
+	}
+	return data, nil
+}
@@ -0,0 +1,97 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadDASH_RefetchesSegments(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		count := requests[r.URL.Path]
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/manifest.mpd":
+			fmt.Fprintf(w, `<?xml version="1.0"?>
+<MPD><Period><AdaptationSet mimeType="video/mp4">
+<Representation id="133" bandwidth="100" codecs="avc1.4d4015"><BaseURL>%[1]s/low/</BaseURL></Representation>
+<Representation id="137" bandwidth="900" codecs="avc1.640028"><BaseURL>%[1]s/high/</BaseURL>
+<SegmentList><Initialization sourceURL="sq/0"/><SegmentURL media="sq/1/dur/5"/><SegmentURL media="sq/2/dur/5"/><SegmentURL media="sq/4/dur/5"/></SegmentList>
+</Representation></AdaptationSet></Period></MPD>`, server.URL)
+		case "/high/sq/0":
+			fmt.Fprint(w, "[init]")
+		case "/high/sq/1/dur/5":
+			if count == 1 {
+				// the connection breaks after a part of the segment
+				w.Header().Set("Content-Length", "10")
+				fmt.Fprint(w, "[1")
+				return
+			}
+			fmt.Fprint(w, "[1]")
+		case "/high/sq/2/dur/5":
+			if count == 1 {
+				w.Header().Set("X-Sequence-Num", "3")
+				fmt.Fprint(w, "[3]")
+				return
+			}
+			w.Header().Set("X-Sequence-Num", "2")
+			fmt.Fprint(w, "[2]")
+		case "/high/sq/4/dur/5":
+			fmt.Fprint(w, "[4]")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dl := &Downloader{OutputDir: dir}
+	video := &youtube.Video{ID: "BaW_jenozKc", Title: "otf", DASHManifestURL: server.URL + "/manifest.mpd"}
+	require.NoError(t, dl.DownloadDASH(context.Background(), video, 0, "otf.mp4"))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "otf.mp4"))
+	require.NoError(t, err)
+	assert.Equal(t, "[init][1][2][4]", string(data))
+	assert.Equal(t, 2, requests["/high/sq/1/dur/5"])
+	assert.Equal(t, 2, requests["/high/sq/2/dur/5"])
+}
+
+func TestDownloadDASH_GivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/manifest.mpd" {
+			fmt.Fprint(w, `<MPD><Period><AdaptationSet mimeType="audio/mp4"><Representation id="140" bandwidth="128">
+<BaseURL>/audio/</BaseURL><SegmentList><SegmentURL media="sq/1"/></SegmentList></Representation></AdaptationSet></Period></MPD>`)
+			return
+		}
+		w.Header().Set("Content-Length", "10")
+		fmt.Fprint(w, "[1")
+	}))
+	defer server.Close()
+
+	dl := &Downloader{OutputDir: t.TempDir(), StreamRetries: 1}
+	video := &youtube.Video{ID: "BaW_jenozKc", DASHManifestURL: server.URL + "/manifest.mpd"}
+
+	err := dl.DownloadDASH(context.Background(), video, 140, "audio.m4a")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errSegmentTruncated))
+
+	err = dl.DownloadDASH(context.Background(), video, 137, "video.mp4")
+	assert.True(t, errors.Is(err, youtube.ErrFormatNotFound))
+}
@@ -6,9 +6,11 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/kkdai/youtube/v2"
 	"github.com/vbauerster/mpb/v5"
@@ -19,12 +21,115 @@ import (
 type Downloader struct {
 	youtube.Client
 	OutputDir string // optional directory to store the files
+
+	// NoProgress disables the progress bar, which is also hidden if stdout is not a terminal
+	NoProgress bool
+
+	// OutputTemplate is a text/template for the names of output files without extension,
+	// e.g. "{{.Author}}/{{.PublishDate}} {{.Title}}". Defaults to the title of the video.
+	OutputTemplate string
+	// FilenameSanitizer cleans the fields of the output template, defaults to SanitizeFilename
+	FilenameSanitizer *FilenameSanitizer
+
+	// TempDir holds intermediate files like separate video and audio streams, defaults to the directory of the output file
+	TempDir string
+
+	// Storage receives the downloaded files instead of the output directory, which then only holds temporary files.
+	// Single formats without PostProcessors are streamed into the storage. Merged and post-processed downloads
+	// are staged in the output directory and uploaded once complete, so they need local space for the whole file.
+	// Collisions are not detected in storages.
+	Storage Storage
+
+	// StreamRetries limits how often an interrupted or expired stream is resumed, defaults to 3.
+	// Negative values disable resuming.
+	StreamRetries int
+
+	// WriteLiveChat captures the live chat while recording live streams with DownloadLive
+	WriteLiveChat bool
+	// LiveFromStart records live streams from the start of their DVR window instead of the live edge
+	LiveFromStart bool
+
+	// Bandwidth limits the download rate depending on the time of day, unlimited if nil
+	Bandwidth *BandwidthSchedule
+
+	// Chunks downloads streams of known size in chunks over parallel connections, which adapt to the throughput
+	Chunks *ChunkConfig
+	// OnTransferStats is called with the measured throughput whenever a chunk has been downloaded
+	OnTransferStats func(stats TransferStats)
+	// OnBytesDownloaded is called with the size of every read from a stream, possibly from several goroutines
+	OnBytesDownloaded func(n int)
+	// Pacing downloads streams of known size in sequential ranges like the web player, if Chunks is not set
+	Pacing *PacingConfig
+
+	// observer is notified about the streams being downloaded, used by the Manager
+	observer streamObserver
+
+	// OnCollision defines what happens if an output file already exists, defaults to CollisionOverwrite.
+	// Files of a different video with the same name are kept, the video ID is appended to the new file instead.
+	OnCollision CollisionPolicy
+	// DedupByID skips videos whose info.json sidecar exists anywhere under OutputDir
+	DedupByID bool
+	// WriteInfoJSON writes the metadata of every downloaded video into an .info.json sidecar file
+	WriteInfoJSON bool
+
+	// OnFileCompleted is called with the path of every finished output file, or its name in the storage
+	OnFileCompleted func(file string)
+
+	// PostProcessors are applied to every downloaded file
+	PostProcessors []PostProcessor
+
+	// FFmpegPath is the path of the ffmpeg binary, defaults to "ffmpeg" looked up in PATH
+	FFmpegPath string
+	// FFmpegExtraArgs are passed to ffmpeg right before the output file
+	FFmpegExtraArgs []string
+
+	// MergeOutputFormat is the container of merged downloads: mkv, mp4 or webm.
+	// If empty, a container compatible with the codecs is picked.
+	MergeOutputFormat string
+
+	// AudioLanguage is the preferred audio track language of merged downloads, e.g. "en".
+	// If empty, the default audio track is preferred.
+	AudioLanguage string
+	// AllAudioTracks merges every audio track of a dubbed video, starting with the preferred one
+	AllAudioTracks bool
+	// NoFaststart keeps the index at the end of merged MP4 files, which then have to be downloaded completely before
+	// they can be played in browsers
+	NoFaststart bool
+	// PipeMerge streams video and audio directly into ffmpeg instead of downloading them to temporary files first.
+	// Progress bars are not shown for piped streams.
+	PipeMerge bool
+}
+
+func (dl *Downloader) ffmpeg() FFmpeg {
+	return FFmpeg{
+		Path:      dl.FFmpegPath,
+		ExtraArgs: dl.FFmpegExtraArgs,
+	}
+}
+
+// outputName returns the name of the output file relative to the output directory
+func (dl *Downloader) outputName(v *youtube.Video, format *youtube.Format, outputFile string) (string, error) {
+	if outputFile != "" {
+		return outputFile, nil
+	}
+
+	name, err := dl.renderOutputTemplate(v)
+	if err != nil {
+		return "", err
+	}
+	return name + pickIdealFileExtension(format.MimeType), nil
 }
 
 func (dl *Downloader) getOutputFile(v *youtube.Video, format *youtube.Format, outputFile string) (string, error) {
-	if outputFile == "" {
-		outputFile = SanitizeFilename(v.Title)
-		outputFile += pickIdealFileExtension(format.MimeType)
+	outputFile, err := dl.outputName(v, format, outputFile)
+	if err != nil {
+		return "", err
+	}
+
+	if dir := filepath.Dir(outputFile); dir != "." {
+		if err := os.MkdirAll(filepath.Join(dl.OutputDir, dir), 0o755); err != nil {
+			return "", err
+		}
 	}
 
 	if dl.OutputDir != "" {
@@ -39,11 +144,24 @@ func (dl *Downloader) getOutputFile(v *youtube.Video, format *youtube.Format, ou
 
 // Download : Starting download video by arguments.
 func (dl *Downloader) Download(ctx context.Context, v *youtube.Video, format *youtube.Format, outputFile string) error {
+	ctx = youtube.WithDownloadKey(ctx, v.ID)
 	dl.logf("Video '%s' - Quality '%s' - Codec '%s'", v.Title, format.QualityLabel, format.MimeType)
+	if dl.Storage != nil && len(dl.PostProcessors) == 0 {
+		return dl.downloadToStorage(ctx, v, format, outputFile)
+	}
+
 	destFile, err := dl.getOutputFile(v, format, outputFile)
 	if err != nil {
 		return err
 	}
+	destFile, err = dl.resolveOutput(v, destFile)
+	if err != nil || destFile == "" {
+		return err
+	}
+
+	if len(dl.PostProcessors) > 0 {
+		return dl.downloadAndProcess(ctx, v, format, destFile)
+	}
 
 	// Create output file
 	out, err := os.Create(destFile)
@@ -53,98 +171,284 @@ func (dl *Downloader) Download(ctx context.Context, v *youtube.Video, format *yo
 	defer out.Close()
 
 	dl.logf("Download to file=%s", destFile)
-	return dl.videoDLWorker(ctx, out, v, format)
+	if err := dl.videoDLWorker(ctx, out, v, format); err != nil {
+		// a partial file would pass for a complete download
+		out.Close()
+		os.Remove(destFile)
+		return err
+	}
+	dl.fileCompleted(v, destFile)
+	return nil
+}
+
+// downloadToStorage streams a format directly into the storage
+func (dl *Downloader) downloadToStorage(ctx context.Context, v *youtube.Video, format *youtube.Format, outputFile string) error {
+	name, err := dl.outputName(v, format, outputFile)
+	if err != nil {
+		return err
+	}
+
+	out, err := dl.Storage.Create(filepath.ToSlash(name))
+	if err != nil {
+		return err
+	}
+
+	dl.logf("Download to storage=%s", name)
+	if err := dl.videoDLWorker(ctx, out, v, format); err != nil {
+		abortStorageWriter(out)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	dl.fileCompleted(v, name)
+	return nil
+}
+
+func (dl *Downloader) downloadAndProcess(ctx context.Context, v *youtube.Video, format *youtube.Format, destFile string) error {
+	// Create temporary file
+	tmpFile, err := ioutil.TempFile(dl.tempDir(destFile), "youtube_*"+filepath.Ext(destFile))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	dl.logf("Download to file=%s", tmpFile.Name())
+	err = dl.videoDLWorker(ctx, tmpFile, v, format)
+	tmpFile.Close()
+	if err != nil {
+		return err
+	}
+
+	return dl.postProcess(ctx, v, []string{tmpFile.Name()}, destFile)
+}
+
+// postProcess runs the given post processors followed by the ones of the downloader
+// and moves the resulting file to destFile, keeping the extension of the result.
+func (dl *Downloader) postProcess(ctx context.Context, v *youtube.Video, files []string, destFile string, processors ...PostProcessor) error {
+	chain := append(PostProcessorChain(processors), dl.PostProcessors...)
+	outputs, err := chain.Process(ctx, v, files)
+	if err != nil {
+		return err
+	}
+	if len(outputs) != 1 {
+		return fmt.Errorf("post processing must result in a single file, got %d", len(outputs))
+	}
+
+	destFile = strings.TrimSuffix(destFile, filepath.Ext(destFile)) + filepath.Ext(outputs[0])
+	dl.logf("moving result to %s", destFile)
+
+	if err := moveFile(outputs[0], destFile); err != nil {
+		return err
+	}
+	if dl.Storage != nil {
+		return dl.storeFile(v, destFile)
+	}
+	dl.fileCompleted(v, destFile)
+	return nil
 }
 
 // DownloadWithHighQuality : Starting downloading video with high quality (>720p).
+// The quality is a high quality like "hd1080" or an alias like "best" or "1080p60", see youtube.FormatList.FilterQuality.
+// Its video format is merged with the best audio format, formats which already have audio are downloaded as they are.
 func (dl *Downloader) DownloadWithHighQuality(ctx context.Context, outputFile string, v *youtube.Video, quality string) error {
-	var videoFormat, audioFormat *youtube.Format
-
-	switch quality {
-	case "hdr2060":
-		videoFormat = v.Formats.FindByItag(401)
-		audioFormat = v.Formats.FindByItag(140)
-	case "hdr1080":
-		videoFormat = v.Formats.FindByItag(399)
-		audioFormat = v.Formats.FindByItag(140)
-	case "hd1080":
-		videoFormat = v.Formats.FindByItag(137)
-		audioFormat = v.Formats.FindByItag(140)
-	case "hdr720":
-		videoFormat = v.Formats.FindByItag(398)
-		audioFormat = v.Formats.FindByItag(140)
-	case "hd720":
-		videoFormat = v.Formats.FindByItag(136)
-		audioFormat = v.Formats.FindByItag(140)
-	default:
+	ctx = youtube.WithDownloadKey(ctx, v.ID)
+	if quality == "" {
 		return fmt.Errorf("unknown quality: %s", quality)
 	}
+	videoFormat, err := v.GetFormat(youtube.FormatOptions{Quality: quality})
+	if err != nil {
+		return err
+	}
+	if videoFormat.AudioCodec() != "" {
+		return dl.Download(ctx, v, videoFormat, outputFile)
+	}
 
-	if videoFormat == nil {
-		return fmt.Errorf("no format video/mp4 for %s found", quality)
+	var audioFormats []*youtube.Format
+	if dl.AllAudioTracks {
+		audioFormats = selectAudioFormats(v.Formats, videoFormat, dl.AudioLanguage)
+	} else if audioFormat := selectAudioFormat(v.Formats, videoFormat, dl.AudioLanguage); audioFormat != nil {
+		audioFormats = []*youtube.Format{audioFormat}
+	}
+	if len(audioFormats) == 0 {
+		return fmt.Errorf("no audio format for %s found", quality)
 	}
-	if audioFormat == nil {
-		return fmt.Errorf("no format audio/mp4 for %s found", quality)
+
+	for _, audioFormat := range audioFormats {
+		dl.logf("Video '%s' - Quality '%s' - Video Codec '%s' - Audio Codec '%s'", v.Title, videoFormat.QualityLabel, videoFormat.MimeType, audioFormat.MimeType)
 	}
 
-	dl.logf("Video '%s' - Quality '%s' - Video Codec '%s' - Audio Codec '%s'", v.Title, videoFormat.QualityLabel, videoFormat.MimeType, audioFormat.MimeType)
+	requestedContainer := dl.MergeOutputFormat
+	if requestedContainer == "" && len(audioFormats) > 1 {
+		requestedContainer = ContainerMKV
+	}
+	container, err := mergeContainer(requestedContainer, append([]*youtube.Format{videoFormat}, audioFormats...)...)
+	if err != nil {
+		return err
+	}
 
 	destFile, err := dl.getOutputFile(v, videoFormat, outputFile)
 	if err != nil {
 		return err
 	}
-	outputDir := filepath.Dir(destFile)
+	// check the name of the merged file
+	destFile, err = dl.resolveOutput(v, strings.TrimSuffix(destFile, filepath.Ext(destFile))+"."+container)
+	if err != nil || destFile == "" {
+		return err
+	}
+	tempDir := dl.tempDir(destFile)
 
-	// Create temporary video file
-	videoFile, err := ioutil.TempFile(outputDir, "youtube_*.m4v")
+	var languages []string
+	for _, audioFormat := range audioFormats {
+		if audioFormat.AudioTrack != nil {
+			languages = append(languages, audioFormat.AudioTrack.Language())
+		}
+	}
+	if len(languages) != len(audioFormats) {
+		languages = nil
+	}
+
+	if dl.PipeMerge {
+		output, err := tempOutput(filepath.Join(tempDir, filepath.Base(destFile)), "."+container)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(output)
+
+		dl.logf("Downloading and merging video and audio into %s", container)
+		if err := dl.mergePiped(ctx, v, append([]*youtube.Format{videoFormat}, audioFormats...), languages, output); err != nil {
+			return err
+		}
+		return dl.postProcess(ctx, v, []string{output}, destFile)
+	}
+
+	dl.logf("Downloading video file...")
+	videoFile, err := dl.downloadToTempFile(ctx, tempDir, v, videoFormat)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(videoFile.Name())
+	defer os.Remove(videoFile)
+
+	files := []string{videoFile}
+	for _, audioFormat := range audioFormats {
+		dl.logf("Downloading audio file...")
+		audioFile, err := dl.downloadToTempFile(ctx, tempDir, v, audioFormat)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(audioFile)
+
+		files = append(files, audioFile)
+	}
+
+	dl.logf("merging video and audio into %s", container)
+
+	return dl.postProcess(ctx, v, files, destFile,
+		&MergeProcessor{FFmpeg: dl.ffmpeg(), Extension: "." + container, AudioLanguages: languages, Faststart: !dl.NoFaststart},
+	)
+}
+
+// downloadToTempFile downloads a format into a new temporary file in dir and returns its name
+func (dl *Downloader) downloadToTempFile(ctx context.Context, dir string, v *youtube.Video, format *youtube.Format) (string, error) {
+	file, err := ioutil.TempFile(dir, "youtube_*"+tempExtension(format))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := dl.videoDLWorker(ctx, file, v, format); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
 
-	// Create temporary audio file
-	audioFile, err := ioutil.TempFile(outputDir, "youtube_*.m4a")
+// DownloadSection : Starting download of a time window of the video.
+// Only the bytes up to the estimated end of the section are fetched when the server supports it,
+// the clip is then cut with ffmpeg.
+func (dl *Downloader) DownloadSection(ctx context.Context, v *youtube.Video, format *youtube.Format, outputFile string, section Section) error {
+	ctx = youtube.WithDownloadKey(ctx, v.ID)
+	dl.logf("Video '%s' - Quality '%s' - Codec '%s' - Section %s-%s", v.Title, format.QualityLabel, format.MimeType, section.From, section.To)
+	destFile, err := dl.getOutputFile(v, format, outputFile)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(audioFile.Name())
+	destFile, err = dl.resolveOutput(v, destFile)
+	if err != nil || destFile == "" {
+		return err
+	}
 
-	dl.logf("Downloading video file...")
-	err = dl.videoDLWorker(ctx, videoFile, v, videoFormat)
+	// Create temporary file for the partial stream
+	partFile, err := ioutil.TempFile(dl.tempDir(destFile), "youtube_*"+filepath.Ext(destFile))
 	if err != nil {
 		return err
 	}
+	defer os.Remove(partFile.Name())
 
-	dl.logf("Downloading audio file...")
-	err = dl.videoDLWorker(ctx, audioFile, v, audioFormat)
+	resp, err := dl.getSectionStream(ctx, v, format, section)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+
+	dl.logf("Downloading partial file...")
+	if err := dl.copyWithProgress(partFile, resp.Body, resp.ContentLength); err != nil {
+		return err
+	}
 
-	ffmpegVersionCmd := exec.Command("ffmpeg", "-y",
-		"-i", videoFile.Name(),
-		"-i", audioFile.Name(),
-		"-c", "copy", // Just copy without re-encoding
-		"-shortest", // Finish encoding when the shortest input stream ends
-		destFile,
-		"-loglevel", "warning",
+	dl.logf("cutting section to %s", destFile)
+	partFile.Close()
+
+	return dl.postProcess(ctx, v, []string{partFile.Name()}, destFile,
+		&TrimProcessor{FFmpeg: dl.ffmpeg(), Section: section},
 	)
-	ffmpegVersionCmd.Stderr = os.Stderr
-	ffmpegVersionCmd.Stdout = os.Stdout
-	dl.logf("merging video and audio to %s", destFile)
+}
 
-	return ffmpegVersionCmd.Run()
+// getSectionStream requests the stream up to the estimated end of the section,
+// falling back to the whole stream if the end cannot be estimated or ranges are not supported.
+func (dl *Downloader) getSectionStream(ctx context.Context, v *youtube.Video, format *youtube.Format, section Section) (*http.Response, error) {
+	if end, ok := estimateSectionEnd(v, format, section); ok {
+		resp, err := dl.GetStreamRange(ctx, v, format, 0, end)
+		if err != youtube.ErrRangeNotSupported {
+			return resp, err
+		}
+		dl.logf("range requests not supported, downloading the whole stream")
+	}
+
+	return dl.GetStreamContext(ctx, v, format)
 }
 
-func (dl *Downloader) videoDLWorker(ctx context.Context, out *os.File, video *youtube.Video, format *youtube.Format) error {
-	resp, err := dl.GetStreamContext(ctx, video, format)
+func (dl *Downloader) videoDLWorker(ctx context.Context, out io.Writer, video *youtube.Video, format *youtube.Format) error {
+	stream, size, err := dl.getStream(ctx, video, format)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	defer stream.Close()
+
+	return dl.copyWithProgress(out, stream, size)
+}
+
+func (dl *Downloader) copyWithProgress(out io.Writer, body io.Reader, size int64) error {
+	if dl.observer != nil {
+		dl.observer.streamStarted(size)
+		body = &observedReader{r: body, observer: dl.observer}
+	}
+	if dl.OnBytesDownloaded != nil {
+		body = &observedReader{r: body, observer: bytesObserver(dl.OnBytesDownloaded)}
+	}
+	if dl.Bandwidth != nil {
+		body = &throttledReader{r: body, schedule: dl.Bandwidth}
+	}
+
+	if dl.NoProgress || !isTerminal(os.Stdout) {
+		_, err := io.Copy(out, body)
+		return err
+	}
 
 	prog := &progress{
-		contentLength: float64(resp.ContentLength),
+		contentLength: float64(size),
 	}
 
 	// create progress bar
@@ -163,9 +467,9 @@ func (dl *Downloader) videoDLWorker(ctx context.Context, out *os.File, video *yo
 		),
 	)
 
-	reader := bar.ProxyReader(resp.Body)
+	reader := bar.ProxyReader(body)
 	mw := io.MultiWriter(out, prog)
-	_, err = io.Copy(mw, reader)
+	_, err := io.Copy(mw, reader)
 	if err != nil {
 		return err
 	}
@@ -174,8 +478,45 @@ func (dl *Downloader) videoDLWorker(ctx context.Context, out *os.File, video *yo
 	return nil
 }
 
+func (dl *Downloader) fileCompleted(v *youtube.Video, file string) {
+	if dl.WriteInfoJSON && dl.Storage == nil && v.ID != "" {
+		if err := writeInfoJSON(v, file); err != nil {
+			log.Printf("unable to write info.json of %s: %v", file, err)
+		}
+	}
+	if dl.OnFileCompleted != nil {
+		dl.OnFileCompleted(file)
+	}
+}
+
+// tempDir returns the directory for intermediate files of the output file
+func (dl *Downloader) tempDir(destFile string) string {
+	if dl.TempDir != "" {
+		return dl.TempDir
+	}
+	return filepath.Dir(destFile)
+}
+
 func (dl *Downloader) logf(format string, v ...interface{}) {
 	if dl.Debug {
 		log.Printf(format, v...)
 	}
 }
+
+// NeedsAudio checks whether the format selected by the quality has no audio and is to be merged with audio
+// by DownloadWithHighQuality, like the formats of "hd1080" or "best". Formats selected by itag are used as they are.
+func NeedsAudio(quality string, format *youtube.Format) bool {
+	if quality == "" || quality == youtube.QualityAudioOnly {
+		return false
+	}
+	if _, err := strconv.Atoi(quality); err == nil {
+		return false
+	}
+	return format.AudioCodec() == ""
+}
+
+// isTerminal checks whether the file is a character device like a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
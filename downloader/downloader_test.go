@@ -55,12 +55,12 @@ func TestYoutube_DownloadWithHighQualityFails(t *testing.T) {
 		{
 			name:    "video format not found",
 			formats: []youtube.Format{{ItagNo: 140}},
-			message: "no format video/mp4 for hd1080 found",
+			message: "no format found with quality hd1080",
 		},
 		{
 			name:    "audio format not found",
 			formats: []youtube.Format{{ItagNo: 137}},
-			message: "no format audio/mp4 for hd1080 found",
+			message: "no audio format for hd1080 found",
 		},
 	}
 	for _, tt := range tests {
@@ -1,8 +1,13 @@
 package downloader
 
 import (
+	"io"
 	"mime"
+	"os"
 	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 const defaultExtension = ".mov"
@@ -48,18 +53,99 @@ func pickIdealFileExtension(mediaType string) string {
 	return extensions[0]
 }
 
-func SanitizeFilename(fileName string) string {
+var (
+	invalidFilenameChars = regexp.MustCompile(`[:/<>\:"\\|?*\x00-\x1f]`)
+	whitespaces          = regexp.MustCompile(`\s+`)
+	reservedWindowsNames = regexp.MustCompile(`(?i)^(CON|PRN|AUX|NUL|COM[1-9]|LPT[1-9])(\..*)?$`)
+)
+
+// FilenameSanitizer turns titles into file names which are valid on all platforms.
+// The zero value strips invalid characters and has no length limit.
+type FilenameSanitizer struct {
+	// Replacement for invalid characters, they are removed if empty
+	Replacement string
+	// RestrictASCII replaces all non-ASCII characters, e.g. emoji and CJK
+	RestrictASCII bool
+	// MaxLength is the maximum length in bytes, 0 means no limit. Characters are never split.
+	MaxLength int
+}
+
+// Sanitize returns a valid file name for the given name
+func (s *FilenameSanitizer) Sanitize(fileName string) string {
 	// Characters not allowed on mac
 	//	:/
 	// Characters not allowed on linux
 	//	/
 	// Characters not allowed on windows
-	//	<>:"/\|?*
+	//	<>:"/\|?* and control characters
 
 	// Ref https://docs.microsoft.com/en-us/windows/win32/fileio/naming-a-file#naming-conventions
 
-	fileName = regexp.MustCompile(`[:/<>\:"\\|?*]`).ReplaceAllString(fileName, "")
-	fileName = regexp.MustCompile(`\s+`).ReplaceAllString(fileName, " ")
+	fileName = invalidFilenameChars.ReplaceAllLiteralString(fileName, s.Replacement)
+	fileName = whitespaces.ReplaceAllString(fileName, " ")
+
+	if s.RestrictASCII {
+		var b strings.Builder
+		for _, r := range fileName {
+			if r > unicode.MaxASCII {
+				b.WriteString(s.Replacement)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+		fileName = b.String()
+	}
+
+	if s.MaxLength > 0 && len(fileName) > s.MaxLength {
+		// don't split multi-byte characters
+		end := s.MaxLength
+		for end > 0 && !utf8.RuneStart(fileName[end]) {
+			end--
+		}
+		fileName = fileName[:end]
+	}
+
+	// Windows doesn't allow names ending with a dot or a space
+	fileName = strings.TrimRight(fileName, ". ")
+
+	if reservedWindowsNames.MatchString(fileName) {
+		fileName = "_" + fileName
+	}
 
 	return fileName
 }
+
+// SanitizeFilename removes characters which are not allowed in file names
+func SanitizeFilename(fileName string) string {
+	return (&FilenameSanitizer{}).Sanitize(fileName)
+}
+
+// moveFile renames a file, falling back to copying if the target is on another file system
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	in.Close()
+	return os.Remove(src)
+}
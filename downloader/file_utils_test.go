@@ -1,6 +1,9 @@
 package downloader
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -23,3 +26,53 @@ func TestSanitizeFilename(t *testing.T) {
 		t.Error("The common harmless symbols should remain valid")
 	}
 }
+
+func TestFilenameSanitizer(t *testing.T) {
+	tests := []struct {
+		name      string
+		sanitizer FilenameSanitizer
+		input     string
+		want      string
+	}{
+		{"replacement", FilenameSanitizer{Replacement: "_"}, "a/b:c", "a_b_c"},
+		{"control characters", FilenameSanitizer{}, "a\tb\x00c", "abc"},
+		{"trailing dots", FilenameSanitizer{}, "Hello... ", "Hello"},
+		{"reserved name", FilenameSanitizer{}, "CON", "_CON"},
+		{"reserved name with extension", FilenameSanitizer{}, "com1.part", "_com1.part"},
+		{"not reserved", FilenameSanitizer{}, "CONSOLE", "CONSOLE"},
+		{"restrict ascii", FilenameSanitizer{RestrictASCII: true, Replacement: "_"}, "日本 video", "__ video"},
+		{"max length", FilenameSanitizer{MaxLength: 5}, "abcdefgh", "abcde"},
+		{"max length utf8", FilenameSanitizer{MaxLength: 4}, "日本語", "日"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sanitizer.Sanitize(tt.input); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "move")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src.mp4")
+	dst := filepath.Join(dir, "dst.mp4")
+	if err := ioutil.WriteFile(src, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := moveFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("source file must be removed")
+	}
+	if data, err := ioutil.ReadFile(dst); err != nil || string(data) != "data" {
+		t.Errorf("unexpected content %q: %v", data, err)
+	}
+}
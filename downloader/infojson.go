@@ -0,0 +1,93 @@
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// infoJSONExtension is the extension of the metadata files written next to downloads, see Downloader.WriteInfoJSON
+const infoJSONExtension = ".info.json"
+
+// InfoJSON is the metadata of a downloaded video, written as sidecar file
+type InfoJSON struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Duration    float64   `json:"durationSeconds,omitempty"`
+	PublishDate time.Time `json:"publishDate,omitempty"`
+	URL         string    `json:"url"`
+	// Heatmap is the "most replayed" graph of the video
+	Heatmap []youtube.HeatmapPoint `json:"heatmap,omitempty"`
+}
+
+// infoJSONFile returns the name of the sidecar file of a download
+func infoJSONFile(file string) string {
+	return strings.TrimSuffix(file, filepath.Ext(file)) + infoJSONExtension
+}
+
+// writeInfoJSON writes the metadata of the video next to the downloaded file
+func writeInfoJSON(v *youtube.Video, file string) error {
+	data, err := json.MarshalIndent(InfoJSON{
+		ID:          v.ID,
+		Title:       v.Title,
+		Author:      v.Author,
+		Description: v.Description,
+		Duration:    v.Duration.Seconds(),
+		PublishDate: v.PublishDate,
+		URL:         "https://www.youtube.com/watch?v=" + v.ID,
+		Heatmap:     v.Heatmap,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(infoJSONFile(file), data, 0o644)
+}
+
+// readInfoJSONID returns the video ID of a sidecar file, or an empty string if it cannot be read
+func readInfoJSONID(file string) string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	var info InfoJSON
+	if json.Unmarshal(data, &info) != nil {
+		return ""
+	}
+	return info.ID
+}
+
+// findInfoJSON searches dir and its subdirectories for the sidecar file of the video ID
+func findInfoJSON(dir, id string) (string, error) {
+	var found string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) || os.IsPermission(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, infoJSONExtension) {
+			return nil
+		}
+		if readInfoJSONID(path) == id {
+			found = path
+			return errInfoJSONFound
+		}
+		return nil
+	})
+	if err == errInfoJSONFound {
+		err = nil
+	}
+	return found, err
+}
+
+// errInfoJSONFound stops walking the directory once the sidecar is found
+var errInfoJSONFound = errors.New("info.json found")
@@ -0,0 +1,118 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// ErrNotLive is returned by DownloadLive for videos which are not broadcasting
+var ErrNotLive = errors.New("video is not a live stream")
+
+// liveFormat names recordings of live streams, MPEG-TS stays playable if the recording is interrupted
+var liveFormat = &youtube.Format{MimeType: "video/mp2t"}
+
+// LiveChatRecord is a line of the live chat file written by DownloadLive
+type LiveChatRecord struct {
+	// Offset is the time of the message relative to the start of the recording,
+	// negative for messages sent before the recording started
+	Offset time.Duration `json:"-"`
+	*youtube.LiveChatMessage
+}
+
+// MarshalJSON writes the offset in milliseconds
+func (r LiveChatRecord) MarshalJSON() ([]byte, error) {
+	type message youtube.LiveChatMessage
+	return json.Marshal(struct {
+		OffsetMs int64 `json:"offsetMs"`
+		*message
+	}{r.Offset.Milliseconds(), (*message)(r.LiveChatMessage)})
+}
+
+// DownloadLive records a live stream through its HLS manifest with ffmpeg until the stream ends or ctx is done,
+// stopping with ctx keeps the recording. If LiveFromStart is set, the recording starts at the beginning
+// of the DVR window instead of the live edge and doesn't require ffmpeg. If WriteLiveChat is set, the live chat is captured alongside
+// into a JSON lines file named like the recording with the extension .live_chat.jsonl.
+func (dl *Downloader) DownloadLive(ctx context.Context, v *youtube.Video, outputFile string) error {
+	ctx = youtube.WithDownloadKey(ctx, v.ID)
+	if !v.IsLive || v.HLSManifestURL == "" {
+		return ErrNotLive
+	}
+	dl.logf("Recording live stream '%s'", v.Title)
+
+	destFile, err := dl.getOutputFile(v, liveFormat, outputFile)
+	if err != nil {
+		return err
+	}
+	destFile, err = dl.resolveOutput(v, destFile)
+	if err != nil || destFile == "" {
+		return err
+	}
+
+	recordCtx, stopChat := context.WithCancel(ctx)
+	defer stopChat()
+
+	start := time.Now()
+	var chatErr error
+	var wg sync.WaitGroup
+	if dl.WriteLiveChat {
+		chatFile := strings.TrimSuffix(destFile, filepath.Ext(destFile)) + ".live_chat.jsonl"
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chatErr = dl.writeLiveChat(recordCtx, v, chatFile, start)
+		}()
+	}
+
+	dl.logf("Recording to file=%s", destFile)
+	if dl.LiveFromStart {
+		err = dl.recordFromStart(ctx, v, destFile)
+	} else {
+		err = dl.ffmpeg().run(ctx, destFile, "-i", dl.RewriteStreamURL(v.HLSManifestURL), "-c", "copy", "-f", "mpegts")
+	}
+	// the chat continues until the stream has ended, which may be shortly after the recording
+	stopChat()
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		// ffmpeg has been killed, the recording up to that point is kept
+		if _, statErr := os.Stat(destFile); statErr != nil {
+			return ctx.Err()
+		}
+	} else if err != nil {
+		return err
+	}
+	if chatErr != nil && !errors.Is(chatErr, context.Canceled) {
+		dl.logf("live chat capture failed: %v", chatErr)
+	}
+
+	if dl.Storage != nil {
+		return dl.storeFile(v, destFile)
+	}
+	dl.fileCompleted(v, destFile)
+	return nil
+}
+
+// writeLiveChat captures the live chat into the file, with offsets relative to start
+func (dl *Downloader) writeLiveChat(ctx context.Context, v *youtube.Video, file string, start time.Time) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	encoder := json.NewEncoder(out)
+	return dl.LiveChat(ctx, v.ID, func(message *youtube.LiveChatMessage) error {
+		return encoder.Encode(LiveChatRecord{
+			Offset:          message.Time.Sub(start),
+			LiveChatMessage: message,
+		})
+	})
+}
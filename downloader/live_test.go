@@ -0,0 +1,67 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadLive_NotLive(t *testing.T) {
+	dl := &Downloader{OutputDir: t.TempDir()}
+	err := dl.DownloadLive(context.Background(), &youtube.Video{ID: "BaW_jenozKc", HLSManifestURL: "https://example.com/index.m3u8"}, "")
+	assert.Equal(t, ErrNotLive, err)
+}
+
+func TestLiveChatRecord_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(LiveChatRecord{
+		Offset:          -1500 * time.Millisecond,
+		LiveChatMessage: &youtube.LiveChatMessage{ID: "m1", Message: "hello"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"offsetMs":-1500`)
+	assert.Contains(t, string(data), `"id":"m1"`)
+	assert.Contains(t, string(data), `"message":"hello"`)
+}
+
+func TestDownloadLive_FromStart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/master.m3u8":
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=100000\nlow.m3u8\n#EXT-X-STREAM-INF:BANDWIDTH=900000\nhigh.m3u8\n")
+		case r.URL.Path == "/high.m3u8":
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:10\n#EXTINF:1,\n/seg/sq/10/file.ts\n#EXTINF:1,\n/seg/sq/11/file.ts\n#EXT-X-ENDLIST\n")
+		case strings.HasPrefix(r.URL.Path, "/seg/sq/"):
+			// the DVR window starts at segment 3
+			sq, _ := strconv.Atoi(strings.Split(r.URL.Path, "/")[3])
+			if sq < 3 || sq > 11 {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, "[%d]", sq)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dl := &Downloader{OutputDir: dir, LiveFromStart: true}
+	video := &youtube.Video{ID: "BaW_jenozKc", Title: "live", IsLive: true, HLSManifestURL: server.URL + "/master.m3u8"}
+	require.NoError(t, dl.DownloadLive(context.Background(), video, "live.ts"))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "live.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "[3][4][5][6][7][8][9][10][11]", string(data))
+}
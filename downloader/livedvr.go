@@ -0,0 +1,237 @@
+package downloader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// segmentSequencePattern matches the sequence number in the URLs of live stream segments
+var segmentSequencePattern = regexp.MustCompile(`/sq/(\d+)/`)
+
+// hlsMediaPlaylist is the part of an HLS media playlist needed to record a live stream
+type hlsMediaPlaylist struct {
+	sequence       int
+	targetDuration time.Duration
+	segments       []string
+	ended          bool
+}
+
+// recordFromStart writes the MPEG-TS segments of the live stream to destFile, starting with the oldest segment
+// of the DVR window and following the stream until it ends or ctx is done.
+// Segments before the current playlist are requested by their sequence number.
+func (dl *Downloader) recordFromStart(ctx context.Context, v *youtube.Video, destFile string) error {
+	playlistURL, err := dl.bestHLSVariant(ctx, v.HLSManifestURL)
+	if err != nil {
+		return err
+	}
+	playlist, err := dl.fetchMediaPlaylist(ctx, playlistURL)
+	if err != nil {
+		return err
+	}
+	if len(playlist.segments) == 0 || !segmentSequencePattern.MatchString(playlist.segments[0]) {
+		return fmt.Errorf("live stream has no numbered segments, DVR is not available")
+	}
+	template := playlist.segments[0]
+
+	out, err := os.Create(destFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	first, err := dl.firstAvailableSegment(ctx, template, playlist.sequence)
+	if err != nil {
+		return err
+	}
+	dl.logf("DVR window starts at segment %d, the live edge is at %d", first, playlist.sequence+len(playlist.segments)-1)
+
+	for sq := first; ; {
+		latest := playlist.sequence + len(playlist.segments) - 1
+		for ; sq <= latest; sq++ {
+			if err := dl.copySegment(ctx, out, segmentURL(template, sq)); err != nil {
+				return fmt.Errorf("segment %d: %w", sq, err)
+			}
+		}
+		if playlist.ended {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(playlist.targetDuration):
+		}
+		if playlist, err = dl.fetchMediaPlaylist(ctx, playlistURL); err != nil {
+			return err
+		}
+	}
+}
+
+// firstAvailableSegment finds the oldest segment of the DVR window with a binary search,
+// segments are available without gaps from the start of the window up to the live edge
+func (dl *Downloader) firstAvailableSegment(ctx context.Context, template string, live int) (int, error) {
+	low, high := 0, live
+	for low < high {
+		mid := (low + high) / 2
+		available, err := dl.segmentAvailable(ctx, segmentURL(template, mid))
+		if err != nil {
+			return 0, err
+		}
+		if available {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return low, nil
+}
+
+func (dl *Downloader) segmentAvailable(ctx context.Context, rawURL string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, dl.RewriteStreamURL(rawURL), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := dl.httpClient().Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+func (dl *Downloader) copySegment(ctx context.Context, out io.Writer, rawURL string) error {
+	body, err := dl.httpGet(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	_, err = io.Copy(out, body)
+	return err
+}
+
+// segmentURL replaces the sequence number of a segment URL
+func segmentURL(template string, sq int) string {
+	return segmentSequencePattern.ReplaceAllString(template, "/sq/"+strconv.Itoa(sq)+"/")
+}
+
+// bestHLSVariant returns the media playlist with the highest bandwidth of a master playlist
+func (dl *Downloader) bestHLSVariant(ctx context.Context, masterURL string) (string, error) {
+	data, err := dl.httpGetBytes(ctx, masterURL)
+	if err != nil {
+		return "", err
+	}
+
+	var best string
+	bestBandwidth := -1
+	bandwidth := -1
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			bandwidth = 0
+			for _, attr := range strings.Split(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"), ",") {
+				if strings.HasPrefix(attr, "BANDWIDTH=") {
+					bandwidth, _ = strconv.Atoi(strings.TrimPrefix(attr, "BANDWIDTH="))
+				}
+			}
+		case line != "" && !strings.HasPrefix(line, "#") && bandwidth >= 0:
+			if bandwidth > bestBandwidth {
+				best, bestBandwidth = resolveReference(masterURL, line), bandwidth
+			}
+			bandwidth = -1
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no variant found in HLS manifest")
+	}
+	return best, nil
+}
+
+func (dl *Downloader) fetchMediaPlaylist(ctx context.Context, playlistURL string) (*hlsMediaPlaylist, error) {
+	data, err := dl.httpGetBytes(ctx, playlistURL)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist := &hlsMediaPlaylist{targetDuration: 5 * time.Second}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			playlist.sequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			if seconds, _ := strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:")); seconds > 0 {
+				playlist.targetDuration = time.Duration(seconds) * time.Second
+			}
+		case line == "#EXT-X-ENDLIST":
+			playlist.ended = true
+		case line != "" && !strings.HasPrefix(line, "#"):
+			playlist.segments = append(playlist.segments, resolveReference(playlistURL, line))
+		}
+	}
+	return playlist, scanner.Err()
+}
+
+// resolveReference resolves a URI of a playlist relative to the playlist's URL
+func resolveReference(base, ref string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+func (dl *Downloader) httpClient() *http.Client {
+	if dl.HTTPClient != nil {
+		return dl.HTTPClient
+	}
+	if dl.Transport != nil {
+		return dl.Transport.HTTPClient()
+	}
+	return http.DefaultClient
+}
+
+func (dl *Downloader) httpGet(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dl.RewriteStreamURL(rawURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := dl.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, youtube.ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func (dl *Downloader) httpGetBytes(ctx context.Context, rawURL string) ([]byte, error) {
+	body, err := dl.httpGet(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
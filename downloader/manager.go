@@ -0,0 +1,542 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// ErrJobNotFound is returned by the Manager for unknown job IDs
+var ErrJobNotFound = errors.New("job not found")
+
+// maxFinishedJobs limits the finished jobs kept by the Manager, the oldest are removed first
+const maxFinishedJobs = 100
+
+// JobState is the state of a download job
+type JobState int
+
+const (
+	JobQueued JobState = iota
+	JobRunning
+	JobPaused
+	JobDone
+	JobFailed
+	JobCanceled
+)
+
+func (s JobState) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobPaused:
+		return "paused"
+	case JobDone:
+		return "done"
+	case JobFailed:
+		return "failed"
+	case JobCanceled:
+		return "canceled"
+	default:
+		return fmt.Sprintf("JobState(%d)", int(s))
+	}
+}
+
+// finished checks whether the job will not change anymore
+func (s JobState) finished() bool {
+	return s == JobDone || s == JobFailed || s == JobCanceled
+}
+
+// JobOptions configure a single download of the Manager
+type JobOptions struct {
+	// Format selects the format, qualities like "hd1080" download and merge separate streams
+	Format youtube.FormatOptions
+	// OutputFile is the name of the file, defaults to the output template of the downloader
+	OutputFile string
+}
+
+// Progress is an update of a job sent to subscribers
+type Progress struct {
+	JobID          string
+	State          JobState
+	BytesCompleted int64
+	// BytesTotal grows when downloads of several streams are merged
+	BytesTotal int64
+	// Stats are the throughput measurements of chunked downloads, see Downloader.Chunks
+	Stats *TransferStats
+	Err   error
+}
+
+// Job is a download managed by the Manager
+type Job struct {
+	ID       string
+	VideoURL string
+	Options  JobOptions
+
+	manager *Manager
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu       sync.Mutex
+	paused   bool
+	resumed  chan struct{}
+	progress Progress
+}
+
+// Progress returns the current progress of the job
+func (j *Job) Progress() Progress {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.progress
+}
+
+// State returns the current state of the job
+func (j *Job) State() JobState {
+	return j.Progress().State
+}
+
+func (j *Job) setState(state JobState, err error) {
+	j.mu.Lock()
+	j.progress.State, j.progress.Err = state, err
+	p := j.progress
+	j.mu.Unlock()
+
+	j.manager.publish(p, true)
+}
+
+// streamStarted implements streamObserver
+func (j *Job) streamStarted(size int64) {
+	j.mu.Lock()
+	if size > 0 {
+		j.progress.BytesTotal += size
+	}
+	p := j.progress
+	j.mu.Unlock()
+
+	j.manager.publish(p, false)
+}
+
+// transferStats implements statsObserver
+func (j *Job) transferStats(stats TransferStats) {
+	j.mu.Lock()
+	j.progress.Stats = &stats
+	p := j.progress
+	j.mu.Unlock()
+
+	j.manager.publish(p, false)
+}
+
+// streamRead implements streamObserver, it blocks while the job is paused
+func (j *Job) streamRead(n int) error {
+	j.mu.Lock()
+	j.progress.BytesCompleted += int64(n)
+	p := j.progress
+	ctx, paused, resumed := j.ctx, j.paused, j.resumed
+	j.mu.Unlock()
+
+	j.manager.publish(p, false)
+
+	if paused {
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Manager downloads videos with a pool of workers.
+// Jobs can be paused, resumed and canceled, their progress is published to subscribers.
+type Manager struct {
+	downloader *Downloader
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*Job
+	jobs    map[string]*Job
+	nextID  int
+	closed  bool
+	wg      sync.WaitGroup
+
+	subscribersMu sync.Mutex
+	subscribers   map[*subscriber]bool
+}
+
+// subscriber receives the updates on ch. Updates which do not fit into ch are dropped,
+// except for state changes which are kept as latest update of their job until ch has room again.
+type subscriber struct {
+	ch chan Progress
+	// done is closed by unsubscribe, stopped is closed once deliver returned
+	done    chan struct{}
+	stopped chan struct{}
+	wake    chan struct{}
+
+	mu      sync.Mutex
+	backlog map[string]backlogEntry
+	// order lists the jobs of the backlog, oldest first
+	order []string
+	seq   uint64
+}
+
+type backlogEntry struct {
+	progress Progress
+	seq      uint64
+}
+
+// NewManager starts a manager with the given number of workers.
+// Every job uses its own copy of the downloader, as the client is not safe for concurrent use.
+func NewManager(dl *Downloader, workers int) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+
+	m := &Manager{
+		downloader:  dl,
+		jobs:        make(map[string]*Job),
+		subscribers: make(map[*subscriber]bool),
+	}
+	m.cond = sync.NewCond(&m.mu)
+
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go m.work()
+	}
+
+	return m
+}
+
+// Add queues the download of a video, subscribers receive its queued state
+func (m *Manager) Add(videoURL string, opts JobOptions) *Job {
+	m.mu.Lock()
+	m.nextID++
+	job := &Job{
+		ID:       strconv.Itoa(m.nextID),
+		VideoURL: videoURL,
+		Options:  opts,
+		manager:  m,
+		resumed:  make(chan struct{}),
+	}
+	job.progress.JobID = job.ID
+
+	m.jobs[job.ID] = job
+	m.pending = append(m.pending, job)
+	m.cond.Signal()
+	m.mu.Unlock()
+
+	// published without the lock, subscribers may look up the job
+	m.publish(job.Progress(), true)
+	return job
+}
+
+// Jobs returns the jobs in the order they were added.
+// Finished jobs are kept until more than 100 others have finished, they are not listed anymore then.
+func (m *Manager) Jobs() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		a, _ := strconv.Atoi(jobs[i].ID)
+		b, _ := strconv.Atoi(jobs[j].ID)
+		return a < b
+	})
+	return jobs
+}
+
+// Job returns the job with the given ID
+func (m *Manager) Job(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return job, nil
+}
+
+// Pause halts a job, a running download keeps its connection open but stops reading
+func (m *Manager) Pause(id string) error {
+	job, err := m.Job(id)
+	if err != nil {
+		return err
+	}
+
+	job.mu.Lock()
+	if job.progress.State.finished() || job.paused {
+		job.mu.Unlock()
+		return nil
+	}
+	job.paused = true
+	job.mu.Unlock()
+
+	job.setState(JobPaused, nil)
+	return nil
+}
+
+// Resume continues a paused job
+func (m *Manager) Resume(id string) error {
+	job, err := m.Job(id)
+	if err != nil {
+		return err
+	}
+
+	job.mu.Lock()
+	if !job.paused {
+		job.mu.Unlock()
+		return nil
+	}
+	job.paused = false
+	close(job.resumed)
+	job.resumed = make(chan struct{})
+	state := JobQueued
+	if job.cancel != nil {
+		state = JobRunning
+	}
+	job.mu.Unlock()
+
+	job.setState(state, nil)
+	return nil
+}
+
+// Cancel stops a job, partial files are removed
+func (m *Manager) Cancel(id string) error {
+	job, err := m.Job(id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	for i, pending := range m.pending {
+		if pending == job {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+			m.mu.Unlock()
+			job.setState(JobCanceled, context.Canceled)
+			m.prune()
+			return nil
+		}
+	}
+	m.mu.Unlock()
+
+	job.mu.Lock()
+	cancel := job.cancel
+	job.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving the progress of all jobs and a function to unsubscribe.
+// Byte counts are dropped if the channel is full. State changes are never dropped, but if the channel
+// stays full they are coalesced to the latest update of each job, so slow subscribers don't stall the jobs.
+func (m *Manager) Subscribe() (<-chan Progress, func()) {
+	sub := &subscriber{
+		ch:      make(chan Progress, 64),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		wake:    make(chan struct{}, 1),
+		backlog: make(map[string]backlogEntry),
+	}
+	go sub.deliver()
+
+	m.subscribersMu.Lock()
+	m.subscribers[sub] = true
+	m.subscribersMu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			close(sub.done)
+			<-sub.stopped
+
+			m.subscribersMu.Lock()
+			delete(m.subscribers, sub)
+			close(sub.ch)
+			m.subscribersMu.Unlock()
+		})
+	}
+}
+
+// publish sends the update to all subscribers without blocking
+func (m *Manager) publish(p Progress, important bool) {
+	m.subscribersMu.Lock()
+	defer m.subscribersMu.Unlock()
+
+	for sub := range m.subscribers {
+		sub.publish(p, important)
+	}
+}
+
+func (sub *subscriber) publish(p Progress, important bool) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	// updates must not overtake the backlog
+	if len(sub.backlog) == 0 {
+		select {
+		case sub.ch <- p:
+			return
+		default:
+		}
+		if !important {
+			return
+		}
+	}
+
+	if _, ok := sub.backlog[p.JobID]; !ok {
+		sub.order = append(sub.order, p.JobID)
+	}
+	sub.seq++
+	sub.backlog[p.JobID] = backlogEntry{progress: p, seq: sub.seq}
+
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the backlog once the subscriber reads again
+func (sub *subscriber) deliver() {
+	defer close(sub.stopped)
+
+	for {
+		select {
+		case <-sub.wake:
+		case <-sub.done:
+			return
+		}
+
+		for {
+			sub.mu.Lock()
+			if len(sub.order) == 0 {
+				sub.mu.Unlock()
+				break
+			}
+			jobID := sub.order[0]
+			entry := sub.backlog[jobID]
+			sub.mu.Unlock()
+
+			select {
+			case sub.ch <- entry.progress:
+			case <-sub.done:
+				return
+			}
+
+			// the entry stays in the backlog while it is sent, so newer updates of the job are queued behind it
+			sub.mu.Lock()
+			if sub.backlog[jobID].seq == entry.seq {
+				delete(sub.backlog, jobID)
+				sub.order = sub.order[1:]
+			}
+			sub.mu.Unlock()
+		}
+	}
+}
+
+// Close waits for all queued jobs to finish and stops the workers
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.closed = true
+	m.cond.Broadcast()
+	m.mu.Unlock()
+
+	m.wg.Wait()
+}
+
+func (m *Manager) work() {
+	defer m.wg.Done()
+
+	for {
+		m.mu.Lock()
+		for len(m.pending) == 0 && !m.closed {
+			m.cond.Wait()
+		}
+		if len(m.pending) == 0 {
+			m.mu.Unlock()
+			return
+		}
+		job := m.pending[0]
+		m.pending = m.pending[1:]
+		m.mu.Unlock()
+
+		m.run(job)
+	}
+}
+
+func (m *Manager) run(job *Job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	job.mu.Lock()
+	job.ctx, job.cancel = ctx, cancel
+	paused, resumed := job.paused, job.resumed
+	job.mu.Unlock()
+
+	// jobs paused while queued wait for being resumed
+	if paused {
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+		}
+	}
+
+	job.setState(JobRunning, nil)
+	err := m.download(ctx, job)
+	switch {
+	case ctx.Err() != nil:
+		job.setState(JobCanceled, ctx.Err())
+	case err != nil:
+		job.setState(JobFailed, err)
+	default:
+		job.setState(JobDone, nil)
+	}
+	m.prune()
+}
+
+// prune removes the oldest finished jobs beyond maxFinishedJobs
+func (m *Manager) prune() {
+	jobs := m.Jobs()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	finished := 0
+	for i := len(jobs) - 1; i >= 0; i-- {
+		if !jobs[i].State().finished() {
+			continue
+		}
+		if finished++; finished > maxFinishedJobs {
+			delete(m.jobs, jobs[i].ID)
+		}
+	}
+}
+
+func (m *Manager) download(ctx context.Context, job *Job) error {
+	dl := *m.downloader
+	dl.observer = job
+
+	video, err := dl.GetVideoContext(ctx, job.VideoURL)
+	if err != nil {
+		return err
+	}
+
+	format, err := video.GetFormat(job.Options.Format)
+	if err != nil {
+		return err
+	}
+	if quality := job.Options.Format.Quality; NeedsAudio(quality, format) {
+		return dl.DownloadWithHighQuality(ctx, job.Options.OutputFile, video, quality)
+	}
+	return dl.Download(ctx, video, format, job.Options.OutputFile)
+}
@@ -0,0 +1,144 @@
+package downloader
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// waitForState reads progress updates until the job reaches the state
+func waitForState(t *testing.T, updates <-chan Progress, jobID string, state JobState) Progress {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case p := <-updates:
+			if p.JobID == jobID && p.State == state {
+				return p
+			}
+		case <-timeout:
+			t.Fatalf("job %s did not reach state %s", jobID, state)
+		}
+	}
+}
+
+func TestManager_Failure(t *testing.T) {
+	dl := &Downloader{}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("offline")
+	})}
+
+	m := NewManager(dl, 2)
+	updates, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	job := m.Add("BaW_jenozKc", JobOptions{})
+	waitForState(t, updates, job.ID, JobQueued)
+	p := waitForState(t, updates, job.ID, JobFailed)
+	assert.Error(t, p.Err)
+	assert.Equal(t, JobFailed, job.State())
+
+	m.Close()
+}
+
+func TestManager_Cancel(t *testing.T) {
+	// requests block until canceled
+	dl := &Downloader{}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})}
+
+	m := NewManager(dl, 1)
+	updates, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	running := m.Add("BaW_jenozKc", JobOptions{})
+	waitForState(t, updates, running.ID, JobRunning)
+	queued := m.Add("XbNghLqsVwU", JobOptions{})
+
+	require.NoError(t, m.Pause(queued.ID))
+	waitForState(t, updates, queued.ID, JobPaused)
+	require.NoError(t, m.Cancel(queued.ID))
+	waitForState(t, updates, queued.ID, JobCanceled)
+
+	require.NoError(t, m.Cancel(running.ID))
+	waitForState(t, updates, running.ID, JobCanceled)
+
+	jobs := m.Jobs()
+	require.Len(t, jobs, 2)
+	assert.Equal(t, running.ID, jobs[0].ID)
+	assert.Equal(t, queued.ID, jobs[1].ID)
+
+	_, err := m.Job("unknown")
+	assert.True(t, errors.Is(err, ErrJobNotFound))
+
+	m.Close()
+}
+
+func TestManager_Prune(t *testing.T) {
+	dl := &Downloader{}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("offline")
+	})}
+
+	m := NewManager(dl, 1)
+	defer m.Close()
+
+	var jobs []*Job
+	for i := 0; i < maxFinishedJobs+2; i++ {
+		jobs = append(jobs, m.Add("BaW_jenozKc", JobOptions{}))
+	}
+
+	// the two oldest jobs are removed once the last one has finished
+	assert.Eventually(t, func() bool {
+		return len(m.Jobs()) == maxFinishedJobs
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, jobs[2:], m.Jobs())
+	_, err := m.Job(jobs[0].ID)
+	assert.True(t, errors.Is(err, ErrJobNotFound))
+}
+
+func TestManager_SlowSubscriber(t *testing.T) {
+	dl := &Downloader{}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("offline")
+	})}
+
+	m := NewManager(dl, 2)
+	defer m.Close()
+	updates, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	// the jobs finish although nobody reads the updates
+	var jobs []*Job
+	for i := 0; i < 150; i++ {
+		jobs = append(jobs, m.Add("BaW_jenozKc", JobOptions{}))
+	}
+	require.Eventually(t, func() bool {
+		return jobs[len(jobs)-1].State() == JobFailed
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the final state of every job is delivered
+	failed := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(failed) < len(jobs) {
+		select {
+		case p := <-updates:
+			if p.State == JobFailed {
+				failed[p.JobID] = true
+			}
+		case <-timeout:
+			t.Fatalf("received the failures of %d jobs", len(failed))
+		}
+	}
+}
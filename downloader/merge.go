@@ -0,0 +1,238 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// Containers supported for merged downloads
+const (
+	ContainerMKV  = "mkv"
+	ContainerMP4  = "mp4"
+	ContainerWebM = "webm"
+)
+
+// containerCodecs lists the codec families which can be stored in a container without re-encoding.
+// MKV accepts everything and is therefore not listed.
+var containerCodecs = map[string]map[string]bool{
+	ContainerMP4:  {"avc1": true, "av01": true, "hev1": true, "hvc1": true, "mp4a": true},
+	ContainerWebM: {"vp8": true, "vp9": true, "vp09": true, "av01": true, "opus": true, "vorbis": true},
+}
+
+// mergeContainer returns the container for merging the given formats.
+// If requested is empty, MP4 or WebM is picked when all codecs fit, MKV otherwise.
+func mergeContainer(requested string, formats ...*youtube.Format) (string, error) {
+	var codecs []string
+	for _, format := range formats {
+		codecs = append(codecs, format.Codecs()...)
+	}
+
+	switch requested {
+	case "":
+		for _, container := range []string{ContainerMP4, ContainerWebM} {
+			if containerSupports(container, codecs) {
+				return container, nil
+			}
+		}
+		return ContainerMKV, nil
+
+	case ContainerMKV:
+		return ContainerMKV, nil
+
+	case ContainerMP4, ContainerWebM:
+		if !containerSupports(requested, codecs) {
+			return "", fmt.Errorf("codecs %s cannot be merged into %s, use %s instead", strings.Join(codecs, ", "), requested, ContainerMKV)
+		}
+		return requested, nil
+
+	default:
+		return "", fmt.Errorf("unknown merge output format: %s", requested)
+	}
+}
+
+func containerSupports(container string, codecs []string) bool {
+	if len(codecs) == 0 {
+		return false
+	}
+
+	for _, codec := range codecs {
+		if !containerCodecs[container][youtube.CodecFamily(codec)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// selectAudioFormat picks the audio format to merge with the video format.
+// Formats of the requested language (or of the default track if no language is given) are preferred,
+// then formats sharing a container with the video, then the highest bitrate.
+func selectAudioFormat(formats youtube.FormatList, videoFormat *youtube.Format, language string) *youtube.Format {
+	videoCodecs := videoFormat.Codecs()
+
+	var best *youtube.Format
+	var bestScore int
+	for i := range formats {
+		format := &formats[i]
+		if format.AudioCodec() == "" || format.VideoCodec() != "" {
+			continue
+		}
+
+		score := 0
+		if matchesLanguage(format, language) {
+			score += 2
+		}
+		codecs := append(format.Codecs(), videoCodecs...)
+		if containerSupports(ContainerMP4, codecs) || containerSupports(ContainerWebM, codecs) {
+			score++
+		}
+
+		if best == nil || score > bestScore || (score == bestScore && format.Bitrate > best.Bitrate) {
+			best, bestScore = format, score
+		}
+	}
+
+	return best
+}
+
+// selectAudioFormats picks the best audio format for every audio track of a dubbed video.
+// The track matching the language, or the default track, comes first.
+func selectAudioFormats(formats youtube.FormatList, videoFormat *youtube.Format, language string) []*youtube.Format {
+	first := selectAudioFormat(formats, videoFormat, language)
+	if first == nil {
+		return nil
+	}
+
+	selected := []*youtube.Format{first}
+	for _, track := range formats.AudioTracks() {
+		if first.AudioTrack != nil && first.AudioTrack.ID == track.ID {
+			continue
+		}
+		if format := selectAudioFormat(formats, videoFormat, track.Language()); format != nil {
+			selected = append(selected, format)
+		}
+	}
+
+	return selected
+}
+
+// matchesLanguage checks the audio track of a format against a language like "en" or "de-DE".
+// Without a language, the default track matches.
+func matchesLanguage(format *youtube.Format, language string) bool {
+	track := format.AudioTrack
+	if language == "" {
+		return track == nil || track.IsDefault
+	}
+	if track == nil {
+		return false
+	}
+
+	trackLanguage := strings.ToLower(track.Language())
+	language = strings.ToLower(language)
+	return trackLanguage == language || strings.HasPrefix(trackLanguage, language+"-")
+}
+
+// tempExtension returns the extension for a temporary file holding a stream of the format
+func tempExtension(format *youtube.Format) string {
+	mediaType, _, _ := mime.ParseMediaType(format.MimeType)
+	switch mediaType {
+	case "audio/mp4":
+		return ".m4a"
+	case "audio/webm":
+		return ".weba"
+	case "video/mp4":
+		return ".m4v"
+	}
+	return pickIdealFileExtension(format.MimeType)
+}
+
+// mergePiped streams the formats directly into ffmpeg through pipes, so no temporary files are written for them.
+// The pipes are passed as additional file descriptors, which is not supported on Windows.
+func (dl *Downloader) mergePiped(ctx context.Context, v *youtube.Video, formats []*youtube.Format, audioLanguages []string, output string) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("piped merging is not supported on windows")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	readers := make([]*os.File, 0, len(formats))
+	writers := make([]*os.File, 0, len(formats))
+	closeAll := func(files []*os.File) {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+
+	inputs := make([]string, len(formats))
+	for i := range formats {
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeAll(readers)
+			closeAll(writers)
+			return err
+		}
+		readers = append(readers, r)
+		writers = append(writers, w)
+		// ExtraFiles start at file descriptor 3
+		inputs[i] = "pipe:" + strconv.Itoa(3+i)
+	}
+
+	var stderr bytes.Buffer
+	cmd := dl.ffmpeg().command(ctx, output, mergeArgs(inputs, audioLanguages, !dl.NoFaststart && isMP4(filepath.Ext(output)))...)
+	cmd.ExtraFiles = readers
+	cmd.Stderr = &stderr
+	err := cmd.Start()
+	// ffmpeg holds its own copies of the read ends
+	closeAll(readers)
+	if err != nil {
+		closeAll(writers)
+		return ffmpegError(err, output, &stderr)
+	}
+
+	// concurrent progress bars would garble each other
+	streamDL := *dl
+	streamDL.NoProgress = true
+
+	errs := make(chan error, len(formats))
+	for i, format := range formats {
+		go func(w *os.File, format *youtube.Format) {
+			err := streamDL.videoDLWorker(ctx, w, v, format)
+			w.Close()
+			// ffmpeg stops reading the longer streams as it merges up to the shortest one
+			if err != nil && !errors.Is(err, syscall.EPIPE) {
+				cancel()
+			}
+			errs <- err
+		}(writers[i], format)
+	}
+
+	var downloadErr error
+	for range formats {
+		if err := <-errs; err != nil && downloadErr == nil {
+			downloadErr = err
+		}
+	}
+	if err := ffmpegError(cmd.Wait(), output, &stderr); err != nil {
+		if downloadErr != nil && !errors.Is(downloadErr, syscall.EPIPE) {
+			return downloadErr
+		}
+		return err
+	}
+	if downloadErr != nil && !errors.Is(downloadErr, syscall.EPIPE) {
+		os.Remove(output)
+		return downloadErr
+	}
+	return nil
+}
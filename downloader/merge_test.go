@@ -0,0 +1,114 @@
+package downloader
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeContainer(t *testing.T) {
+	avc := &youtube.Format{MimeType: `video/mp4; codecs="avc1.640028"`}
+	vp9 := &youtube.Format{MimeType: `video/webm; codecs="vp9"`}
+	aac := &youtube.Format{MimeType: `audio/mp4; codecs="mp4a.40.2"`}
+	opus := &youtube.Format{MimeType: `audio/webm; codecs="opus"`}
+
+	tests := []struct {
+		name      string
+		requested string
+		formats   []*youtube.Format
+		expected  string
+		err       bool
+	}{
+		{"auto mp4", "", []*youtube.Format{avc, aac}, ContainerMP4, false},
+		{"auto webm", "", []*youtube.Format{vp9, opus}, ContainerWebM, false},
+		{"auto mkv", "", []*youtube.Format{vp9, aac}, ContainerMKV, false},
+		{"auto without codecs", "", []*youtube.Format{{}, {}}, ContainerMKV, false},
+		{"mkv", ContainerMKV, []*youtube.Format{avc, aac}, ContainerMKV, false},
+		{"incompatible mp4", ContainerMP4, []*youtube.Format{vp9, opus}, "", true},
+		{"incompatible webm", ContainerWebM, []*youtube.Format{avc, opus}, "", true},
+		{"unknown", "avi", []*youtube.Format{avc, aac}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container, err := mergeContainer(tt.requested, tt.formats...)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, container)
+		})
+	}
+}
+
+func TestSelectAudioFormat(t *testing.T) {
+	avc := &youtube.Format{ItagNo: 137, MimeType: `video/mp4; codecs="avc1.640028"`}
+	vp9 := &youtube.Format{ItagNo: 248, MimeType: `video/webm; codecs="vp9"`}
+
+	formats := youtube.FormatList{
+		*avc,
+		*vp9,
+		{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, Bitrate: 130000},
+		{ItagNo: 251, MimeType: `audio/webm; codecs="opus"`, Bitrate: 150000},
+		{ItagNo: 250, MimeType: `audio/webm; codecs="opus"`, Bitrate: 70000},
+	}
+
+	assert.Equal(t, 140, selectAudioFormat(formats, avc, "").ItagNo)
+	assert.Equal(t, 251, selectAudioFormat(formats, vp9, "").ItagNo)
+	assert.Nil(t, selectAudioFormat(formats[:2], avc, ""))
+
+	dubbed := youtube.FormatList{
+		{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, Bitrate: 130000, AudioTrack: &youtube.AudioTrack{ID: "en.4", IsDefault: true}},
+		{ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`, Bitrate: 120000, AudioTrack: &youtube.AudioTrack{ID: "de-DE.3"}},
+		{ItagNo: 251, MimeType: `audio/webm; codecs="opus"`, Bitrate: 150000, AudioTrack: &youtube.AudioTrack{ID: "de-DE.3"}},
+	}
+
+	assert.Equal(t, "en.4", selectAudioFormat(dubbed, avc, "").AudioTrack.ID)
+	assert.Equal(t, 120000, selectAudioFormat(dubbed, avc, "de").Bitrate)
+	assert.Equal(t, 251, selectAudioFormat(dubbed, vp9, "de-de").ItagNo)
+
+	selected := selectAudioFormats(dubbed, avc, "de")
+	if assert.Len(t, selected, 2) {
+		assert.Equal(t, "de-DE.3", selected[0].AudioTrack.ID)
+		assert.Equal(t, "en.4", selected[1].AudioTrack.ID)
+	}
+}
+
+func TestMergePiped(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("piped merging is not supported on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "merge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the fake ffmpeg concatenates both pipes into the output, which is the last argument
+	script := filepath.Join(dir, "ffmpeg")
+	require.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\nfor last; do :; done\ncat /dev/fd/3 /dev/fd/4 > \"$last\"\n"), 0o755))
+
+	dl := &Downloader{FFmpegPath: script, NoProgress: true}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(req.URL.Path)),
+		}, nil
+	})}
+
+	formats := []*youtube.Format{{URL: "http://example.com/video"}, {URL: "http://example.com/audio"}}
+	output := filepath.Join(dir, "merged.mkv")
+	require.NoError(t, dl.mergePiped(context.Background(), &youtube.Video{}, formats, nil, output))
+
+	data, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "/video/audio", string(data))
+}
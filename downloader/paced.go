@@ -0,0 +1,163 @@
+package downloader
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// defaultPacedChunkSize is the size of the ranges requested by the web player
+const defaultPacedChunkSize = 10 << 20
+
+// PacingConfig enables downloading streams in sequential ranges like the web player, see Downloader.Pacing.
+// Some CDNs throttle long running requests mid-stream, but not a player which keeps requesting the next range.
+type PacingConfig struct {
+	// ChunkSize is the size of a range in bytes, defaults to 10 MiB
+	ChunkSize int64
+}
+
+func (c PacingConfig) withDefaults() PacingConfig {
+	if c.ChunkSize <= 0 {
+		c.ChunkSize = defaultPacedChunkSize
+	}
+	return c
+}
+
+// pacedReader requests a stream range by range, each request numbered with rn and
+// reporting the buffered milliseconds with rbuf as the web player does
+type pacedReader struct {
+	ctx    context.Context
+	dl     *Downloader
+	video  *youtube.Video
+	format *youtube.Format
+	size   int64
+	config PacingConfig
+
+	body     io.ReadCloser
+	offset   int64
+	end      int64 // end of the current range, exclusive
+	requests int
+	started  time.Time
+	retries  int
+}
+
+func (dl *Downloader) newPacedReader(ctx context.Context, v *youtube.Video, format *youtube.Format, size int64) *pacedReader {
+	retries := dl.StreamRetries
+	if retries == 0 {
+		retries = defaultStreamRetries
+	}
+	return &pacedReader{
+		ctx:     ctx,
+		dl:      dl,
+		video:   v,
+		format:  format,
+		size:    size,
+		config:  dl.Pacing.withDefaults(),
+		started: time.Now(),
+		retries: retries,
+	}
+}
+
+func (r *pacedReader) Read(p []byte) (int, error) {
+	for {
+		if r.body == nil {
+			if r.offset >= r.size {
+				return 0, io.EOF
+			}
+			if err := r.open(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if err == nil {
+			return n, nil
+		}
+
+		r.body.Close()
+		r.body = nil
+		if err == io.EOF {
+			if r.offset >= r.end {
+				if n > 0 {
+					return n, nil
+				}
+				continue
+			}
+			err = io.ErrUnexpectedEOF
+		}
+
+		if r.retries <= 0 || r.ctx.Err() != nil {
+			return n, err
+		}
+		r.retries--
+		r.dl.logf("range interrupted at %d of %d bytes: %v", r.offset, r.size, err)
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (r *pacedReader) Close() error {
+	if r.body != nil {
+		return r.body.Close()
+	}
+	return nil
+}
+
+// open requests the next range, refreshing the format on 403 Forbidden
+func (r *pacedReader) open() error {
+	r.end = r.offset + r.config.ChunkSize
+	if r.end > r.size {
+		r.end = r.size
+	}
+
+	resp, err := r.dl.GetStreamRange(r.ctx, r.video, r.nextRequest(), r.offset, r.end-1)
+	if isForbidden(err) && r.retries > 0 {
+		r.retries--
+		var format *youtube.Format
+		if format, err = r.dl.refreshFormat(r.ctx, r.video, r.format); err == nil {
+			r.format = format
+			resp, err = r.dl.GetStreamRange(r.ctx, r.video, r.nextRequest(), r.offset, r.end-1)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	r.body = resp.Body
+	return nil
+}
+
+// nextRequest returns a copy of the format with the rn and rbuf parameters of the next request
+func (r *pacedReader) nextRequest() *youtube.Format {
+	r.requests++
+
+	params := make(url.Values, len(r.format.QueryParams)+2)
+	for key, values := range r.format.QueryParams {
+		params[key] = values
+	}
+	params.Set("rn", strconv.Itoa(r.requests))
+	params.Set("rbuf", strconv.FormatInt(r.bufferedMillis(), 10))
+
+	format := *r.format
+	format.QueryParams = params
+	return &format
+}
+
+// bufferedMillis estimates how far the downloaded media is ahead of playing it since the start, 0 if unknown
+func (r *pacedReader) bufferedMillis() int64 {
+	duration, err := strconv.ParseInt(r.format.ApproxDurationMs, 10, 64)
+	if err != nil || duration <= 0 || r.size <= 0 {
+		return 0
+	}
+
+	buffered := r.offset*duration/r.size - time.Since(r.started).Milliseconds()
+	if buffered < 0 {
+		return 0
+	}
+	return buffered
+}
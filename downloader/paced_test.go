@@ -0,0 +1,75 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoDLWorker_Paced(t *testing.T) {
+	content := strings.Repeat("0123456789", 250)
+
+	var ranges, rns []string
+	dl := &Downloader{NoProgress: true, Pacing: &PacingConfig{ChunkSize: 1000}}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var start, end int
+		if _, err := fmt.Sscanf(req.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+			return nil, fmt.Errorf("range expected: %w", err)
+		}
+		ranges = append(ranges, req.Header.Get("Range"))
+		query := req.URL.Query()
+		rns = append(rns, query.Get("rn"))
+		assert.Equal(t, "yes", query.Get("keep"))
+		_, err := strconv.Atoi(query.Get("rbuf"))
+		assert.NoError(t, err)
+
+		body := ioutil.NopCloser(strings.NewReader(content[start : end+1]))
+		if len(ranges) == 2 {
+			// the second range breaks after 100 bytes
+			body = ioutil.NopCloser(&failingReader{strings.NewReader(content[start : start+100])})
+		}
+		return &http.Response{
+			StatusCode:    http.StatusPartialContent,
+			ContentLength: int64(end - start + 1),
+			Header:        http.Header{"Content-Range": {fmt.Sprintf("bytes %d-%d/%d", start, end, len(content))}},
+			Body:          body,
+		}, nil
+	})}
+
+	format := &youtube.Format{
+		URL:              "http://example.com/stream",
+		ContentLength:    strconv.Itoa(len(content)),
+		ApproxDurationMs: "60000",
+		QueryParams:      url.Values{"keep": {"yes"}},
+	}
+	var out bytes.Buffer
+	require.NoError(t, dl.videoDLWorker(context.Background(), &out, &youtube.Video{}, format))
+	assert.Equal(t, content, out.String())
+
+	assert.Equal(t, []string{"bytes=0-999", "bytes=1000-1999", "bytes=1100-2099", "bytes=2100-2499"}, ranges)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, rns)
+	assert.Equal(t, url.Values{"keep": {"yes"}}, format.QueryParams, "the format must not be modified")
+}
+
+func TestPacedReader_BufferedMillis(t *testing.T) {
+	r := &pacedReader{format: &youtube.Format{ApproxDurationMs: "100000"}, size: 1000, started: time.Now()}
+	assert.Equal(t, int64(0), r.bufferedMillis())
+
+	r.offset = 500
+	buffered := r.bufferedMillis()
+	assert.True(t, buffered > 0 && buffered <= 50000, "buffered: %d", buffered)
+
+	r.format = &youtube.Format{}
+	assert.Equal(t, int64(0), r.bufferedMillis())
+}
@@ -0,0 +1,86 @@
+package downloader
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PlaylistFileEntry is a downloaded file referenced by a playlist file
+type PlaylistFileEntry struct {
+	Title string
+	// File is the path of the file, usually relative to the playlist file
+	File string
+	// Duration is 0 if unknown
+	Duration time.Duration
+}
+
+// WriteM3U8 writes the entries as extended M3U playlist in UTF-8
+func WriteM3U8(w io.Writer, entries []PlaylistFileEntry) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("#EXTM3U\n")
+	for _, entry := range entries {
+		seconds := -1
+		if entry.Duration > 0 {
+			seconds = int(entry.Duration.Round(time.Second) / time.Second)
+		}
+		// line breaks would start a new entry
+		title := strings.NewReplacer("\r", " ", "\n", " ").Replace(entry.Title)
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n%s\n", seconds, title, entry.File)
+	}
+	return bw.Flush()
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Duration int64  `xml:"duration,omitempty"` // milliseconds
+}
+
+// WriteXSPF writes the entries as XSPF playlist with the given title
+func WriteXSPF(w io.Writer, title string, entries []PlaylistFileEntry) error {
+	playlist := xspfPlaylist{Version: "1", Title: title}
+	for _, entry := range entries {
+		playlist.Tracks = append(playlist.Tracks, xspfTrack{
+			Location: fileLocation(entry.File),
+			Title:    entry.Title,
+			Duration: entry.Duration.Milliseconds(),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(playlist); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// fileLocation returns the URI reference of a file path, relative paths stay relative
+func fileLocation(file string) string {
+	location := &url.URL{Path: filepath.ToSlash(file)}
+	if filepath.IsAbs(file) {
+		location.Scheme = "file"
+		if !strings.HasPrefix(location.Path, "/") {
+			// Windows paths like C:/Videos
+			location.Path = "/" + location.Path
+		}
+	}
+	return location.String()
+}
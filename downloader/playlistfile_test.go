@@ -0,0 +1,47 @@
+package downloader
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPlaylistFileEntries = []PlaylistFileEntry{
+	{Title: "First & best", File: "Lectures/01 First.mp4", Duration: 61500 * time.Millisecond},
+	{Title: "Second\nline", File: "Lectures/02 Second.mp4"},
+}
+
+func TestWriteM3U8(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteM3U8(&buf, testPlaylistFileEntries))
+	assert.Equal(t, `#EXTM3U
+#EXTINF:62,First & best
+Lectures/01 First.mp4
+#EXTINF:-1,Second line
+Lectures/02 Second.mp4
+`, buf.String())
+}
+
+func TestWriteXSPF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteXSPF(&buf, "Lectures", testPlaylistFileEntries))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<playlist xmlns="http://xspf.org/ns/0/" version="1">
+  <title>Lectures</title>
+  <trackList>
+    <track>
+      <location>Lectures/01%20First.mp4</location>
+      <title>First &amp; best</title>
+      <duration>61500</duration>
+    </track>
+    <track>
+      <location>Lectures/02%20Second.mp4</location>
+      <title>Second&#xA;line</title>
+    </track>
+  </trackList>
+</playlist>
+`, buf.String())
+}
@@ -0,0 +1,281 @@
+package downloader
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// podcastMimeType is the type of the AAC audio downloaded for podcast episodes
+const podcastMimeType = "audio/mp4"
+
+// PodcastChannel describes a podcast feed
+type PodcastChannel struct {
+	Title       string
+	Description string
+	Author      string
+	// Link is the web page of the podcast
+	Link string
+	// Image is the URL of the artwork
+	Image string
+}
+
+// PodcastEpisode is a downloaded file of a podcast feed
+type PodcastEpisode struct {
+	// GUID identifies the episode across feed updates, e.g. the video ID
+	GUID        string
+	Title       string
+	Description string
+	// URL of the audio file
+	URL         string
+	Size        int64
+	MimeType    string
+	Duration    time.Duration
+	PublishDate time.Time
+	// Link is the web page of the episode
+	Link string
+	// Image is the URL of the artwork of the episode
+	Image string
+}
+
+const itunesNamespace = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Itunes  string     `xml:"xmlns:itunes,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Author        string    `xml:"itunes:author,omitempty"`
+	Image         *rssImage `xml:"itunes:image"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link,omitempty"`
+	Description string       `xml:"description,omitempty"`
+	GUID        rssGUID      `xml:"guid"`
+	PubDate     string       `xml:"pubDate,omitempty"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+	Duration    string       `xml:"itunes:duration,omitempty"`
+	Image       *rssImage    `xml:"itunes:image"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// WritePodcastRSS writes the episodes as RSS 2.0 feed with the iTunes extensions podcast apps expect
+func WritePodcastRSS(w io.Writer, channel PodcastChannel, episodes []PodcastEpisode) error {
+	feed := rssFeed{
+		Version: "2.0",
+		Itunes:  itunesNamespace,
+		Channel: rssChannel{
+			Title:         channel.Title,
+			Link:          channel.Link,
+			Description:   channel.Description,
+			Author:        channel.Author,
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	if channel.Image != "" {
+		feed.Channel.Image = &rssImage{Href: channel.Image}
+	}
+
+	for _, episode := range episodes {
+		item := rssItem{
+			Title:       episode.Title,
+			Link:        episode.Link,
+			Description: episode.Description,
+			GUID:        rssGUID{Value: episode.GUID},
+			Enclosure:   rssEnclosure{URL: episode.URL, Length: episode.Size, Type: episode.MimeType},
+		}
+		if !episode.PublishDate.IsZero() {
+			item.PubDate = episode.PublishDate.UTC().Format(time.RFC1123Z)
+		}
+		if episode.Duration > 0 {
+			item.Duration = formatPodcastDuration(episode.Duration)
+		}
+		if episode.Image != "" {
+			item.Image = &rssImage{Href: episode.Image}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(feed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// formatPodcastDuration returns the duration as HH:MM:SS
+func formatPodcastDuration(d time.Duration) string {
+	seconds := int(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// DownloadPodcast downloads the AAC audio of the videos of the playlist into OutputDir and writes a podcast feed
+// referencing the files below baseURL, where the output directory is expected to be published.
+// The files are named by video ID, files of previous runs are kept and listed again.
+// maxItems limits the episodes to the first entries of the playlist, 0 includes all.
+func (dl *Downloader) DownloadPodcast(ctx context.Context, playlist *youtube.Playlist, baseURL, feedFile string, maxItems int) error {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL: %w", err)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+
+	dir := dl.OutputDir
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	channel := PodcastChannel{
+		Title:       playlist.Title,
+		Description: playlist.Title,
+		Author:      playlist.Author,
+		Link:        "https://www.youtube.com/playlist?list=" + playlist.ID,
+	}
+	var episodes []PodcastEpisode
+
+	it := playlist.Entries(ctx)
+	for maxItems <= 0 || len(episodes) < maxItems {
+		entry, err := it.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !entry.IsAvailable() {
+			dl.logf("Skipping unavailable video %s", entry.ID)
+			continue
+		}
+
+		v, err := dl.GetVideoContext(ctx, entry.ID)
+		var playability *youtube.ErrPlayabiltyStatus
+		if errors.As(err, &playability) {
+			dl.logf("Skipping %s: %v", entry.ID, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		episode, err := dl.downloadEpisode(ctx, v, dir, base)
+		if err != nil {
+			return err
+		}
+		if channel.Image == "" {
+			channel.Image = episode.Image
+		}
+		episodes = append(episodes, *episode)
+	}
+
+	if feedFile == "" {
+		feedFile = "feed.xml"
+	}
+	if !filepath.IsAbs(feedFile) {
+		feedFile = filepath.Join(dir, feedFile)
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(feedFile), "youtube_*.xml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := WritePodcastRSS(file, channel, episodes); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	dl.logf("Writing feed with %d episodes to %s", len(episodes), feedFile)
+	return os.Rename(file.Name(), feedFile)
+}
+
+// downloadEpisode downloads the audio of the video unless a previous run did
+func (dl *Downloader) downloadEpisode(ctx context.Context, v *youtube.Video, dir string, base *url.URL) (*PodcastEpisode, error) {
+	name := v.ID + ".m4a"
+	file := filepath.Join(dir, name)
+
+	format := selectAudiobookFormat(v.Formats, "mp4a", dl.AudioLanguage)
+	if format == nil {
+		return nil, fmt.Errorf("%w: no AAC audio for %s", youtube.ErrFormatNotFound, v.ID)
+	}
+
+	if !fileExists(file) {
+		dl.logf("Downloading audio of '%s'", v.Title)
+		// the feed references the file by its name, nothing may rename or move it
+		episodeDL := *dl
+		episodeDL.OutputDir = dir
+		episodeDL.OnCollision = CollisionOverwrite
+		episodeDL.DedupByID = false
+		episodeDL.PostProcessors = nil
+		episodeDL.Storage = nil
+		if err := episodeDL.Download(ctx, v, format, name); err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	episode := &PodcastEpisode{
+		GUID:        v.ID,
+		Title:       v.Title,
+		Description: v.Description,
+		URL:         base.ResolveReference(&url.URL{Path: name}).String(),
+		Size:        info.Size(),
+		MimeType:    podcastMimeType,
+		Duration:    v.Duration,
+		PublishDate: v.PublishDate,
+		Link:        "https://www.youtube.com/watch?v=" + v.ID,
+	}
+	if thumbnail := v.Thumbnails.Best(); thumbnail != nil {
+		episode.Image = thumbnail.URL
+	}
+	return episode, nil
+}
@@ -0,0 +1,49 @@
+package downloader
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePodcastRSS(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WritePodcastRSS(&buf, PodcastChannel{
+		Title:  "Lectures",
+		Author: "Prof",
+		Link:   "https://www.youtube.com/playlist?list=PLqAfPOrmacr963ATEroh67fbvjmTzTEx5",
+		Image:  "https://i.ytimg.com/vi/BaW_jenozKc/maxresdefault.jpg",
+	}, []PodcastEpisode{{
+		GUID:        "BaW_jenozKc",
+		Title:       "First & best",
+		URL:         "https://files.example.com/BaW_jenozKc.m4a",
+		Size:        1234,
+		MimeType:    "audio/mp4",
+		Duration:    time.Hour + 2*time.Minute + 3500*time.Millisecond,
+		PublishDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+	}}))
+
+	feed := buf.String()
+	assert.Contains(t, feed, `<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">`)
+	assert.Contains(t, feed, `<itunes:author>Prof</itunes:author>`)
+	assert.Contains(t, feed, `<itunes:image href="https://i.ytimg.com/vi/BaW_jenozKc/maxresdefault.jpg"></itunes:image>`)
+	assert.Contains(t, feed, `<title>First &amp; best</title>`)
+	assert.Contains(t, feed, `<guid isPermaLink="false">BaW_jenozKc</guid>`)
+	assert.Contains(t, feed, `<pubDate>Mon, 02 Jan 2023 00:00:00 +0000</pubDate>`)
+	assert.Contains(t, feed, `<enclosure url="https://files.example.com/BaW_jenozKc.m4a" length="1234" type="audio/mp4"></enclosure>`)
+	assert.Contains(t, feed, `<itunes:duration>01:02:04</itunes:duration>`)
+
+	// the feed is well-formed
+	var parsed struct {
+		Items []struct {
+			Title string `xml:"title"`
+		} `xml:"channel>item"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &parsed))
+	require.Len(t, parsed.Items, 1)
+	assert.Equal(t, "First & best", parsed.Items[0].Title)
+}
@@ -0,0 +1,514 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// PostProcessor transforms the files produced by a download.
+// It receives the files of the previous step and returns the files for the next one.
+type PostProcessor interface {
+	Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error)
+}
+
+var (
+	_ PostProcessor = PostProcessorChain{}
+	_ PostProcessor = &MergeProcessor{}
+	_ PostProcessor = &TranscodeProcessor{}
+	_ PostProcessor = &AudioNormalizeProcessor{}
+	_ PostProcessor = &TrimProcessor{}
+	_ PostProcessor = &TagProcessor{}
+	_ PostProcessor = &ThumbnailEmbedProcessor{}
+	_ PostProcessor = &ExecProcessor{}
+)
+
+// PostProcessorChain runs post processors one after another.
+// Intermediate files are removed as soon as the next step has consumed them.
+type PostProcessorChain []PostProcessor
+
+// Process runs all post processors of the chain
+func (chain PostProcessorChain) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	inputs := make(map[string]bool, len(files))
+	for _, file := range files {
+		inputs[file] = true
+	}
+
+	current := files
+	for _, p := range chain {
+		next, err := p.Process(ctx, v, current)
+		removeIntermediates(current, next, inputs)
+		if err != nil {
+			removeIntermediates(next, nil, inputs)
+			return nil, err
+		}
+		current = next
+	}
+
+	return current, nil
+}
+
+// removeIntermediates removes files produced by a previous step which are not passed on
+func removeIntermediates(current, next []string, inputs map[string]bool) {
+	keep := make(map[string]bool, len(next))
+	for _, file := range next {
+		keep[file] = true
+	}
+	for _, file := range current {
+		if !inputs[file] && !keep[file] {
+			os.Remove(file)
+		}
+	}
+}
+
+// MergeProcessor merges all files into a single one without re-encoding
+type MergeProcessor struct {
+	FFmpeg
+	// Extension of the merged file, defaults to the extension of the first file
+	Extension string
+	// AudioLanguages are written as language tags of the audio streams, in input order
+	AudioLanguages []string
+	// Faststart moves the index of MP4 files to the front, so they can be played while downloading
+	Faststart bool
+}
+
+func (p *MergeProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("nothing to merge")
+	}
+
+	ext := p.Extension
+	if ext == "" {
+		ext = filepath.Ext(files[0])
+	}
+	output, err := tempOutput(files[0], ext)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{output}, p.run(ctx, output, mergeArgs(files, p.AudioLanguages, p.Faststart && isMP4(ext))...)
+}
+
+// isMP4 checks whether the extension belongs to the MP4 family of containers, which supports faststart
+func isMP4(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".mp4", ".m4a", ".m4b", ".m4v", ".mov":
+		return true
+	}
+	return false
+}
+
+// mergeArgs returns the ffmpeg arguments for merging the inputs without re-encoding
+func mergeArgs(inputs []string, audioLanguages []string, faststart bool) []string {
+	args := []string{"-y"}
+	for _, input := range inputs {
+		args = append(args, "-i", input)
+	}
+	// Keep the streams of all inputs, ffmpeg picks only one per type otherwise
+	for i := range inputs {
+		args = append(args, "-map", strconv.Itoa(i))
+	}
+	for i, language := range audioLanguages {
+		args = append(args, fmt.Sprintf("-metadata:s:a:%d", i), "language="+language)
+	}
+	if faststart {
+		args = append(args, "-movflags", "+faststart")
+	}
+	return append(args,
+		"-c", "copy", // Just copy without re-encoding
+		"-shortest", // Finish encoding when the shortest input stream ends
+	)
+}
+
+// TranscodeProcessor re-encodes every file with the given ffmpeg arguments
+type TranscodeProcessor struct {
+	FFmpeg
+	// Extension of the transcoded files, defaults to the extension of the input
+	Extension string
+	// Args are the ffmpeg output arguments, e.g. "-c:a", "libmp3lame"
+	Args []string
+}
+
+func (p *TranscodeProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	return forEachFile(files, p.Extension, func(input, output string) error {
+		args := append([]string{"-y", "-i", input}, p.Args...)
+		return p.run(ctx, output, args...)
+	})
+}
+
+// TranscodeProfile is a named set of ffmpeg arguments for a TranscodeProcessor
+type TranscodeProfile struct {
+	// Extension of the transcoded files, e.g. ".mp4"
+	Extension string
+	// Args are the ffmpeg output arguments
+	Args []string
+}
+
+// TranscodeProfiles are the built-in profiles, e.g. for players supporting only H.264
+var TranscodeProfiles = map[string]TranscodeProfile{
+	"h264-1080p": {
+		Extension: ".mp4",
+		Args: []string{
+			"-c:v", "libx264", "-preset", "medium", "-crf", "20", "-pix_fmt", "yuv420p",
+			"-vf", "scale=-2:'min(1080,ih)'",
+			"-c:a", "aac", "-b:a", "192k",
+			"-movflags", "+faststart",
+		},
+	},
+	"h264-720p": {
+		Extension: ".mp4",
+		Args: []string{
+			"-c:v", "libx264", "-preset", "medium", "-crf", "21", "-pix_fmt", "yuv420p",
+			"-vf", "scale=-2:'min(720,ih)'",
+			"-c:a", "aac", "-b:a", "160k",
+			"-movflags", "+faststart",
+		},
+	},
+	"webm-vp9": {
+		Extension: ".webm",
+		Args: []string{
+			"-c:v", "libvpx-vp9", "-crf", "32", "-b:v", "0", "-row-mt", "1",
+			"-c:a", "libopus", "-b:a", "128k",
+		},
+	},
+	"mp3": {
+		Extension: ".mp3",
+		Args:      []string{"-vn", "-c:a", "libmp3lame", "-q:a", "2"},
+	},
+}
+
+// Processor returns a TranscodeProcessor applying the profile
+func (p TranscodeProfile) Processor(ffmpeg FFmpeg) *TranscodeProcessor {
+	return &TranscodeProcessor{
+		FFmpeg:    ffmpeg,
+		Extension: p.Extension,
+		Args:      p.Args,
+	}
+}
+
+// AudioNormalizeProcessor normalizes the loudness of every file according to EBU R128 with the ffmpeg loudnorm filter.
+// Audio is re-encoded with the default encoder of the container, video streams are copied.
+type AudioNormalizeProcessor struct {
+	FFmpeg
+	// Integrated is the target loudness in LUFS, defaults to -16
+	Integrated float64
+	// TruePeak is the maximum true peak in dBTP, defaults to -1.5
+	TruePeak float64
+	// LoudnessRange is the target loudness range in LU, defaults to 11
+	LoudnessRange float64
+}
+
+func (p *AudioNormalizeProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	return forEachFile(files, "", func(input, output string) error {
+		return p.run(ctx, output, "-y",
+			"-i", input,
+			"-map", "0",
+			"-c", "copy",
+			"-af", p.filter(),
+			"-c:a", audioEncoder(filepath.Ext(output)),
+			"-ar", "48000", // loudnorm upsamples to 192 kHz otherwise
+		)
+	})
+}
+
+// filter returns the loudnorm filter with the targets of the processor
+func (p *AudioNormalizeProcessor) filter() string {
+	integrated, truePeak, loudnessRange := p.Integrated, p.TruePeak, p.LoudnessRange
+	if integrated == 0 {
+		integrated = -16
+	}
+	if truePeak == 0 {
+		truePeak = -1.5
+	}
+	if loudnessRange == 0 {
+		loudnessRange = 11
+	}
+	return "loudnorm=I=" + formatFilterValue(integrated) +
+		":TP=" + formatFilterValue(truePeak) +
+		":LRA=" + formatFilterValue(loudnessRange)
+}
+
+func formatFilterValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// audioEncoder returns the ffmpeg audio encoder fitting the container of the extension
+func audioEncoder(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".webm", ".ogg", ".opus", ".mkv":
+		return "libopus"
+	case ".mp3":
+		return "libmp3lame"
+	case ".flac":
+		return "flac"
+	default:
+		return "aac"
+	}
+}
+
+// TrimProcessor cuts every file to a section without re-encoding
+type TrimProcessor struct {
+	FFmpeg
+	Section Section
+}
+
+func (p *TrimProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	return forEachFile(files, "", func(input, output string) error {
+		return p.run(ctx, output, "-y",
+			"-ss", formatFFmpegDuration(p.Section.From),
+			"-i", input,
+			"-t", formatFFmpegDuration(p.Section.Duration()),
+			"-c", "copy",
+		)
+	})
+}
+
+// TagProcessor writes metadata tags into every file
+type TagProcessor struct {
+	FFmpeg
+	// Metadata to write, defaults to title, artist and comment of the video or the music metadata of tracks
+	Metadata map[string]string
+}
+
+func (p *TagProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	metadata := p.Metadata
+	if metadata == nil {
+		metadata = defaultTags(v)
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return forEachFile(files, "", func(input, output string) error {
+		args := []string{"-y", "-i", input, "-map", "0", "-c", "copy"}
+		for _, key := range keys {
+			args = append(args, "-metadata", key+"="+metadata[key])
+		}
+		return p.run(ctx, output, args...)
+	})
+}
+
+// defaultTags returns title, artist and comment of the video, tracks of YouTube Music are tagged with their
+// track title, artists, album, track number and release date instead
+func defaultTags(v *youtube.Video) map[string]string {
+	if v.Music == nil {
+		return map[string]string{
+			"title":   v.Title,
+			"artist":  v.Author,
+			"comment": v.Description,
+		}
+	}
+
+	tags := map[string]string{
+		"title":  v.Music.Track,
+		"artist": v.Music.Artist(),
+		"album":  v.Music.Album,
+	}
+	if v.Music.TrackNumber > 0 {
+		tags["track"] = strconv.Itoa(v.Music.TrackNumber)
+	}
+	if !v.Music.ReleaseDate.IsZero() {
+		tags["date"] = v.Music.ReleaseDate.Format("2006-01-02")
+	}
+	return tags
+}
+
+// ThumbnailEmbedProcessor embeds the largest thumbnail of the video as cover art
+type ThumbnailEmbedProcessor struct {
+	FFmpeg
+	// HTTPClient is used to fetch the thumbnail, defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+func (p *ThumbnailEmbedProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	thumbnail := v.Thumbnails.Best()
+	if thumbnail == nil || len(files) == 0 {
+		return files, nil
+	}
+
+	thumbnailFile, err := p.fetch(ctx, thumbnail.URL, filepath.Dir(files[0]))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(thumbnailFile)
+
+	return forEachFile(files, "", func(input, output string) error {
+		return p.run(ctx, output, "-y",
+			"-i", input,
+			"-i", thumbnailFile,
+			"-map", "0", "-map", "1",
+			"-c", "copy",
+			"-disposition:v:1", "attached_pic",
+		)
+	})
+}
+
+func (p *ThumbnailEmbedProcessor) fetch(ctx context.Context, url, dir string) (string, error) {
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", youtube.ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+
+	file, err := ioutil.TempFile(dir, "youtube_*.jpg")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// ExecProcessor runs a command for every file, "{}" in the arguments is replaced by the file path.
+// The files are passed on unchanged.
+type ExecProcessor struct {
+	Command string
+	Args    []string
+}
+
+func (p *ExecProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	for _, file := range files {
+		args := make([]string, len(p.Args))
+		for i, arg := range p.Args {
+			args[i] = strings.ReplaceAll(arg, "{}", file)
+		}
+
+		cmd := exec.CommandContext(ctx, p.Command, args...)
+		cmd.Stderr = os.Stderr
+		cmd.Stdout = os.Stdout
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("exec %s: %w", p.Command, err)
+		}
+	}
+
+	return files, nil
+}
+
+// forEachFile calls fn for each file with a new output file next to it
+func forEachFile(files []string, ext string, fn func(input, output string) error) ([]string, error) {
+	outputs := make([]string, 0, len(files))
+	for _, input := range files {
+		fileExt := ext
+		if fileExt == "" {
+			fileExt = filepath.Ext(input)
+		}
+
+		output, err := tempOutput(input, fileExt)
+		if err != nil {
+			return outputs, err
+		}
+		outputs = append(outputs, output)
+
+		if err := fn(input, output); err != nil {
+			return outputs, err
+		}
+	}
+
+	return outputs, nil
+}
+
+// tempOutput creates an empty file with the given extension next to the input file
+func tempOutput(input, ext string) (string, error) {
+	file, err := ioutil.TempFile(filepath.Dir(input), "youtube_*"+ext)
+	if err != nil {
+		return "", err
+	}
+	file.Close()
+
+	return file.Name(), nil
+}
+
+func formatFFmpegDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// FFmpeg configures how ffmpeg is invoked by the post processors
+type FFmpeg struct {
+	// Path of the ffmpeg binary, defaults to "ffmpeg" looked up in PATH
+	Path string
+	// ExtraArgs are passed to ffmpeg right before the output file
+	ExtraArgs []string
+}
+
+// run invokes ffmpeg with the given arguments followed by the extra arguments and the output file.
+// The output of ffmpeg is captured and returned as part of the error.
+func (f FFmpeg) run(ctx context.Context, output string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := f.command(ctx, output, args...)
+	cmd.Stderr = &stderr
+	return ffmpegError(cmd.Run(), output, &stderr)
+}
+
+// command prepares the invocation of ffmpeg without starting it
+func (f FFmpeg) command(ctx context.Context, output string, args ...string) *exec.Cmd {
+	path := f.Path
+	if path == "" {
+		path = "ffmpeg"
+	}
+
+	args = append(args, "-loglevel", "warning")
+	args = append(args, f.ExtraArgs...)
+	args = append(args, output)
+
+	return exec.CommandContext(ctx, path, args...)
+}
+
+// FFmpegError is returned if ffmpeg cannot be started or fails, Output holds the messages of ffmpeg
+type FFmpegError struct {
+	Err    error
+	Output string
+}
+
+func (err *FFmpegError) Error() string {
+	if err.Output != "" {
+		return fmt.Sprintf("ffmpeg: %v: %s", err.Err, err.Output)
+	}
+	return fmt.Sprintf("ffmpeg: %v", err.Err)
+}
+
+func (err *FFmpegError) Unwrap() error {
+	return err.Err
+}
+
+// ffmpegError removes the output of a failed invocation and adds the captured output to the error
+func ffmpegError(err error, output string, stderr *bytes.Buffer) error {
+	if err == nil {
+		return nil
+	}
+
+	os.Remove(output)
+	return &FFmpegError{Err: err, Output: strings.TrimSpace(stderr.String())}
+}
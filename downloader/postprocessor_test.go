@@ -0,0 +1,111 @@
+package downloader
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copyProcessor copies every file to a new one, like the ffmpeg based processors do
+type copyProcessor struct{}
+
+func (copyProcessor) Process(ctx context.Context, v *youtube.Video, files []string) ([]string, error) {
+	return forEachFile(files, ".out", func(input, output string) error {
+		data, err := ioutil.ReadFile(input)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(output, data, 0o644)
+	})
+}
+
+func TestPostProcessorChain(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "postprocessor")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.mp4")
+	require.NoError(ioutil.WriteFile(input, []byte("data"), 0o644))
+
+	chain := PostProcessorChain{copyProcessor{}, copyProcessor{}, copyProcessor{}}
+	outputs, err := chain.Process(context.Background(), &youtube.Video{}, []string{input})
+	require.NoError(err)
+	require.Len(outputs, 1)
+	assert.Equal(t, ".out", filepath.Ext(outputs[0]))
+
+	// only the input and the final output must remain
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(err)
+	assert.ElementsMatch(t, []string{input, outputs[0]}, files)
+}
+
+func TestFFmpeg_CapturesStderr(t *testing.T) {
+	ffmpeg := FFmpeg{Path: "sh"}
+	err := ffmpeg.run(context.Background(), filepath.Join(os.TempDir(), "nonexistent.mp4"), "-c", "echo boom >&2; exit 1")
+	assert.EqualError(t, err, "ffmpeg: exit status 1: boom")
+}
+
+func TestMergeArgs_Faststart(t *testing.T) {
+	args := mergeArgs([]string{"video.mp4", "audio.m4a"}, nil, true)
+	assert.Contains(t, strings.Join(args, " "), "-movflags +faststart")
+
+	args = mergeArgs([]string{"video.webm", "audio.webm"}, nil, false)
+	assert.NotContains(t, args, "-movflags")
+
+	assert.True(t, isMP4(".MP4"))
+	assert.True(t, isMP4(".m4a"))
+	assert.False(t, isMP4(".mkv"))
+}
+
+func TestTranscodeProfile_Processor(t *testing.T) {
+	for name, profile := range TranscodeProfiles {
+		assert.True(t, strings.HasPrefix(profile.Extension, "."), name)
+		assert.NotEmpty(t, profile.Args, name)
+	}
+
+	processor := TranscodeProfiles["h264-1080p"].Processor(FFmpeg{Path: "ffmpeg"})
+	assert.Equal(t, ".mp4", processor.Extension)
+	assert.Equal(t, "ffmpeg", processor.Path)
+	assert.Contains(t, processor.Args, "libx264")
+}
+
+func TestAudioNormalizeProcessor_Filter(t *testing.T) {
+	p := &AudioNormalizeProcessor{}
+	assert.Equal(t, "loudnorm=I=-16:TP=-1.5:LRA=11", p.filter())
+
+	p = &AudioNormalizeProcessor{Integrated: -23, TruePeak: -1, LoudnessRange: 7}
+	assert.Equal(t, "loudnorm=I=-23:TP=-1:LRA=7", p.filter())
+
+	assert.Equal(t, "libopus", audioEncoder(".webm"))
+	assert.Equal(t, "aac", audioEncoder(".m4a"))
+}
+
+func TestDefaultTags(t *testing.T) {
+	video := &youtube.Video{Title: "Artist - Song (Official Audio)", Author: "Artist - Topic", Description: "Provided to YouTube by ..."}
+	assert.Equal(t, map[string]string{"title": video.Title, "artist": video.Author, "comment": video.Description}, defaultTags(video))
+
+	video.Music = &youtube.MusicMetadata{
+		Track:       "Song",
+		Artists:     []string{"Artist", "Guest"},
+		Album:       "Album",
+		TrackNumber: 3,
+		ReleaseDate: time.Date(2019, 5, 17, 0, 0, 0, 0, time.UTC),
+	}
+	assert.Equal(t, map[string]string{
+		"title":  "Song",
+		"artist": "Artist, Guest",
+		"album":  "Album",
+		"track":  "3",
+		"date":   "2019-05-17",
+	}, defaultTags(video))
+}
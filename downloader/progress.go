@@ -1,5 +1,7 @@
 package downloader
 
+import "io"
+
 type progress struct {
 	contentLength     float64
 	totalWrittenBytes float64
@@ -15,3 +17,33 @@ func (dl *progress) Write(p []byte) (n int, err error) {
 	}
 	return
 }
+
+// streamObserver is notified about the progress of downloads
+type streamObserver interface {
+	streamStarted(size int64)
+	// streamRead may block, e.g. while a download is paused
+	streamRead(n int) error
+}
+
+// bytesObserver passes the reads of streams to Downloader.OnBytesDownloaded
+type bytesObserver func(n int)
+
+func (f bytesObserver) streamStarted(size int64) {}
+
+func (f bytesObserver) streamRead(n int) error {
+	f(n)
+	return nil
+}
+
+type observedReader struct {
+	r        io.Reader
+	observer streamObserver
+}
+
+func (o *observedReader) Read(p []byte) (int, error) {
+	n, err := o.r.Read(p)
+	if observerErr := o.observer.streamRead(n); observerErr != nil && err == nil {
+		err = observerErr
+	}
+	return n, err
+}
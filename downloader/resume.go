@@ -0,0 +1,157 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+const defaultStreamRetries = 3
+
+// resumingReader continues an interrupted stream from its last offset.
+// If the stream URL has expired, which YouTube answers with 403 Forbidden, the format is resolved again.
+type resumingReader struct {
+	ctx    context.Context
+	dl     *Downloader
+	video  *youtube.Video
+	format *youtube.Format
+
+	body    io.ReadCloser
+	offset  int64
+	size    int64
+	retries int
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+
+	switch {
+	case err == nil:
+		return n, nil
+	case err == io.EOF && (r.size <= 0 || r.offset >= r.size):
+		return n, io.EOF
+	case r.size <= 0 || r.retries <= 0 || r.ctx.Err() != nil:
+		// the stream cannot be resumed without knowing its size
+		return n, err
+	}
+
+	r.dl.logf("stream interrupted at %d of %d bytes: %v", r.offset, r.size, err)
+	r.body.Close()
+	if err := r.reopen(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+func (r *resumingReader) Close() error {
+	return r.body.Close()
+}
+
+// reopen requests the rest of the stream, refreshing the format on 403 Forbidden
+func (r *resumingReader) reopen() error {
+	for r.retries > 0 {
+		r.retries--
+
+		resp, err := r.dl.GetStreamRange(r.ctx, r.video, r.format, r.offset, r.size-1)
+		if isForbidden(err) {
+			if err = r.refreshFormat(); err == nil {
+				resp, err = r.dl.GetStreamRange(r.ctx, r.video, r.format, r.offset, r.size-1)
+			}
+		}
+		if err == nil {
+			r.body = resp.Body
+			return nil
+		}
+		if r.retries == 0 || r.ctx.Err() != nil {
+			return fmt.Errorf("unable to resume stream at byte %d: %w", r.offset, err)
+		}
+		r.dl.logf("resuming stream failed: %v", err)
+	}
+	return errors.New("stream retries exhausted")
+}
+
+// refreshFormat fetches the video again for a new URL of the format
+func (r *resumingReader) refreshFormat() error {
+	format, err := r.dl.refreshFormat(r.ctx, r.video, r.format)
+	if err != nil {
+		return err
+	}
+	r.format = format
+	return nil
+}
+
+// refreshFormat returns the format of a freshly fetched video with the same itag and audio track
+func (dl *Downloader) refreshFormat(ctx context.Context, v *youtube.Video, format *youtube.Format) (*youtube.Format, error) {
+	dl.logf("refreshing URL of itag %d", format.ItagNo)
+
+	fresh, err := dl.GetVideoContext(ctx, v.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range fresh.Formats {
+		candidate := &fresh.Formats[i]
+		if candidate.ItagNo != format.ItagNo {
+			continue
+		}
+		if format.AudioTrack != nil && (candidate.AudioTrack == nil || candidate.AudioTrack.ID != format.AudioTrack.ID) {
+			continue
+		}
+		return candidate, nil
+	}
+	return nil, fmt.Errorf("%w: itag %d", youtube.ErrFormatNotFound, format.ItagNo)
+}
+
+// getStream requests a stream and resolves the format again if its URL has expired
+func (dl *Downloader) getStream(ctx context.Context, v *youtube.Video, format *youtube.Format) (io.ReadCloser, int64, error) {
+	if dl.Chunks != nil {
+		if size, err := strconv.ParseInt(format.ContentLength, 10, 64); err == nil && size > 0 {
+			return dl.newChunkedReader(ctx, v, format, size), size, nil
+		}
+	}
+	if dl.Pacing != nil {
+		if size, err := strconv.ParseInt(format.ContentLength, 10, 64); err == nil && size > 0 {
+			return dl.newPacedReader(ctx, v, format, size), size, nil
+		}
+	}
+
+	retries := dl.StreamRetries
+	if retries == 0 {
+		retries = defaultStreamRetries
+	}
+
+	resp, err := dl.GetStreamContext(ctx, v, format)
+	if isForbidden(err) && retries > 0 {
+		retries--
+		if format, err = dl.refreshFormat(ctx, v, format); err == nil {
+			resp, err = dl.GetStreamContext(ctx, v, format)
+		}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if retries < 0 {
+		return resp.Body, resp.ContentLength, nil
+	}
+	return &resumingReader{
+		ctx:     ctx,
+		dl:      dl,
+		video:   v,
+		format:  format,
+		body:    resp.Body,
+		size:    resp.ContentLength,
+		retries: retries,
+	}, resp.ContentLength, nil
+}
+
+func isForbidden(err error) bool {
+	var statusErr youtube.ErrUnexpectedStatusCode
+	return errors.As(err, &statusErr) && int(statusErr) == http.StatusForbidden
+}
@@ -0,0 +1,101 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingReader returns an error after its data
+type failingReader struct {
+	r io.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func TestVideoDLWorker_Resume(t *testing.T) {
+	const content = "0123456789"
+
+	var ranges []string
+	dl := &Downloader{NoProgress: true}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rangeHeader := req.Header.Get("Range")
+		if rangeHeader == "" {
+			// the first response breaks after 4 bytes
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				ContentLength: int64(len(content)),
+				Body:          ioutil.NopCloser(&failingReader{strings.NewReader(content[:4])}),
+			}, nil
+		}
+
+		ranges = append(ranges, rangeHeader)
+		var start, end int
+		fmt.Sscanf(rangeHeader, "bytes=%d-%d", &start, &end)
+		return &http.Response{
+			StatusCode:    http.StatusPartialContent,
+			ContentLength: int64(end - start + 1),
+			Header:        http.Header{"Content-Range": {fmt.Sprintf("bytes %d-%d/%d", start, end, len(content))}},
+			Body:          ioutil.NopCloser(strings.NewReader(content[start : end+1])),
+		}, nil
+	})}
+
+	var out bytes.Buffer
+	err := dl.videoDLWorker(context.Background(), &out, &youtube.Video{}, &youtube.Format{URL: "http://example.com/stream"})
+	require.NoError(t, err)
+	assert.Equal(t, content, out.String())
+	assert.Equal(t, []string{"bytes=4-9"}, ranges)
+}
+
+func TestVideoDLWorker_ResumeDisabled(t *testing.T) {
+	dl := &Downloader{NoProgress: true, StreamRetries: -1}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			ContentLength: 10,
+			Body:          ioutil.NopCloser(&failingReader{strings.NewReader("0123")}),
+		}, nil
+	})}
+
+	var out bytes.Buffer
+	err := dl.videoDLWorker(context.Background(), &out, &youtube.Video{}, &youtube.Format{URL: "http://example.com/stream"})
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+func TestIsForbidden(t *testing.T) {
+	assert.True(t, isForbidden(fmt.Errorf("stream: %w", youtube.ErrUnexpectedStatusCode(http.StatusForbidden))))
+	assert.False(t, isForbidden(youtube.ErrUnexpectedStatusCode(http.StatusNotFound)))
+	assert.False(t, isForbidden(nil))
+}
+
+func TestDownload_RemovesPartialFile(t *testing.T) {
+	dl := &Downloader{OutputDir: t.TempDir(), NoProgress: true, StreamRetries: -1}
+	dl.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			ContentLength: 10,
+			Body:          ioutil.NopCloser(&failingReader{strings.NewReader("0123")}),
+		}, nil
+	})}
+
+	err := dl.Download(context.Background(), &youtube.Video{}, &youtube.Format{URL: "http://example.com/stream", MimeType: "video/mp4"}, "video.mp4")
+	require.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dl.OutputDir, "video.mp4"))
+}
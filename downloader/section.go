@@ -0,0 +1,102 @@
+package downloader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// sectionMargin is added to the estimated end of a section, as the bitrate of a stream is never constant
+const sectionMargin = 0.05
+
+// Section is a time window of a video
+type Section struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// ParseSection parses a time range like "*00:10:00-00:20:00".
+// Each timestamp may be given as [[HH:]MM:]SS.
+func ParseSection(s string) (Section, error) {
+	if !strings.HasPrefix(s, "*") {
+		return Section{}, fmt.Errorf("invalid section %q: time ranges must start with '*'", s)
+	}
+
+	parts := strings.SplitN(s[1:], "-", 2)
+	if len(parts) != 2 {
+		return Section{}, fmt.Errorf("invalid section %q: expected FROM-TO", s)
+	}
+
+	from, err := parseTimestamp(parts[0])
+	if err != nil {
+		return Section{}, fmt.Errorf("invalid section %q: %w", s, err)
+	}
+	to, err := parseTimestamp(parts[1])
+	if err != nil {
+		return Section{}, fmt.Errorf("invalid section %q: %w", s, err)
+	}
+	if to <= from {
+		return Section{}, fmt.Errorf("invalid section %q: end must be after start", s)
+	}
+
+	return Section{From: from, To: to}, nil
+}
+
+func parseTimestamp(s string) (time.Duration, error) {
+	fields := strings.Split(strings.TrimSpace(s), ":")
+	if len(fields) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	var d time.Duration
+	for i, field := range fields {
+		if i < len(fields)-1 {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid timestamp %q", s)
+			}
+			d = (d + time.Duration(n)) * 60
+			continue
+		}
+
+		seconds, err := strconv.ParseFloat(field, 64)
+		if err != nil || seconds < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		d = d*time.Second + time.Duration(seconds*float64(time.Second))
+	}
+
+	return d, nil
+}
+
+// Duration returns the length of the section
+func (s Section) Duration() time.Duration {
+	return s.To - s.From
+}
+
+// estimateSectionEnd maps the end of a section to a byte offset, assuming a constant bitrate.
+// It returns false if the format lacks the information required for the estimation.
+func estimateSectionEnd(v *youtube.Video, format *youtube.Format, section Section) (int64, bool) {
+	size, _ := strconv.ParseInt(format.ContentLength, 10, 64)
+	if size <= 0 {
+		return 0, false
+	}
+
+	duration := v.Duration
+	if ms, _ := strconv.ParseInt(format.ApproxDurationMs, 10, 64); ms > 0 {
+		duration = time.Duration(ms) * time.Millisecond
+	}
+	if duration <= 0 {
+		return 0, false
+	}
+
+	end := int64(float64(size) * (float64(section.To)/float64(duration) + sectionMargin))
+	if end >= size {
+		end = size - 1
+	}
+
+	return end, true
+}
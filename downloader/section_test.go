@@ -0,0 +1,53 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSection(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Section
+		err      bool
+	}{
+		{"*00:10:00-00:20:00", Section{10 * time.Minute, 20 * time.Minute}, false},
+		{"*1:30-2:00", Section{90 * time.Second, 2 * time.Minute}, false},
+		{"*5-7.5", Section{5 * time.Second, 7500 * time.Millisecond}, false},
+		{"00:10:00-00:20:00", Section{}, true},
+		{"*00:10:00", Section{}, true},
+		{"*00:20:00-00:10:00", Section{}, true},
+		{"*1:2:3:4-5", Section{}, true},
+		{"*a-b", Section{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			section, err := ParseSection(tt.input)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, section)
+		})
+	}
+}
+
+func TestEstimateSectionEnd(t *testing.T) {
+	video := &youtube.Video{Duration: 100 * time.Second}
+	section := Section{From: 10 * time.Second, To: 50 * time.Second}
+
+	end, ok := estimateSectionEnd(video, &youtube.Format{ContentLength: "1000"}, section)
+	assert.True(t, ok)
+	assert.Equal(t, int64(550), end)
+
+	end, ok = estimateSectionEnd(video, &youtube.Format{ContentLength: "1000", ApproxDurationMs: "50000"}, section)
+	assert.True(t, ok)
+	assert.Equal(t, int64(999), end)
+
+	_, ok = estimateSectionEnd(video, &youtube.Format{}, section)
+	assert.False(t, ok)
+}
@@ -0,0 +1,80 @@
+package downloader
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// Storage is the target of downloaded files.
+// Names are slash separated paths relative to the root of the storage.
+// Google Cloud Storage is written with an S3Storage through its interoperability endpoint.
+type Storage interface {
+	Create(name string) (io.WriteCloser, error)
+}
+
+var (
+	_ Storage = &LocalStorage{}
+	_ Storage = &S3Storage{}
+	_ Storage = &SFTPStorage{}
+)
+
+// LocalStorage writes files to a directory on the local disk
+type LocalStorage struct {
+	Dir string
+}
+
+// Create creates the file and its parent directories
+func (s *LocalStorage) Create(name string) (io.WriteCloser, error) {
+	path := filepath.Join(s.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// storeFile moves a local file into the storage. Merged and post-processed downloads are written
+// to the output directory by ffmpeg and the post processors, which need local files, and stored once complete.
+func (dl *Downloader) storeFile(v *youtube.Video, file string) error {
+	name := file
+	if dl.OutputDir != "" {
+		rel, err := filepath.Rel(dl.OutputDir, file)
+		if err != nil {
+			return err
+		}
+		name = rel
+	}
+
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file)
+	defer in.Close()
+
+	dl.logf("storing %s", name)
+	out, err := dl.Storage.Create(filepath.ToSlash(name))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		abortStorageWriter(out)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	dl.fileCompleted(v, filepath.ToSlash(name))
+	return nil
+}
+
+// abortStorageWriter discards a failed write, closing would commit a partial upload
+func abortStorageWriter(w io.WriteCloser) {
+	if a, ok := w.(interface{ abort() }); ok {
+		a.abort()
+		return
+	}
+	w.Close()
+}
@@ -0,0 +1,272 @@
+package downloader
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+const defaultS3PartSize = 8 << 20
+
+// S3Storage uploads files to an S3 compatible object storage.
+// Google Cloud Storage is supported through its interoperability endpoint with HMAC keys.
+// Files are uploaded in parts while downloading, no temporary files are written.
+type S3Storage struct {
+	// Endpoint like "https://s3.eu-west-1.amazonaws.com" or "https://storage.googleapis.com"
+	Endpoint string
+	// Region used for signing, e.g. "eu-west-1" or "auto" for Google Cloud Storage
+	Region string
+	Bucket string
+	// Prefix is prepended to all object names, e.g. "videos/"
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PartSize is the size of multipart upload parts, defaults to 8 MiB. S3 requires at least 5 MiB.
+	PartSize int
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// Create starts the upload of an object, it is completed by closing the writer
+func (s *S3Storage) Create(name string) (io.WriteCloser, error) {
+	partSize := s.PartSize
+	if partSize <= 0 {
+		partSize = defaultS3PartSize
+	}
+	return &s3Writer{storage: s, key: s.Prefix + name, partSize: partSize}, nil
+}
+
+// s3Writer buffers a part in memory, small files are uploaded with a single request
+type s3Writer struct {
+	storage  *S3Storage
+	key      string
+	partSize int
+
+	buf      bytes.Buffer
+	uploadID string
+	etags    []string
+	err      error
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, _ := w.buf.Write(p)
+	for w.buf.Len() >= w.partSize {
+		if w.err = w.uploadPart(w.buf.Next(w.partSize)); w.err != nil {
+			w.abort()
+			return n, w.err
+		}
+	}
+	return n, nil
+}
+
+func (w *s3Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.uploadID == "" {
+		_, err := w.storage.do(http.MethodPut, w.key, nil, w.buf.Bytes())
+		return err
+	}
+
+	if w.buf.Len() > 0 {
+		if err := w.uploadPart(w.buf.Bytes()); err != nil {
+			w.abort()
+			return err
+		}
+	}
+
+	var complete bytes.Buffer
+	complete.WriteString("<CompleteMultipartUpload>")
+	for i, etag := range w.etags {
+		fmt.Fprintf(&complete, "<Part><PartNumber>%d</PartNumber><ETag>%s</ETag></Part>", i+1, etag)
+	}
+	complete.WriteString("</CompleteMultipartUpload>")
+
+	if _, err := w.storage.do(http.MethodPost, w.key, url.Values{"uploadId": {w.uploadID}}, complete.Bytes()); err != nil {
+		w.abort()
+		return err
+	}
+	return nil
+}
+
+func (w *s3Writer) uploadPart(part []byte) error {
+	if w.uploadID == "" {
+		resp, err := w.storage.do(http.MethodPost, w.key, url.Values{"uploads": {""}}, nil)
+		if err != nil {
+			return err
+		}
+
+		var initiate struct {
+			UploadID string `xml:"UploadId"`
+		}
+		if err := xml.Unmarshal(resp, &initiate); err != nil {
+			return err
+		}
+		w.uploadID = initiate.UploadID
+	}
+
+	query := url.Values{
+		"partNumber": {fmt.Sprint(len(w.etags) + 1)},
+		"uploadId":   {w.uploadID},
+	}
+	req, err := w.storage.newRequest(http.MethodPut, w.key, query, part)
+	if err != nil {
+		return err
+	}
+	resp, err := w.storage.send(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	w.etags = append(w.etags, resp.Header.Get("ETag"))
+	return nil
+}
+
+// abort removes the parts of a failed upload
+func (w *s3Writer) abort() {
+	if w.uploadID != "" {
+		w.storage.do(http.MethodDelete, w.key, url.Values{"uploadId": {w.uploadID}}, nil) //nolint:errcheck
+	}
+}
+
+// do sends a signed request and returns the response body
+func (s *S3Storage) do(method, key string, query url.Values, body []byte) ([]byte, error) {
+	req, err := s.newRequest(method, key, query, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (s *S3Storage) send(req *http.Request) (*http.Response, error) {
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: %w: %s", req.Method, req.URL.Path, youtube.ErrUnexpectedStatusCode(resp.StatusCode), msg)
+	}
+	return resp, nil
+}
+
+// newRequest creates a path style request signed with AWS Signature Version 4
+func (s *S3Storage) newRequest(method, key string, query url.Values, body []byte) (*http.Request, error) {
+	u, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join("/", s.Bucket, key)
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	s.sign(req, body, time.Now().UTC())
+	return req, nil
+}
+
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, payloadHash, amzDate)
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// s3EscapePath encodes everything but unreserved characters and slashes
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func s3Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// s3CanonicalQuery sorts and encodes the query as required by the signature
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, s3Escape(key)+"="+s3Escape(query.Get(key)))
+	}
+	return strings.Join(parts, "&")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
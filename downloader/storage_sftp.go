@@ -0,0 +1,569 @@
+package downloader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// SFTP version 3 packet types, see draft-ietf-secsh-filexfer-02
+const (
+	sftpInit     = 1
+	sftpVersion  = 2
+	sftpOpen     = 3
+	sftpClose    = 4
+	sftpWrite    = 6
+	sftpRemove   = 13
+	sftpMkdir    = 14
+	sftpRename   = 18
+	sftpStat     = 17
+	sftpStatus   = 101
+	sftpHandle   = 102
+	sftpAttrs    = 105
+	sftpExtended = 200
+)
+
+const (
+	sftpOpenWrite    = 0x02
+	sftpOpenCreate   = 0x08
+	sftpOpenTruncate = 0x10
+
+	// attribute flags and the file type bits of the permissions
+	sftpAttrSize        = 0x01
+	sftpAttrUIDGID      = 0x02
+	sftpAttrPermissions = 0x04
+	sftpModeType        = 0o170000
+	sftpModeDir         = 0o040000
+
+	// sftpMaxWrite is the data of a write request, which all servers accept
+	sftpMaxWrite = 32 << 10
+	// sftpMaxInflight is the number of write requests sent before waiting for their status
+	sftpMaxInflight = 16
+	// sftpMaxPacket limits the size of packets received from the server
+	sftpMaxPacket = 256 << 10
+
+	// sftpPosixRename replaces existing files, plain renames of SFTP version 3 fail then
+	sftpPosixRename = "posix-rename@openssh.com"
+)
+
+// SFTPStorage uploads files over SFTP. The ssh command of the system opens the connection,
+// so authentication uses its keys, agent and ~/.ssh/config like the sftp command.
+// Files are written while downloading as name.part, which is renamed once complete.
+type SFTPStorage struct {
+	// Host is the destination of ssh, like "user@example.com" or a host of ~/.ssh/config
+	Host string
+	// Port of the SSH server, defaults to the one of the ssh configuration
+	Port int
+	// Dir holds the files on the server, relative to the home directory unless absolute
+	Dir string
+	// SSHPath is the ssh executable, defaults to "ssh"
+	SSHPath string
+	// SSHArgs are passed to ssh before the host, e.g. []string{"-i", "~/.ssh/backup"}
+	SSHArgs []string
+
+	// connect starts the SFTP subsystem, tests replace it with an in-memory server
+	connect func() (io.ReadWriteCloser, error)
+}
+
+// Create opens a connection for the file, it is uploaded by closing the writer
+func (s *SFTPStorage) Create(name string) (io.WriteCloser, error) {
+	connect := s.connect
+	if connect == nil {
+		connect = s.startSSH
+	}
+	rw, err := connect()
+	if err != nil {
+		return nil, fmt.Errorf("sftp: %w", err)
+	}
+
+	conn := &sftpConn{rw: rw, r: bufio.NewReader(rw)}
+	if err := conn.init(); err != nil {
+		rw.Close()
+		return nil, err
+	}
+
+	file := path.Join(s.Dir, name)
+	for _, dir := range parentDirs(path.Dir(file)) {
+		if err := conn.mkdir(dir); err != nil {
+			rw.Close()
+			return nil, fmt.Errorf("sftp: mkdir %s: %w", dir, err)
+		}
+	}
+
+	w := &sftpWriter{conn: conn, file: file, part: file + ".part"}
+	typ, payload, err := conn.call(sftpOpen, func(p *sftpPacket) {
+		p.string(w.part)
+		p.uint32(sftpOpenWrite | sftpOpenCreate | sftpOpenTruncate)
+		p.uint32(0) // no attributes
+	})
+	if err == nil {
+		w.handle, err = conn.handle(typ, payload)
+	}
+	if err != nil {
+		rw.Close()
+		return nil, fmt.Errorf("sftp: open %s: %w", w.part, err)
+	}
+	return w, nil
+}
+
+// sshArgs are the arguments of ssh to start the SFTP subsystem on the host
+func (s *SFTPStorage) sshArgs() []string {
+	args := append([]string(nil), s.SSHArgs...)
+	if s.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(s.Port))
+	}
+	return append(args, "-s", s.Host, "sftp")
+}
+
+func (s *SFTPStorage) startSSH() (io.ReadWriteCloser, error) {
+	sshPath := s.SSHPath
+	if sshPath == "" {
+		sshPath = "ssh"
+	}
+
+	sub := &sshSubsystem{cmd: exec.Command(sshPath, s.sshArgs()...)}
+	sub.cmd.Stderr = &sub.stderr
+	var err error
+	if sub.stdin, err = sub.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	if sub.stdout, err = sub.cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	if err := sub.cmd.Start(); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// sshSubsystem is the connection over the standard input and output of ssh
+type sshSubsystem struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.Reader
+	stderr bytes.Buffer
+
+	waited  bool
+	waitErr error
+}
+
+// Write returns the error of ssh if it exited before reading the request
+func (s *sshSubsystem) Write(p []byte) (int, error) {
+	n, err := s.stdin.Write(p)
+	if err != nil {
+		if exitErr := s.exitError(); exitErr != nil {
+			return n, exitErr
+		}
+	}
+	return n, err
+}
+
+// Read returns the error of ssh once it exited, like a failed authentication
+func (s *sshSubsystem) Read(p []byte) (int, error) {
+	n, err := s.stdout.Read(p)
+	if err == io.EOF {
+		if exitErr := s.exitError(); exitErr != nil {
+			return n, exitErr
+		}
+	}
+	return n, err
+}
+
+// exitError waits for ssh and returns why it failed with its error output
+func (s *sshSubsystem) exitError() error {
+	if err := s.wait(); err != nil {
+		return fmt.Errorf("ssh: %v: %s", err, strings.TrimSpace(s.stderr.String()))
+	}
+	return nil
+}
+
+// Close ends the session, ssh exits once its input is closed
+func (s *sshSubsystem) Close() error {
+	s.stdin.Close()
+	return s.wait()
+}
+
+func (s *sshSubsystem) wait() error {
+	if !s.waited {
+		s.waited = true
+		s.waitErr = s.cmd.Wait()
+	}
+	return s.waitErr
+}
+
+// parentDirs returns the directory and its parents, outermost first
+func parentDirs(dir string) []string {
+	var dirs []string
+	for ; dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+	return dirs
+}
+
+// sftpWriter writes a file, write requests are pipelined and their status checked later
+type sftpWriter struct {
+	conn   *sftpConn
+	file   string
+	part   string
+	handle string
+
+	offset uint64
+	// inflight are the IDs of the write requests without status, oldest first
+	inflight []uint32
+	err      error
+}
+
+func (w *sftpWriter) Write(data []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	written := 0
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > sftpMaxWrite {
+			chunk = chunk[:sftpMaxWrite]
+		}
+		id, err := w.conn.send(sftpWrite, func(p *sftpPacket) {
+			p.string(w.handle)
+			p.uint64(w.offset)
+			p.string(string(chunk))
+		})
+		if err != nil {
+			w.err = err
+			return written, w.err
+		}
+		w.inflight = append(w.inflight, id)
+		w.offset += uint64(len(chunk))
+		written += len(chunk)
+		data = data[len(chunk):]
+
+		if len(w.inflight) >= sftpMaxInflight {
+			if w.err = w.wait(); w.err != nil {
+				return written, w.err
+			}
+		}
+	}
+	return written, nil
+}
+
+// wait reads the status of the oldest write request
+func (w *sftpWriter) wait() error {
+	id := w.inflight[0]
+	w.inflight = w.inflight[1:]
+	typ, payload, err := w.conn.recv(id)
+	if err == nil {
+		err = w.conn.status(typ, payload)
+	}
+	if err != nil {
+		return fmt.Errorf("sftp: write %s: %w", w.part, err)
+	}
+	return nil
+}
+
+// Close waits for the pending writes and renames the file to its name
+func (w *sftpWriter) Close() error {
+	defer w.conn.rw.Close()
+
+	// responses of all writes have to be read before the next request
+	for len(w.inflight) > 0 {
+		if err := w.wait(); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+	if err := w.conn.simpleCall(sftpClose, func(p *sftpPacket) { p.string(w.handle) }); err != nil && w.err == nil {
+		w.err = fmt.Errorf("sftp: close %s: %w", w.part, err)
+	}
+	if w.err != nil {
+		w.conn.simpleCall(sftpRemove, func(p *sftpPacket) { p.string(w.part) })
+		return w.err
+	}
+
+	if err := w.conn.rename(w.part, w.file); err != nil {
+		w.conn.simpleCall(sftpRemove, func(p *sftpPacket) { p.string(w.part) })
+		return fmt.Errorf("sftp: rename %s: %w", w.part, err)
+	}
+	return nil
+}
+
+// abort discards the partial file
+func (w *sftpWriter) abort() {
+	if w.err == nil {
+		w.err = errors.New("sftp: upload aborted")
+	}
+	w.Close()
+}
+
+// sftpConn is a session of the SFTP protocol
+type sftpConn struct {
+	rw     io.ReadWriteCloser
+	r      *bufio.Reader
+	nextID uint32
+	// responses holds the responses read while waiting for another request, servers may answer in any order
+	responses map[uint32]sftpResponse
+	// extensions announced by the server
+	extensions map[string]string
+}
+
+type sftpResponse struct {
+	typ     byte
+	payload []byte
+}
+
+// sftpStatusError is a failed request
+type sftpStatusError struct {
+	code    uint32
+	message string
+}
+
+func (e *sftpStatusError) Error() string {
+	return fmt.Sprintf("%s (status %d)", e.message, e.code)
+}
+
+func (c *sftpConn) init() error {
+	packet := sftpPacket{0, 0, 0, 0, sftpInit}
+	packet.uint32(3)
+	if err := c.write(packet); err != nil {
+		return fmt.Errorf("sftp: %w", err)
+	}
+
+	typ, payload, err := c.read()
+	if err != nil {
+		return fmt.Errorf("sftp: %w", err)
+	}
+	if typ != sftpVersion {
+		return fmt.Errorf("sftp: unexpected packet type %d instead of the version", typ)
+	}
+
+	r := sftpReader(payload)
+	if version, ok := r.uint32(); !ok || version < 3 {
+		return fmt.Errorf("sftp: unsupported protocol version %d", version)
+	}
+	c.extensions = make(map[string]string)
+	for len(r) > 0 {
+		name, ok1 := r.string()
+		data, ok2 := r.string()
+		if !ok1 || !ok2 {
+			break
+		}
+		c.extensions[name] = data
+	}
+	return nil
+}
+
+// send writes a request with a new ID followed by the fields written by build
+// send sends a request and returns its ID
+func (c *sftpConn) send(typ byte, build func(p *sftpPacket)) (uint32, error) {
+	c.nextID++
+	packet := sftpPacket{0, 0, 0, 0, typ}
+	packet.uint32(c.nextID)
+	build(&packet)
+	return c.nextID, c.write(packet)
+}
+
+func (c *sftpConn) write(packet sftpPacket) error {
+	binary.BigEndian.PutUint32(packet, uint32(len(packet)-4))
+	_, err := c.rw.Write(packet)
+	return err
+}
+
+// recv returns the response of the request, the payload starts after the request ID.
+// Responses of other requests read meanwhile are kept for them.
+func (c *sftpConn) recv(id uint32) (byte, []byte, error) {
+	if resp, ok := c.responses[id]; ok {
+		delete(c.responses, id)
+		return resp.typ, resp.payload, nil
+	}
+
+	for {
+		typ, payload, err := c.read()
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(payload) < 4 {
+			return 0, nil, fmt.Errorf("packet of type %d without request ID", typ)
+		}
+		respID := binary.BigEndian.Uint32(payload)
+		if respID == id {
+			return typ, payload[4:], nil
+		}
+		if respID == 0 || respID > c.nextID {
+			return 0, nil, fmt.Errorf("response to unknown request %d", respID)
+		}
+		if c.responses == nil {
+			c.responses = make(map[uint32]sftpResponse)
+		}
+		c.responses[respID] = sftpResponse{typ: typ, payload: payload[4:]}
+	}
+}
+
+// read reads a packet and returns its type and payload
+func (c *sftpConn) read() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > sftpMaxPacket {
+		return 0, nil, fmt.Errorf("invalid packet length %d", length)
+	}
+	payload := make([]byte, length-1)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[4], payload, nil
+}
+
+// call sends a request and reads its response
+func (c *sftpConn) call(typ byte, build func(p *sftpPacket)) (byte, []byte, error) {
+	id, err := c.send(typ, build)
+	if err != nil {
+		return 0, nil, err
+	}
+	return c.recv(id)
+}
+
+// simpleCall sends a request answered with a status
+func (c *sftpConn) simpleCall(typ byte, build func(p *sftpPacket)) error {
+	respType, payload, err := c.call(typ, build)
+	if err != nil {
+		return err
+	}
+	return c.status(respType, payload)
+}
+
+// status returns the error of a status response
+func (c *sftpConn) status(typ byte, payload []byte) error {
+	if typ != sftpStatus {
+		return fmt.Errorf("unexpected packet type %d instead of a status", typ)
+	}
+	r := sftpReader(payload)
+	code, _ := r.uint32()
+	if code == 0 {
+		return nil
+	}
+	message, _ := r.string()
+	return &sftpStatusError{code: code, message: message}
+}
+
+// handle returns the handle of an open response
+func (c *sftpConn) handle(typ byte, payload []byte) (string, error) {
+	if typ != sftpHandle {
+		return "", c.status(typ, payload)
+	}
+	r := sftpReader(payload)
+	handle, ok := r.string()
+	if !ok {
+		return "", errors.New("invalid handle")
+	}
+	return handle, nil
+}
+
+// mkdir creates the directory unless it exists. SFTP version 3 has no status for existing files,
+// so a failure is only ignored if the path is a directory.
+func (c *sftpConn) mkdir(dir string) error {
+	err := c.simpleCall(sftpMkdir, func(p *sftpPacket) {
+		p.string(dir)
+		p.uint32(0) // no attributes
+	})
+	if err == nil {
+		return nil
+	}
+	if isDir, statErr := c.isDir(dir); statErr == nil && isDir {
+		return nil
+	}
+	return err
+}
+
+// isDir checks whether the path is a directory, following links
+func (c *sftpConn) isDir(name string) (bool, error) {
+	typ, payload, err := c.call(sftpStat, func(p *sftpPacket) { p.string(name) })
+	if err != nil {
+		return false, err
+	}
+	if typ != sftpAttrs {
+		return false, c.status(typ, payload)
+	}
+
+	r := sftpReader(payload)
+	flags, _ := r.uint32()
+	if flags&sftpAttrSize != 0 {
+		r.uint32()
+		r.uint32()
+	}
+	if flags&sftpAttrUIDGID != 0 {
+		r.uint32()
+		r.uint32()
+	}
+	if flags&sftpAttrPermissions == 0 {
+		return false, errors.New("no permissions in attributes")
+	}
+	permissions, ok := r.uint32()
+	if !ok {
+		return false, errors.New("invalid attributes")
+	}
+	return permissions&sftpModeType == sftpModeDir, nil
+}
+
+// rename replaces newPath, with the extension of OpenSSH or by removing it first
+func (c *sftpConn) rename(oldPath, newPath string) error {
+	if _, ok := c.extensions[sftpPosixRename]; ok {
+		return c.simpleCall(sftpExtended, func(p *sftpPacket) {
+			p.string(sftpPosixRename)
+			p.string(oldPath)
+			p.string(newPath)
+		})
+	}
+
+	c.simpleCall(sftpRemove, func(p *sftpPacket) { p.string(newPath) })
+	return c.simpleCall(sftpRename, func(p *sftpPacket) {
+		p.string(oldPath)
+		p.string(newPath)
+	})
+}
+
+// sftpPacket is an outgoing packet, starting with room for its length
+type sftpPacket []byte
+
+func (p *sftpPacket) uint32(v uint32) {
+	*p = append(*p, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func (p *sftpPacket) uint64(v uint64) {
+	p.uint32(uint32(v >> 32))
+	p.uint32(uint32(v))
+}
+
+func (p *sftpPacket) string(s string) {
+	p.uint32(uint32(len(s)))
+	*p = append(*p, s...)
+}
+
+// sftpReader reads the fields of an incoming packet
+type sftpReader []byte
+
+func (r *sftpReader) uint32() (uint32, bool) {
+	if len(*r) < 4 {
+		return 0, false
+	}
+	v := binary.BigEndian.Uint32(*r)
+	*r = (*r)[4:]
+	return v, true
+}
+
+func (r *sftpReader) string() (string, bool) {
+	length, ok := r.uint32()
+	if !ok || uint32(len(*r)) < length {
+		return "", false
+	}
+	s := string((*r)[:length])
+	*r = (*r)[length:]
+	return s, true
+}
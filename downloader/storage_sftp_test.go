@@ -0,0 +1,276 @@
+package downloader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSFTP serves the SFTP requests of the storage from a directory
+type fakeSFTP struct {
+	root string
+	// posixRename announces the rename extension of OpenSSH
+	posixRename bool
+	// failWrites fails the writes after the first one
+	failWrites bool
+	// denyMkdir fails the creation of directories like a server without permission
+	denyMkdir bool
+	// reorder swaps pending responses, servers may answer in any order
+	reorder bool
+}
+
+func (f *fakeSFTP) connect() (io.ReadWriteCloser, error) {
+	client, server := net.Pipe()
+	go f.serve(server)
+	return client, nil
+}
+
+func (f *fakeSFTP) serve(conn net.Conn) {
+	// responses are buffered like by pipes and ssh, writes are pipelined before their responses are read
+	responses := make(chan sftpPacket, 2*sftpMaxInflight)
+	defer close(responses)
+	go func() {
+		defer conn.Close()
+		write := func(resp sftpPacket) {
+			binary.BigEndian.PutUint32(resp, uint32(len(resp)-4))
+			conn.Write(resp)
+		}
+		for resp := range responses {
+			if f.reorder {
+				select {
+				case next, ok := <-responses:
+					if ok {
+						write(next)
+					}
+				case <-time.After(time.Millisecond):
+				}
+			}
+			write(resp)
+		}
+	}()
+
+	r := bufio.NewReader(conn)
+	files := make(map[string]*os.File)
+
+	for {
+		var header [5]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[:4])-1)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return
+		}
+		req := sftpReader(payload)
+
+		if header[4] == sftpInit {
+			resp := sftpPacket{0, 0, 0, 0, sftpVersion}
+			resp.uint32(3)
+			if f.posixRename {
+				resp.string(sftpPosixRename)
+				resp.string("1")
+			}
+			responses <- resp
+			continue
+		}
+
+		id, _ := req.uint32()
+		var err error
+		var handle string
+		switch header[4] {
+		case sftpOpen:
+			name, _ := req.string()
+			var file *os.File
+			if file, err = os.Create(f.path(name)); err == nil {
+				handle = name
+				files[handle] = file
+			}
+		case sftpWrite:
+			handle, _ := req.string()
+			offsetHigh, _ := req.uint32()
+			offsetLow, _ := req.uint32()
+			data, _ := req.string()
+			offset := int64(offsetHigh)<<32 | int64(offsetLow)
+			if f.failWrites && offset > 0 {
+				err = io.ErrShortWrite
+			} else {
+				_, err = files[handle].WriteAt([]byte(data), offset)
+			}
+		case sftpClose:
+			handle, _ := req.string()
+			err = files[handle].Close()
+			delete(files, handle)
+		case sftpMkdir:
+			name, _ := req.string()
+			if f.denyMkdir {
+				err = os.ErrPermission
+			} else {
+				err = os.Mkdir(f.path(name), 0o755)
+			}
+		case sftpStat:
+			name, _ := req.string()
+			var info os.FileInfo
+			if info, err = os.Stat(f.path(name)); err == nil {
+				attrs := sftpPacket{0, 0, 0, 0, sftpAttrs}
+				attrs.uint32(id)
+				attrs.uint32(sftpAttrPermissions)
+				if info.IsDir() {
+					attrs.uint32(sftpModeDir | 0o755)
+				} else {
+					attrs.uint32(0o100644)
+				}
+				responses <- attrs
+				continue
+			}
+		case sftpRemove:
+			name, _ := req.string()
+			err = os.Remove(f.path(name))
+		case sftpRename:
+			oldName, _ := req.string()
+			newName, _ := req.string()
+			if _, statErr := os.Stat(f.path(newName)); statErr == nil {
+				err = os.ErrExist
+			} else {
+				err = os.Rename(f.path(oldName), f.path(newName))
+			}
+		case sftpExtended:
+			extension, _ := req.string()
+			oldName, _ := req.string()
+			newName, _ := req.string()
+			if extension == sftpPosixRename && f.posixRename {
+				err = os.Rename(f.path(oldName), f.path(newName))
+			} else {
+				err = os.ErrInvalid
+			}
+		default:
+			err = os.ErrInvalid
+		}
+
+		resp := sftpPacket{0, 0, 0, 0, sftpStatus}
+		if handle != "" && err == nil {
+			resp[4] = sftpHandle
+		}
+		resp.uint32(id)
+		switch {
+		case resp[4] == sftpHandle:
+			resp.string(handle)
+		case err != nil:
+			resp.uint32(4) // failure
+			resp.string(err.Error())
+			resp.string("")
+		default:
+			resp.uint32(0)
+			resp.string("")
+			resp.string("")
+		}
+		responses <- resp
+	}
+}
+
+func (f *fakeSFTP) path(name string) string {
+	return filepath.Join(f.root, filepath.FromSlash(name))
+}
+
+func TestSFTPStorage(t *testing.T) {
+	data := strings.Repeat("0123456789", 100000)
+
+	for _, posixRename := range []bool{true, false} {
+		root, err := ioutil.TempDir("", "sftp")
+		require.NoError(t, err)
+		defer os.RemoveAll(root)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(root, "video.mp4"), []byte("old"), 0o644))
+
+		server := &fakeSFTP{root: root, posixRename: posixRename, reorder: true}
+		storage := &SFTPStorage{connect: server.connect}
+
+		for _, name := range []string{"videos/author/video.mp4", "video.mp4"} {
+			w, err := storage.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(data[:10]))
+			require.NoError(t, err)
+			_, err = io.Copy(w, strings.NewReader(data[10:]))
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			written, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+			require.NoError(t, err)
+			assert.Equal(t, data, string(written), name)
+			assert.NoFileExists(t, filepath.Join(root, filepath.FromSlash(name))+".part")
+		}
+	}
+}
+
+func TestSFTPStorage_failures(t *testing.T) {
+	root, err := ioutil.TempDir("", "sftp")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	server := &fakeSFTP{root: root, failWrites: true}
+	storage := &SFTPStorage{Dir: "videos", connect: server.connect}
+
+	w, err := storage.Create("video.mp4")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(strings.Repeat("x", 3*sftpMaxWrite)))
+	require.NoError(t, err, "the status of pipelined writes is checked later")
+	err = w.Close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sftp: write videos/video.mp4.part: short write")
+	assert.NoFileExists(t, filepath.Join(root, "videos", "video.mp4"))
+	assert.NoFileExists(t, filepath.Join(root, "videos", "video.mp4.part"))
+
+	// aborted uploads leave nothing behind
+	server.failWrites = false
+	w, err = storage.Create("video.mp4")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("partial"))
+	require.NoError(t, err)
+	abortStorageWriter(w)
+	assert.NoFileExists(t, filepath.Join(root, "videos", "video.mp4"))
+	assert.NoFileExists(t, filepath.Join(root, "videos", "video.mp4.part"))
+
+	// files are not created in directories which cannot be created
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "file"), nil, 0o644))
+	_, err = (&SFTPStorage{Dir: "file", connect: server.connect}).Create("video.mp4")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sftp: mkdir file: ")
+	assert.Contains(t, err.Error(), "file exists")
+
+	// existing directories are used, missing ones without permission fail
+	server.denyMkdir = true
+	w, err = storage.Create("video.mp4")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, err = storage.Create("author/video.mp4")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sftp: mkdir videos/author: permission denied")
+}
+
+func TestSFTPStorage_sshArgs(t *testing.T) {
+	assert.Equal(t, []string{"-s", "backup", "sftp"}, (&SFTPStorage{Host: "backup"}).sshArgs())
+	assert.Equal(t, []string{"-i", "key", "-p", "2222", "-s", "user@example.com", "sftp"},
+		(&SFTPStorage{Host: "user@example.com", Port: 2222, SSHArgs: []string{"-i", "key"}}).sshArgs())
+}
+
+func TestSFTPStorage_sshFailure(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell")
+	}
+	script := filepath.Join(t.TempDir(), "ssh")
+	require.NoError(t, ioutil.WriteFile(script, []byte("#!"+sh+"\necho 'Permission denied (publickey).' >&2\nexit 255\n"), 0o755))
+
+	_, err = (&SFTPStorage{Host: "example.com", SSHPath: script}).Create("video.mp4")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Permission denied (publickey).")
+}
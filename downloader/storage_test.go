@@ -0,0 +1,122 @@
+package downloader
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	storage := &LocalStorage{Dir: dir}
+	w, err := storage.Create("author/video.mp4")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("data"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "author", "video.mp4"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+}
+
+// fakeS3 records the requests of an upload
+type fakeS3 struct {
+	mu       sync.Mutex
+	requests []string
+	objects  map[string]string
+	parts    []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	query := r.URL.Query()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+
+	switch {
+	case r.Method == http.MethodPost && query.Get("uploadId") == "":
+		w.Write([]byte("<InitiateMultipartUploadResult><UploadId>42</UploadId></InitiateMultipartUploadResult>"))
+	case r.Method == http.MethodPut && query.Get("partNumber") != "":
+		f.parts = append(f.parts, string(body))
+		w.Header().Set("ETag", `"etag`+query.Get("partNumber")+`"`)
+	case r.Method == http.MethodPost:
+		f.objects[r.URL.Path] = strings.Join(f.parts, "")
+	case r.Method == http.MethodPut:
+		f.objects[r.URL.Path] = string(body)
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	storage := &S3Storage{
+		Endpoint:        server.URL,
+		Region:          "auto",
+		Bucket:          "bucket",
+		Prefix:          "videos/",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		PartSize:        4,
+	}
+
+	t.Run("single request", func(t *testing.T) {
+		w, err := storage.Create("small.mp4")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("abc"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, "abc", fake.objects["/bucket/videos/small.mp4"])
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		fake.requests = nil
+
+		w, err := storage.Create("my video.mp4")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("abcdefghij"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, "abcdefghij", fake.objects["/bucket/videos/my video.mp4"])
+		assert.Equal(t, []string{
+			"POST /bucket/videos/my video.mp4?uploads=",
+			"PUT /bucket/videos/my video.mp4?partNumber=1&uploadId=42",
+			"PUT /bucket/videos/my video.mp4?partNumber=2&uploadId=42",
+			"PUT /bucket/videos/my video.mp4?partNumber=3&uploadId=42",
+			"POST /bucket/videos/my video.mp4?uploadId=42",
+		}, fake.requests)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		storage := *storage
+		storage.AccessKeyID = "invalid"
+
+		w, err := storage.Create("small.mp4")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("abc"))
+		require.NoError(t, err)
+		assert.Error(t, w.Close())
+	})
+}
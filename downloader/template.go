@@ -0,0 +1,55 @@
+package downloader
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+const defaultOutputTemplate = "{{.Title}}"
+
+// templateData holds the fields available in output templates, all of them are safe to use in filenames
+type templateData struct {
+	ID          string
+	Title       string
+	Author      string
+	PublishDate string
+}
+
+// renderOutputTemplate returns the name of the output file for a video without extension.
+// Slashes in the template create sub directories.
+func (dl *Downloader) renderOutputTemplate(v *youtube.Video) (string, error) {
+	text := dl.OutputTemplate
+	if text == "" {
+		text = defaultOutputTemplate
+	}
+
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := templateData{
+		ID:     dl.sanitizeFilename(v.ID),
+		Title:  dl.sanitizeFilename(v.Title),
+		Author: dl.sanitizeFilename(v.Author),
+	}
+	if !v.PublishDate.IsZero() {
+		data.PublishDate = v.PublishDate.Format("2006-01-02")
+	}
+
+	var name strings.Builder
+	if err := tmpl.Execute(&name, data); err != nil {
+		return "", err
+	}
+
+	return name.String(), nil
+}
+
+func (dl *Downloader) sanitizeFilename(name string) string {
+	if dl.FilenameSanitizer == nil {
+		return SanitizeFilename(name)
+	}
+	return dl.FilenameSanitizer.Sanitize(name)
+}
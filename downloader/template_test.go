@@ -0,0 +1,37 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderOutputTemplate(t *testing.T) {
+	video := &youtube.Video{
+		ID:          "BaW_jenozKc",
+		Title:       `youtube-dl test video "'/\ä↭𝕐`,
+		Author:      "Philipp Hagemeister",
+		PublishDate: time.Date(2012, 10, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"", `youtube-dl test video 'ä↭𝕐`},
+		{"{{.Author}}/{{.PublishDate}} {{.ID}}", "Philipp Hagemeister/2012-10-02 BaW_jenozKc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			dl := Downloader{OutputTemplate: tt.template}
+			name, err := dl.renderOutputTemplate(video)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, name)
+		})
+	}
+
+	_, err := (&Downloader{OutputTemplate: "{{.Unknown}}"}).renderOutputTemplate(video)
+	assert.Error(t, err)
+}
@@ -3,6 +3,8 @@ package youtube
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 )
 
 var (
@@ -12,6 +14,26 @@ var (
 	ErrReadOnClosedResBody        = errors.New("http: read on closed response body")
 	ErrNotPlayableInEmbed         = errors.New("embedding of this video has been disabled")
 	ErrInvalidPlaylist            = errors.New("no playlist detected or invalid playlist ID")
+	ErrInvalidChannel             = errors.New("no channel detected or invalid channel ID")
+	ErrInvalidClip                = errors.New("no clip detected or invalid clip ID")
+	ErrInvalidRange               = errors.New("invalid byte range")
+	ErrRangeNotSupported          = errors.New("server does not support range requests")
+	ErrFormatNotFound             = errors.New("no format found")
+	ErrParse                      = errors.New("invalid server answer")
+	ErrDecipher                   = errors.New("unable to decipher")
+	ErrNoArchive                  = errors.New("no archived copy found")
+	ErrNotAuthenticated           = errors.New("not signed in, the cookies of an account are required")
+	ErrResponseTooLarge           = errors.New("response exceeds the maximum size")
+)
+
+// Categories of failures, errors of the client match them with errors.Is, e.g. errors.Is(err, ErrPrivate)
+var (
+	ErrNotFound      = errors.New("video not found")
+	ErrPrivate       = errors.New("video is private")
+	ErrAgeRestricted = errors.New("video is age restricted")
+	ErrGeoBlocked    = errors.New("video is not available in this country")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrTransient     = errors.New("temporary failure")
 )
 
 type ErrResponseStatus struct {
@@ -40,9 +62,63 @@ func (err ErrPlayabiltyStatus) Error() string {
 	return fmt.Sprintf("cannot playback and download, status: %s, reason: %s", err.Status, err.Reason)
 }
 
+// Is matches the category of the status
+func (err ErrPlayabiltyStatus) Is(target error) bool {
+	category := err.Category()
+	return category != nil && category == target
+}
+
+// Category derives the category from the status and the reason given by YouTube, nil if unknown
+func (err ErrPlayabiltyStatus) Category() error {
+	reason := strings.ToLower(err.Reason)
+	switch {
+	case strings.Contains(reason, "private"):
+		return ErrPrivate
+	case err.Status == "AGE_VERIFICATION_REQUIRED", err.Status == "AGE_CHECK_REQUIRED",
+		strings.Contains(reason, "confirm your age"), strings.Contains(reason, "age-restricted"):
+		return ErrAgeRestricted
+	case strings.Contains(reason, "not a bot"), strings.Contains(reason, "unusual traffic"):
+		return ErrRateLimited
+	case strings.Contains(reason, "country"):
+		return ErrGeoBlocked
+	case err.Status == "ERROR":
+		// e.g. "Video unavailable" or "This video has been removed by the uploader"
+		return ErrNotFound
+	}
+	return nil
+}
+
 // ErrUnexpectedStatusCode is returned on unexpected HTTP status codes
 type ErrUnexpectedStatusCode int
 
 func (err ErrUnexpectedStatusCode) Error() string {
 	return fmt.Sprintf("unexpected status code: %d", err)
 }
+
+// Is matches ErrNotFound, ErrRateLimited and ErrTransient
+func (err ErrUnexpectedStatusCode) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return err == http.StatusNotFound || err == http.StatusGone
+	case ErrRateLimited:
+		return err == http.StatusTooManyRequests
+	case ErrTransient:
+		return err >= 500
+	}
+	return false
+}
+
+// ErrDecipherOpsNotFound is returned if the signature operations can't be found in the player,
+// usually after YouTube changed the player JS. It matches ErrDecipher.
+type ErrDecipherOpsNotFound struct {
+	PlayerVersion string // e.g. "f676c671" of /s/player/f676c671/player_ias.vflset/en_US/base.js
+}
+
+func (err ErrDecipherOpsNotFound) Error() string {
+	return fmt.Sprintf("%v: signature operations not found in player %s", ErrDecipher, err.PlayerVersion)
+}
+
+// Is matches ErrDecipher
+func (err ErrDecipherOpsNotFound) Is(target error) bool {
+	return target == ErrDecipher
+}
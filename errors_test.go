@@ -1,6 +1,8 @@
 package youtube
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"testing"
 
@@ -24,3 +26,29 @@ func TestErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorCategories(t *testing.T) {
+	tests := []struct {
+		err      error
+		category error
+	}{
+		{ErrUnexpectedStatusCode(404), ErrNotFound},
+		{ErrUnexpectedStatusCode(429), ErrRateLimited},
+		{ErrUnexpectedStatusCode(503), ErrTransient},
+		{&ErrPlayabiltyStatus{"LOGIN_REQUIRED", "This video is private"}, ErrPrivate},
+		{&ErrPlayabiltyStatus{"LOGIN_REQUIRED", "Sign in to confirm your age"}, ErrAgeRestricted},
+		{&ErrPlayabiltyStatus{"LOGIN_REQUIRED", "Sign in to confirm you're not a bot"}, ErrRateLimited},
+		{&ErrPlayabiltyStatus{"UNPLAYABLE", "The uploader has not made this video available in your country"}, ErrGeoBlocked},
+		{&ErrPlayabiltyStatus{"ERROR", "Video unavailable"}, ErrNotFound},
+		{fmt.Errorf("wrapped: %w", ErrPlayabiltyStatus{"ERROR", "Video unavailable"}), ErrNotFound},
+	}
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.True(t, errors.Is(tt.err, tt.category))
+			assert.False(t, errors.Is(tt.err, ErrTransient) && tt.category != ErrTransient)
+		})
+	}
+
+	assert.False(t, errors.Is(ErrUnexpectedStatusCode(403), ErrNotFound))
+	assert.Nil(t, ErrPlayabiltyStatus{"UNPLAYABLE", "unknown"}.Category())
+}
@@ -0,0 +1,54 @@
+package youtube
+
+import "context"
+
+// Extractor fetches the metadata of videos and resolves the URLs of their streams.
+// Set Client.Extractor to use another strategy, like other clients of the innertube API or an external helper.
+// Extractors may wrap DefaultExtractor and handle only the cases it fails on.
+type Extractor interface {
+	// ExtractVideo fetches the metadata and the formats of the video with the ID
+	ExtractVideo(ctx context.Context, client *Client, videoID string) (*Video, error)
+	// StreamURL resolves the URL of the format, Client.StreamHostRewrite is applied to the result
+	StreamURL(ctx context.Context, client *Client, video *Video, format *Format) (string, error)
+}
+
+var _ Extractor = DefaultExtractor{}
+
+// DefaultExtractor reads the video info, with the watch page and the innertube player as fallbacks.
+// Ciphers are deciphered by Client.DecipherStrategy or with the operations parsed from the player.
+type DefaultExtractor struct{}
+
+// ExtractVideo fetches the video info of the video
+func (DefaultExtractor) ExtractVideo(ctx context.Context, client *Client, videoID string) (*Video, error) {
+	return client.videoFromID(ctx, videoID)
+}
+
+// StreamURL returns the URL of the format, deciphered if it only has a cipher
+func (DefaultExtractor) StreamURL(ctx context.Context, client *Client, video *Video, format *Format) (string, error) {
+	streamURL := format.URL
+	if streamURL == "" {
+		cipher := format.Cipher
+		if cipher == "" {
+			return "", ErrCipherNotFound
+		}
+
+		var err error
+		streamURL, err = client.decipherURL(ctx, video.ID, cipher)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if client.DecipherStrategy != nil {
+		return client.transformN(ctx, video.ID, streamURL)
+	}
+	return streamURL, nil
+}
+
+// extractor returns the configured extractor or the default one
+func (c *Client) extractor() Extractor {
+	if c.Extractor != nil {
+		return c.Extractor
+	}
+	return DefaultExtractor{}
+}
@@ -0,0 +1,51 @@
+package youtube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testExtractor serves a fixed video and resolves the streams of other formats by the default extractor
+type testExtractor struct {
+	DefaultExtractor
+	videoIDs []string
+}
+
+func (e *testExtractor) ExtractVideo(ctx context.Context, client *Client, videoID string) (*Video, error) {
+	e.videoIDs = append(e.videoIDs, videoID)
+	return &Video{ID: videoID, Title: "test"}, nil
+}
+
+func (e *testExtractor) StreamURL(ctx context.Context, client *Client, video *Video, format *Format) (string, error) {
+	if format.ItagNo == 18 {
+		return "https://example.com/videoplayback?itag=18", nil
+	}
+	return e.DefaultExtractor.StreamURL(ctx, client, video, format)
+}
+
+func TestClient_Extractor(t *testing.T) {
+	extractor := &testExtractor{}
+	client := &Client{Extractor: extractor}
+
+	video, err := client.GetVideo("https://www.youtube.com/watch?v=BaW_jenozKc")
+	require.NoError(t, err)
+	assert.Equal(t, "test", video.Title)
+
+	video, err = client.VideoFromPlaylistEntry(&PlaylistEntry{ID: "9bZkp7q19f0"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BaW_jenozKc", "9bZkp7q19f0"}, extractor.videoIDs)
+
+	streamURL, err := client.GetStreamURL(video, &Format{ItagNo: 18})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/videoplayback?itag=18", streamURL)
+
+	streamURL, err = client.GetStreamURL(video, &Format{ItagNo: 22, URL: "https://example.com/videoplayback?itag=22"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/videoplayback?itag=22", streamURL)
+
+	_, err = client.GetStreamURL(video, &Format{ItagNo: 140})
+	assert.Equal(t, ErrCipherNotFound, err)
+}
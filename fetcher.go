@@ -0,0 +1,26 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+)
+
+// VideoFetcher fetches the metadata of videos and playlists.
+// It is implemented by Client and can be replaced by fakes in tests.
+type VideoFetcher interface {
+	GetVideoContext(ctx context.Context, url string) (*Video, error)
+	GetPlaylistContext(ctx context.Context, url string) (*Playlist, error)
+}
+
+// StreamFetcher fetches the streams of formats.
+// It is implemented by Client and can be replaced by fakes in tests.
+type StreamFetcher interface {
+	GetStreamContext(ctx context.Context, video *Video, format *Format) (*http.Response, error)
+	GetStreamRange(ctx context.Context, video *Video, format *Format, start, end int64) (*http.Response, error)
+	GetStreamURLContext(ctx context.Context, video *Video, format *Format) (string, error)
+}
+
+var (
+	_ VideoFetcher  = &Client{}
+	_ StreamFetcher = &Client{}
+)
@@ -0,0 +1,218 @@
+package youtube
+
+import (
+	"encoding/json"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EstimatedBitrate returns the average bitrate of the format, falling back to the peak bitrate
+// as some formats don't have the average.
+func (f *Format) EstimatedBitrate() int {
+	if f.AverageBitrate > 0 {
+		return f.AverageBitrate
+	}
+	return f.Bitrate
+}
+
+// Size returns the content length of the format in bytes.
+// If it is unknown, it is estimated from the bitrate and the given duration of the video.
+func (f *Format) Size(duration time.Duration) int64 {
+	if size, _ := strconv.ParseInt(f.ContentLength, 10, 64); size > 0 {
+		return size
+	}
+	return int64(float64(f.EstimatedBitrate()) * duration.Seconds() / 8)
+}
+
+// Codecs returns the codecs of the MimeType, e.g. ["avc1.64001F", "mp4a.40.2"]
+func (f *Format) Codecs() []string {
+	return f.mimeInfo().codecs
+}
+
+// Container returns the container of the format, e.g. "mp4" or "webm"
+func (f *Format) Container() string {
+	return f.mimeInfo().container
+}
+
+// VideoCodec returns the video codec of the format, e.g. "avc1.64001F".
+// It is empty for audio only formats.
+func (f *Format) VideoCodec() string {
+	return f.mimeInfo().videoCodec
+}
+
+// AudioCodec returns the audio codec of the format, e.g. "mp4a.40.2".
+// It is empty for video only formats.
+func (f *Format) AudioCodec() string {
+	return f.mimeInfo().audioCodec
+}
+
+// HasCodec checks whether the format uses the given codec, container or media type.
+// Codecs match without profile, so "avc1" matches "avc1.64001F" but "mp4" doesn't match "mp4a.40.2".
+func (f *Format) HasCodec(name string) bool {
+	info := f.mimeInfo()
+	name = strings.ToLower(name)
+	if name == info.mediaType || name == info.container {
+		return true
+	}
+	for _, codec := range info.codecs {
+		if name == strings.ToLower(codec) || name == CodecFamily(codec) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON parses the MimeType once while decoding
+func (f *Format) UnmarshalJSON(data []byte) error {
+	type format Format
+	if err := json.Unmarshal(data, (*format)(f)); err != nil {
+		return err
+	}
+
+	info := parseMimeType(f.MimeType)
+	f.mime = &info
+	return nil
+}
+
+// mimeInfo is the parsed form of a mime type like `video/mp4; codecs="avc1.64001F, mp4a.40.2"`
+type mimeInfo struct {
+	raw        string
+	mediaType  string
+	container  string
+	codecs     []string
+	videoCodec string
+	audioCodec string
+}
+
+func (f *Format) mimeInfo() mimeInfo {
+	if f.mime != nil && f.mime.raw == f.MimeType {
+		return *f.mime
+	}
+	// formats not created by decoding JSON, or with a modified MimeType
+	return parseMimeType(f.MimeType)
+}
+
+// audioCodecFamilies lists the RFC 6381 names of audio codecs, all others are considered video
+var audioCodecFamilies = map[string]bool{
+	"mp4a":   true,
+	"opus":   true,
+	"vorbis": true,
+	"ac-3":   true,
+	"ec-3":   true,
+	"flac":   true,
+	"mp3":    true,
+}
+
+func parseMimeType(mimeType string) mimeInfo {
+	info := mimeInfo{raw: mimeType}
+
+	mediaType, params, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return info
+	}
+	if i := strings.IndexByte(mediaType, '/'); i >= 0 {
+		info.mediaType, info.container = mediaType[:i], mediaType[i+1:]
+	}
+
+	if params["codecs"] == "" {
+		return info
+	}
+	for _, codec := range strings.Split(params["codecs"], ",") {
+		codec = strings.TrimSpace(codec)
+		info.codecs = append(info.codecs, codec)
+
+		if audioCodecFamilies[CodecFamily(codec)] || info.mediaType == "audio" {
+			if info.audioCodec == "" {
+				info.audioCodec = codec
+			}
+		} else if info.videoCodec == "" {
+			info.videoCodec = codec
+		}
+	}
+
+	return info
+}
+
+// hasVideo checks whether the format contains video, formats without mime type are recognized by their dimensions
+func (f *Format) hasVideo() bool {
+	return f.VideoCodec() != "" || f.Height > 0 || f.QualityLabel != ""
+}
+
+// hasAudio checks whether the format contains audio
+func (f *Format) hasAudio() bool {
+	return f.AudioCodec() != "" || f.AudioChannels > 0 || f.AudioQuality != ""
+}
+
+// resolution returns the height and frame rate of the video, taken from the quality label like "1080p60",
+// which is the shorter side also for portrait videos
+func (f *Format) resolution() (height, fps int) {
+	height, fps = f.Height, f.FPS
+	if m := resolutionPattern.FindStringSubmatch(f.QualityLabel); m != nil {
+		height, _ = strconv.Atoi(m[1])
+		if labelFPS, _ := strconv.Atoi(m[2]); labelFPS > 0 {
+			fps = labelFPS
+		}
+	}
+	if fps == 0 {
+		fps = 30
+	}
+	return height, fps
+}
+
+// betterThan compares the formats by resolution, frame rate and bitrate
+func (f *Format) betterThan(other *Format) bool {
+	height, fps := f.resolution()
+	otherHeight, otherFPS := other.resolution()
+	if height != otherHeight {
+		return height > otherHeight
+	}
+	if fps != otherFPS {
+		return fps > otherFPS
+	}
+	return f.EstimatedBitrate() > other.EstimatedBitrate()
+}
+
+// CodecFamily returns the codec without profile and level, e.g. "avc1" for "avc1.64001F"
+func CodecFamily(codec string) string {
+	if i := strings.IndexByte(codec, '.'); i >= 0 {
+		codec = codec[:i]
+	}
+	return strings.ToLower(codec)
+}
+
+// FormatInfo is the representation of a format for reports, its JSON field names are stable
+// unlike the ones of Format, which follow the responses of YouTube.
+type FormatInfo struct {
+	Itag         int    `json:"itag"`
+	VideoQuality string `json:"videoQuality,omitempty"`
+	AudioQuality string `json:"audioQuality,omitempty"`
+	Size         int64  `json:"size"`
+	Bitrate      int    `json:"bitrate"`
+	MimeType     string `json:"mimeType"`
+	Container    string `json:"container,omitempty"`
+	VideoCodec   string `json:"videoCodec,omitempty"`
+	AudioCodec   string `json:"audioCodec,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	FPS          int    `json:"fps,omitempty"`
+}
+
+// Info returns the report representation of the format, the duration of the video is used to estimate the size
+func (f *Format) Info(duration time.Duration) FormatInfo {
+	return FormatInfo{
+		Itag:         f.ItagNo,
+		VideoQuality: f.QualityLabel,
+		AudioQuality: strings.ToLower(strings.TrimPrefix(f.AudioQuality, "AUDIO_QUALITY_")),
+		Size:         f.Size(duration),
+		Bitrate:      f.EstimatedBitrate(),
+		MimeType:     f.MimeType,
+		Container:    f.Container(),
+		VideoCodec:   f.VideoCodec(),
+		AudioCodec:   f.AudioCodec(),
+		Width:        f.Width,
+		Height:       f.Height,
+		FPS:          f.FPS,
+	}
+}
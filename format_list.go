@@ -1,18 +1,103 @@
 package youtube
 
 import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type FormatList []Format
 
+// Quality aliases of FilterQuality and FindByQuality
+const (
+	// QualityBest selects the video format with the highest resolution, frame rate and bitrate
+	QualityBest = "best"
+	// QualityWorst selects the video format with the lowest resolution, frame rate and bitrate
+	QualityWorst = "worst"
+	// QualityAudioOnly selects the audio format without video with the highest bitrate
+	QualityAudioOnly = "audio-only"
+)
+
+// resolutionAliases are the names of resolutions accepted as quality
+var resolutionAliases = map[string]int{
+	"4k": 2160,
+	"8k": 4320,
+}
+
+// resolutionPattern matches resolutions like "1080p" and "1080p60", and quality labels like "1080p60 HDR" or "720s"
+var resolutionPattern = regexp.MustCompile(`^(\d+)[ps](\d*)`)
+
+// FindByQuality returns the format with the quality or quality label, like "medium" or "720p".
+// Otherwise the quality is resolved as alias like in FilterQuality and the best matching format is returned,
+// or the worst one for QualityWorst.
 func (list FormatList) FindByQuality(quality string) *Format {
 	for i := range list {
 		if list[i].Quality == quality || list[i].QualityLabel == quality {
 			return &list[i]
 		}
 	}
-	return nil
+
+	matches := qualityMatcher(quality)
+	var found *Format
+	for i := range list {
+		if !matches(&list[i]) {
+			continue
+		}
+		if found == nil ||
+			quality != QualityWorst && list[i].betterThan(found) ||
+			quality == QualityWorst && found.betterThan(&list[i]) {
+			found = &list[i]
+		}
+	}
+	return found
+}
+
+// FilterQuality returns the formats matching the quality, which is a quality like "medium", a quality label
+// or one of the aliases:
+//
+//	best, worst   all formats with video, best and worst make a difference for FindByQuality
+//	audio-only    all formats with audio but without video
+//	2160p, 4k     formats of a resolution
+//	1080p60       formats of a resolution and frame rate
+func (list FormatList) FilterQuality(quality string) FormatList {
+	matches := qualityMatcher(quality)
+	var formats FormatList
+	for i := range list {
+		if matches(&list[i]) {
+			formats = append(formats, list[i])
+		}
+	}
+	return formats
+}
+
+// qualityMatcher returns the check of FilterQuality whether a format matches the quality
+func qualityMatcher(quality string) func(f *Format) bool {
+	quality = strings.ToLower(quality)
+	switch quality {
+	case QualityBest, QualityWorst:
+		return func(f *Format) bool { return f.hasVideo() }
+	case QualityAudioOnly:
+		return func(f *Format) bool { return f.hasAudio() && !f.hasVideo() }
+	}
+
+	height, fps := resolutionAliases[quality], 0
+	if m := resolutionPattern.FindStringSubmatch(quality); m != nil && m[0] == quality {
+		height, _ = strconv.Atoi(m[1])
+		fps, _ = strconv.Atoi(m[2])
+	}
+	if height > 0 {
+		return func(f *Format) bool {
+			h, r := f.resolution()
+			return h == height && (fps == 0 || r == fps)
+		}
+	}
+
+	return func(f *Format) bool {
+		return strings.ToLower(f.Quality) == quality || strings.ToLower(f.QualityLabel) == quality
+	}
 }
 
 func (list FormatList) FindByItag(itagNo int) *Format {
@@ -24,6 +109,105 @@ func (list FormatList) FindByItag(itagNo int) *Format {
 	return nil
 }
 
+// FilterResolution returns the formats with a height between minHeight and maxHeight, 0 means no limit.
+// The height is the shorter side, so a portrait video of 1080x1920 counts as 1080.
+// Formats without video are kept, so they can still be merged with the selected video.
+func (list FormatList) FilterResolution(minHeight, maxHeight int) FormatList {
+	var formats FormatList
+	for i := range list {
+		height := list[i].Height
+		if list[i].Width > 0 && list[i].Width < height {
+			height = list[i].Width
+		}
+		if height > 0 && (height < minHeight || maxHeight > 0 && height > maxHeight) {
+			continue
+		}
+		formats = append(formats, list[i])
+	}
+	return formats
+}
+
+// FilterFPS returns the formats with a frame rate up to maxFPS, formats without video are kept
+func (list FormatList) FilterFPS(maxFPS int) FormatList {
+	var formats FormatList
+	for i := range list {
+		if list[i].hasVideo() {
+			if _, fps := list[i].resolution(); fps > maxFPS {
+				continue
+			}
+		}
+		formats = append(formats, list[i])
+	}
+	return formats
+}
+
+// PreferFPS leaves out the video formats of a resolution with another frame rate than fps,
+// if the resolution is available with fps. Resolutions only available with other frame rates are kept,
+// so unlike FilterFPS no resolution is lost. Formats without video are kept.
+func (list FormatList) PreferFPS(fps int) FormatList {
+	available := make(map[int]bool)
+	for i := range list {
+		if height, r := list[i].resolution(); list[i].hasVideo() && r == fps {
+			available[height] = true
+		}
+	}
+
+	var formats FormatList
+	for i := range list {
+		if height, r := list[i].resolution(); list[i].hasVideo() && r != fps && available[height] {
+			continue
+		}
+		formats = append(formats, list[i])
+	}
+	return formats
+}
+
+// trackingParams are the query parameters of stream URLs which differ between otherwise identical formats
+var trackingParams = []string{"cpn", "rn", "rbuf", "alr", "pot", "ump", "srfvp"}
+
+// Dedup returns the formats without duplicates, keeping the first of them.
+// Formats are duplicates if they have the same itag and audio track and their stream URLs only differ
+// in tracking parameters, like the progressive formats repeated in the adaptive ones.
+// Variants of an itag with other streams, like the tracks of dubbed videos, are kept.
+func (list FormatList) Dedup() FormatList {
+	seen := make(map[string]bool, len(list))
+	formats := make(FormatList, 0, len(list))
+	for i := range list {
+		key := list[i].dedupKey()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		formats = append(formats, list[i])
+	}
+	return formats
+}
+
+// dedupKey identifies the stream of a format
+func (f *Format) dedupKey() string {
+	streamURL := f.URL
+	if streamURL == "" && f.Cipher != "" {
+		if params, err := url.ParseQuery(f.Cipher); err == nil {
+			streamURL = params.Get("url")
+		}
+	}
+	if u, err := url.Parse(streamURL); err == nil {
+		query := u.Query()
+		for _, param := range trackingParams {
+			query.Del(param)
+		}
+		// Encode sorts the parameters, so their order doesn't matter either
+		u.RawQuery = query.Encode()
+		streamURL = u.String()
+	}
+
+	track := ""
+	if f.AudioTrack != nil {
+		track = f.AudioTrack.ID
+	}
+	return fmt.Sprintf("%d|%s|%s", f.ItagNo, track, streamURL)
+}
+
 // FindByType returns mime type of video which only audio or video
 func (list FormatList) FindByType(t string) []Format {
 	var f []Format
@@ -34,3 +218,47 @@ func (list FormatList) FindByType(t string) []Format {
 	}
 	return f
 }
+
+// AudioTracks returns the distinct audio tracks of a dubbed video, in order of appearance
+func (list FormatList) AudioTracks() []AudioTrack {
+	var tracks []AudioTrack
+	seen := make(map[string]bool)
+	for i := range list {
+		track := list[i].AudioTrack
+		if track == nil || seen[track.ID] {
+			continue
+		}
+		seen[track.ID] = true
+		tracks = append(tracks, *track)
+	}
+	return tracks
+}
+
+// FormatTableHeader holds the column names of the rows returned by MarshalTable
+var FormatTableHeader = []string{"itag", "video quality", "audio quality", "size [MB]", "bitrate", "MimeType"}
+
+// Infos returns the report representation of all formats
+func (list FormatList) Infos(duration time.Duration) []FormatInfo {
+	infos := make([]FormatInfo, len(list))
+	for i := range list {
+		infos[i] = list[i].Info(duration)
+	}
+	return infos
+}
+
+// MarshalTable returns a row of strings for each format, see FormatTableHeader for the columns.
+// The duration of the video is used to estimate sizes.
+func (list FormatList) MarshalTable(duration time.Duration) [][]string {
+	rows := make([][]string, len(list))
+	for i, info := range list.Infos(duration) {
+		rows[i] = []string{
+			strconv.Itoa(info.Itag),
+			info.VideoQuality,
+			info.AudioQuality,
+			fmt.Sprintf("%0.1f", float64(info.Size)/1024/1024),
+			strconv.Itoa(info.Bitrate),
+			info.MimeType,
+		}
+	}
+	return rows
+}
@@ -1,7 +1,9 @@
 package youtube
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -68,6 +70,112 @@ func TestFormatList_FindByQuality(t *testing.T) {
 	}
 }
 
+func TestFormatList_QualityAliases(t *testing.T) {
+	list := FormatList{
+		{ItagNo: 18, QualityLabel: "360p", Height: 360, Bitrate: 500000, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`},
+		{ItagNo: 137, QualityLabel: "1080p", Height: 1080, Bitrate: 4000000, MimeType: `video/mp4; codecs="avc1.640028"`},
+		{ItagNo: 299, QualityLabel: "1080p60", Height: 1080, FPS: 60, Bitrate: 6000000, MimeType: `video/mp4; codecs="avc1.64002a"`},
+		{ItagNo: 313, QualityLabel: "2160p", Height: 2160, Bitrate: 15000000, MimeType: `video/webm; codecs="vp9"`},
+		{ItagNo: 160, QualityLabel: "144p", Height: 144, Bitrate: 100000, MimeType: `video/mp4; codecs="avc1.4d400c"`},
+		{ItagNo: 140, AudioQuality: "AUDIO_QUALITY_MEDIUM", Bitrate: 130000, MimeType: `audio/mp4; codecs="mp4a.40.2"`},
+		{ItagNo: 251, AudioQuality: "AUDIO_QUALITY_MEDIUM", Bitrate: 160000, MimeType: `audio/webm; codecs="opus"`},
+	}
+
+	tests := []struct {
+		quality  string
+		itag     int
+		filtered int
+	}{
+		{"best", 313, 5},
+		{"worst", 160, 5},
+		{"4k", 313, 1},
+		{"2160p", 313, 1},
+		{"1080p", 137, 2},
+		{"1080p60", 299, 1},
+		{"1080P60", 299, 1},
+		{"audio-only", 251, 2},
+		{"720p", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.quality, func(t *testing.T) {
+			assert.Len(t, list.FilterQuality(tt.quality), tt.filtered)
+
+			format := list.FindByQuality(tt.quality)
+			if tt.itag == 0 {
+				assert.Nil(t, format)
+			} else if assert.NotNil(t, format) {
+				assert.Equal(t, tt.itag, format.ItagNo)
+			}
+		})
+	}
+}
+
+func TestFormatList_FilterResolution(t *testing.T) {
+	list := FormatList{
+		{ItagNo: 18, Width: 640, Height: 360},
+		{ItagNo: 137, Width: 1920, Height: 1080},
+		{ItagNo: 313, Width: 3840, Height: 2160},
+		{ItagNo: 399, Width: 1080, Height: 1920, QualityLabel: "1080p"},
+		{ItagNo: 140},
+	}
+
+	itags := func(formats FormatList) []int {
+		var result []int
+		for _, f := range formats {
+			result = append(result, f.ItagNo)
+		}
+		return result
+	}
+	assert.Equal(t, []int{18, 137, 399, 140}, itags(list.FilterResolution(0, 1080)))
+	assert.Equal(t, []int{137, 313, 399, 140}, itags(list.FilterResolution(720, 0)))
+	assert.Equal(t, []int{18, 140}, itags(list.FilterResolution(360, 720)))
+	assert.Len(t, list.FilterResolution(0, 0), len(list))
+}
+
+func TestFormatList_FPS(t *testing.T) {
+	list := FormatList{
+		{ItagNo: 136, QualityLabel: "720p", Height: 720, FPS: 30},
+		{ItagNo: 298, QualityLabel: "720p60", Height: 720, FPS: 60},
+		{ItagNo: 299, QualityLabel: "1080p60", Height: 1080, FPS: 60},
+		{ItagNo: 18, QualityLabel: "360p", Height: 360, FPS: 25},
+		{ItagNo: 140, AudioChannels: 2},
+	}
+
+	itags := func(formats FormatList) []int {
+		var result []int
+		for _, f := range formats {
+			result = append(result, f.ItagNo)
+		}
+		return result
+	}
+	assert.Equal(t, []int{136, 18, 140}, itags(list.FilterFPS(30)))
+	assert.Equal(t, []int{136, 299, 18, 140}, itags(list.PreferFPS(30)))
+	assert.Equal(t, []int{298, 299, 18, 140}, itags(list.PreferFPS(60)))
+	assert.Equal(t, 136, list.PreferFPS(30).FindByQuality("720p").ItagNo)
+}
+
+func TestFormatList_Dedup(t *testing.T) {
+	list := FormatList{
+		{ItagNo: 18, URL: "https://r1.googlevideo.com/videoplayback?itag=18&id=abc&cpn=AAA"},
+		{ItagNo: 137, URL: "https://r1.googlevideo.com/videoplayback?itag=137&id=abc"},
+		{ItagNo: 18, URL: "https://r1.googlevideo.com/videoplayback?id=abc&itag=18&cpn=BBB&rn=3"},
+		{ItagNo: 251, URL: "https://r1.googlevideo.com/videoplayback?itag=251&xtags=drc%3D1"},
+		{ItagNo: 251, URL: "https://r1.googlevideo.com/videoplayback?itag=251"},
+		{ItagNo: 140, Cipher: "s=abc&sp=sig&url=https%3A%2F%2Fr1.googlevideo.com%2Fvideoplayback%3Fitag%3D140%26rn%3D1", AudioTrack: &AudioTrack{ID: "en.4"}},
+		{ItagNo: 140, Cipher: "s=abc&sp=sig&url=https%3A%2F%2Fr1.googlevideo.com%2Fvideoplayback%3Fitag%3D140%26rn%3D2", AudioTrack: &AudioTrack{ID: "en.4"}},
+		{ItagNo: 140, Cipher: "s=abc&sp=sig&url=https%3A%2F%2Fr1.googlevideo.com%2Fvideoplayback%3Fitag%3D140%26rn%3D1", AudioTrack: &AudioTrack{ID: "de.3"}},
+	}
+
+	formats := list.Dedup()
+	var itags []int
+	for _, f := range formats {
+		itags = append(itags, f.ItagNo)
+	}
+	assert.Equal(t, []int{18, 137, 251, 251, 140, 140}, itags)
+	assert.Equal(t, list[0].URL, formats[0].URL)
+	assert.Equal(t, "de.3", formats[5].AudioTrack.ID)
+}
+
 func TestFormatList_FindByItag(t *testing.T) {
 	list := []Format{{
 		ItagNo: 18,
@@ -154,3 +262,38 @@ func TestFormatList_FindByType(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatList_AudioTracks(t *testing.T) {
+	list := FormatList{
+		{ItagNo: 137},
+		{ItagNo: 140, AudioTrack: &AudioTrack{ID: "en.4", DisplayName: "English original", IsDefault: true}},
+		{ItagNo: 251, AudioTrack: &AudioTrack{ID: "en.4", DisplayName: "English original", IsDefault: true}},
+		{ItagNo: 140, AudioTrack: &AudioTrack{ID: "de-DE.3", DisplayName: "German"}},
+	}
+
+	tracks := list.AudioTracks()
+	assert.Len(t, tracks, 2)
+	assert.Equal(t, "en", tracks[0].Language())
+	assert.Equal(t, "de-DE", tracks[1].Language())
+	assert.Empty(t, FormatList{{ItagNo: 18}}.AudioTracks())
+}
+
+func TestFormatList_MarshalTable(t *testing.T) {
+	list := FormatList{
+		{ItagNo: 18, QualityLabel: "360p", AudioQuality: "AUDIO_QUALITY_LOW", ContentLength: "2097152", Bitrate: 500000, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`},
+		{ItagNo: 251, AudioQuality: "AUDIO_QUALITY_MEDIUM", Bitrate: 160000, AverageBitrate: 128000, MimeType: `audio/webm; codecs="opus"`},
+	}
+
+	rows := list.MarshalTable(time.Minute)
+	assert.Equal(t, [][]string{
+		{"18", "360p", "low", "2.0", "500000", `video/mp4; codecs="avc1.42001E, mp4a.40.2"`},
+		{"251", "", "medium", "0.9", "128000", `audio/webm; codecs="opus"`},
+	}, rows)
+	for _, row := range rows {
+		assert.Len(t, row, len(FormatTableHeader))
+	}
+
+	data, err := json.Marshal(list.Infos(time.Minute)[1])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"itag":251,"audioQuality":"medium","size":960000,"bitrate":128000,"mimeType":"audio/webm; codecs=\"opus\"","container":"webm","audioCodec":"opus"}`, string(data))
+}
@@ -0,0 +1,84 @@
+package youtube
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// highQualityItags maps the qualities above 720p to the itags of their video only formats
+var highQualityItags = map[string]int{
+	"hdr2060": 401,
+	"hdr1080": 399,
+	"hd1080":  137,
+	"hdr720":  398,
+	"hd720":   136,
+}
+
+// FormatOptions are the criteria of Video.GetFormat, all given criteria must match
+type FormatOptions struct {
+	// Quality is an itag number, a quality like "medium", a quality label like "720p"
+	// or one of the high qualities "hd720", "hdr720", "hd1080", "hdr1080" and "hdr2060".
+	// If empty, the format with the highest bitrate is selected.
+	Quality string
+	// Codecs are codecs, containers or media types like "avc1", "mp4" or "audio", see Format.HasCodec
+	Codecs []string
+	// AudioOnly selects formats without video
+	AudioOnly bool
+	// VideoOnly selects formats without audio
+	VideoOnly bool
+	// Muxed selects formats with both audio and video
+	Muxed bool
+	// MaxSize is the maximum size in bytes, estimated from the bitrate if unknown. 0 means no limit.
+	MaxSize int64
+}
+
+// GetFormat selects a format of the video matching the options
+func (v *Video) GetFormat(opts FormatOptions) (*Format, error) {
+	formats := v.filterFormats(opts)
+	if len(formats) == 0 {
+		return nil, ErrFormatNotFound
+	}
+
+	var format *Format
+	if opts.Quality == "" {
+		format = &formats[0]
+		for i := range formats {
+			if formats[i].Bitrate > format.Bitrate {
+				format = &formats[i]
+			}
+		}
+	} else if itag, err := strconv.Atoi(opts.Quality); err == nil {
+		format = formats.FindByItag(itag)
+	} else if itag, ok := highQualityItags[opts.Quality]; ok {
+		format = formats.FindByItag(itag)
+	} else {
+		format = formats.FindByQuality(opts.Quality)
+	}
+
+	if format == nil {
+		return nil, fmt.Errorf("%w with quality %s", ErrFormatNotFound, opts.Quality)
+	}
+	return format, nil
+}
+
+func (opts FormatOptions) matches(format *Format, duration time.Duration) bool {
+	for _, codec := range opts.Codecs {
+		if !format.HasCodec(codec) {
+			return false
+		}
+	}
+
+	hasAudio, hasVideo := format.AudioCodec() != "", format.VideoCodec() != ""
+	if opts.AudioOnly && (!hasAudio || hasVideo) {
+		return false
+	}
+	if opts.VideoOnly && (!hasVideo || hasAudio) {
+		return false
+	}
+	if opts.Muxed && (!hasAudio || !hasVideo) {
+		return false
+	}
+
+	return opts.MaxSize <= 0 || format.Size(duration) <= opts.MaxSize
+}
@@ -0,0 +1,56 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideo_GetFormat(t *testing.T) {
+	video := &Video{
+		Duration: time.Minute,
+		Formats: FormatList{
+			{ItagNo: 18, Quality: "medium", QualityLabel: "360p", Bitrate: 500000, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`},
+			{ItagNo: 137, QualityLabel: "1080p", Bitrate: 4000000, MimeType: `video/mp4; codecs="avc1.640028"`},
+			{ItagNo: 248, QualityLabel: "1080p", Bitrate: 3000000, MimeType: `video/webm; codecs="vp9"`},
+			{ItagNo: 140, Bitrate: 130000, MimeType: `audio/mp4; codecs="mp4a.40.2"`},
+			{ItagNo: 251, Bitrate: 150000, MimeType: `audio/webm; codecs="opus"`},
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts FormatOptions
+		itag int
+	}{
+		{"highest bitrate", FormatOptions{}, 137},
+		{"itag", FormatOptions{Quality: "251"}, 251},
+		{"high quality", FormatOptions{Quality: "hd1080"}, 137},
+		{"quality label with codec", FormatOptions{Quality: "1080p", Codecs: []string{"webm"}}, 248},
+		{"audio only", FormatOptions{AudioOnly: true}, 251},
+		{"audio only with codec", FormatOptions{AudioOnly: true, Codecs: []string{"mp4a"}}, 140},
+		{"video only", FormatOptions{VideoOnly: true}, 137},
+		{"video only with codec", FormatOptions{VideoOnly: true, Codecs: []string{"webm"}}, 248},
+		{"muxed", FormatOptions{Muxed: true}, 18},
+		{"max size", FormatOptions{MaxSize: 25000000}, 248},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := video.GetFormat(tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.itag, format.ItagNo)
+		})
+	}
+
+	assert.Len(t, video.AudioFormats(), 2)
+	assert.Len(t, video.VideoFormats(), 2)
+
+	_, err := video.GetFormat(FormatOptions{Quality: "hdr2060"})
+	assert.True(t, errors.Is(err, ErrFormatNotFound))
+
+	_, err = video.GetFormat(FormatOptions{Muxed: true, Codecs: []string{"webm"}})
+	assert.True(t, errors.Is(err, ErrFormatNotFound))
+}
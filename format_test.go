@@ -0,0 +1,69 @@
+package youtube
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat_Size(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.EqualValues(1234, (&Format{ContentLength: "1234", Bitrate: 8000}).Size(time.Minute))
+	assert.EqualValues(60000, (&Format{Bitrate: 8000}).Size(time.Minute))
+	assert.EqualValues(30000, (&Format{Bitrate: 8000, AverageBitrate: 4000}).Size(time.Minute))
+	assert.EqualValues(0, (&Format{}).Size(time.Minute))
+}
+
+func TestFormat_Codecs(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"avc1.64001F", "mp4a.40.2"}, (&Format{MimeType: `video/mp4; codecs="avc1.64001F, mp4a.40.2"`}).Codecs())
+	assert.Equal([]string{"opus"}, (&Format{MimeType: `audio/webm; codecs="opus"`}).Codecs())
+	assert.Nil((&Format{MimeType: "video/mp4"}).Codecs())
+}
+
+func TestFormat_MimeType(t *testing.T) {
+	tests := []struct {
+		mimeType   string
+		container  string
+		videoCodec string
+		audioCodec string
+	}{
+		{`video/mp4; codecs="avc1.42001E, mp4a.40.2"`, "mp4", "avc1.42001E", "mp4a.40.2"},
+		{`video/webm; codecs="vp9"`, "webm", "vp9", ""},
+		{`audio/mp4; codecs="mp4a.40.2"`, "mp4", "", "mp4a.40.2"},
+		{`audio/webm; codecs="opus"`, "webm", "", "opus"},
+		{"invalid", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mimeType, func(t *testing.T) {
+			var format Format
+			require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(`{"mimeType": %q}`, tt.mimeType)), &format))
+
+			assert.Equal(t, tt.container, format.Container())
+			assert.Equal(t, tt.videoCodec, format.VideoCodec())
+			assert.Equal(t, tt.audioCodec, format.AudioCodec())
+		})
+	}
+}
+
+func TestFormat_HasCodec(t *testing.T) {
+	assert := assert.New(t)
+
+	audio := &Format{MimeType: `audio/mp4; codecs="mp4a.40.2"`}
+	assert.True(audio.HasCodec("mp4a"))
+	assert.True(audio.HasCodec("mp4"))
+	assert.True(audio.HasCodec("audio"))
+	assert.False(audio.HasCodec("video"))
+
+	video := &Format{MimeType: `video/webm; codecs="vp9"`}
+	assert.True(video.HasCodec("vp9"))
+	assert.False(video.HasCodec("mp4"))
+	assert.False(video.HasCodec("mp4a"))
+	assert.False(video.HasCodec("vp"))
+}
@@ -1,6 +1,6 @@
 module github.com/kkdai/youtube/v2
 
-go 1.15
+go 1.16
 
 require (
 	github.com/mitchellh/go-homedir v1.1.0
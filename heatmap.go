@@ -0,0 +1,102 @@
+package youtube
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// HeatmapPoint is a window of the "most replayed" graph of a video
+type HeatmapPoint struct {
+	Start    time.Duration
+	Duration time.Duration
+	// Intensity is the normalized replay score between 0 and 1
+	Intensity float64
+}
+
+type heatmapPointJSON struct {
+	StartMs    int64   `json:"startMs"`
+	DurationMs int64   `json:"durationMs"`
+	Intensity  float64 `json:"intensity"`
+}
+
+// MarshalJSON writes the start and the duration in milliseconds
+func (p HeatmapPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(heatmapPointJSON{p.Start.Milliseconds(), p.Duration.Milliseconds(), p.Intensity})
+}
+
+// UnmarshalJSON reads the format written by MarshalJSON
+func (p *HeatmapPoint) UnmarshalJSON(data []byte) error {
+	var v heatmapPointJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*p = HeatmapPoint{
+		Start:     time.Duration(v.StartMs) * time.Millisecond,
+		Duration:  time.Duration(v.DurationMs) * time.Millisecond,
+		Intensity: v.Intensity,
+	}
+	return nil
+}
+
+// frameworkUpdatesData holds the entities of the player response and the initial data, among them the heatmap markers
+type frameworkUpdatesData struct {
+	EntityBatchUpdate struct {
+		Mutations []struct {
+			Payload struct {
+				MacroMarkersListEntity struct {
+					MarkersList struct {
+						MarkerType string `json:"markerType"`
+						Markers    []struct {
+							StartMillis              string  `json:"startMillis"`
+							DurationMillis           string  `json:"durationMillis"`
+							IntensityScoreNormalized float64 `json:"intensityScoreNormalized"`
+						} `json:"markers"`
+					} `json:"markersList"`
+				} `json:"macroMarkersListEntity"`
+			} `json:"payload"`
+		} `json:"mutations"`
+	} `json:"entityBatchUpdate"`
+}
+
+// heatmap returns the points of the "most replayed" markers ordered by start, or nil if there are none
+func (f frameworkUpdatesData) heatmap() []HeatmapPoint {
+	var points []HeatmapPoint
+	for _, mutation := range f.EntityBatchUpdate.Mutations {
+		list := mutation.Payload.MacroMarkersListEntity.MarkersList
+		if list.MarkerType != "MARKER_TYPE_HEATMAP" {
+			continue
+		}
+		for _, marker := range list.Markers {
+			start, err := strconv.ParseInt(marker.StartMillis, 10, 64)
+			if err != nil {
+				continue
+			}
+			duration, _ := strconv.ParseInt(marker.DurationMillis, 10, 64)
+			points = append(points, HeatmapPoint{
+				Start:     time.Duration(start) * time.Millisecond,
+				Duration:  time.Duration(duration) * time.Millisecond,
+				Intensity: marker.IntensityScoreNormalized,
+			})
+		}
+	}
+
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Start < points[j].Start })
+	return points
+}
+
+// initialDataHeatmap returns the heatmap of the ytInitialData of a watch page
+func initialDataHeatmap(data []byte) []HeatmapPoint {
+	if data == nil {
+		return nil
+	}
+
+	var initialData struct {
+		FrameworkUpdates frameworkUpdatesData `json:"frameworkUpdates"`
+	}
+	if json.Unmarshal(data, &initialData) != nil {
+		return nil
+	}
+	return initialData.FrameworkUpdates.heatmap()
+}
@@ -0,0 +1,54 @@
+package youtube
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const heatmapFrameworkUpdates = `{"entityBatchUpdate":{"mutations":[
+	{"payload":{"macroMarkersListEntity":{"markersList":{"markerType":"MARKER_TYPE_CHAPTERS","markers":[{"startMillis":"0"}]}}}},
+	{"payload":{"macroMarkersListEntity":{"markersList":{"markerType":"MARKER_TYPE_HEATMAP","markers":[
+		{"startMillis":"2500","durationMillis":"2500","intensityScoreNormalized":0.25},
+		{"startMillis":"0","durationMillis":"2500","intensityScoreNormalized":1}
+	]}}}}
+]}}`
+
+func TestVideo_Heatmap(t *testing.T) {
+	var prData playerResponseData
+	require.NoError(t, json.Unmarshal([]byte(`{"frameworkUpdates":`+heatmapFrameworkUpdates+`}`), &prData))
+
+	assert.Equal(t, []HeatmapPoint{
+		{Start: 0, Duration: 2500 * time.Millisecond, Intensity: 1},
+		{Start: 2500 * time.Millisecond, Duration: 2500 * time.Millisecond, Intensity: 0.25},
+	}, prData.FrameworkUpdates.heatmap())
+}
+
+func TestParseInitialDataHeatmap(t *testing.T) {
+	// the initial data of watch pages is a single line
+	var compact bytes.Buffer
+	require.NoError(t, json.Compact(&compact, []byte(heatmapFrameworkUpdates)))
+	page := []byte(`<script>var ytInitialData = {"frameworkUpdates":` + compact.String() + `};</script>`)
+	scanned, err := scanWatchPage(bytes.NewReader(page))
+	require.NoError(t, err)
+	points := initialDataHeatmap(scanned.initialData)
+	require.Len(t, points, 2)
+	assert.Equal(t, 1.0, points[0].Intensity)
+
+	assert.Nil(t, initialDataHeatmap(nil))
+}
+
+func TestHeatmapPoint_JSON(t *testing.T) {
+	point := HeatmapPoint{Start: 1500 * time.Millisecond, Duration: time.Second, Intensity: 0.5}
+	data, err := json.Marshal(point)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"startMs":1500,"durationMs":1000,"intensity":0.5}`, string(data))
+
+	var decoded HeatmapPoint
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, point, decoded)
+}
@@ -0,0 +1,109 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWatchPage = `<script>ytcfg.set({"INNERTUBE_API_KEY":"test-key","INNERTUBE_CLIENT_NAME":"WEB",` +
+	`"INNERTUBE_CLIENT_VERSION":"2.20990101.00.00","INNERTUBE_CONTEXT_CLIENT_NAME":1});</script>`
+
+func TestParseInnertubeConfig(t *testing.T) {
+	config, ok := parseInnertubeConfig([]byte(testWatchPage))
+	assert.True(t, ok)
+	assert.Equal(t, "test-key", config.APIKey)
+	assert.Equal(t, "WEB", config.ClientName)
+	assert.Equal(t, 1, config.ClientID)
+	assert.Equal(t, "2.20990101.00.00", config.ClientVersion)
+
+	config, ok = parseInnertubeConfig([]byte("<html></html>"))
+	assert.False(t, ok)
+	assert.Equal(t, defaultInnertubeAPIKey, config.APIKey)
+	assert.Equal(t, defaultInnertubeClientVersion, config.ClientVersion)
+}
+
+func TestClient_innertubeRequest(t *testing.T) {
+	innertubeCache.configs = nil
+	defer func() { innertubeCache.configs = nil }()
+
+	watchRequests := 0
+	client := &Client{HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := ""
+		switch req.URL.Path {
+		case "/watch":
+			watchRequests++
+			body = testWatchPage
+		case "/youtubei/v1/browse":
+			assert.Equal(t, "test-key", req.URL.Query().Get("key"))
+			assert.Equal(t, "2.20990101.00.00", req.Header.Get("X-YouTube-Client-Version"))
+
+			var payload struct {
+				Context struct {
+					Client struct {
+						ClientVersion string `json:"clientVersion"`
+					} `json:"client"`
+				} `json:"context"`
+				BrowseID string `json:"browseId"`
+			}
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&payload))
+			assert.Equal(t, "2.20990101.00.00", payload.Context.Client.ClientVersion)
+			body = fmt.Sprintf(`{"browseId":%q}`, payload.BrowseID)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}}
+
+	for i := 0; i < 2; i++ {
+		var response struct {
+			BrowseID string `json:"browseId"`
+		}
+		err := client.innertubeRequest(context.Background(), "browse", "", map[string]interface{}{"browseId": "FEtrending"}, &response)
+		require.NoError(t, err)
+		assert.Equal(t, "FEtrending", response.BrowseID)
+	}
+	assert.Equal(t, 1, watchRequests, "the configuration must be cached")
+}
+
+func TestClient_innertubeRequestMaxResponseSize(t *testing.T) {
+	innertubeCache.configs = nil
+	defer func() { innertubeCache.configs = nil }()
+
+	client := newInnertubeTestClient(func(endpoint string, payload map[string]interface{}) string {
+		return `{"browseId":"` + strings.Repeat("a", len(testWatchPage)) + `"}`
+	})
+	client.MaxResponseSize = int64(len(testWatchPage))
+
+	var response struct{}
+	err := client.innertubeRequest(context.Background(), "browse", "", nil, &response)
+	assert.True(t, errors.Is(err, ErrResponseTooLarge), "unexpected error: %v", err)
+}
+
+// newInnertubeTestClient returns a client answering innertube requests with the result of respond,
+// an empty result is answered with 404 Not Found
+func newInnertubeTestClient(respond func(endpoint string, payload map[string]interface{}) string) *Client {
+	return &Client{HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, status := testWatchPage, http.StatusOK
+		if endpoint := strings.TrimPrefix(req.URL.Path, "/youtubei/v1/"); endpoint != req.URL.Path {
+			var payload map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&payload) //nolint:errcheck
+			if body = respond(endpoint, payload); body == "" {
+				status = http.StatusNotFound
+			}
+		}
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}}
+}
@@ -0,0 +1,211 @@
+package youtube
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ItagInfo is what is known about the formats of an itag, independent of a video
+type ItagInfo struct {
+	Itag      int
+	Container string
+	// VideoCodec and AudioCodec are codec families like "avc1" or "opus", empty if the format has no video or audio
+	VideoCodec string
+	AudioCodec string
+	// Height is the nominal height of the video, the actual one depends on the aspect ratio of the video
+	Height int
+	// FPS is set for formats with a fixed high frame rate, other formats use the frame rate of the video up to 30
+	FPS int
+	// AudioBitrate is the nominal audio bitrate in kbit/s
+	AudioBitrate int
+	Notes        string
+	// Deprecated itags are no longer served for new videos
+	Deprecated bool
+}
+
+// itags are the known itags, see https://gist.github.com/sidneys/7095afe4da4ae58694d128b1034e01e2
+var itags = map[int]ItagInfo{
+	// progressive formats with audio and video
+	5:   {Container: "flv", VideoCodec: "h263", AudioCodec: "mp3", Height: 240, AudioBitrate: 64, Deprecated: true},
+	6:   {Container: "flv", VideoCodec: "h263", AudioCodec: "mp3", Height: 270, AudioBitrate: 64, Deprecated: true},
+	13:  {Container: "3gp", VideoCodec: "mp4v", AudioCodec: "mp4a", Height: 144, Deprecated: true},
+	17:  {Container: "3gp", VideoCodec: "mp4v", AudioCodec: "mp4a", Height: 144, AudioBitrate: 24, Deprecated: true},
+	18:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 360, AudioBitrate: 96},
+	22:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 720, AudioBitrate: 192, Notes: "only for some older videos", Deprecated: true},
+	34:  {Container: "flv", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 360, AudioBitrate: 128, Deprecated: true},
+	35:  {Container: "flv", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 480, AudioBitrate: 128, Deprecated: true},
+	36:  {Container: "3gp", VideoCodec: "mp4v", AudioCodec: "mp4a", Height: 240, Deprecated: true},
+	37:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 1080, AudioBitrate: 192, Deprecated: true},
+	38:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 3072, AudioBitrate: 192, Deprecated: true},
+	43:  {Container: "webm", VideoCodec: "vp8", AudioCodec: "vorbis", Height: 360, AudioBitrate: 128, Deprecated: true},
+	44:  {Container: "webm", VideoCodec: "vp8", AudioCodec: "vorbis", Height: 480, AudioBitrate: 128, Deprecated: true},
+	45:  {Container: "webm", VideoCodec: "vp8", AudioCodec: "vorbis", Height: 720, AudioBitrate: 192, Deprecated: true},
+	46:  {Container: "webm", VideoCodec: "vp8", AudioCodec: "vorbis", Height: 1080, AudioBitrate: 192, Deprecated: true},
+	59:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 480, AudioBitrate: 128, Deprecated: true},
+	78:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 480, AudioBitrate: 128, Deprecated: true},
+	82:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 360, AudioBitrate: 128, Notes: "3D", Deprecated: true},
+	83:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 480, AudioBitrate: 128, Notes: "3D", Deprecated: true},
+	84:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 720, AudioBitrate: 192, Notes: "3D", Deprecated: true},
+	85:  {Container: "mp4", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 1080, AudioBitrate: 192, Notes: "3D", Deprecated: true},
+	100: {Container: "webm", VideoCodec: "vp8", AudioCodec: "vorbis", Height: 360, AudioBitrate: 128, Notes: "3D", Deprecated: true},
+	101: {Container: "webm", VideoCodec: "vp8", AudioCodec: "vorbis", Height: 480, AudioBitrate: 192, Notes: "3D", Deprecated: true},
+	102: {Container: "webm", VideoCodec: "vp8", AudioCodec: "vorbis", Height: 720, AudioBitrate: 192, Notes: "3D", Deprecated: true},
+
+	// HLS formats of live streams
+	91:  {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 144, AudioBitrate: 48, Notes: "HLS"},
+	92:  {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 240, AudioBitrate: 48, Notes: "HLS"},
+	93:  {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 360, AudioBitrate: 128, Notes: "HLS"},
+	94:  {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 480, AudioBitrate: 128, Notes: "HLS"},
+	95:  {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 720, AudioBitrate: 256, Notes: "HLS"},
+	96:  {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 1080, AudioBitrate: 256, Notes: "HLS"},
+	132: {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 240, AudioBitrate: 48, Notes: "HLS"},
+	151: {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 72, AudioBitrate: 24, Notes: "HLS"},
+	300: {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 720, FPS: 60, AudioBitrate: 128, Notes: "HLS"},
+	301: {Container: "ts", VideoCodec: "avc1", AudioCodec: "mp4a", Height: 1080, FPS: 60, AudioBitrate: 128, Notes: "HLS"},
+
+	// adaptive H.264 video
+	133: {Container: "mp4", VideoCodec: "avc1", Height: 240},
+	134: {Container: "mp4", VideoCodec: "avc1", Height: 360},
+	135: {Container: "mp4", VideoCodec: "avc1", Height: 480},
+	136: {Container: "mp4", VideoCodec: "avc1", Height: 720},
+	137: {Container: "mp4", VideoCodec: "avc1", Height: 1080},
+	138: {Container: "mp4", VideoCodec: "avc1", Height: 2160, Notes: "up to 4320p"},
+	160: {Container: "mp4", VideoCodec: "avc1", Height: 144},
+	212: {Container: "mp4", VideoCodec: "avc1", Height: 480},
+	264: {Container: "mp4", VideoCodec: "avc1", Height: 1440},
+	266: {Container: "mp4", VideoCodec: "avc1", Height: 2160},
+	298: {Container: "mp4", VideoCodec: "avc1", Height: 720, FPS: 60},
+	299: {Container: "mp4", VideoCodec: "avc1", Height: 1080, FPS: 60},
+	304: {Container: "mp4", VideoCodec: "avc1", Height: 1440, FPS: 60},
+	305: {Container: "mp4", VideoCodec: "avc1", Height: 2160, FPS: 60},
+
+	// adaptive VP8 and VP9 video
+	167: {Container: "webm", VideoCodec: "vp8", Height: 360, Deprecated: true},
+	168: {Container: "webm", VideoCodec: "vp8", Height: 480, Deprecated: true},
+	169: {Container: "webm", VideoCodec: "vp8", Height: 720, Deprecated: true},
+	170: {Container: "webm", VideoCodec: "vp8", Height: 1080, Deprecated: true},
+	218: {Container: "webm", VideoCodec: "vp8", Height: 480, Deprecated: true},
+	219: {Container: "webm", VideoCodec: "vp8", Height: 480, Deprecated: true},
+	242: {Container: "webm", VideoCodec: "vp9", Height: 240},
+	243: {Container: "webm", VideoCodec: "vp9", Height: 360},
+	244: {Container: "webm", VideoCodec: "vp9", Height: 480},
+	247: {Container: "webm", VideoCodec: "vp9", Height: 720},
+	248: {Container: "webm", VideoCodec: "vp9", Height: 1080},
+	271: {Container: "webm", VideoCodec: "vp9", Height: 1440},
+	272: {Container: "webm", VideoCodec: "vp9", Height: 2160, Notes: "up to 4320p"},
+	278: {Container: "webm", VideoCodec: "vp9", Height: 144},
+	302: {Container: "webm", VideoCodec: "vp9", Height: 720, FPS: 60},
+	303: {Container: "webm", VideoCodec: "vp9", Height: 1080, FPS: 60},
+	308: {Container: "webm", VideoCodec: "vp9", Height: 1440, FPS: 60},
+	313: {Container: "webm", VideoCodec: "vp9", Height: 2160},
+	315: {Container: "webm", VideoCodec: "vp9", Height: 2160, FPS: 60},
+	330: {Container: "webm", VideoCodec: "vp9", Height: 144, FPS: 60, Notes: "HDR"},
+	331: {Container: "webm", VideoCodec: "vp9", Height: 240, FPS: 60, Notes: "HDR"},
+	332: {Container: "webm", VideoCodec: "vp9", Height: 360, FPS: 60, Notes: "HDR"},
+	333: {Container: "webm", VideoCodec: "vp9", Height: 480, FPS: 60, Notes: "HDR"},
+	334: {Container: "webm", VideoCodec: "vp9", Height: 720, FPS: 60, Notes: "HDR"},
+	335: {Container: "webm", VideoCodec: "vp9", Height: 1080, FPS: 60, Notes: "HDR"},
+	336: {Container: "webm", VideoCodec: "vp9", Height: 1440, FPS: 60, Notes: "HDR"},
+	337: {Container: "webm", VideoCodec: "vp9", Height: 2160, FPS: 60, Notes: "HDR"},
+
+	// adaptive AV1 video, HDR if the video is
+	394: {Container: "mp4", VideoCodec: "av01", Height: 144},
+	395: {Container: "mp4", VideoCodec: "av01", Height: 240},
+	396: {Container: "mp4", VideoCodec: "av01", Height: 360},
+	397: {Container: "mp4", VideoCodec: "av01", Height: 480},
+	398: {Container: "mp4", VideoCodec: "av01", Height: 720},
+	399: {Container: "mp4", VideoCodec: "av01", Height: 1080},
+	400: {Container: "mp4", VideoCodec: "av01", Height: 1440},
+	401: {Container: "mp4", VideoCodec: "av01", Height: 2160},
+	402: {Container: "mp4", VideoCodec: "av01", Height: 4320},
+	571: {Container: "mp4", VideoCodec: "av01", Height: 4320},
+	694: {Container: "mp4", VideoCodec: "av01", Height: 144, FPS: 60, Notes: "HDR"},
+	695: {Container: "mp4", VideoCodec: "av01", Height: 240, FPS: 60, Notes: "HDR"},
+	696: {Container: "mp4", VideoCodec: "av01", Height: 360, FPS: 60, Notes: "HDR"},
+	697: {Container: "mp4", VideoCodec: "av01", Height: 480, FPS: 60, Notes: "HDR"},
+	698: {Container: "mp4", VideoCodec: "av01", Height: 720, FPS: 60, Notes: "HDR"},
+	699: {Container: "mp4", VideoCodec: "av01", Height: 1080, FPS: 60, Notes: "HDR"},
+	700: {Container: "mp4", VideoCodec: "av01", Height: 1440, FPS: 60, Notes: "HDR"},
+	701: {Container: "mp4", VideoCodec: "av01", Height: 2160, FPS: 60, Notes: "HDR"},
+	702: {Container: "mp4", VideoCodec: "av01", Height: 4320, FPS: 60, Notes: "HDR"},
+
+	// adaptive audio
+	139: {Container: "mp4", AudioCodec: "mp4a", AudioBitrate: 48},
+	140: {Container: "mp4", AudioCodec: "mp4a", AudioBitrate: 128},
+	141: {Container: "mp4", AudioCodec: "mp4a", AudioBitrate: 256, Notes: "YouTube Music Premium"},
+	171: {Container: "webm", AudioCodec: "vorbis", AudioBitrate: 128, Deprecated: true},
+	172: {Container: "webm", AudioCodec: "vorbis", AudioBitrate: 256, Deprecated: true},
+	249: {Container: "webm", AudioCodec: "opus", AudioBitrate: 50},
+	250: {Container: "webm", AudioCodec: "opus", AudioBitrate: 70},
+	251: {Container: "webm", AudioCodec: "opus", AudioBitrate: 160},
+	256: {Container: "mp4", AudioCodec: "mp4a", AudioBitrate: 192, Notes: "5.1 surround"},
+	258: {Container: "mp4", AudioCodec: "mp4a", AudioBitrate: 384, Notes: "5.1 surround"},
+	327: {Container: "mp4", AudioCodec: "mp4a", AudioBitrate: 256, Notes: "5.1 surround"},
+	328: {Container: "mp4", AudioCodec: "ec-3", AudioBitrate: 384, Notes: "5.1 surround"},
+	338: {Container: "webm", AudioCodec: "opus", AudioBitrate: 480, Notes: "ambisonic"},
+	380: {Container: "mp4", AudioCodec: "ac-3", AudioBitrate: 384, Notes: "5.1 surround"},
+}
+
+// LookupItag returns what is known about the itag
+func LookupItag(itag int) (ItagInfo, bool) {
+	info, ok := itags[itag]
+	info.Itag = itag
+	return info, ok
+}
+
+// Describe returns a short description of the format like "mp4, avc1 1080p60, video only".
+// Properties missing in the format are taken from the known itags, whose notes are appended.
+func (f *Format) Describe() string {
+	known, _ := LookupItag(f.ItagNo)
+
+	container := f.Container()
+	if container == "" {
+		container = known.Container
+	}
+	videoCodec, audioCodec := CodecFamily(f.VideoCodec()), CodecFamily(f.AudioCodec())
+	if f.MimeType == "" {
+		videoCodec, audioCodec = known.VideoCodec, known.AudioCodec
+	}
+
+	var parts []string
+	if container != "" {
+		parts = append(parts, container)
+	}
+
+	if videoCodec != "" {
+		height, fps := f.Height, f.FPS
+		if height == 0 {
+			height, fps = known.Height, known.FPS
+		}
+		video := videoCodec
+		if height > 0 {
+			video += fmt.Sprintf(" %dp", height)
+			if fps > 30 {
+				video += fmt.Sprint(fps)
+			}
+		}
+		parts = append(parts, video)
+	}
+
+	if audioCodec != "" {
+		audio := audioCodec
+		if known.AudioBitrate > 0 {
+			audio += fmt.Sprintf(" %d kbit/s", known.AudioBitrate)
+		}
+		parts = append(parts, audio)
+	}
+
+	switch {
+	case videoCodec == "" && audioCodec != "":
+		parts = append(parts, "audio only")
+	case videoCodec != "" && audioCodec == "":
+		parts = append(parts, "video only")
+	}
+	if known.Notes != "" {
+		parts = append(parts, known.Notes)
+	}
+	if known.Deprecated {
+		parts = append(parts, "deprecated")
+	}
+	return strings.Join(parts, ", ")
+}
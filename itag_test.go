@@ -16,3 +16,30 @@ func TestYoutube_GetItagInfo(t *testing.T) {
 	require.NoError(err)
 	require.Len(video.Formats, 18)
 }
+
+func TestLookupItag(t *testing.T) {
+	info, ok := LookupItag(251)
+	require.True(t, ok)
+	require.Equal(t, ItagInfo{Itag: 251, Container: "webm", AudioCodec: "opus", AudioBitrate: 160}, info)
+
+	_, ok = LookupItag(1)
+	require.False(t, ok)
+}
+
+func TestFormat_Describe(t *testing.T) {
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{Format{ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, Height: 360}, "mp4, avc1 360p, mp4a 96 kbit/s"},
+		{Format{ItagNo: 299, MimeType: `video/mp4; codecs="avc1.64002a"`, Height: 1080, FPS: 60}, "mp4, avc1 1080p60, video only"},
+		{Format{ItagNo: 251, MimeType: `audio/webm; codecs="opus"`}, "webm, opus 160 kbit/s, audio only"},
+		{Format{ItagNo: 22}, "mp4, avc1 720p, mp4a 192 kbit/s, only for some older videos, deprecated"},
+		{Format{ItagNo: 9999, MimeType: `video/webm; codecs="vp9"`, Height: 1440, FPS: 30}, "webm, vp9 1440p, video only"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.format.Describe())
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// minLiveChatPollInterval limits the polling rate if YouTube asks to poll again immediately
+var minLiveChatPollInterval = time.Second
+
+// LiveChatMessage is a message of the live chat of a stream
+type LiveChatMessage struct {
+	ID string `json:"id"`
+	// Time is when the message was sent
+	Time            time.Time `json:"time"`
+	Author          string    `json:"author"`
+	AuthorChannelID string    `json:"authorChannelId"`
+	Message         string    `json:"message"`
+	// Amount is the purchase amount of paid messages (Super Chats), e.g. "€5.00"
+	Amount string `json:"amount,omitempty"`
+}
+
+// LiveChat captures the live chat of a live stream, given by its ID or URL, and calls fn for every message.
+// It returns once the stream has ended, ctx is done or fn fails. The messages of the first poll may
+// have been sent before LiveChat was called.
+func (c *Client) LiveChat(ctx context.Context, videoURL string, fn func(*LiveChatMessage) error) error {
+	id, err := extractVideoID(videoURL)
+	if err != nil {
+		return fmt.Errorf("extractVideoID failed: %w", err)
+	}
+
+	var next interface{}
+	if err := c.innertubeRequest(ctx, "next", "", map[string]interface{}{"videoId": id}, &next); err != nil {
+		return err
+	}
+
+	var reloads []struct {
+		Continuation string `json:"continuation"`
+	}
+	if err := findRenderers(next, "reloadContinuationData", &reloads); err != nil {
+		return fmt.Errorf("%w: %v", ErrParse, err)
+	}
+	if len(reloads) == 0 || reloads[0].Continuation == "" {
+		return fmt.Errorf("%w: no live chat found", ErrParse)
+	}
+	continuation := reloads[0].Continuation
+
+	for {
+		var response interface{}
+		err := c.innertubeRequest(ctx, "live_chat/get_live_chat", "", map[string]interface{}{"continuation": continuation}, &response)
+		if err != nil {
+			return err
+		}
+
+		messages, err := parseLiveChatMessages(response)
+		if err != nil {
+			return err
+		}
+		for _, message := range messages {
+			if err := fn(message); err != nil {
+				return err
+			}
+		}
+
+		var timeout time.Duration
+		continuation, timeout = liveChatContinuation(response)
+		if continuation == "" {
+			// the stream has ended
+			return nil
+		}
+		if timeout < minLiveChatPollInterval {
+			timeout = minLiveChatPollInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(timeout):
+		}
+	}
+}
+
+// liveChatContinuation returns the token of the next poll and how long to wait for it
+func liveChatContinuation(response interface{}) (string, time.Duration) {
+	type continuationData struct {
+		Continuation string `json:"continuation"`
+		TimeoutMs    int    `json:"timeoutMs"`
+	}
+
+	for _, key := range []string{"invalidationContinuationData", "timedContinuationData"} {
+		var data []continuationData
+		if err := findRenderers(response, key, &data); err != nil {
+			continue
+		}
+		for _, d := range data {
+			if d.Continuation != "" {
+				return d.Continuation, time.Duration(d.TimeoutMs) * time.Millisecond
+			}
+		}
+	}
+	return "", 0
+}
+
+type liveChatMessageRenderer struct {
+	ID                      string        `json:"id"`
+	TimestampUsec           string        `json:"timestampUsec"`
+	AuthorName              innertubeText `json:"authorName"`
+	AuthorExternalChannelID string        `json:"authorExternalChannelId"`
+	Message                 innertubeText `json:"message"`
+	PurchaseAmountText      innertubeText `json:"purchaseAmountText"`
+}
+
+func parseLiveChatMessages(response interface{}) ([]*LiveChatMessage, error) {
+	var renderers []liveChatMessageRenderer
+	for _, key := range []string{"liveChatTextMessageRenderer", "liveChatPaidMessageRenderer"} {
+		var found []liveChatMessageRenderer
+		if err := findRenderers(response, key, &found); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrParse, err)
+		}
+		renderers = append(renderers, found...)
+	}
+
+	messages := make([]*LiveChatMessage, 0, len(renderers))
+	for _, r := range renderers {
+		usec, _ := strconv.ParseInt(r.TimestampUsec, 10, 64)
+		messages = append(messages, &LiveChatMessage{
+			ID:              r.ID,
+			Time:            time.Unix(0, usec*int64(time.Microsecond)),
+			Author:          r.AuthorName.String(),
+			AuthorChannelID: r.AuthorExternalChannelID,
+			Message:         r.Message.String(),
+			Amount:          r.PurchaseAmountText.String(),
+		})
+	}
+
+	// paid and text messages are interleaved in the chat
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Time.Before(messages[j].Time)
+	})
+	return messages, nil
+}
@@ -0,0 +1,52 @@
+package youtube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_LiveChat(t *testing.T) {
+	minLiveChatPollInterval = time.Millisecond
+	defer func() { minLiveChatPollInterval = time.Second }()
+
+	responses := map[string]string{
+		"": `{"contents":{"twoColumnWatchNextResults":{"conversationBar":{"liveChatRenderer":{"continuations":[{"reloadContinuationData":{"continuation":"first"}}]}}}}}`,
+		"first": `{"continuationContents":{"liveChatContinuation":{"continuations":[{"invalidationContinuationData":{"continuation":"second","timeoutMs":0}}],
+"actions":[{"addChatItemAction":{"item":{"liveChatPaidMessageRenderer":{"id":"m2","timestampUsec":"1600000002000000","authorName":{"simpleText":"Bob"},
+"purchaseAmountText":{"simpleText":"€5.00"},"message":{"runs":[{"text":"thanks"}]}}}}},
+{"addChatItemAction":{"item":{"liveChatTextMessageRenderer":{"id":"m1","timestampUsec":"1600000001000000","authorName":{"simpleText":"Alice"},
+"authorExternalChannelId":"UCaaaaaaaaaaaaaaaaaaaaaa","message":{"runs":[{"text":"hello "},{"text":"world"}]}}}}}]}}}`,
+		// the stream has ended once there is no continuation
+		"second": `{"continuationContents":{"liveChatContinuation":{"actions":[{"addChatItemAction":{"item":{"liveChatTextMessageRenderer":{"id":"m3","timestampUsec":"1600000003000000","message":{"runs":[{"text":"bye"}]}}}}}]}}}`,
+	}
+	client := newInnertubeTestClient(func(endpoint string, payload map[string]interface{}) string {
+		token, _ := payload["continuation"].(string)
+		if token == "" {
+			assert.Equal(t, "next", endpoint)
+			assert.Equal(t, "BaW_jenozKc", payload["videoId"])
+		} else {
+			assert.Equal(t, "live_chat/get_live_chat", endpoint)
+		}
+		return responses[token]
+	})
+
+	var messages []*LiveChatMessage
+	err := client.LiveChat(context.Background(), "BaW_jenozKc", func(message *LiveChatMessage) error {
+		messages = append(messages, message)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+
+	assert.Equal(t, "m1", messages[0].ID)
+	assert.Equal(t, "hello world", messages[0].Message)
+	assert.Equal(t, "Alice", messages[0].Author)
+	assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa", messages[0].AuthorChannelID)
+	assert.Equal(t, time.Unix(1600000001, 0), messages[0].Time)
+	assert.Equal(t, "€5.00", messages[1].Amount)
+	assert.Equal(t, "bye", messages[2].Message)
+}
@@ -0,0 +1,102 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const musicDescriptionPrefix = "Provided to YouTube by "
+
+var (
+	musicAlbumURLPattern = regexp.MustCompile(`music\.youtube\.com/browse/(MPREb_[\w-]+)`)
+	// albumPlaylistPattern matches the ID of the playlist behind an album
+	albumPlaylistPattern = regexp.MustCompile(`OLAK5uy_[\w-]{33}`)
+	releasedOnPattern    = regexp.MustCompile(`(?m)^Released on: (\d{4}-\d{2}-\d{2})$`)
+)
+
+// MusicMetadata is the metadata of a track published through YouTube Music,
+// read from the description of the auto-generated video
+type MusicMetadata struct {
+	Track   string
+	Artists []string
+	Album   string
+	// TrackNumber is the position on the album, 0 if unknown
+	TrackNumber int
+	ReleaseDate time.Time
+}
+
+// Artist returns the artists joined for tagging
+func (m *MusicMetadata) Artist() string {
+	return strings.Join(m.Artists, ", ")
+}
+
+// IsMusicURL reports whether the URL belongs to music.youtube.com
+func IsMusicURL(url string) bool {
+	return strings.Contains(url, "music.youtube.com")
+}
+
+// IsAlbumPlaylist reports whether the playlist ID is the one of an album
+func IsAlbumPlaylist(id string) bool {
+	return strings.HasPrefix(id, "OLAK5uy_")
+}
+
+// ResolveMusicURL returns the playlist URL of music.youtube.com album URLs, which have no video or playlist ID.
+// Track and playlist URLs of YouTube Music are understood by GetVideo and GetPlaylist and are returned unchanged.
+func (c *Client) ResolveMusicURL(ctx context.Context, musicURL string) (string, error) {
+	match := musicAlbumURLPattern.FindStringSubmatch(musicURL)
+	if match == nil {
+		return musicURL, nil
+	}
+
+	page, err := c.httpGetBodyBytes(ctx, "https://music.youtube.com/browse/"+match[1])
+	if err != nil {
+		return "", err
+	}
+	playlistID := albumPlaylistPattern.Find(page)
+	if playlistID == nil {
+		return "", fmt.Errorf("%w: no playlist of album %s found", ErrParse, match[1])
+	}
+	return "https://music.youtube.com/playlist?list=" + string(playlistID), nil
+}
+
+// parseMusicDescription reads the metadata from the description of auto-generated music videos:
+//
+//	Provided to YouTube by Label
+//
+//	Track · Artist · Other Artist
+//
+//	Album
+//
+//	℗ 2019 Label
+//
+//	Released on: 2019-05-17
+//
+// It returns nil for other descriptions.
+func parseMusicDescription(description string) *MusicMetadata {
+	if !strings.HasPrefix(description, musicDescriptionPrefix) {
+		return nil
+	}
+
+	blocks := strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n\n")
+	if len(blocks) < 3 {
+		return nil
+	}
+
+	names := strings.Split(strings.TrimSpace(blocks[1]), " · ")
+	if len(names) < 2 {
+		return nil
+	}
+	music := &MusicMetadata{
+		Track:   strings.TrimSpace(names[0]),
+		Artists: names[1:],
+		Album:   strings.TrimSpace(blocks[2]),
+	}
+
+	if match := releasedOnPattern.FindStringSubmatch(description); match != nil {
+		music.ReleaseDate, _ = time.Parse("2006-01-02", match[1])
+	}
+	return music
+}
@@ -0,0 +1,53 @@
+package youtube
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMusicDescription(t *testing.T) {
+	music := parseMusicDescription("Provided to YouTube by Example Records\n\nSong · Artist · Guest\n\nAlbum\n\n℗ 2019 Example Records\n\nReleased on: 2019-05-17\n\nAuto-generated by YouTube.")
+	require.NotNil(t, music)
+	assert.Equal(t, "Song", music.Track)
+	assert.Equal(t, []string{"Artist", "Guest"}, music.Artists)
+	assert.Equal(t, "Artist, Guest", music.Artist())
+	assert.Equal(t, "Album", music.Album)
+	assert.Equal(t, time.Date(2019, 5, 17, 0, 0, 0, 0, time.UTC), music.ReleaseDate)
+
+	assert.Nil(t, parseMusicDescription("Check out my new video!"))
+	assert.Nil(t, parseMusicDescription("Provided to YouTube by Example Records\n\nno separator\n\nAlbum"))
+}
+
+func TestExtractPlaylistID_Album(t *testing.T) {
+	id, err := extractPlaylistID("https://music.youtube.com/playlist?list=OLAK5uy_nMr9h2VlS-2PULNz3M3XVXQj_P3C2bqaY")
+	require.NoError(t, err)
+	assert.Equal(t, "OLAK5uy_nMr9h2VlS-2PULNz3M3XVXQj_P3C2bqaY", id)
+	assert.True(t, IsAlbumPlaylist(id))
+	assert.False(t, IsAlbumPlaylist("PLqAfPOrmacr963ATEroh67fbvjmTzTEx5"))
+}
+
+func TestResolveMusicURL(t *testing.T) {
+	client := &Client{HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/browse/MPREb_4pL8gzRtw1p", req.URL.Path)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"playlistId":"OLAK5uy_nMr9h2VlS-2PULNz3M3XVXQj_P3C2bqaY"}`)),
+		}, nil
+	})}}
+
+	resolved, err := client.ResolveMusicURL(context.Background(), "https://music.youtube.com/browse/MPREb_4pL8gzRtw1p")
+	require.NoError(t, err)
+	assert.Equal(t, "https://music.youtube.com/playlist?list=OLAK5uy_nMr9h2VlS-2PULNz3M3XVXQj_P3C2bqaY", resolved)
+
+	// track URLs need no resolution
+	resolved, err = client.ResolveMusicURL(context.Background(), "https://music.youtube.com/watch?v=BaW_jenozKc")
+	require.NoError(t, err)
+	assert.Equal(t, "https://music.youtube.com/watch?v=BaW_jenozKc", resolved)
+}
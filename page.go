@@ -0,0 +1,174 @@
+package youtube
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+// defaultMaxResponseSize caps the responses read by default, watch pages and players have a few MiB
+const defaultMaxResponseSize = 32 << 20
+
+// Markers of the JSON objects kept by scanWatchPage
+var (
+	playerResponseMarker = []byte("var ytInitialPlayerResponse")
+	initialDataMarker    = []byte("var ytInitialData")
+	ytcfgMarker          = []byte("ytcfg.set(")
+)
+
+// watchPage holds the parts of a watch page which are parsed, the rest of the HTML is dropped while reading
+type watchPage struct {
+	playerResponse []byte // the object assigned to ytInitialPlayerResponse
+	initialData    []byte // the object assigned to ytInitialData
+	config         []byte // the objects passed to ytcfg.set
+}
+
+// getWatchPage requests a page and scans it without holding the whole body in memory
+func (c *Client) getWatchPage(ctx context.Context, url string) (*watchPage, error) {
+	resp, err := c.httpGet(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return scanWatchPage(c.limitResponse(resp.Body))
+}
+
+// scanWatchPage reads the page once and only keeps the objects of the player response,
+// the initial data and the configuration
+func scanWatchPage(r io.Reader) (*watchPage, error) {
+	br := bufio.NewReader(r)
+	page := &watchPage{}
+
+	// tail holds the last bytes read, enough to recognize the longest marker
+	tail := make([]byte, 0, len(playerResponseMarker))
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return page, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tail) == cap(tail) {
+			copy(tail, tail[1:])
+			tail = tail[:len(tail)-1]
+		}
+		tail = append(tail, b)
+
+		var target *[]byte
+		switch {
+		case page.playerResponse == nil && bytes.HasSuffix(tail, playerResponseMarker):
+			target = &page.playerResponse
+		case page.initialData == nil && bytes.HasSuffix(tail, initialDataMarker):
+			target = &page.initialData
+		case bytes.HasSuffix(tail, ytcfgMarker):
+			target = &page.config
+		default:
+			continue
+		}
+
+		object, err := scanObject(br)
+		if err != nil {
+			return nil, err
+		}
+		*target = append(*target, object...)
+		tail = tail[:0]
+	}
+}
+
+// scanObject reads the JSON object following a marker, like = {...} or ({...}).
+// It returns nil if the marker isn't followed by an object, e.g. if it was part of a longer name.
+func scanObject(br *bufio.Reader) ([]byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b == '{' {
+			break
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' && b != '=' {
+			return nil, br.UnreadByte()
+		}
+	}
+
+	object := []byte{'{'}
+	depth := 1
+	inString, escaped := false, false
+	for depth > 0 {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: unterminated JSON object in page", ErrParse)
+		}
+		if err != nil {
+			return nil, err
+		}
+		object = append(object, b)
+
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch b {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case b == '"':
+			inString = true
+		case b == '{':
+			depth++
+		case b == '}':
+			depth--
+		}
+	}
+	return object, nil
+}
+
+// limitResponse fails reading a response body with ErrResponseTooLarge after Client.MaxResponseSize bytes
+func (c *Client) limitResponse(body io.Reader) io.Reader {
+	limit := c.maxResponseSize()
+	if limit < 0 {
+		return body
+	}
+	return &limitedReader{r: body, remaining: limit}
+}
+
+// maxResponseSize returns the limit of the response size, negative if unlimited
+func (c *Client) maxResponseSize() int64 {
+	if c.MaxResponseSize == 0 {
+		return defaultMaxResponseSize
+	}
+	return c.MaxResponseSize
+}
+
+// limitedReader is like io.LimitedReader, but fails instead of ending the body early
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// a body of exactly the maximum size ends here
+		var b [1]byte
+		if n, err := l.r.Read(b[:]); n == 0 && err != nil {
+			return 0, err
+		}
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
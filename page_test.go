@@ -0,0 +1,56 @@
+package youtube
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanWatchPage(t *testing.T) {
+	page := testWatchPage +
+		`<script>window["ytInitialPlayerResponse"] = null;` +
+		`var ytInitialPlayerResponse = {"videoDetails":{"title":"a } in \"quotes\"};"}};var meta = {};</script>` +
+		strings.Repeat("<div></div>", 10000) +
+		`<script>var ytInitialData = {"contents":{}};</script>` +
+		`<script>ytcfg.set({"VISITOR_DATA":"Cgt2aXNpdG9y"});</script>`
+
+	scanned, err := scanWatchPage(strings.NewReader(page))
+	require.NoError(t, err)
+	assert.Equal(t, `{"videoDetails":{"title":"a } in \"quotes\"};"}}`, string(scanned.playerResponse))
+	assert.Equal(t, `{"contents":{}}`, string(scanned.initialData))
+
+	config, ok := parseInnertubeConfig(scanned.config)
+	assert.True(t, ok)
+	assert.Equal(t, "test-key", config.APIKey)
+	assert.Equal(t, "Cgt2aXNpdG9y", config.VisitorData)
+
+	v := &Video{}
+	require.NoError(t, v.parseVideoPage(&watchPage{playerResponse: []byte(
+		`{"playabilityStatus":{"status":"OK"},"videoDetails":{"title":"test"},` +
+			`"streamingData":{"formats":[{"itag":18,"url":"https://example.com/videoplayback?itag=18"}]}}`)}, parseOptions{}))
+	assert.Equal(t, "test", v.Title)
+	assert.True(t, errors.Is((&Video{}).parseVideoPage(&watchPage{}, parseOptions{}), ErrParse))
+
+	_, err = scanWatchPage(strings.NewReader(`var ytInitialPlayerResponse = {"a":{`))
+	assert.True(t, errors.Is(err, ErrParse))
+}
+
+func TestClient_limitResponse(t *testing.T) {
+	client := &Client{MaxResponseSize: 10}
+
+	body, err := ioutil.ReadAll(client.limitResponse(strings.NewReader("0123456789")))
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(body))
+
+	_, err = ioutil.ReadAll(client.limitResponse(strings.NewReader("0123456789a")))
+	assert.True(t, errors.Is(err, ErrResponseTooLarge))
+
+	client.MaxResponseSize = -1
+	body, err = ioutil.ReadAll(client.limitResponse(strings.NewReader("0123456789a")))
+	require.NoError(t, err)
+	assert.Len(t, body, 11)
+}
@@ -0,0 +1,100 @@
+package youtube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ParseMode controls how player responses which deviate from the expected schema are handled
+type ParseMode int
+
+const (
+	// ParseStrict fails on values of unexpected types, the default
+	ParseStrict ParseMode = iota
+	// ParseLenient keeps what could be parsed and reports the deviations as Video.ParseWarnings,
+	// e.g. values of unexpected types or renamed sections. Videos may lack formats in this mode.
+	ParseLenient
+)
+
+// playerResponseSections are the parts of the player response the video is extracted from,
+// they are reported as missing in lenient mode since a renamed section would be silently empty
+var playerResponseSections = []string{"playabilityStatus", "videoDetails", "streamingData", "microformat"}
+
+// parseOptions are the parse settings of the client
+type parseOptions struct {
+	mode                ParseMode
+	keepRaw             bool
+	descriptionChapters bool
+}
+
+func (c *Client) parseOptions() parseOptions {
+	return parseOptions{
+		mode:                c.ParseMode,
+		keepRaw:             c.KeepRawPlayerResponse,
+		descriptionChapters: c.ChaptersFromDescription,
+	}
+}
+
+// parsePlayerResponse decodes the player response and extracts the video from it
+func (v *Video) parsePlayerResponse(data []byte, isVideoPage bool, opts parseOptions) error {
+	if opts.keepRaw {
+		v.RawPlayerResponse = append(json.RawMessage(nil), data...)
+	}
+
+	var prData playerResponseData
+	if err := json.Unmarshal(data, &prData); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if opts.mode != ParseLenient || !errors.As(err, &typeErr) {
+			return fmt.Errorf("%w: unable to parse player response JSON: %v", ErrParse, err)
+		}
+		// the decoder continued after the value of the unexpected type
+		v.ParseWarnings = append(v.ParseWarnings, err.Error())
+	}
+	if opts.mode == ParseLenient {
+		v.ParseWarnings = append(v.ParseWarnings, missingSections(data)...)
+	}
+
+	// a missing status is reported above, lenient parsing assumes the video is playable then
+	if opts.mode != ParseLenient || prData.PlayabilityStatus.Status != "" {
+		if err := v.isVideoDownloadable(prData, isVideoPage); err != nil {
+			return err
+		}
+	}
+
+	err := v.extractDataFromPlayerResponse(prData)
+	if opts.descriptionChapters {
+		v.Chapters = DescriptionChapters(v.DescriptionEntities, v.Duration)
+	}
+	if opts.mode == ParseLenient && errors.Is(err, ErrParse) {
+		v.ParseWarnings = append(v.ParseWarnings, err.Error())
+		return nil
+	}
+	return err
+}
+
+// missingSections returns a warning for every section of the player response which is not present
+func missingSections(data []byte) []string {
+	var sections map[string]json.RawMessage
+	if json.Unmarshal(data, &sections) != nil {
+		return nil
+	}
+
+	var warnings []string
+	for _, section := range playerResponseSections {
+		if _, ok := sections[section]; !ok {
+			warnings = append(warnings, "player response has no "+section)
+		}
+	}
+	return warnings
+}
+
+// ParsePlayerResponse decodes a player response, like the ytInitialPlayerResponse of a watch page,
+// the answer of the player endpoint of the innertube API or a Video.RawPlayerResponse
+func ParsePlayerResponse(data []byte) (*PlayerResponse, error) {
+	var response PlayerResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse player response JSON: %v", ErrParse, err)
+	}
+	return &response, nil
+}
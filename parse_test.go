@@ -0,0 +1,63 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideo_parsePlayerResponse(t *testing.T) {
+	// lengthSeconds became a number and streamingData was renamed
+	const data = `{"playabilityStatus":{"status":"OK"},` +
+		`"videoDetails":{"title":"test","lengthSeconds":60,"author":"someone"},` +
+		`"streamingDataV2":{"formats":[{"itag":18}]},"newSection":{"a":1}}`
+
+	v := &Video{}
+	err := v.parsePlayerResponse([]byte(data), true, parseOptions{})
+	assert.True(t, errors.Is(err, ErrParse))
+	assert.Nil(t, v.RawPlayerResponse)
+
+	v = &Video{}
+	require.NoError(t, v.parsePlayerResponse([]byte(data), true, parseOptions{mode: ParseLenient, keepRaw: true}))
+	assert.Equal(t, "test", v.Title)
+	assert.Equal(t, "someone", v.Author)
+	assert.Empty(t, v.Formats)
+	assert.JSONEq(t, data, string(v.RawPlayerResponse))
+	require.Len(t, v.ParseWarnings, 4)
+	assert.Contains(t, v.ParseWarnings[0], "lengthSeconds")
+	assert.Equal(t, "player response has no streamingData", v.ParseWarnings[1])
+	assert.Equal(t, "player response has no microformat", v.ParseWarnings[2])
+	assert.Contains(t, v.ParseWarnings[3], "no formats found")
+
+	// syntax errors are not tolerated
+	err = (&Video{}).parsePlayerResponse([]byte(`{"videoDetails":`), true, parseOptions{mode: ParseLenient})
+	assert.True(t, errors.Is(err, ErrParse))
+
+	// unplayable videos still fail
+	err = (&Video{}).parsePlayerResponse([]byte(`{"playabilityStatus":{"status":"LOGIN_REQUIRED"}}`), true, parseOptions{mode: ParseLenient})
+	var statusErr *ErrPlayabiltyStatus
+	assert.True(t, errors.As(err, &statusErr))
+}
+
+func TestParsePlayerResponse(t *testing.T) {
+	response, err := ParsePlayerResponse([]byte(`{"playabilityStatus":{"status":"OK"},` +
+		`"videoDetails":{"videoId":"BaW_jenozKc","title":"test"},` +
+		`"streamingData":{"adaptiveFormats":[{"itag":140,"mimeType":"audio/mp4"}]},` +
+		`"captions":{"playerCaptionsTracklistRenderer":{"captionTracks":[{"languageCode":"en","kind":"asr"}]}},` +
+		`"storyboards":{"playerStoryboardSpecRenderer":{"spec":"https://i.ytimg.com/sb/BaW_jenozKc/storyboard3_L$L/$N.jpg|48#27"}},` +
+		`"microformat":{"playerMicroformatRenderer":{"category":"Education","publishDate":"2012-10-02"}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "OK", response.PlayabilityStatus.Status)
+	assert.Equal(t, "BaW_jenozKc", response.VideoDetails.VideoID)
+	require.Len(t, response.StreamingData.AdaptiveFormats, 1)
+	assert.Equal(t, 140, response.StreamingData.AdaptiveFormats[0].ItagNo)
+	require.Len(t, response.Captions.PlayerCaptionsTracklistRenderer.CaptionTracks, 1)
+	assert.Equal(t, "asr", response.Captions.PlayerCaptionsTracklistRenderer.CaptionTracks[0].Kind)
+	assert.Contains(t, response.Storyboards.PlayerStoryboardSpecRenderer.Spec, "storyboard3_L$L")
+	assert.Equal(t, "Education", response.Microformat.PlayerMicroformatRenderer.Category)
+
+	_, err = ParsePlayerResponse([]byte(`{"videoDetails":`))
+	assert.True(t, errors.Is(err, ErrParse))
+}
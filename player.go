@@ -0,0 +1,126 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// playerJSExpiration is the time after which the player is fetched again, YouTube rolls out new players every few days
+const playerJSExpiration = 6 * time.Hour
+
+// signatureTimestampPattern matches the signature timestamp in the player, e.g. signatureTimestamp:19137 or sts:19137
+var signatureTimestampPattern = regexp.MustCompile(`(?:signatureTimestamp|sts)\s*:\s*(\d{5})`)
+
+// playerVersionPattern matches the version of the player in its URL
+var playerVersionPattern = regexp.MustCompile(`/s/player/(\w+)/`)
+
+// playerJS is the base.js of the web player
+type playerJS struct {
+	URL     string // e.g. /s/player/f676c671/player_ias.vflset/en_US/base.js
+	Version string // e.g. f676c671
+	Body    []byte
+	// SignatureTimestamp identifies the version of the signature algorithm of the player.
+	// Player requests without it may be answered with URLs which can't be deciphered.
+	SignatureTimestamp int
+
+	expiredAt time.Time
+}
+
+// playerCache holds the last fetched player, it is shared by all clients
+var playerCache struct {
+	sync.Mutex
+	player *playerJS
+}
+
+// parseSignatureTimestamp extracts the signature timestamp of the player, 0 if not found
+func parseSignatureTimestamp(body []byte) int {
+	if m := signatureTimestampPattern.FindSubmatch(body); m != nil {
+		sts, _ := strconv.Atoi(string(m[1]))
+		return sts
+	}
+	return 0
+}
+
+// playerVersion extracts the version from the URL of the player, the URL itself if it has an unknown form
+func playerVersion(basejsURL string) string {
+	if m := playerVersionPattern.FindStringSubmatch(basejsURL); m != nil {
+		return m[1]
+	}
+	return basejsURL
+}
+
+// getPlayerJS returns the cached player, the embed page of the video is scraped for the current one if it has expired
+func (c *Client) getPlayerJS(ctx context.Context, videoID string) (*playerJS, error) {
+	playerCache.Lock()
+	player := playerCache.player
+	playerCache.Unlock()
+
+	if player != nil && player.expiredAt.After(time.Now()) {
+		return player, nil
+	}
+
+	embedURL := fmt.Sprintf("https://youtube.com/embed/%s?hl=en", videoID)
+	embedBody, err := c.httpGetBodyBytes(ctx, embedURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// example: /s/player/f676c671/player_ias.vflset/en_US/base.js
+	escapedBasejsURL := string(basejsPattern.Find(embedBody))
+	if escapedBasejsURL == "" {
+		log.Println("playerConfig:", string(embedBody))
+		return nil, fmt.Errorf("%w: basejs URL not found in playerConfig", ErrDecipher)
+	}
+
+	basejsBody, err := c.httpGetBodyBytes(ctx, "https://youtube.com"+escapedBasejsURL)
+	if err != nil {
+		return nil, err
+	}
+
+	player = &playerJS{
+		URL:                escapedBasejsURL,
+		Version:            playerVersion(escapedBasejsURL),
+		Body:               basejsBody,
+		SignatureTimestamp: parseSignatureTimestamp(basejsBody),
+		expiredAt:          time.Now().Add(playerJSExpiration),
+	}
+	if player.SignatureTimestamp == 0 && c.Debug {
+		log.Println("no signature timestamp found in", escapedBasejsURL)
+	}
+
+	playerCache.Lock()
+	playerCache.player = player
+	playerCache.Unlock()
+
+	return player, nil
+}
+
+// videoFromInnertube fetches the player response of the video from the innertube API.
+// The signature timestamp of the player is sent along, so the ciphers match the cached player.
+func (c *Client) videoFromInnertube(ctx context.Context, v *Video) error {
+	request := map[string]interface{}{"videoId": v.ID}
+
+	player, err := c.getPlayerJS(ctx, v.ID)
+	if err != nil {
+		return err
+	}
+	if player.SignatureTimestamp > 0 {
+		request["playbackContext"] = map[string]interface{}{
+			"contentPlaybackContext": map[string]interface{}{
+				"signatureTimestamp": player.SignatureTimestamp,
+			},
+		}
+	}
+
+	var data json.RawMessage
+	if err := c.innertubeRequest(ctx, "player", "", request, &data); err != nil {
+		return err
+	}
+	return v.parsePlayerResponse(data, true, c.parseOptions())
+}
@@ -0,0 +1,68 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSignatureTimestamp(t *testing.T) {
+	assert.Equal(t, 19137, parseSignatureTimestamp([]byte(`var a={signatureTimestamp:19137,b:1}`)))
+	assert.Equal(t, 18953, parseSignatureTimestamp([]byte(`c.set("x",{sts:18953})`)))
+	assert.Equal(t, 0, parseSignatureTimestamp([]byte(`var sts=1;`)))
+}
+
+func TestClient_videoFromInnertube(t *testing.T) {
+	innertubeCache.configs = nil
+	playerCache.player = nil
+	defer func() {
+		innertubeCache.configs = nil
+		playerCache.player = nil
+	}()
+
+	embedRequests := 0
+	client := &Client{HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := testWatchPage
+		switch {
+		case strings.HasPrefix(req.URL.Path, "/embed/"):
+			embedRequests++
+			body = `<script src="/s/player/f676c671/player_ias.vflset/en_US/base.js"></script>`
+		case strings.HasSuffix(req.URL.Path, "/base.js"):
+			body = `var x={signatureTimestamp:19137};`
+		case req.URL.Path == "/youtubei/v1/player":
+			var payload struct {
+				VideoID         string `json:"videoId"`
+				PlaybackContext struct {
+					ContentPlaybackContext struct {
+						SignatureTimestamp int `json:"signatureTimestamp"`
+					} `json:"contentPlaybackContext"`
+				} `json:"playbackContext"`
+			}
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&payload))
+			assert.Equal(t, "BaW_jenozKc", payload.VideoID)
+			assert.Equal(t, 19137, payload.PlaybackContext.ContentPlaybackContext.SignatureTimestamp)
+			body = `{"playabilityStatus":{"status":"OK"},"videoDetails":{"title":"test"},` +
+				`"streamingData":{"formats":[{"itag":18,"url":"https://example.com/videoplayback?itag=18"}]}}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}}
+
+	for i := 0; i < 2; i++ {
+		v := &Video{ID: "BaW_jenozKc"}
+		require.NoError(t, client.videoFromInnertube(context.Background(), v))
+		assert.Equal(t, "test", v.Title)
+		require.Len(t, v.Formats, 1)
+		assert.Equal(t, 18, v.Formats[0].ItagNo)
+	}
+	assert.Equal(t, 1, embedRequests, "the player must be cached")
+	assert.Equal(t, 19137, playerCache.player.SignatureTimestamp)
+}
@@ -12,22 +12,42 @@ const (
 )
 
 var (
-	playlistIDRegex    = regexp.MustCompile("^[A-Za-z0-9_-]{24,34}$")
-	playlistInURLRegex = regexp.MustCompile("[&?]list=([A-Za-z0-9_-]{24,34})(&.*)?$")
+	// album playlists of YouTube Music are longer than the others
+	playlistIDRegex    = regexp.MustCompile("^(?:[A-Za-z0-9_-]{24,34}|OLAK5uy_[A-Za-z0-9_-]{33})$")
+	playlistInURLRegex = regexp.MustCompile("[&?]list=([A-Za-z0-9_-]{24,34}|OLAK5uy_[A-Za-z0-9_-]{33})(&.*)?$")
+)
+
+// Titles of unavailable playlist entries
+const (
+	deletedVideoTitle = "[Deleted video]"
+	privateVideoTitle = "[Private video]"
 )
 
 type Playlist struct {
-	ID     string
-	Title  string           `json:"title"`
-	Author string           `json:"author"`
-	Videos []*PlaylistEntry `json:"video"`
+	ID         string
+	Title      string           `json:"title"`
+	Author     string           `json:"author"`
+	Videos     []*PlaylistEntry `json:"video"`
+	VideoCount int              `json:"-"`
+
+	// client is used to fetch further pages of the playlist
+	client *Client
+	// complete is set if Videos holds all entries, so there are no further pages
+	complete bool
 }
 
 type PlaylistEntry struct {
-	ID       string `json:"encrypted_id"`
-	Title    string `json:"title"`
-	Author   string `json:"author"`
-	Duration time.Duration
+	ID        string `json:"encrypted_id"`
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	Duration  time.Duration
+	IsPrivate bool
+	IsDeleted bool
+}
+
+// IsAvailable tells whether the entry can be downloaded
+func (p *PlaylistEntry) IsAvailable() bool {
+	return !p.IsPrivate && !p.IsDeleted
 }
 
 func (p *PlaylistEntry) UnmarshalJSON(b []byte) error {
@@ -42,6 +62,8 @@ func (p *PlaylistEntry) UnmarshalJSON(b []byte) error {
 	}
 	p.ID, p.Title, p.Author = wf.ID, wf.Title, wf.Author
 	p.Duration = time.Second * time.Duration(wf.DurationSeconds)
+	p.IsDeleted = wf.Title == deletedVideoTitle
+	p.IsPrivate = wf.Title == privateVideoTitle
 	return nil
 }
 
@@ -0,0 +1,91 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// PlaylistIterator lazily pages through the entries of a playlist.
+// Further pages are only requested once the entries of the previous one have been consumed.
+type PlaylistIterator struct {
+	ctx     context.Context
+	client  *Client
+	id      string
+	entries []*PlaylistEntry
+	seen    map[string]bool
+	done    bool
+}
+
+// Entries returns an iterator over all entries of the playlist, starting with the already fetched ones
+func (p *Playlist) Entries(ctx context.Context) *PlaylistIterator {
+	client := p.client
+	if client == nil {
+		client = &Client{}
+	}
+
+	it := &PlaylistIterator{
+		ctx:    ctx,
+		client: client,
+		id:     p.ID,
+		seen:   make(map[string]bool),
+		done:   p.complete,
+	}
+	it.push(p.Videos)
+
+	return it
+}
+
+// Next returns the next entry of the playlist, or io.EOF once all entries have been returned
+func (it *PlaylistIterator) Next() (*PlaylistEntry, error) {
+	for len(it.entries) == 0 {
+		if it.done {
+			return nil, io.EOF
+		}
+		if err := it.fetch(); err != nil {
+			return nil, err
+		}
+	}
+
+	entry := it.entries[0]
+	it.entries = it.entries[1:]
+	return entry, nil
+}
+
+// fetch requests the page starting after the last seen entry
+func (it *PlaylistIterator) fetch() error {
+	requestURL := fmt.Sprintf(playlistFetchURL, it.id) + "&index=" + strconv.Itoa(len(it.seen)+1)
+	resp, err := it.client.httpGet(it.ctx, requestURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var page Playlist
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return err
+	}
+
+	// pages overlap, the playlist is exhausted once a page has nothing new
+	if it.push(page.Videos) == 0 {
+		it.done = true
+	}
+
+	return nil
+}
+
+// push queues the entries which have not been seen yet and returns their number
+func (it *PlaylistIterator) push(entries []*PlaylistEntry) int {
+	n := 0
+	for _, entry := range entries {
+		if it.seen[entry.ID] {
+			continue
+		}
+		it.seen[entry.ID] = true
+		it.entries = append(it.entries, entry)
+		n++
+	}
+	return n
+}
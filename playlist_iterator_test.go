@@ -0,0 +1,59 @@
+package youtube
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestPlaylistIterator(t *testing.T) {
+	require := require.New(t)
+
+	// pages of the playlist, list_ajax returns overlapping windows around the requested index
+	pages := map[string]string{
+		"":  `{"title":"test","author":"me","video":[{"encrypted_id":"a"},{"encrypted_id":"b","title":"[Deleted video]"}]}`,
+		"3": `{"video":[{"encrypted_id":"b"},{"encrypted_id":"c","title":"[Private video]"},{"encrypted_id":"d"}]}`,
+		"5": `{"video":[{"encrypted_id":"c"},{"encrypted_id":"d"}]}`,
+	}
+	var requests []string
+	client := Client{HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		index := req.URL.Query().Get("index")
+		requests = append(requests, index)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(pages[index])),
+		}, nil
+	})}}
+
+	playlist, err := client.GetPlaylistContext(context.Background(), "PLqAfPOrmacr963ATEroh67fbvjmTzTEx5")
+	require.NoError(err)
+	require.Equal(2, playlist.VideoCount)
+	require.True(playlist.Videos[1].IsDeleted)
+
+	it := playlist.Entries(context.Background())
+	var ids []string
+	for {
+		entry, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+		ids = append(ids, entry.ID+":"+strconv.FormatBool(entry.IsAvailable()))
+	}
+
+	assert.Equal(t, []string{"a:true", "b:false", "c:false", "d:true"}, ids)
+	assert.Equal(t, []string{"", "3", "5"}, requests)
+}
@@ -0,0 +1,61 @@
+package youtube
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+// POTokenProvider returns the proof-of-origin token for the streams of a video.
+// Some videos demand the token, their streams are answered with 403 Forbidden otherwise.
+// Tokens come from an attestation of a browser, so they are supplied by an external generator.
+// The provider is called for every stream request, see CachePOTokens.
+type POTokenProvider func(ctx context.Context, videoID string) (string, error)
+
+// StaticPOToken returns a provider of the same token for all videos
+func StaticPOToken(token string) POTokenProvider {
+	return func(ctx context.Context, videoID string) (string, error) {
+		return token, nil
+	}
+}
+
+// CachePOTokens returns a provider asking the given one only once per video
+func CachePOTokens(provider POTokenProvider) POTokenProvider {
+	var mu sync.Mutex
+	tokens := make(map[string]string)
+	return func(ctx context.Context, videoID string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if token, ok := tokens[videoID]; ok {
+			return token, nil
+		}
+		token, err := provider(ctx, videoID)
+		if err != nil {
+			return "", err
+		}
+		tokens[videoID] = token
+		return token, nil
+	}
+}
+
+// addPOToken sets the pot parameter of the stream URL to the token of the provider
+func (c *Client) addPOToken(ctx context.Context, videoID, streamURL string) (string, error) {
+	if c.POTokenProvider == nil {
+		return streamURL, nil
+	}
+
+	token, err := c.POTokenProvider(ctx, videoID)
+	if err != nil || token == "" {
+		return streamURL, err
+	}
+
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("pot", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
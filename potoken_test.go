@@ -0,0 +1,38 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_POTokenProvider(t *testing.T) {
+	calls := 0
+	client := &Client{POTokenProvider: CachePOTokens(func(ctx context.Context, videoID string) (string, error) {
+		calls++
+		assert.Equal(t, "BaW_jenozKc", videoID)
+		return "token-" + videoID, nil
+	})}
+	video := &Video{ID: "BaW_jenozKc"}
+	format := &Format{ItagNo: 18, URL: "https://example.com/videoplayback?itag=18"}
+
+	for i := 0; i < 2; i++ {
+		streamURL, err := client.GetStreamURL(video, format)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/videoplayback?itag=18&pot=token-BaW_jenozKc", streamURL)
+	}
+	assert.Equal(t, 1, calls, "the tokens must be cached")
+
+	client.POTokenProvider = StaticPOToken("")
+	streamURL, err := client.GetStreamURL(video, format)
+	require.NoError(t, err)
+	assert.Equal(t, format.URL, streamURL)
+
+	failure := errors.New("no token")
+	client.POTokenProvider = func(ctx context.Context, videoID string) (string, error) { return "", failure }
+	_, err = client.GetStreamURL(video, format)
+	assert.True(t, errors.Is(err, failure))
+}
@@ -0,0 +1,230 @@
+package youtube
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder receives the HTTP exchanges of a client for debugging extraction failures
+type Recorder interface {
+	Record(exchange *Exchange)
+}
+
+var (
+	_ Recorder = &JSONLRecorder{}
+	_ Recorder = &HARRecorder{}
+)
+
+// Exchange is a recorded HTTP request and its response.
+// Credentials, cookies, the visitor data and the client IP are removed, media bodies are not recorded.
+type Exchange struct {
+	Time           time.Time     `json:"time"`
+	Duration       time.Duration `json:"duration"`
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	RequestHeader  http.Header   `json:"requestHeader,omitempty"`
+	Status         int           `json:"status,omitempty"`
+	ResponseHeader http.Header   `json:"responseHeader,omitempty"`
+	ResponseBody   string        `json:"responseBody,omitempty"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// Headers and query parameters which are not recorded
+var (
+	sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Goog-Visitor-Id"}
+	sensitiveParams  = []string{"ip", "key", "sig", "lsig", "signature", "pot"}
+)
+
+// visitorDataPattern matches the visitor data in the JSON of innertube responses and watch pages,
+// which identifies the session like the X-Goog-Visitor-Id header
+var visitorDataPattern = regexp.MustCompile(`"(visitorData|VISITOR_DATA)"\s*:\s*"[^"]*"`)
+
+// recordExchange records the exchange, the response body is replaced by a copy if it is recorded.
+// At most maxBody bytes of the body are read for the record, the rest is read by the caller,
+// so the response size limit of the client still applies. A negative maxBody records all of it.
+func recordExchange(recorder Recorder, req *http.Request, resp *http.Response, err error, start time.Time, maxBody int64) {
+	exchange := &Exchange{
+		Time:          start,
+		Duration:      time.Since(start),
+		Method:        req.Method,
+		URL:           sanitizeURL(req.URL),
+		RequestHeader: sanitizeHeader(req.Header),
+	}
+
+	if err != nil {
+		exchange.Error = err.Error()
+	} else {
+		exchange.Status = resp.StatusCode
+		exchange.ResponseHeader = sanitizeHeader(resp.Header)
+
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if !strings.HasPrefix(mediaType, "video/") && !strings.HasPrefix(mediaType, "audio/") {
+			reader := io.Reader(resp.Body)
+			if maxBody >= 0 {
+				reader = io.LimitReader(resp.Body, maxBody)
+			}
+			body, readErr := ioutil.ReadAll(reader)
+
+			var rest io.Reader = resp.Body
+			if readErr != nil {
+				rest = errReader{readErr}
+			}
+			resp.Body = recordedBody{Reader: io.MultiReader(bytes.NewReader(body), rest), Closer: resp.Body}
+			exchange.ResponseBody = sanitizeBody(string(body))
+		}
+	}
+
+	recorder.Record(exchange)
+}
+
+// recordedBody returns the recorded part of the body followed by the rest of the original one
+type recordedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader returns the error of reading the original body, or io.EOF
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
+
+func sanitizeURL(u *url.URL) string {
+	sanitized := *u
+	query := sanitized.Query()
+	for _, param := range sensitiveParams {
+		if query.Get(param) != "" {
+			query.Set(param, "REDACTED")
+		}
+	}
+	sanitized.RawQuery = query.Encode()
+	return sanitized.String()
+}
+
+// sanitizeBody removes the visitor data from the body
+func sanitizeBody(body string) string {
+	return visitorDataPattern.ReplaceAllString(body, `"$1":"REDACTED"`)
+}
+
+func sanitizeHeader(header http.Header) http.Header {
+	sanitized := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if sanitized.Get(name) != "" {
+			sanitized.Set(name, "REDACTED")
+		}
+	}
+	return sanitized
+}
+
+// JSONLRecorder writes every exchange as a line of JSON
+type JSONLRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLRecorder returns a recorder writing to w
+func NewJSONLRecorder(w io.Writer) *JSONLRecorder {
+	return &JSONLRecorder{enc: json.NewEncoder(w)}
+}
+
+func (r *JSONLRecorder) Record(exchange *Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enc.Encode(exchange) //nolint:errcheck
+}
+
+// HARRecorder collects the exchanges for writing them as HTTP Archive, which browsers can import
+type HARRecorder struct {
+	mu        sync.Mutex
+	exchanges []*Exchange
+}
+
+func (r *HARRecorder) Record(exchange *Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, exchange)
+}
+
+// WriteFile writes the recorded exchanges as HAR file
+func (r *HARRecorder) WriteFile(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type harHeader struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	headers := func(header http.Header) []harHeader {
+		list := []harHeader{}
+		for name, values := range header {
+			for _, value := range values {
+				list = append(list, harHeader{name, value})
+			}
+		}
+		return list
+	}
+
+	entries := make([]interface{}, 0, len(r.exchanges))
+	for _, exchange := range r.exchanges {
+		ms := float64(exchange.Duration) / float64(time.Millisecond)
+		entries = append(entries, map[string]interface{}{
+			"startedDateTime": exchange.Time.Format(time.RFC3339Nano),
+			"time":            ms,
+			"request": map[string]interface{}{
+				"method":      exchange.Method,
+				"url":         exchange.URL,
+				"httpVersion": "HTTP/1.1",
+				"headers":     headers(exchange.RequestHeader),
+				"queryString": []interface{}{},
+				"cookies":     []interface{}{},
+				"headersSize": -1,
+				"bodySize":    -1,
+			},
+			"response": map[string]interface{}{
+				"status":      exchange.Status,
+				"statusText":  http.StatusText(exchange.Status),
+				"httpVersion": "HTTP/1.1",
+				"headers":     headers(exchange.ResponseHeader),
+				"cookies":     []interface{}{},
+				"content": map[string]interface{}{
+					"size":     len(exchange.ResponseBody),
+					"mimeType": exchange.ResponseHeader.Get("Content-Type"),
+					"text":     exchange.ResponseBody,
+				},
+				"redirectURL": "",
+				"headersSize": -1,
+				"bodySize":    -1,
+				"_error":      exchange.Error,
+			},
+			"cache":   map[string]interface{}{},
+			"timings": map[string]interface{}{"send": 0, "wait": ms, "receive": 0},
+		})
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"log": map[string]interface{}{
+			"version": "1.2",
+			"creator": map[string]string{"name": "github.com/kkdai/youtube", "version": "2"},
+			"entries": entries,
+		},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, 0o644)
+}
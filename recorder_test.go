@@ -0,0 +1,114 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "VISITOR_INFO1_LIVE", Value: "secret"})
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := &Client{DebugRecorder: NewJSONLRecorder(&buf)}
+
+	body, err := client.httpGetBodyBytes(context.Background(), server.URL+"/get_video_info?video_id=BaW_jenozKc&ip=1.2.3.4")
+	require.NoError(t, err)
+	assert.Equal(t, `{"status":"ok"}`, string(body), "the body must remain readable")
+
+	var exchange Exchange
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &exchange))
+	assert.Equal(t, http.MethodGet, exchange.Method)
+	assert.Equal(t, server.URL+"/get_video_info?ip=REDACTED&video_id=BaW_jenozKc", exchange.URL)
+	assert.Equal(t, http.StatusOK, exchange.Status)
+	assert.Equal(t, "REDACTED", exchange.ResponseHeader.Get("Set-Cookie"))
+	assert.Equal(t, `{"status":"ok"}`, exchange.ResponseBody)
+}
+
+func TestHARRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write([]byte("media"))
+	}))
+	defer server.Close()
+
+	recorder := &HARRecorder{}
+	client := &Client{DebugRecorder: recorder}
+	body, err := client.httpGetBodyBytes(context.Background(), server.URL+"/videoplayback")
+	require.NoError(t, err)
+	assert.Equal(t, "media", string(body))
+
+	dir, err := ioutil.TempDir("", "har")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "dump.har")
+	require.NoError(t, recorder.WriteFile(file))
+
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	var har struct {
+		Log struct {
+			Entries []struct {
+				Request struct {
+					URL string `json:"url"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	require.NoError(t, json.Unmarshal(data, &har))
+	require.Len(t, har.Log.Entries, 1)
+	assert.Equal(t, server.URL+"/videoplayback", har.Log.Entries[0].Request.URL)
+	assert.Equal(t, http.StatusOK, har.Log.Entries[0].Response.Status)
+	assert.Empty(t, har.Log.Entries[0].Response.Content.Text, "media is not recorded")
+}
+
+func TestSanitizeURL(t *testing.T) {
+	u, err := url.Parse("https://rr1---sn-abc.googlevideo.com/videoplayback?itag=18&ip=1.2.3.4&sig=abc&pot=token&n=xyz")
+	require.NoError(t, err)
+	assert.Equal(t, "https://rr1---sn-abc.googlevideo.com/videoplayback?ip=REDACTED&itag=18&n=xyz&pot=REDACTED&sig=REDACTED",
+		sanitizeURL(u))
+}
+
+func TestRecorder_maxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), 100))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := &Client{DebugRecorder: NewJSONLRecorder(&buf), MaxResponseSize: 10}
+
+	_, err := client.httpGetBodyBytes(context.Background(), server.URL)
+	assert.True(t, errors.Is(err, ErrResponseTooLarge), "the limit still applies with a recorder")
+
+	var exchange Exchange
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &exchange))
+	assert.Equal(t, "aaaaaaaaaa", exchange.ResponseBody, "only the limit is recorded")
+}
+
+func TestSanitizeBody(t *testing.T) {
+	assert.Equal(t, `{"responseContext":{"visitorData":"REDACTED"},"VISITOR_DATA":"REDACTED"}`,
+		sanitizeBody(`{"responseContext":{"visitorData": "CgtzZWNyZXQ%3D"},"VISITOR_DATA":"secret"}`))
+}
@@ -1,123 +1,168 @@
 package youtube
 
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PlayerResponse is the answer of the player of YouTube, which describes a video and its streams.
+// Videos are extracted from it, it gives access to the details which are not part of Video.
+type PlayerResponse struct {
+	PlayabilityStatus PlayabilityStatus `json:"playabilityStatus"`
+	StreamingData     StreamingData     `json:"streamingData"`
+	Captions          Captions          `json:"captions"`
+	VideoDetails      VideoDetails      `json:"videoDetails"`
+	PlayerConfig      PlayerConfig      `json:"playerConfig"`
+	Storyboards       Storyboards       `json:"storyboards"`
+	Microformat       Microformat       `json:"microformat"`
+}
+
+// playerResponseData adds the parts of the player response which are only used internally
 type playerResponseData struct {
-	PlayabilityStatus struct {
-		Status          string `json:"status"`
-		Reason          string `json:"reason"`
-		PlayableInEmbed bool   `json:"playableInEmbed"`
-		ContextParams   string `json:"contextParams"`
-	} `json:"playabilityStatus"`
-	StreamingData struct {
-		ExpiresInSeconds string   `json:"expiresInSeconds"`
-		Formats          []Format `json:"formats"`
-		AdaptiveFormats  []Format `json:"adaptiveFormats"`
-		DashManifestURL  string   `json:"dashManifestUrl"`
-		HlsManifestURL   string   `json:"hlsManifestUrl"`
-	} `json:"streamingData"`
-	Captions struct {
-		PlayerCaptionsRenderer struct {
-			BaseURL    string `json:"baseUrl"`
-			Visibility string `json:"visibility"`
-		} `json:"playerCaptionsRenderer"`
-		PlayerCaptionsTracklistRenderer struct {
-			CaptionTracks []struct {
-				BaseURL string `json:"baseUrl"`
-				Name    struct {
-					SimpleText string `json:"simpleText"`
-				} `json:"name"`
-				VssID          string `json:"vssId"`
-				LanguageCode   string `json:"languageCode"`
-				Kind           string `json:"kind"`
-				IsTranslatable bool   `json:"isTranslatable"`
-			} `json:"captionTracks"`
-			AudioTracks []struct {
-				CaptionTrackIndices []int `json:"captionTrackIndices"`
-			} `json:"audioTracks"`
-			TranslationLanguages []struct {
-				LanguageCode string `json:"languageCode"`
-				LanguageName struct {
-					SimpleText string `json:"simpleText"`
-				} `json:"languageName"`
-			} `json:"translationLanguages"`
-			DefaultAudioTrackIndex int `json:"defaultAudioTrackIndex"`
-		} `json:"playerCaptionsTracklistRenderer"`
-	} `json:"captions"`
-	VideoDetails struct {
-		VideoID          string `json:"videoId"`
-		Title            string `json:"title"`
-		LengthSeconds    string `json:"lengthSeconds"`
-		ChannelID        string `json:"channelId"`
-		IsOwnerViewing   bool   `json:"isOwnerViewing"`
-		ShortDescription string `json:"shortDescription"`
-		IsCrawlable      bool   `json:"isCrawlable"`
-		Thumbnail        struct {
-			Thumbnails []Thumbnail `json:"thumbnails"`
-		} `json:"thumbnail"`
-		AverageRating     float64 `json:"averageRating"`
-		AllowRatings      bool    `json:"allowRatings"`
-		ViewCount         string  `json:"viewCount"`
-		Author            string  `json:"author"`
-		IsPrivate         bool    `json:"isPrivate"`
-		IsUnpluggedCorpus bool    `json:"isUnpluggedCorpus"`
-		IsLiveContent     bool    `json:"isLiveContent"`
-	} `json:"videoDetails"`
-	PlayerConfig struct {
-		AudioConfig struct {
-			LoudnessDb              float64 `json:"loudnessDb"`
-			PerceptualLoudnessDb    float64 `json:"perceptualLoudnessDb"`
-			EnablePerFormatLoudness bool    `json:"enablePerFormatLoudness"`
-		} `json:"audioConfig"`
-		StreamSelectionConfig struct {
-			MaxBitrate string `json:"maxBitrate"`
-		} `json:"streamSelectionConfig"`
-		MediaCommonConfig struct {
-			DynamicReadaheadConfig struct {
-				MaxReadAheadMediaTimeMs int `json:"maxReadAheadMediaTimeMs"`
-				MinReadAheadMediaTimeMs int `json:"minReadAheadMediaTimeMs"`
-				ReadAheadGrowthRateMs   int `json:"readAheadGrowthRateMs"`
-			} `json:"dynamicReadaheadConfig"`
-		} `json:"mediaCommonConfig"`
-	} `json:"playerConfig"`
-	Storyboards struct {
-		PlayerStoryboardSpecRenderer struct {
-			Spec string `json:"spec"`
-		} `json:"playerStoryboardSpecRenderer"`
-	} `json:"storyboards"`
-	Microformat struct {
-		PlayerMicroformatRenderer struct {
-			Thumbnail struct {
-				Thumbnails []struct {
-					URL    string `json:"url"`
-					Width  int    `json:"width"`
-					Height int    `json:"height"`
-				} `json:"thumbnails"`
-			} `json:"thumbnail"`
-			Embed struct {
-				IframeURL      string `json:"iframeUrl"`
-				FlashURL       string `json:"flashUrl"`
-				Width          int    `json:"width"`
-				Height         int    `json:"height"`
-				FlashSecureURL string `json:"flashSecureUrl"`
-			} `json:"embed"`
-			Title struct {
-				SimpleText string `json:"simpleText"`
-			} `json:"title"`
-			Description struct {
-				SimpleText string `json:"simpleText"`
-			} `json:"description"`
-			LengthSeconds      string   `json:"lengthSeconds"`
-			OwnerProfileURL    string   `json:"ownerProfileUrl"`
-			ExternalChannelID  string   `json:"externalChannelId"`
-			AvailableCountries []string `json:"availableCountries"`
-			IsUnlisted         bool     `json:"isUnlisted"`
-			HasYpcMetadata     bool     `json:"hasYpcMetadata"`
-			ViewCount          string   `json:"viewCount"`
-			Category           string   `json:"category"`
-			PublishDate        string   `json:"publishDate"`
-			OwnerChannelName   string   `json:"ownerChannelName"`
-			UploadDate         string   `json:"uploadDate"`
-		} `json:"playerMicroformatRenderer"`
-	} `json:"microformat"`
+	PlayerResponse
+	FrameworkUpdates frameworkUpdatesData `json:"frameworkUpdates"`
+}
+
+// PlayabilityStatus tells whether the video can be played, Status is "OK" if so
+type PlayabilityStatus struct {
+	Status          string `json:"status"`
+	Reason          string `json:"reason"`
+	PlayableInEmbed bool   `json:"playableInEmbed"`
+	ContextParams   string `json:"contextParams"`
+}
+
+// StreamingData lists the streams of the video
+type StreamingData struct {
+	ExpiresInSeconds string   `json:"expiresInSeconds"`
+	Formats          []Format `json:"formats"`
+	AdaptiveFormats  []Format `json:"adaptiveFormats"`
+	DashManifestURL  string   `json:"dashManifestUrl"`
+	HlsManifestURL   string   `json:"hlsManifestUrl"`
+}
+
+// Captions lists the subtitles of the video
+type Captions struct {
+	PlayerCaptionsRenderer struct {
+		BaseURL    string `json:"baseUrl"`
+		Visibility string `json:"visibility"`
+	} `json:"playerCaptionsRenderer"`
+	PlayerCaptionsTracklistRenderer CaptionTracklist `json:"playerCaptionsTracklistRenderer"`
+}
+
+// CaptionTracklist holds the caption tracks and the languages they can be translated to
+type CaptionTracklist struct {
+	CaptionTracks []CaptionTrack `json:"captionTracks"`
+	AudioTracks   []struct {
+		CaptionTrackIndices []int `json:"captionTrackIndices"`
+	} `json:"audioTracks"`
+	TranslationLanguages   []TranslationLanguage `json:"translationLanguages"`
+	DefaultAudioTrackIndex int                   `json:"defaultAudioTrackIndex"`
+}
+
+// CaptionTrack is a subtitle track, Kind is "asr" for automatic captions
+type CaptionTrack struct {
+	BaseURL string `json:"baseUrl"`
+	Name    struct {
+		SimpleText string `json:"simpleText"`
+	} `json:"name"`
+	VssID          string `json:"vssId"`
+	LanguageCode   string `json:"languageCode"`
+	Kind           string `json:"kind"`
+	IsTranslatable bool   `json:"isTranslatable"`
+}
+
+// TranslationLanguage is a language the caption tracks can be translated to
+type TranslationLanguage struct {
+	LanguageCode string `json:"languageCode"`
+	LanguageName struct {
+		SimpleText string `json:"simpleText"`
+	} `json:"languageName"`
+}
+
+// VideoDetails are the basic metadata of the video
+type VideoDetails struct {
+	VideoID          string `json:"videoId"`
+	Title            string `json:"title"`
+	LengthSeconds    string `json:"lengthSeconds"`
+	ChannelID        string `json:"channelId"`
+	IsOwnerViewing   bool   `json:"isOwnerViewing"`
+	ShortDescription string `json:"shortDescription"`
+	IsCrawlable      bool   `json:"isCrawlable"`
+	Thumbnail        struct {
+		Thumbnails []Thumbnail `json:"thumbnails"`
+	} `json:"thumbnail"`
+	AverageRating     float64 `json:"averageRating"`
+	AllowRatings      bool    `json:"allowRatings"`
+	ViewCount         string  `json:"viewCount"`
+	Author            string  `json:"author"`
+	IsPrivate         bool    `json:"isPrivate"`
+	IsUnpluggedCorpus bool    `json:"isUnpluggedCorpus"`
+	IsLiveContent     bool    `json:"isLiveContent"`
+	IsLive            bool    `json:"isLive"`
+}
+
+// PlayerConfig tunes the playback, like the loudness normalization
+type PlayerConfig struct {
+	AudioConfig struct {
+		LoudnessDb              float64 `json:"loudnessDb"`
+		PerceptualLoudnessDb    float64 `json:"perceptualLoudnessDb"`
+		EnablePerFormatLoudness bool    `json:"enablePerFormatLoudness"`
+	} `json:"audioConfig"`
+	StreamSelectionConfig struct {
+		MaxBitrate string `json:"maxBitrate"`
+	} `json:"streamSelectionConfig"`
+	MediaCommonConfig struct {
+		DynamicReadaheadConfig struct {
+			MaxReadAheadMediaTimeMs int `json:"maxReadAheadMediaTimeMs"`
+			MinReadAheadMediaTimeMs int `json:"minReadAheadMediaTimeMs"`
+			ReadAheadGrowthRateMs   int `json:"readAheadGrowthRateMs"`
+		} `json:"dynamicReadaheadConfig"`
+	} `json:"mediaCommonConfig"`
+}
+
+// Storyboards describe the preview images shown while seeking
+type Storyboards struct {
+	PlayerStoryboardSpecRenderer struct {
+		// Spec is a list of levels separated by |, starting with the URL template
+		Spec string `json:"spec"`
+	} `json:"playerStoryboardSpecRenderer"`
+}
+
+// Microformat holds the metadata of the video page
+type Microformat struct {
+	PlayerMicroformatRenderer PlayerMicroformat `json:"playerMicroformatRenderer"`
+}
+
+// PlayerMicroformat are the metadata of the video page, like the category and the publish date
+type PlayerMicroformat struct {
+	Thumbnail struct {
+		Thumbnails []Thumbnail `json:"thumbnails"`
+	} `json:"thumbnail"`
+	Embed struct {
+		IframeURL      string `json:"iframeUrl"`
+		FlashURL       string `json:"flashUrl"`
+		Width          int    `json:"width"`
+		Height         int    `json:"height"`
+		FlashSecureURL string `json:"flashSecureUrl"`
+	} `json:"embed"`
+	Title struct {
+		SimpleText string `json:"simpleText"`
+	} `json:"title"`
+	Description struct {
+		SimpleText string `json:"simpleText"`
+	} `json:"description"`
+	LengthSeconds      string   `json:"lengthSeconds"`
+	OwnerProfileURL    string   `json:"ownerProfileUrl"`
+	ExternalChannelID  string   `json:"externalChannelId"`
+	AvailableCountries []string `json:"availableCountries"`
+	IsUnlisted         bool     `json:"isUnlisted"`
+	HasYpcMetadata     bool     `json:"hasYpcMetadata"`
+	ViewCount          string   `json:"viewCount"`
+	Category           string   `json:"category"`
+	PublishDate        string   `json:"publishDate"`
+	OwnerChannelName   string   `json:"ownerChannelName"`
+	UploadDate         string   `json:"uploadDate"`
 }
 
 type Format struct {
@@ -151,12 +196,31 @@ type Format struct {
 		Start string `json:"start"`
 		End   string `json:"end"`
 	} `json:"indexRange"`
+
+	// AudioTrack is only available for videos with multiple audio tracks
+	AudioTrack *AudioTrack `json:"audioTrack"`
+
+	// Headers are added to the stream requests of the format, e.g. the User-Agent a CDN expects
+	Headers http.Header `json:"-"`
+	// QueryParams are set on the stream URL of the format, e.g. rn and rbuf like the web player.
+	// Empty values remove the parameter.
+	QueryParams url.Values `json:"-"`
+
+	// mime is the MimeType parsed during decoding
+	mime *mimeInfo
 }
 
-type Thumbnails []Thumbnail
+// AudioTrack describes the audio track of a format of a dubbed video
+type AudioTrack struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	IsDefault   bool   `json:"audioIsDefault"`
+}
 
-type Thumbnail struct {
-	URL    string
-	Width  uint
-	Height uint
+// Language returns the language of the track, e.g. "en" or "de-DE"
+func (t AudioTrack) Language() string {
+	if i := strings.IndexByte(t.ID, '.'); i >= 0 {
+		return t.ID[:i]
+	}
+	return t.ID
 }
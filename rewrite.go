@@ -0,0 +1,68 @@
+package youtube
+
+import (
+	"net/url"
+	"strings"
+)
+
+// RewriteStreamURL applies StreamHostRewrite to the URL of a stream, it is returned unchanged on errors
+func (c *Client) RewriteStreamURL(rawURL string) string {
+	if c.StreamHostRewrite == nil {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if rewritten := c.StreamHostRewrite(u); rewritten != nil {
+		return rewritten.String()
+	}
+	return rawURL
+}
+
+// StreamHostMapping returns a StreamHostRewrite replacing hosts by the mapping.
+// Keys are host names, "*.googlevideo.com" matches all subdomains.
+// Values are host names or base URLs, "{host}" is replaced by the original host, e.g.
+//
+//	"*.googlevideo.com": "http://cache.example.edu:3128/{host}"
+//
+// sends "https://r1---sn-abc.googlevideo.com/videoplayback?..." to
+// "http://cache.example.edu:3128/r1---sn-abc.googlevideo.com/videoplayback?...".
+func StreamHostMapping(mapping map[string]string) func(*url.URL) *url.URL {
+	return func(u *url.URL) *url.URL {
+		target, ok := matchHost(mapping, u.Hostname())
+		if !ok {
+			return u
+		}
+		target = strings.ReplaceAll(target, "{host}", u.Host)
+
+		rewritten := *u
+		if !strings.Contains(target, "://") {
+			rewritten.Host = target
+			return &rewritten
+		}
+
+		base, err := url.Parse(target)
+		if err != nil {
+			return u
+		}
+		rewritten.Scheme = base.Scheme
+		rewritten.Host = base.Host
+		rewritten.Path = strings.TrimSuffix(base.Path, "/") + u.Path
+		rewritten.RawPath = ""
+		return &rewritten
+	}
+}
+
+// matchHost looks up the host in the mapping, exact names take precedence over wildcards
+func matchHost(mapping map[string]string, host string) (string, bool) {
+	if target, ok := mapping[host]; ok {
+		return target, true
+	}
+	for pattern, target := range mapping {
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return target, true
+		}
+	}
+	return "", false
+}
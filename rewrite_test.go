@@ -0,0 +1,46 @@
+package youtube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamHostMapping(t *testing.T) {
+	rewrite := StreamHostMapping(map[string]string{
+		"*.googlevideo.com":             "http://cache.example.edu:3128/{host}/",
+		"r1---sn-exact.googlevideo.com": "mirror.example.edu",
+	})
+	c := &Client{StreamHostRewrite: rewrite}
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{
+			"https://r4---sn-abc.googlevideo.com/videoplayback?itag=18&sig=a%2Fb",
+			"http://cache.example.edu:3128/r4---sn-abc.googlevideo.com/videoplayback?itag=18&sig=a%2Fb",
+		},
+		{
+			"https://r1---sn-exact.googlevideo.com/videoplayback?itag=18",
+			"https://mirror.example.edu/videoplayback?itag=18",
+		},
+		{
+			"https://www.youtube.com/watch?v=BaW_jenozKc",
+			"https://www.youtube.com/watch?v=BaW_jenozKc",
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, c.RewriteStreamURL(tt.url))
+	}
+}
+
+func TestClient_GetStreamURL_Rewrite(t *testing.T) {
+	c := &Client{StreamHostRewrite: StreamHostMapping(map[string]string{"*.googlevideo.com": "mirror.example.edu"})}
+
+	url, err := c.GetStreamURLContext(context.Background(), &Video{ID: "BaW_jenozKc"}, &Format{URL: "https://r4---sn-abc.googlevideo.com/videoplayback?itag=18"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://mirror.example.edu/videoplayback?itag=18", url)
+}
@@ -0,0 +1,99 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IDs of the special playlists of the signed in account, GetPlaylist accepts them and their URLs
+const (
+	WatchLaterPlaylist  = "WL"
+	LikedVideosPlaylist = "LL"
+	HistoryPlaylist     = "history"
+)
+
+// specialPlaylistBrowseIDs map the special playlists to the browse IDs of their pages,
+// they are not available through the playlist API
+var specialPlaylistBrowseIDs = map[string]string{
+	WatchLaterPlaylist:  "VLWL",
+	LikedVideosPlaylist: "VLLL",
+	HistoryPlaylist:     "FEhistory",
+}
+
+var specialPlaylistTitles = map[string]string{
+	WatchLaterPlaylist:  "Watch later",
+	LikedVideosPlaylist: "Liked videos",
+	HistoryPlaylist:     "History",
+}
+
+// extractSpecialPlaylistID recognizes the special playlists, e.g. "WL", "https://www.youtube.com/playlist?list=LL"
+// or "https://www.youtube.com/feed/history"
+func extractSpecialPlaylistID(rawURL string) (string, bool) {
+	if _, ok := specialPlaylistBrowseIDs[rawURL]; ok {
+		return rawURL, true
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.HasSuffix(u.Hostname(), "youtube.com") {
+		return "", false
+	}
+	if u.Path == "/feed/history" {
+		return HistoryPlaylist, true
+	}
+	if id := u.Query().Get("list"); u.Path == "/playlist" && (id == WatchLaterPlaylist || id == LikedVideosPlaylist) {
+		return id, true
+	}
+	return "", false
+}
+
+// getSpecialPlaylist fetches all entries of a special playlist from its browse page
+func (c *Client) getSpecialPlaylist(ctx context.Context, id string) (*Playlist, error) {
+	responses, err := c.browseAccount(ctx, specialPlaylistBrowseIDs[id])
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Playlist{ID: id, Title: specialPlaylistTitles[id], client: c, complete: true}
+	seen := make(map[string]bool)
+	for _, response := range responses {
+		// entries of Watch later and Liked videos, the history lists plain videos
+		for _, key := range []string{"playlistVideoRenderer", "videoRenderer"} {
+			var renderers []struct {
+				VideoID         string        `json:"videoId"`
+				Title           innertubeText `json:"title"`
+				ShortBylineText innertubeText `json:"shortBylineText"`
+				LengthSeconds   string        `json:"lengthSeconds"`
+				LengthText      innertubeText `json:"lengthText"`
+			}
+			if err := findRenderers(response, key, &renderers); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrParse, err)
+			}
+
+			for _, r := range renderers {
+				if r.VideoID == "" || seen[r.VideoID] {
+					continue
+				}
+				seen[r.VideoID] = true
+
+				entry := &PlaylistEntry{
+					ID:       r.VideoID,
+					Title:    r.Title.String(),
+					Author:   r.ShortBylineText.String(),
+					Duration: parseClockDuration(r.LengthText.String()),
+				}
+				if seconds, err := strconv.Atoi(r.LengthSeconds); err == nil {
+					entry.Duration = time.Duration(seconds) * time.Second
+				}
+				entry.IsDeleted = entry.Title == deletedVideoTitle
+				entry.IsPrivate = entry.Title == privateVideoTitle
+				p.Videos = append(p.Videos, entry)
+			}
+		}
+	}
+	p.VideoCount = len(p.Videos)
+	return p, nil
+}
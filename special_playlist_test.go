@@ -0,0 +1,63 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSpecialPlaylistID(t *testing.T) {
+	tests := map[string]string{
+		"WL": WatchLaterPlaylist,
+		"https://www.youtube.com/playlist?list=WL": WatchLaterPlaylist,
+		"https://youtube.com/playlist?list=LL":     LikedVideosPlaylist,
+		"https://www.youtube.com/feed/history":     HistoryPlaylist,
+		"history":                                  HistoryPlaylist,
+		"https://www.youtube.com/playlist?list=PLqAfPOrmacr963ATEroh67fbvjmTzTEx5": "",
+		"https://www.youtube.com/watch?v=BaW_jenozKc&list=WL":                      "",
+		"https://example.com/playlist?list=WL":                                     "",
+	}
+	for rawURL, want := range tests {
+		id, ok := extractSpecialPlaylistID(rawURL)
+		assert.Equal(t, want != "", ok, rawURL)
+		assert.Equal(t, want, id, rawURL)
+	}
+}
+
+func TestClient_GetPlaylist_special(t *testing.T) {
+	innertubeCache.configs = nil
+	defer func() { innertubeCache.configs = nil }()
+
+	client := newInnertubeTestClient(func(endpoint string, payload map[string]interface{}) string {
+		assert.Equal(t, "VLWL", payload["browseId"])
+		return `{"contents":{"items":[
+			{"playlistVideoRenderer":{"videoId":"BaW_jenozKc","title":{"runs":[{"text":"Test video"}]},
+				"shortBylineText":{"runs":[{"text":"Author"}]},"lengthSeconds":"10"}},
+			{"playlistVideoRenderer":{"videoId":"deleted0001","title":{"simpleText":"[Deleted video]"}}}]}}`
+	})
+
+	_, err := client.GetPlaylist("https://www.youtube.com/playlist?list=WL")
+	assert.True(t, errors.Is(err, ErrNotAuthenticated))
+
+	signIn(t, client)
+	playlist, err := client.GetPlaylist("https://www.youtube.com/playlist?list=WL")
+	require.NoError(t, err)
+	assert.Equal(t, "Watch later", playlist.Title)
+	assert.Equal(t, 2, playlist.VideoCount)
+	assert.Equal(t, &PlaylistEntry{ID: "BaW_jenozKc", Title: "Test video", Author: "Author", Duration: 10 * time.Second}, playlist.Videos[0])
+	assert.True(t, playlist.Videos[1].IsDeleted)
+
+	// all entries are fetched at once, the iterator must not request further pages
+	it := playlist.Entries(context.Background())
+	for i := 0; i < 2; i++ {
+		_, err := it.Next()
+		require.NoError(t, err)
+	}
+	_, err = it.Next()
+	assert.Equal(t, io.EOF, err)
+}
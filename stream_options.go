@@ -0,0 +1,41 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// newStreamRequest creates the GET request of a stream URL with the headers of the format
+func (c *Client) newStreamRequest(ctx context.Context, format *Format, streamURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range format.Headers {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	return req, nil
+}
+
+// applyQueryParams sets the QueryParams of the format on the stream URL, empty values remove the parameter
+func (f *Format) applyQueryParams(streamURL string) (string, error) {
+	if len(f.QueryParams) == 0 {
+		return streamURL, nil
+	}
+
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for key, values := range f.QueryParams {
+		if len(values) == 0 || len(values) == 1 && values[0] == "" {
+			query.Del(key)
+		} else {
+			query[key] = values
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
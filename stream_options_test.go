@@ -0,0 +1,51 @@
+package youtube
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat_streamOptions(t *testing.T) {
+	var requests []*http.Request
+	client := &Client{HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("data"))}
+		if req.Header.Get("Range") != "" {
+			resp.StatusCode = http.StatusPartialContent
+			resp.Header.Set("Content-Range", "bytes 0-3/4")
+		}
+		return resp, nil
+	})}}
+
+	video := &Video{ID: "BaW_jenozKc"}
+	format := &Format{
+		ItagNo:      18,
+		URL:         "https://example.com/videoplayback?itag=18&ump=1",
+		Headers:     http.Header{"user-agent": {"test-agent"}, "Range": {"bytes=9-9"}},
+		QueryParams: url.Values{"rn": {"1"}, "rbuf": {"0"}, "ump": {""}},
+	}
+
+	streamURL, err := client.GetStreamURL(video, format)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/videoplayback?itag=18&rbuf=0&rn=1", streamURL)
+
+	resp, err := client.GetStreamRange(context.Background(), video, format, 0, 3)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "test-agent", requests[0].Header.Get("User-Agent"))
+	assert.Equal(t, "bytes=0-3", requests[0].Header.Get("Range"), "the range of the request takes precedence")
+
+	format.Headers = http.Header{"User-Agent": {"test-agent"}}
+	resp, err = client.GetStream(video, format)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "test-agent", requests[1].Header.Get("User-Agent"))
+	assert.Equal(t, "1", requests[1].URL.Query().Get("rn"))
+}
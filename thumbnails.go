@@ -0,0 +1,66 @@
+package youtube
+
+import (
+	"sort"
+	"strings"
+)
+
+type Thumbnails []Thumbnail
+
+type Thumbnail struct {
+	URL    string
+	Width  uint
+	Height uint
+}
+
+// Best returns the thumbnail with the highest resolution
+func (list Thumbnails) Best() *Thumbnail {
+	var best *Thumbnail
+	for i := range list {
+		if best == nil || list[i].Width*list[i].Height > best.Width*best.Height {
+			best = &list[i]
+		}
+	}
+	return best
+}
+
+// MinWidth returns the thumbnails which are at least w pixels wide
+func (list Thumbnails) MinWidth(w uint) Thumbnails {
+	var thumbnails Thumbnails
+	for _, thumbnail := range list {
+		if thumbnail.Width >= w {
+			thumbnails = append(thumbnails, thumbnail)
+		}
+	}
+	return thumbnails
+}
+
+// normalizeThumbnails removes duplicates, adds the maxresdefault thumbnail if it is missing
+// and sorts the thumbnails by ascending width.
+func normalizeThumbnails(videoID string, list Thumbnails) Thumbnails {
+	thumbnails := make(Thumbnails, 0, len(list)+1)
+	seen := make(map[string]bool, len(list))
+	hasMaxRes := false
+	for _, thumbnail := range list {
+		if thumbnail.URL == "" || seen[thumbnail.URL] {
+			continue
+		}
+		seen[thumbnail.URL] = true
+		hasMaxRes = hasMaxRes || strings.Contains(thumbnail.URL, "/maxresdefault.")
+		thumbnails = append(thumbnails, thumbnail)
+	}
+
+	if !hasMaxRes && videoID != "" {
+		thumbnails = append(thumbnails, Thumbnail{
+			URL:    "https://i.ytimg.com/vi/" + videoID + "/maxresdefault.jpg",
+			Width:  1280,
+			Height: 720,
+		})
+	}
+
+	sort.SliceStable(thumbnails, func(i, j int) bool {
+		return thumbnails[i].Width < thumbnails[j].Width
+	})
+
+	return thumbnails
+}
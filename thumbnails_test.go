@@ -0,0 +1,26 @@
+package youtube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThumbnails(t *testing.T) {
+	thumbnails := normalizeThumbnails("BaW_jenozKc", Thumbnails{
+		{URL: "https://i.ytimg.com/vi/BaW_jenozKc/hqdefault.jpg?sqp=a", Width: 336, Height: 188},
+		{URL: "https://i.ytimg.com/vi/BaW_jenozKc/hqdefault.jpg?sqp=b", Width: 168, Height: 94},
+		{URL: "https://i.ytimg.com/vi/BaW_jenozKc/hqdefault.jpg?sqp=a", Width: 336, Height: 188},
+	})
+
+	assert.Equal(t, Thumbnails{
+		{URL: "https://i.ytimg.com/vi/BaW_jenozKc/hqdefault.jpg?sqp=b", Width: 168, Height: 94},
+		{URL: "https://i.ytimg.com/vi/BaW_jenozKc/hqdefault.jpg?sqp=a", Width: 336, Height: 188},
+		{URL: "https://i.ytimg.com/vi/BaW_jenozKc/maxresdefault.jpg", Width: 1280, Height: 720},
+	}, thumbnails)
+
+	assert.Equal(t, "https://i.ytimg.com/vi/BaW_jenozKc/maxresdefault.jpg", thumbnails.Best().URL)
+	assert.Len(t, thumbnails.MinWidth(300), 2)
+	assert.Empty(t, thumbnails.MinWidth(2000))
+	assert.Nil(t, Thumbnails{}.Best())
+}
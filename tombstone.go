@@ -0,0 +1,114 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+const (
+	waybackCDXURL      = "https://web.archive.org/cdx/search/cdx?output=json&fl=timestamp,original&filter=statuscode:200&limit=-%d&url=%s"
+	waybackSnapshotURL = "https://web.archive.org/web/%sid_/%s"
+
+	// waybackMaxSnapshots is the number of the newest snapshots tried until one contains the metadata
+	waybackMaxSnapshots = 3
+)
+
+var (
+	metaTitlePattern     = regexp.MustCompile(`<meta name="title" content="([^"]*)"`)
+	metaAuthorPattern    = regexp.MustCompile(`<link itemprop="name" content="([^"]*)"`)
+	metaChannelIDPattern = regexp.MustCompile(`<meta itemprop="channelId" content="([^"]*)"`)
+)
+
+// Tombstone records what is known about an unavailable video from an archived copy of its watch page
+type Tombstone struct {
+	VideoID     string    `json:"videoId"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author,omitempty"`
+	ChannelID   string    `json:"channelId,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ArchivedAt  time.Time `json:"archivedAt"`
+	ArchiveURL  string    `json:"archiveUrl"`
+}
+
+// GetTombstone recovers the metadata of a video, given by its ID or URL, from the newest archived copy
+// of its watch page in the Wayback Machine. It returns ErrNoArchive if no archived copy has the metadata.
+func (c *Client) GetTombstone(ctx context.Context, videoURL string) (*Tombstone, error) {
+	id, err := extractVideoID(videoURL)
+	if err != nil {
+		return nil, fmt.Errorf("extractVideoID failed: %w", err)
+	}
+
+	body, err := c.httpGetBodyBytes(ctx, fmt.Sprintf(waybackCDXURL, waybackMaxSnapshots, url.QueryEscape("youtube.com/watch?v="+id)))
+	if err != nil {
+		return nil, err
+	}
+
+	// the first row holds the field names, snapshots are sorted by ascending time
+	var rows [][]string
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("%w: unable to parse CDX response: %v", ErrParse, err)
+		}
+	}
+
+	for i := len(rows) - 1; i >= 1; i-- {
+		if len(rows[i]) < 2 {
+			continue
+		}
+		timestamp, original := rows[i][0], rows[i][1]
+
+		archiveURL := fmt.Sprintf(waybackSnapshotURL, timestamp, original)
+		page, err := c.httpGetBodyBytes(ctx, archiveURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		tombstone := parseArchivedWatchPage(page)
+		if tombstone.Title == "" {
+			continue
+		}
+		tombstone.VideoID = id
+		// the snapshot without the id_ suffix is the one with the archive toolbar, for humans
+		tombstone.ArchiveURL = "https://web.archive.org/web/" + timestamp + "/" + original
+		tombstone.ArchivedAt, _ = time.Parse("20060102150405", timestamp)
+		return tombstone, nil
+	}
+
+	return nil, ErrNoArchive
+}
+
+// parseArchivedWatchPage extracts the metadata from the player response of a watch page,
+// older pages without one are parsed from their meta tags
+func parseArchivedWatchPage(page []byte) *Tombstone {
+	tombstone := &Tombstone{}
+
+	if m := playerResponsePattern.FindSubmatch(page); m != nil {
+		var prData playerResponseData
+		if err := json.Unmarshal(m[1], &prData); err == nil && prData.VideoDetails.Title != "" {
+			tombstone.Title = prData.VideoDetails.Title
+			tombstone.Author = prData.VideoDetails.Author
+			tombstone.ChannelID = prData.VideoDetails.ChannelID
+			tombstone.Description = prData.VideoDetails.ShortDescription
+			return tombstone
+		}
+	}
+
+	if m := metaTitlePattern.FindSubmatch(page); m != nil {
+		tombstone.Title = html.UnescapeString(string(m[1]))
+	}
+	if m := metaAuthorPattern.FindSubmatch(page); m != nil {
+		tombstone.Author = html.UnescapeString(string(m[1]))
+	}
+	if m := metaChannelIDPattern.FindSubmatch(page); m != nil {
+		tombstone.ChannelID = string(m[1])
+	}
+	return tombstone
+}
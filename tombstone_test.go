@@ -0,0 +1,43 @@
+package youtube
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetTombstone(t *testing.T) {
+	pages := map[string]string{
+		"/cdx/search/cdx": `[["timestamp","original"],["20150102030405","https://www.youtube.com/watch?v=BaW_jenozKc"],["20190102030405","https://www.youtube.com/watch?v=BaW_jenozKc"]]`,
+		// the newest snapshot is an error page
+		"/web/20190102030405id_/https://www.youtube.com/watch": `<html><title>YouTube</title></html>`,
+		"/web/20150102030405id_/https://www.youtube.com/watch": `<meta name="title" content="youtube-dl test video &quot;&#39;/\">` +
+			`<meta itemprop="channelId" content="UCLqxVugv74EIW3VWh2NOa3Q"><link itemprop="name" content="Philipp Hagemeister">`,
+	}
+	client := &Client{HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := pages[req.URL.Path]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+	})}}
+
+	tombstone, err := client.GetTombstone(context.Background(), "https://www.youtube.com/watch?v=BaW_jenozKc")
+	require.NoError(t, err)
+	assert.Equal(t, "BaW_jenozKc", tombstone.VideoID)
+	assert.Equal(t, `youtube-dl test video "'/\`, tombstone.Title)
+	assert.Equal(t, "Philipp Hagemeister", tombstone.Author)
+	assert.Equal(t, "UCLqxVugv74EIW3VWh2NOa3Q", tombstone.ChannelID)
+	assert.Equal(t, time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC), tombstone.ArchivedAt)
+	assert.Equal(t, "https://web.archive.org/web/20150102030405/https://www.youtube.com/watch?v=BaW_jenozKc", tombstone.ArchiveURL)
+
+	pages["/cdx/search/cdx"] = `[]`
+	_, err = client.GetTombstone(context.Background(), "BaW_jenozKc")
+	assert.Equal(t, ErrNoArchive, err)
+}
@@ -0,0 +1,155 @@
+package youtube
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransportConfig tunes the connections of the HTTP client, see Client.Transport.
+// Zero values select the defaults of net/http.
+type TransportConfig struct {
+	// MaxIdleConnsPerHost is the number of connections kept open per host, net/http keeps 2
+	MaxIdleConnsPerHost int
+	// DisableHTTP2 uses HTTP/1.1 only, the googlevideo CDN may throttle HTTP/2 connections differently
+	DisableHTTP2 bool
+	// KeepAlive is the interval of TCP keep-alive probes, defaults to 30s. Negative values disable them.
+	KeepAlive time.Duration
+	// DialTimeout limits the time to establish a TCP connection, defaults to 30s
+	DialTimeout time.Duration
+	// InsecureSkipVerify disables the verification of TLS server certificates
+	InsecureSkipVerify bool
+
+	// SourceAddresses are local IP addresses to send requests from, rotating between them per request.
+	// Invalid addresses are ignored.
+	SourceAddresses []string
+	// RotatePerDownload sends all requests of a download from the same source address, see WithDownloadKey
+	RotatePerDownload bool
+}
+
+// transportClients shares the HTTP clients of equal configurations, so their connections are reused
+var transportClients sync.Map
+
+// NewTransport returns a transport with the configuration, proxies are taken from the environment.
+// Connections are bound to the first of the SourceAddresses, use NewRoundTripper to rotate between them.
+func (t TransportConfig) NewTransport() *http.Transport {
+	var local net.IP
+	if addresses := t.sourceAddresses(); len(addresses) > 0 {
+		local = addresses[0]
+	}
+	return t.newTransport(local)
+}
+
+func (t TransportConfig) newTransport(local net.IP) *http.Transport {
+	dialTimeout := t.DialTimeout
+	if dialTimeout == 0 {
+		dialTimeout = 30 * time.Second
+	}
+	keepAlive := t.KeepAlive
+	if keepAlive == 0 {
+		keepAlive = 30 * time.Second
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: keepAlive,
+	}
+	if local != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: local}
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     !t.DisableHTTP2,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   t.MaxIdleConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if t.DisableHTTP2 {
+		// a non-nil empty map disables the automatic HTTP/2 upgrade
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if t.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+	return transport
+}
+
+// NewRoundTripper returns a transport with the configuration, which rotates between the SourceAddresses if there are several
+func (t TransportConfig) NewRoundTripper() http.RoundTripper {
+	addresses := t.sourceAddresses()
+	if len(addresses) < 2 {
+		return t.NewTransport()
+	}
+
+	rotating := &rotatingTransport{perDownload: t.RotatePerDownload}
+	for _, address := range addresses {
+		rotating.transports = append(rotating.transports, t.newTransport(address))
+	}
+	return rotating
+}
+
+// HTTPClient returns a client with the configuration, shared by all equal configurations
+func (t TransportConfig) HTTPClient() *http.Client {
+	key := fmt.Sprintf("%#v", t)
+	if client, ok := transportClients.Load(key); ok {
+		return client.(*http.Client)
+	}
+	client, _ := transportClients.LoadOrStore(key, &http.Client{Transport: t.NewRoundTripper()})
+	return client.(*http.Client)
+}
+
+func (t TransportConfig) sourceAddresses() []net.IP {
+	var addresses []net.IP
+	for _, address := range t.SourceAddresses {
+		if ip := net.ParseIP(address); ip != nil {
+			addresses = append(addresses, ip)
+		}
+	}
+	return addresses
+}
+
+type downloadKey struct{}
+
+// WithDownloadKey marks the requests of a download, e.g. with the video ID.
+// Requests with the same key are sent from the same source address if TransportConfig.RotatePerDownload is set.
+func WithDownloadKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, downloadKey{}, key)
+}
+
+// rotatingTransport spreads the requests over transports bound to different source addresses
+type rotatingTransport struct {
+	transports  []*http.Transport
+	perDownload bool
+	next        uint32
+}
+
+func (r *rotatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return r.transport(req).RoundTrip(req)
+}
+
+// transport selects the transport of the download key, or the next one
+func (r *rotatingTransport) transport(req *http.Request) *http.Transport {
+	n := uint32(len(r.transports))
+	if key, ok := req.Context().Value(downloadKey{}).(string); ok && r.perDownload {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		return r.transports[h.Sum32()%n]
+	}
+	return r.transports[(atomic.AddUint32(&r.next, 1)-1)%n]
+}
+
+func (r *rotatingTransport) CloseIdleConnections() {
+	for _, transport := range r.transports {
+		transport.CloseIdleConnections()
+	}
+}
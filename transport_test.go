@@ -0,0 +1,52 @@
+package youtube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportConfig_NewTransport(t *testing.T) {
+	transport := TransportConfig{}.NewTransport()
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.Nil(t, transport.TLSNextProto)
+
+	transport = TransportConfig{DisableHTTP2: true, MaxIdleConnsPerHost: 8}.NewTransport()
+	assert.False(t, transport.ForceAttemptHTTP2)
+	assert.NotNil(t, transport.TLSNextProto)
+	assert.Equal(t, 8, transport.MaxIdleConnsPerHost)
+}
+
+func TestTransportConfig_HTTPClient(t *testing.T) {
+	config := TransportConfig{DialTimeout: 5 * time.Second}
+	assert.Same(t, config.HTTPClient(), config.HTTPClient())
+	assert.NotSame(t, config.HTTPClient(), TransportConfig{DialTimeout: 6 * time.Second}.HTTPClient())
+
+	c := &Client{Transport: &config}
+	assert.Same(t, config.HTTPClient(), c.httpClient())
+}
+
+func TestTransportConfig_NewRoundTripper(t *testing.T) {
+	_, ok := TransportConfig{SourceAddresses: []string{"127.0.0.1"}}.NewRoundTripper().(*http.Transport)
+	assert.True(t, ok)
+
+	config := TransportConfig{SourceAddresses: []string{"127.0.0.1", "invalid", "127.0.0.2"}}
+	rotating := config.NewRoundTripper().(*rotatingTransport)
+	require.Len(t, rotating.transports, 2)
+
+	req := httptest.NewRequest(http.MethodGet, "https://www.youtube.com/", nil)
+	assert.Same(t, rotating.transports[0], rotating.transport(req))
+	assert.Same(t, rotating.transports[1], rotating.transport(req))
+	assert.Same(t, rotating.transports[0], rotating.transport(req))
+
+	rotating.perDownload = true
+	req = req.WithContext(WithDownloadKey(req.Context(), "BaW_jenozKc"))
+	first := rotating.transport(req)
+	for i := 0; i < 3; i++ {
+		assert.Same(t, first, rotating.transport(req))
+	}
+}
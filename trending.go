@@ -0,0 +1,129 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrendingCategory is a tab of the trending page
+type TrendingCategory string
+
+// Categories of the trending page
+const (
+	TrendingNow    TrendingCategory = "now"
+	TrendingMusic  TrendingCategory = "music"
+	TrendingGaming TrendingCategory = "gaming"
+	TrendingMovies TrendingCategory = "movies"
+)
+
+// trendingParams selects the tabs of the trending page in browse requests
+var trendingParams = map[TrendingCategory]string{
+	TrendingNow:    "",
+	TrendingMusic:  "4gINGgt5dG1hX2NoYXJ0cw==",
+	TrendingGaming: "4gIcGhpnYW1pbmdfY29ycHVzX21vc3RfcG9wdWxhcg==",
+	TrendingMovies: "4gIKGgh0cmFpbGVycw==",
+}
+
+// TrendingVideo is a video of the trending page, its JSON field names are stable
+type TrendingVideo struct {
+	// Rank is the position on the trending page, starting at 1
+	Rank        int    `json:"rank"`
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	ChannelID   string `json:"channelId"`
+	Description string `json:"description"`
+	// ViewCount is approximate for some videos, YouTube rounds it to three significant digits
+	ViewCount int64 `json:"viewCount"`
+	// PublishedTime is relative as displayed by YouTube, e.g. "2 days ago"
+	PublishedTime string        `json:"publishedTime"`
+	Duration      time.Duration `json:"-"`
+	Thumbnails    Thumbnails    `json:"thumbnails"`
+}
+
+// MarshalJSON adds the duration in seconds
+func (v TrendingVideo) MarshalJSON() ([]byte, error) {
+	type trendingVideo TrendingVideo
+	return json.Marshal(struct {
+		trendingVideo
+		DurationSeconds int `json:"durationSeconds"`
+	}{trendingVideo(v), int(v.Duration.Seconds())})
+}
+
+// GetTrending fetches the trending videos of a region, given by a country code like "DE".
+// An empty region is the one YouTube derives from the IP address, an empty category is TrendingNow.
+func (c *Client) GetTrending(ctx context.Context, region string, category TrendingCategory) ([]*TrendingVideo, error) {
+	if category == "" {
+		category = TrendingNow
+	}
+	params, ok := trendingParams[category]
+	if !ok {
+		return nil, fmt.Errorf("unknown trending category %q", category)
+	}
+
+	response, err := c.browse(ctx, "FEtrending", params, strings.ToUpper(region))
+	if err != nil {
+		return nil, err
+	}
+
+	var renderers []struct {
+		VideoID            string        `json:"videoId"`
+		Title              innertubeText `json:"title"`
+		OwnerText          innertubeText `json:"ownerText"`
+		DescriptionSnippet innertubeText `json:"descriptionSnippet"`
+		ViewCountText      innertubeText `json:"viewCountText"`
+		PublishedTimeText  innertubeText `json:"publishedTimeText"`
+		LengthText         innertubeText `json:"lengthText"`
+		Thumbnail          struct {
+			Thumbnails Thumbnails `json:"thumbnails"`
+		} `json:"thumbnail"`
+	}
+	if err := findRenderers(response, "videoRenderer", &renderers); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParse, err)
+	}
+
+	// videos appear in several shelves of the page
+	videos := make([]*TrendingVideo, 0, len(renderers))
+	seen := make(map[string]bool, len(renderers))
+	for _, r := range renderers {
+		if r.VideoID == "" || seen[r.VideoID] {
+			continue
+		}
+		seen[r.VideoID] = true
+
+		video := &TrendingVideo{
+			Rank:          len(videos) + 1,
+			ID:            r.VideoID,
+			Title:         r.Title.String(),
+			Author:        r.OwnerText.String(),
+			Description:   r.DescriptionSnippet.String(),
+			ViewCount:     parseApproxCount(r.ViewCountText.String()),
+			PublishedTime: r.PublishedTimeText.String(),
+			Duration:      parseClockDuration(r.LengthText.String()),
+			Thumbnails:    r.Thumbnail.Thumbnails,
+		}
+		if len(r.OwnerText.Runs) > 0 {
+			video.ChannelID = r.OwnerText.Runs[0].NavigationEndpoint.BrowseEndpoint.BrowseID
+		}
+		videos = append(videos, video)
+	}
+
+	return videos, nil
+}
+
+// parseClockDuration parses durations as displayed by YouTube, e.g. "1:02:03" or "4:05"
+func parseClockDuration(text string) time.Duration {
+	var d time.Duration
+	for _, part := range strings.Split(strings.TrimSpace(text), ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		d = d*60 + time.Duration(n)
+	}
+	return d * time.Second
+}
@@ -0,0 +1,53 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTrending = `{"contents":{"twoColumnBrowseResultsRenderer":{"tabs":[{"tabRenderer":{"content":{"sectionListRenderer":{"contents":[
+{"itemSectionRenderer":{"contents":[{"shelfRenderer":{"content":{"expandedShelfContentsRenderer":{"items":[
+{"videoRenderer":{"videoId":"a","title":{"runs":[{"text":"First"}]},
+"ownerText":{"runs":[{"text":"Channel A","navigationEndpoint":{"browseEndpoint":{"browseId":"UCaaaaaaaaaaaaaaaaaaaaaa"}}}]},
+"viewCountText":{"simpleText":"1,234,567 views"},"publishedTimeText":{"simpleText":"1 day ago"},"lengthText":{"simpleText":"1:02:03"}}},
+{"videoRenderer":{"videoId":"b","title":{"runs":[{"text":"Second"}]},"lengthText":{"simpleText":"4:05"}}}]}}}}]}},
+{"itemSectionRenderer":{"contents":[{"shelfRenderer":{"content":{"expandedShelfContentsRenderer":{"items":[
+{"videoRenderer":{"videoId":"a","title":{"runs":[{"text":"First"}]}}}]}}}}]}}]}}}}]}}}`
+
+func TestClient_GetTrending(t *testing.T) {
+	var region, params interface{}
+	client := newInnertubeTestClient(func(endpoint string, payload map[string]interface{}) string {
+		region = payload["context"].(map[string]interface{})["client"].(map[string]interface{})["gl"]
+		params = payload["params"]
+		assert.Equal(t, "FEtrending", payload["browseId"])
+		return testTrending
+	})
+
+	videos, err := client.GetTrending(context.Background(), "de", TrendingMusic)
+	require.NoError(t, err)
+	assert.Equal(t, "DE", region)
+	assert.Equal(t, trendingParams[TrendingMusic], params)
+
+	require.Len(t, videos, 2, "duplicates must be removed")
+	assert.Equal(t, 1, videos[0].Rank)
+	assert.Equal(t, "First", videos[0].Title)
+	assert.Equal(t, "Channel A", videos[0].Author)
+	assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa", videos[0].ChannelID)
+	assert.Equal(t, int64(1234567), videos[0].ViewCount)
+	assert.Equal(t, time.Hour+2*time.Minute+3*time.Second, videos[0].Duration)
+	assert.Equal(t, 2, videos[1].Rank)
+	assert.Equal(t, 4*time.Minute+5*time.Second, videos[1].Duration)
+
+	data, err := json.Marshal(videos[1])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"id":"b","title":"Second"`)
+	assert.Contains(t, string(data), `"durationSeconds":245`)
+
+	_, err = client.GetTrending(context.Background(), "", "unknown")
+	assert.Error(t, err)
+}
@@ -2,7 +2,6 @@ package youtube
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
@@ -21,9 +20,27 @@ type Video struct {
 	Thumbnails      Thumbnails
 	DASHManifestURL string // URI of the DASH manifest file
 	HLSManifestURL  string // URI of the HLS manifest file
+	PublishDate     time.Time
+	IsLive          bool // the video is a live stream which is currently broadcasting
+	// Heatmap is the "most replayed" graph, empty for videos without enough views
+	Heatmap []HeatmapPoint
+	// Clip is set if the video was requested by a clip URL
+	Clip *Clip
+	// Music is set for tracks published through YouTube Music
+	Music *MusicMetadata
+	// DescriptionEntities are the links, hashtags and timestamps of the description
+	DescriptionEntities []DescriptionEntity
+	// Chapters are parsed from the timestamps of the description if Client.ChaptersFromDescription is set
+	Chapters []Chapter
+
+	// RawPlayerResponse is the unmodified player response, if Client.KeepRawPlayerResponse is set.
+	// It gives access to the fields which are not parsed.
+	RawPlayerResponse json.RawMessage
+	// ParseWarnings are the deviations of the player response from the expected schema in ParseLenient mode
+	ParseWarnings []string
 }
 
-func (v *Video) parseVideoInfo(body []byte) error {
+func (v *Video) parseVideoInfo(body []byte, opts parseOptions) error {
 	answer, err := url.ParseQuery(string(body))
 	if err != nil {
 		return err
@@ -40,47 +57,27 @@ func (v *Video) parseVideoInfo(body []byte) error {
 	// read the streams map
 	playerResponse := answer.Get("player_response")
 	if playerResponse == "" {
-		return errors.New("no player_response found in the server's answer")
+		return fmt.Errorf("%w: no player_response found", ErrParse)
 	}
 
-	var prData playerResponseData
-	if err := json.Unmarshal([]byte(playerResponse), &prData); err != nil {
-		return fmt.Errorf("unable to parse player response JSON: %w", err)
-	}
-
-	if err := v.isVideoFromInfoDownloadable(prData); err != nil {
-		return err
-	}
-
-	return v.extractDataFromPlayerResponse(prData)
-}
-
-func (v *Video) isVideoFromInfoDownloadable(prData playerResponseData) error {
-	return v.isVideoDownloadable(prData, false)
+	return v.parsePlayerResponse([]byte(playerResponse), false, opts)
 }
 
 var playerResponsePattern = regexp.MustCompile(`var ytInitialPlayerResponse\s*=\s*(\{.+?\});`)
 
-func (v *Video) parseVideoPage(body []byte) error {
-	initialPlayerResponse := playerResponsePattern.FindSubmatch(body)
-	if initialPlayerResponse == nil || len(initialPlayerResponse) < 2 {
-		return errors.New("no ytInitialPlayerResponse found in the server's answer")
-	}
-
-	var prData playerResponseData
-	if err := json.Unmarshal(initialPlayerResponse[1], &prData); err != nil {
-		return fmt.Errorf("unable to parse player response JSON: %w", err)
+func (v *Video) parseVideoPage(page *watchPage, opts parseOptions) error {
+	if page.playerResponse == nil {
+		return fmt.Errorf("%w: no ytInitialPlayerResponse found", ErrParse)
 	}
 
-	if err := v.isVideoFromPageDownloadable(prData); err != nil {
+	if err := v.parsePlayerResponse(page.playerResponse, true, opts); err != nil {
 		return err
 	}
-
-	return v.extractDataFromPlayerResponse(prData)
-}
-
-func (v *Video) isVideoFromPageDownloadable(prData playerResponseData) error {
-	return v.isVideoDownloadable(prData, true)
+	if len(v.Heatmap) == 0 {
+		// watch pages carry the markers in the initial data
+		v.Heatmap = initialDataHeatmap(page.initialData)
+	}
+	return nil
 }
 
 func (v *Video) isVideoDownloadable(prData playerResponseData, isVideoPage bool) error {
@@ -103,17 +100,25 @@ func (v *Video) extractDataFromPlayerResponse(prData playerResponseData) error {
 	v.Title = prData.VideoDetails.Title
 	v.Description = prData.VideoDetails.ShortDescription
 	v.Author = prData.VideoDetails.Author
-	v.Thumbnails = prData.VideoDetails.Thumbnail.Thumbnails
+	v.IsLive = prData.VideoDetails.IsLive
+	v.Thumbnails = normalizeThumbnails(v.ID, prData.VideoDetails.Thumbnail.Thumbnails)
+	v.Heatmap = prData.FrameworkUpdates.heatmap()
+	v.Music = parseMusicDescription(v.Description)
+	v.DescriptionEntities = ParseDescription(v.Description)
 
 	if seconds, _ := strconv.Atoi(prData.Microformat.PlayerMicroformatRenderer.LengthSeconds); seconds > 0 {
 		v.Duration = time.Duration(seconds) * time.Second
 	}
 
+	if date, err := time.Parse("2006-01-02", prData.Microformat.PlayerMicroformatRenderer.PublishDate); err == nil {
+		v.PublishDate = date
+	}
+
 	// Assign Streams
-	v.Formats = append(prData.StreamingData.Formats, prData.StreamingData.AdaptiveFormats...)
+	v.Formats = FormatList(append(prData.StreamingData.Formats, prData.StreamingData.AdaptiveFormats...)).Dedup()
 
 	if len(v.Formats) == 0 {
-		return errors.New("no formats found in the server's answer")
+		return fmt.Errorf("%w: no formats found", ErrParse)
 	}
 	sort.SliceStable(v.Formats, v.SortBitrateDesc)
 
@@ -123,6 +128,26 @@ func (v *Video) extractDataFromPlayerResponse(prData playerResponseData) error {
 	return nil
 }
 
+// AudioFormats returns the formats with audio but without video
+func (v *Video) AudioFormats() FormatList {
+	return v.filterFormats(FormatOptions{AudioOnly: true})
+}
+
+// VideoFormats returns the formats with video but without audio, which have to be merged with an audio format
+func (v *Video) VideoFormats() FormatList {
+	return v.filterFormats(FormatOptions{VideoOnly: true})
+}
+
+func (v *Video) filterFormats(opts FormatOptions) FormatList {
+	var formats FormatList
+	for i := range v.Formats {
+		if opts.matches(&v.Formats[i], v.Duration) {
+			formats = append(formats, v.Formats[i])
+		}
+	}
+	return formats
+}
+
 func (v *Video) SortBitrateDesc(i int, j int) bool {
 	return v.Formats[i].Bitrate > v.Formats[j].Bitrate
 }
@@ -1,6 +1,7 @@
 package youtube
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -12,6 +13,11 @@ var videoRegexpList = []*regexp.Regexp{
 }
 
 func extractVideoID(videoID string) (string, error) {
+	if IsClipURL(videoID) {
+		// the ID of a clip is not the ID of its video
+		return "", fmt.Errorf("%w: resolve clip URLs with GetClip", ErrInvalidClip)
+	}
+
 	if strings.Contains(videoID, "youtu") || strings.ContainsAny(videoID, "\"?&/<%=") {
 		for _, re := range videoRegexpList {
 			if isMatch := re.MatchString(videoID); isMatch {
@@ -0,0 +1,89 @@
+package youtube
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// visitorDataFile is the file in Client.CacheDir the visitor data is kept in
+const visitorDataFile = "visitor_data"
+
+var innertubeVisitorDataPattern = regexp.MustCompile(`"VISITOR_DATA"\s*:\s*"([^"]+)"`)
+
+// visitorData returns the visitor data identifying the session: the one already used, the persisted one,
+// the one of the scraped page or a new one, in this order. Keeping it stable across requests makes
+// the answers of the innertube API consistent and triggers less bot detection challenges.
+func (c *Client) visitorData(previous, scraped string) string {
+	visitorData := previous
+	if visitorData == "" {
+		visitorData = c.loadVisitorData()
+	}
+	if visitorData == "" {
+		visitorData = scraped
+	}
+	if visitorData == "" {
+		visitorData = generateVisitorData(time.Now())
+	}
+
+	if visitorData != previous {
+		c.saveVisitorData(visitorData)
+	}
+	return visitorData
+}
+
+// loadVisitorData reads the visitor data of an earlier run from the CacheDir
+func (c *Client) loadVisitorData() string {
+	if c.CacheDir == "" {
+		return ""
+	}
+	data, err := ioutil.ReadFile(filepath.Join(c.CacheDir, visitorDataFile))
+	if err != nil {
+		if !os.IsNotExist(err) && c.Debug {
+			log.Println("unable to read visitor data:", err)
+		}
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// saveVisitorData keeps the visitor data in the CacheDir for later runs
+func (c *Client) saveVisitorData(visitorData string) {
+	if c.CacheDir == "" {
+		return
+	}
+	err := os.MkdirAll(c.CacheDir, 0o700)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(c.CacheDir, visitorDataFile), []byte(visitorData+"\n"), 0o600)
+	}
+	if err != nil && c.Debug {
+		log.Println("unable to save visitor data:", err)
+	}
+}
+
+// generateVisitorData creates visitor data like the website does: the URL escaped base64 of a protobuf message
+// with a random visitor ID (field 1) and the creation time (field 5)
+func generateVisitorData(now time.Time) string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+	id := make([]byte, 11)
+	rand.Read(id) //nolint:errcheck
+	for i, b := range id {
+		id[i] = alphabet[int(b)%len(alphabet)]
+	}
+
+	message := append([]byte{0x0a, byte(len(id))}, id...)
+	varint := make([]byte, binary.MaxVarintLen64)
+	message = append(message, 0x28)
+	message = append(message, varint[:binary.PutUvarint(varint, uint64(now.Unix()))]...)
+
+	return url.QueryEscape(base64.URLEncoding.EncodeToString(message))
+}
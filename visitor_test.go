@@ -0,0 +1,102 @@
+package youtube
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateVisitorData(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	visitorData := generateVisitorData(now)
+	assert.NotEqual(t, visitorData, generateVisitorData(now))
+
+	unescaped, err := url.QueryUnescape(visitorData)
+	require.NoError(t, err)
+	message, err := base64.URLEncoding.DecodeString(unescaped)
+	require.NoError(t, err)
+
+	require.Len(t, message, 2+11+1+5)
+	assert.Equal(t, []byte{0x0a, 11}, message[:2])
+	assert.Equal(t, byte(0x28), message[13])
+	timestamp, _ := binary.Uvarint(message[14:])
+	assert.Equal(t, uint64(now.Unix()), timestamp)
+}
+
+func TestClient_visitorData(t *testing.T) {
+	innertubeCache.configs = nil
+	defer func() { innertubeCache.configs = nil }()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var visitorIDs []string
+	page := strings.Replace(testWatchPage, `"INNERTUBE_API_KEY"`, `"VISITOR_DATA":"CgtwYWdl","INNERTUBE_API_KEY"`, 1)
+	client := &Client{CacheDir: dir, HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := page
+		if req.URL.Path == "/youtubei/v1/browse" {
+			visitorIDs = append(visitorIDs, req.Header.Get("X-Goog-Visitor-Id"))
+			body = "{}"
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}}
+
+	var response struct{}
+	require.NoError(t, client.innertubeRequest(context.Background(), "browse", "", nil, &response))
+	saved, err := ioutil.ReadFile(filepath.Join(dir, visitorDataFile))
+	require.NoError(t, err)
+	assert.Equal(t, "CgtwYWdl\n", string(saved))
+
+	// a later run continues the session of the cache directory instead of the one of the page
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, visitorDataFile), []byte("CgtzYXZlZA\n"), 0o600))
+	innertubeCache.configs = nil
+	require.NoError(t, client.innertubeRequest(context.Background(), "browse", "", nil, &response))
+
+	// the visitor data stays the same when the configuration is scraped again
+	client.updateInnertubeConfig([]byte(strings.Replace(page, "CgtwYWdl", "CgtvdGhlcg", 1)))
+	require.NoError(t, client.innertubeRequest(context.Background(), "browse", "", nil, &response))
+
+	assert.Equal(t, []string{"CgtwYWdl", "CgtzYXZlZA", "CgtzYXZlZA"}, visitorIDs)
+
+	// without any visitor data, one is generated
+	innertubeCache.configs = nil
+	config := (&Client{}).updateInnertubeConfig([]byte("<html></html>"))
+	assert.NotEmpty(t, config.VisitorData)
+}
+
+func TestClient_visitorDataPerCacheDir(t *testing.T) {
+	innertubeCache.configs = nil
+	defer func() { innertubeCache.configs = nil }()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	page := []byte(strings.Replace(testWatchPage, `"INNERTUBE_API_KEY"`, `"VISITOR_DATA":"CgtwYWdl","INNERTUBE_API_KEY"`, 1))
+	first := &Client{CacheDir: filepath.Join(dir, "first")}
+	require.NoError(t, os.MkdirAll(first.CacheDir, 0o700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(first.CacheDir, visitorDataFile), []byte("CgtmaXJzdA\n"), 0o600))
+	assert.Equal(t, "CgtmaXJzdA", first.updateInnertubeConfig(page).VisitorData)
+
+	second := &Client{CacheDir: filepath.Join(dir, "second")}
+	assert.Equal(t, "CgtwYWdl", second.updateInnertubeConfig(page).VisitorData, "clients of other cache directories have their own session")
+
+	config, err := (&Client{CacheDir: first.CacheDir}).getInnertubeConfig(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "CgtmaXJzdA", config.VisitorData, "clients of the same cache directory share the session")
+}
@@ -0,0 +1,101 @@
+package youtubetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// SampleVideoID is the ID of the video returned by SampleVideo
+const SampleVideoID = "BaW_jenozKc"
+
+// SampleVideo returns a video with a muxed format (itag 18) and separate video (itag 137) and audio (itag 140) formats
+func SampleVideo() *Video {
+	return &Video{
+		ID:          SampleVideoID,
+		Title:       "youtube-dl test video",
+		Author:      "Philipp Hagemeister",
+		Description: "test chars:  \"'/\\ä↭𝕐",
+		Duration:    10 * time.Second,
+		PublishDate: time.Date(2012, 10, 2, 0, 0, 0, 0, time.UTC),
+		Streams: []Stream{
+			{
+				Format: youtube.Format{
+					ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`,
+					Quality: "medium", QualityLabel: "360p", Bitrate: 500000, Width: 640, Height: 360, FPS: 30,
+					AudioQuality: "AUDIO_QUALITY_LOW", AudioChannels: 2,
+				},
+				Data: sampleData(18, 4096),
+			},
+			{
+				Format: youtube.Format{
+					ItagNo: 137, MimeType: `video/mp4; codecs="avc1.640028"`,
+					Quality: "hd1080", QualityLabel: "1080p", Bitrate: 4000000, Width: 1920, Height: 1080, FPS: 30,
+				},
+				Data: sampleData(137, 8192),
+			},
+			{
+				Format: youtube.Format{
+					ItagNo: 140, MimeType: `audio/mp4; codecs="mp4a.40.2"`,
+					Quality: "tiny", Bitrate: 130000, AudioQuality: "AUDIO_QUALITY_MEDIUM", AudioChannels: 2,
+				},
+				Data: sampleData(140, 2048),
+			},
+		},
+	}
+}
+
+// sampleData returns size bytes of recognizable content
+func sampleData(itag, size int) []byte {
+	return bytes.Repeat([]byte(fmt.Sprintf("itag %d ", itag)), size)[:size]
+}
+
+// PlayerResponse returns the player response YouTube would send for the video,
+// stream URLs point to the server at baseURL
+func PlayerResponse(v *Video, baseURL string) ([]byte, error) {
+	status := v.Status
+	if status == "" {
+		status = "OK"
+	}
+
+	formats := make([]youtube.Format, 0, len(v.Streams))
+	for _, stream := range v.Streams {
+		format := stream.Format
+		format.URL = fmt.Sprintf("%s/stream/%s/%d", baseURL, v.ID, format.ItagNo)
+		format.ContentLength = strconv.Itoa(len(stream.Data))
+		formats = append(formats, format)
+	}
+
+	lengthSeconds := strconv.Itoa(int(v.Duration.Seconds()))
+	response := map[string]interface{}{
+		"playabilityStatus": map[string]interface{}{
+			"status":          status,
+			"reason":          v.Reason,
+			"playableInEmbed": true,
+		},
+		"videoDetails": map[string]interface{}{
+			"videoId":          v.ID,
+			"title":            v.Title,
+			"author":           v.Author,
+			"shortDescription": v.Description,
+			"lengthSeconds":    lengthSeconds,
+		},
+		"microformat": map[string]interface{}{
+			"playerMicroformatRenderer": map[string]interface{}{
+				"lengthSeconds": lengthSeconds,
+				"publishDate":   v.PublishDate.Format("2006-01-02"),
+			},
+		},
+	}
+	if status == "OK" {
+		response["streamingData"] = map[string]interface{}{
+			"adaptiveFormats": formats,
+		}
+	}
+
+	return json.Marshal(response)
+}
@@ -0,0 +1,215 @@
+/*
+Package youtubetest provides a fake YouTube for testing applications which use the youtube package.
+
+The Server answers the requests of a youtube.Client with canned player responses and serves the streams
+of the registered videos, including range requests, so no requests reach YouTube:
+
+	server := youtubetest.NewServer()
+	defer server.Close()
+	server.AddVideo(youtubetest.SampleVideo())
+
+	client := server.Client()
+	video, err := client.GetVideo(youtubetest.SampleVideoID)
+*/
+package youtubetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// Video is a fake video served by the Server
+type Video struct {
+	ID          string
+	Title       string
+	Author      string
+	Description string
+	Duration    time.Duration
+	PublishDate time.Time
+	Streams     []Stream
+
+	// Status is the playability status, e.g. "ERROR" or "LOGIN_REQUIRED", defaults to "OK"
+	Status string
+	// Reason explains a status other than "OK", e.g. "This video is private"
+	Reason string
+}
+
+// Stream is a format of a fake video and its content.
+// URL and ContentLength of the format are set by the Server.
+type Stream struct {
+	Format youtube.Format
+	Data   []byte
+}
+
+// Playlist is a fake playlist served by the Server
+type Playlist struct {
+	ID       string
+	Title    string
+	Author   string
+	VideoIDs []string
+}
+
+// Server is a fake YouTube
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	videos    map[string]*Video
+	playlists map[string]*Playlist
+}
+
+// NewServer starts a fake YouTube, it has to be closed after use
+func NewServer() *Server {
+	s := &Server{
+		videos:    make(map[string]*Video),
+		playlists: make(map[string]*Playlist),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddVideo registers a video, replacing one with the same ID
+func (s *Server) AddVideo(v *Video) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.videos[v.ID] = v
+}
+
+// AddPlaylist registers a playlist, replacing one with the same ID
+func (s *Server) AddPlaylist(p *Playlist) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playlists[p.ID] = p
+}
+
+// Client returns a client sending all requests to the server
+func (s *Server) Client() *youtube.Client {
+	return &youtube.Client{
+		HTTPClient: &http.Client{Transport: s.Transport()},
+	}
+}
+
+// Transport redirects requests for YouTube to the server
+func (s *Server) Transport() http.RoundTripper {
+	target, _ := url.Parse(s.URL)
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if host := req.URL.Hostname(); host == "youtube.com" || strings.HasSuffix(host, ".youtube.com") {
+			req = req.Clone(req.Context())
+			req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+			req.Host = ""
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/get_video_info":
+		s.serveVideoInfo(w, r)
+	case r.URL.Path == "/list_ajax":
+		s.servePlaylist(w, r)
+	case strings.HasPrefix(r.URL.Path, "/stream/"):
+		s.serveStream(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) video(id string) *Video {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.videos[id]
+}
+
+func (s *Server) serveVideoInfo(w http.ResponseWriter, r *http.Request) {
+	v := s.video(r.URL.Query().Get("video_id"))
+	if v == nil {
+		v = &Video{Status: "ERROR", Reason: "Video unavailable"}
+	}
+
+	data, err := PlayerResponse(v, s.URL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	answer := url.Values{
+		"status":          {"ok"},
+		"player_response": {string(data)},
+	}
+	fmt.Fprint(w, answer.Encode())
+}
+
+func (s *Server) servePlaylist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	p := s.playlists[r.URL.Query().Get("list")]
+	s.mu.Unlock()
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// pages start at the requested index like the ones of YouTube
+	index, _ := strconv.Atoi(r.URL.Query().Get("index"))
+	if index > 0 {
+		index--
+	}
+	if index > len(p.VideoIDs) {
+		index = len(p.VideoIDs)
+	}
+
+	entries := make([]*youtube.PlaylistEntry, 0, len(p.VideoIDs)-index)
+	for _, id := range p.VideoIDs[index:] {
+		entry := &youtube.PlaylistEntry{ID: id}
+		if v := s.video(id); v != nil {
+			entry.Title, entry.Author, entry.Duration = v.Title, v.Author, v.Duration
+		}
+		entries = append(entries, entry)
+	}
+
+	json.NewEncoder(w).Encode(struct { //nolint:errcheck
+		Title  string                   `json:"title"`
+		Author string                   `json:"author"`
+		Videos []*youtube.PlaylistEntry `json:"video"`
+	}{p.Title, p.Author, entries})
+}
+
+// serveStream serves /stream/<video id>/<itag> with support for range requests
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/stream/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	itag, _ := strconv.Atoi(parts[1])
+
+	v := s.video(parts[0])
+	if v == nil {
+		http.NotFound(w, r)
+		return
+	}
+	for _, stream := range v.Streams {
+		if stream.Format.ItagNo == itag {
+			w.Header().Set("Content-Type", stream.Format.MimeType)
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(stream.Data))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
@@ -0,0 +1,79 @@
+package youtubetest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/kkdai/youtube/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Video(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddVideo(SampleVideo())
+
+	client := server.Client()
+	video, err := client.GetVideo("https://www.youtube.com/watch?v=" + SampleVideoID)
+	require.NoError(t, err)
+	assert.Equal(t, "youtube-dl test video", video.Title)
+	assert.Equal(t, SampleVideo().Duration, video.Duration)
+	require.Len(t, video.Formats, 3)
+
+	format := video.Formats.FindByItag(140)
+	require.NotNil(t, format)
+	assert.Equal(t, "mp4a.40.2", format.AudioCodec())
+
+	resp, err := client.GetStreamRange(context.Background(), video, format, 0, 9)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "itag 140 i", string(data))
+}
+
+func TestServer_Unavailable(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	_, err := server.Client().GetVideo(SampleVideoID)
+	assert.True(t, errors.Is(err, youtube.ErrNotFound))
+
+	v := SampleVideo()
+	v.Status, v.Reason = "LOGIN_REQUIRED", "This video is private"
+	server.AddVideo(v)
+
+	_, err = server.Client().GetVideo(SampleVideoID)
+	assert.True(t, errors.Is(err, youtube.ErrPrivate))
+}
+
+func TestServer_Playlist(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddVideo(SampleVideo())
+	server.AddPlaylist(&Playlist{
+		ID:       "PLqAfPOrmacr963ATEroh67fbvjmTzTEx5",
+		Title:    "Sample playlist",
+		VideoIDs: []string{SampleVideoID, "XbNghLqsVwU"},
+	})
+
+	playlist, err := server.Client().GetPlaylist("PLqAfPOrmacr963ATEroh67fbvjmTzTEx5")
+	require.NoError(t, err)
+	assert.Equal(t, "Sample playlist", playlist.Title)
+
+	var ids []string
+	it := playlist.Entries(context.Background())
+	for {
+		entry, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, entry.ID)
+	}
+	assert.Equal(t, []string{SampleVideoID, "XbNghLqsVwU"}, ids)
+}